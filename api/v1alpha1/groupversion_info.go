@@ -0,0 +1,20 @@
+// Package v1alpha1 contains the API types of the vault.benemon.io group.
+// +kubebuilder:object:generate=true
+// +groupName=vault.benemon.io
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is the group and version of the API types.
+	GroupVersion = schema.GroupVersion{Group: "vault.benemon.io", Version: "v1alpha1"}
+
+	// SchemeBuilder registers the API types with a scheme.
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the API types to a scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
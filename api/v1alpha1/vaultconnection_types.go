@@ -0,0 +1,94 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConnectionLabel routes a Kubernetes namespace to a VaultConnection, unless its
+// VaultNamespaceClass names one.
+const ConnectionLabel = "vault.benemon.io/connection"
+
+// SecretReference names a Secret in a given namespace.
+type SecretReference struct {
+	// Namespace is the namespace of the Secret.
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the Secret.
+	Name string `json:"name"`
+}
+
+// VaultConnectionTLS configures TLS towards a Vault cluster.
+type VaultConnectionTLS struct {
+	// CACert is a PEM-encoded CA certificate bundle to verify the Vault server with.
+	// +optional
+	CACert string `json:"caCert,omitempty"`
+
+	// Insecure disables verification of the Vault server's certificate.
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// VaultConnectionAuth configures how the controller authenticates to a Vault cluster.
+type VaultConnectionAuth struct {
+	// Type is the auth method: kubernetes, token, or approle.
+	// +kubebuilder:validation:Enum=kubernetes;token;approle
+	Type string `json:"type"`
+
+	// Path is the path the auth method is mounted at, when not the default.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Namespace is the Vault namespace the auth method resides in.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Role is the role to log in with using kubernetes auth.
+	// +optional
+	Role string `json:"role,omitempty"`
+
+	// SecretRef names a Secret holding the credentials: a "token" key for token
+	// auth, and "role-id" and "secret-id" keys for approle auth.
+	// +optional
+	SecretRef *SecretReference `json:"secretRef,omitempty"`
+}
+
+// VaultConnectionSpec describes how to reach and authenticate to a Vault cluster.
+type VaultConnectionSpec struct {
+	// Address is the address of the Vault cluster.
+	Address string `json:"address"`
+
+	// TLS configures TLS towards the Vault cluster.
+	// +optional
+	TLS VaultConnectionTLS `json:"tls,omitempty"`
+
+	// Auth configures how the controller authenticates.
+	Auth VaultConnectionAuth `json:"auth"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=vconn
+// +kubebuilder:printcolumn:name="Address",type=string,JSONPath=`.spec.address`
+// +kubebuilder:printcolumn:name="Auth",type=string,JSONPath=`.spec.auth.type`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VaultConnection is a Vault cluster the controller can create Vault namespaces
+// in, besides the one it is configured with.
+type VaultConnection struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VaultConnectionSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultConnectionList is a list of VaultConnections.
+type VaultConnectionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultConnection `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultConnection{}, &VaultConnectionList{})
+}
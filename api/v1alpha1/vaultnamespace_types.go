@@ -0,0 +1,89 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types of a VaultNamespace.
+const (
+	// ConditionCreated is true once the Vault namespace exists.
+	ConditionCreated = "Created"
+	// ConditionSynced is true when the last synchronization succeeded. Its
+	// message summarizes the state of the Vault namespace.
+	ConditionSynced = "Synced"
+	// ConditionDrifted is true when the drift scan found the Vault namespace
+	// missing or at an unexpected path.
+	ConditionDrifted = "Drifted"
+	// ConditionDeletionPending is true while the deletion of the Vault namespace
+	// waits out the deletion grace period.
+	ConditionDeletionPending = "DeletionPending"
+	// ConditionError is true when the last synchronization failed.
+	ConditionError = "Error"
+)
+
+// VaultNamespaceSpec describes the Vault namespace synchronized for a
+// Kubernetes namespace. It is written by the controller.
+type VaultNamespaceSpec struct {
+	// KubernetesNamespace is the namespace the Vault namespace is synchronized for.
+	KubernetesNamespace string `json:"kubernetesNamespace"`
+
+	// Cluster is the cluster the Kubernetes namespace belongs to.
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+
+	// Path is the full path of the Vault namespace.
+	Path string `json:"path"`
+
+	// Parent is the Vault namespace the namespace is created in, empty for the root.
+	// +optional
+	Parent string `json:"parent,omitempty"`
+
+	// Metadata is the custom metadata the controller sets on the Vault namespace.
+	// +optional
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// VaultNamespaceStatus is the observed state of a VaultNamespace.
+type VaultNamespaceStatus struct {
+	// Conditions are the Created, Synced, Drifted, DeletionPending and Error conditions.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// LastSyncTime is when the Vault namespace was last successfully synchronized.
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=vns
+// +kubebuilder:printcolumn:name="Path",type=string,JSONPath=`.spec.path`
+// +kubebuilder:printcolumn:name="Synced",type=string,JSONPath=`.status.conditions[?(@.type=="Synced")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Message",type=string,JSONPath=`.status.conditions[?(@.type=="Synced")].message`
+// +kubebuilder:printcolumn:name="Namespace",type=string,JSONPath=`.spec.kubernetesNamespace`,priority=1
+
+// VaultNamespace records the Vault namespace the controller synchronizes for a
+// Kubernetes namespace, and its state.
+type VaultNamespace struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VaultNamespaceSpec   `json:"spec,omitempty"`
+	Status VaultNamespaceStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultNamespaceList is a list of VaultNamespaces.
+type VaultNamespaceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultNamespace `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultNamespace{}, &VaultNamespaceList{})
+}
@@ -0,0 +1,171 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// BlueprintPolicy is an ACL policy written in each Vault namespace.
+type BlueprintPolicy struct {
+	// Name is the name of the policy.
+	Name string `json:"name"`
+
+	// Policy is the policy document, in HCL or JSON.
+	Policy string `json:"policy"`
+}
+
+// BlueprintMount is a secrets engine or auth method enabled in each Vault namespace.
+type BlueprintMount struct {
+	// Path is the path the secrets engine or auth method is mounted at.
+	Path string `json:"path"`
+
+	// Type is the type of the secrets engine or auth method, such as kv-v2 or kubernetes.
+	Type string `json:"type"`
+
+	// Description is a human-friendly description of the mount.
+	// +optional
+	Description string `json:"description,omitempty"`
+}
+
+// BlueprintAuthRole is a role of an auth method written in each Vault namespace.
+type BlueprintAuthRole struct {
+	// Mount is the path of the auth method the role belongs to.
+	Mount string `json:"mount"`
+
+	// Name is the name of the role.
+	Name string `json:"name"`
+
+	// Data holds the role's parameters, as documented for the auth method.
+	// +optional
+	Data map[string]string `json:"data,omitempty"`
+}
+
+// BlueprintRateLimitQuota is a rate-limit quota on the requests to each Vault namespace.
+type BlueprintRateLimitQuota struct {
+	// Rate is the number of requests allowed per Interval.
+	// +kubebuilder:validation:Minimum=1
+	Rate int32 `json:"rate"`
+
+	// Interval is the duration Rate applies to, such as 1s. Defaults to 1s.
+	// +optional
+	Interval string `json:"interval,omitempty"`
+
+	// BlockInterval is how long clients exceeding Rate are blocked, such as 1m.
+	// +optional
+	BlockInterval string `json:"blockInterval,omitempty"`
+}
+
+// BlueprintBootstrapToken is a short-lived token created in each Vault
+// namespace and handed to its Kubernetes namespace in a Secret, for one-time
+// bootstrap flows.
+type BlueprintBootstrapToken struct {
+	// SecretName is the name of the Secret holding the token under the key
+	// token. Defaults to vault-bootstrap-token.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// Policies are the policies of the token, which must exist in the Vault namespace.
+	// +kubebuilder:validation:MinItems=1
+	Policies []string `json:"policies"`
+
+	// TTL is the time to live of the token, such as 1h.
+	TTL string `json:"ttl"`
+
+	// WrapTTL wraps the token in a response-wrapping token with this time to
+	// live, such as 10m, so it can only be unwrapped once.
+	// +optional
+	WrapTTL string `json:"wrapTTL,omitempty"`
+}
+
+// BlueprintLeaseCountQuota is a lease-count quota on the leases in each Vault namespace.
+type BlueprintLeaseCountQuota struct {
+	// MaxLeases is the number of leases allowed at once.
+	// +kubebuilder:validation:Minimum=1
+	MaxLeases int32 `json:"maxLeases"`
+}
+
+// BlueprintRequest is an arbitrary Vault API request sent in each Vault
+// namespace, for resources the other fields of a blueprint do not cover.
+type BlueprintRequest struct {
+	// Method is the HTTP method of the request.
+	// +kubebuilder:validation:Enum=POST;PUT;DELETE
+	Method string `json:"method"`
+
+	// Path is the API path without the /v1/ prefix, such as
+	// pki/root/generate/internal. It is a template executed against the
+	// namespace, like the controller's namespaceTemplate.
+	Path string `json:"path"`
+
+	// Payload is the JSON object sent as the body of the request. Its string
+	// values are templates, like Path.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Payload *runtime.RawExtension `json:"payload,omitempty"`
+}
+
+// VaultNamespaceBlueprintSpec describes the resources created inside each Vault
+// namespace provisioned from a blueprint.
+type VaultNamespaceBlueprintSpec struct {
+	// Policies are the ACL policies to write.
+	// +optional
+	Policies []BlueprintPolicy `json:"policies,omitempty"`
+
+	// SecretsEngines are the secrets engines to enable.
+	// +optional
+	SecretsEngines []BlueprintMount `json:"secretsEngines,omitempty"`
+
+	// AuthMethods are the auth methods to enable.
+	// +optional
+	AuthMethods []BlueprintMount `json:"authMethods,omitempty"`
+
+	// AuthRoles are the auth method roles to write, once AuthMethods are enabled.
+	// +optional
+	AuthRoles []BlueprintAuthRole `json:"authRoles,omitempty"`
+
+	// RateLimitQuota limits the rate of requests to the Vault namespace and its
+	// children, so one tenant cannot starve the others.
+	// +optional
+	RateLimitQuota *BlueprintRateLimitQuota `json:"rateLimitQuota,omitempty"`
+
+	// LeaseCountQuota limits the number of leases in the Vault namespace and
+	// its children, so a misbehaving workload cannot exhaust Vault's storage.
+	// +optional
+	LeaseCountQuota *BlueprintLeaseCountQuota `json:"leaseCountQuota,omitempty"`
+
+	// BootstrapToken creates a token in the Vault namespace and writes it into
+	// a Secret in the Kubernetes namespace, unless the Secret exists.
+	// +optional
+	BootstrapToken *BlueprintBootstrapToken `json:"bootstrapToken,omitempty"`
+
+	// Requests are sent in order after every other entry, to provision
+	// anything Vault supports that the blueprint has no field for.
+	// +optional
+	Requests []BlueprintRequest `json:"requests,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=vnsblueprint
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VaultNamespaceBlueprint describes resources the controller creates inside each
+// Vault namespace whose VaultNamespaceClass references it, so the namespace is
+// usable by its tenants as soon as it exists.
+type VaultNamespaceBlueprint struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VaultNamespaceBlueprintSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultNamespaceBlueprintList is a list of VaultNamespaceBlueprints.
+type VaultNamespaceBlueprintList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultNamespaceBlueprint `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultNamespaceBlueprint{}, &VaultNamespaceBlueprintList{})
+}
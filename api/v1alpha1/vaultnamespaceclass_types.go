@@ -0,0 +1,78 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClassAnnotation selects the VaultNamespaceClass of a Kubernetes namespace.
+const ClassAnnotation = "vault.benemon.io/class"
+
+// DeletionPolicy decides what happens to a Vault namespace when its Kubernetes
+// namespace is deleted.
+// +kubebuilder:validation:Enum=Delete;Retain
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the Vault namespace.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain keeps the Vault namespace.
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+)
+
+// VaultNamespaceClassSpec describes a flavor of Vault tenancy.
+type VaultNamespaceClassSpec struct {
+	// PathTemplate is a Go template for the Vault namespace path, with the same
+	// fields and functions as the controller's namespaceTemplate. The
+	// controller's namespaceFormat is used when empty.
+	// +optional
+	PathTemplate string `json:"pathTemplate,omitempty"`
+
+	// Parent is the Vault namespace paths are created under, in place of the
+	// controller's namespace root.
+	// +optional
+	Parent string `json:"parent,omitempty"`
+
+	// DeletionPolicy overrides the controller's deleteVaultNamespaces setting
+	// for namespaces of this class.
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Blueprint is the name of a VaultNamespaceBlueprint provisioned inside each
+	// Vault namespace of this class once it is created.
+	// +optional
+	Blueprint string `json:"blueprint,omitempty"`
+
+	// Connection is the name of the VaultConnection the Vault namespaces of this
+	// class are created with, in place of the controller's Vault.
+	// +optional
+	Connection string `json:"connection,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=vnsclass
+// +kubebuilder:printcolumn:name="Parent",type=string,JSONPath=`.spec.parent`
+// +kubebuilder:printcolumn:name="DeletionPolicy",type=string,JSONPath=`.spec.deletionPolicy`
+// +kubebuilder:printcolumn:name="Blueprint",type=string,JSONPath=`.spec.blueprint`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VaultNamespaceClass bundles how the Vault namespaces of the Kubernetes
+// namespaces selecting it are laid out and managed, analogous to a StorageClass.
+type VaultNamespaceClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VaultNamespaceClassSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultNamespaceClassList is a list of VaultNamespaceClasses.
+type VaultNamespaceClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultNamespaceClass `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultNamespaceClass{}, &VaultNamespaceClassList{})
+}
@@ -0,0 +1,78 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ControllerConfigName is the name of the singleton VaultNamespaceControllerConfig.
+const ControllerConfigName = "default"
+
+// ConditionApplied reports whether the controller applied a VaultNamespaceControllerConfig.
+const ConditionApplied = "Applied"
+
+// VaultNamespaceControllerConfigSpec overrides settings of the controller's
+// configuration file. Unset fields keep the file's value.
+type VaultNamespaceControllerConfigSpec struct {
+	// IncludeNamespaces are patterns for namespaces to include, in the patternSyntax
+	// of the configuration file.
+	// +optional
+	IncludeNamespaces []string `json:"includeNamespaces,omitempty"`
+
+	// ExcludeNamespaces are patterns for namespaces to exclude, in the patternSyntax
+	// of the configuration file.
+	// +optional
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+
+	// NamespaceFormat is the format string for Vault namespace names.
+	// +optional
+	NamespaceFormat string `json:"namespaceFormat,omitempty"`
+
+	// DeleteVaultNamespaces sets whether Vault namespaces are deleted with their
+	// Kubernetes namespace.
+	// +optional
+	DeleteVaultNamespaces *bool `json:"deleteVaultNamespaces,omitempty"`
+
+	// DeleteNonEmptyNamespaces sets whether Vault namespaces with secret or auth
+	// mounts are deleted.
+	// +optional
+	DeleteNonEmptyNamespaces *bool `json:"deleteNonEmptyNamespaces,omitempty"`
+}
+
+// VaultNamespaceControllerConfigStatus reports whether the spec was applied.
+type VaultNamespaceControllerConfigStatus struct {
+	// Conditions holds the Applied condition.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=vnsconfig
+// +kubebuilder:validation:XValidation:rule="self.metadata.name == 'default'",message="the VaultNamespaceControllerConfig must be named default"
+// +kubebuilder:printcolumn:name="Applied",type=string,JSONPath=`.status.conditions[?(@.type=="Applied")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VaultNamespaceControllerConfig changes settings of the running controller
+// without a restart. There is a single one, named default.
+type VaultNamespaceControllerConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VaultNamespaceControllerConfigSpec   `json:"spec,omitempty"`
+	Status VaultNamespaceControllerConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultNamespaceControllerConfigList is a list of VaultNamespaceControllerConfigs.
+type VaultNamespaceControllerConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultNamespaceControllerConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultNamespaceControllerConfig{}, &VaultNamespaceControllerConfigList{})
+}
@@ -0,0 +1,99 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionHealthy is true when every synchronized namespace has its Vault
+// namespace and none failed to synchronize recently.
+const ConditionHealthy = "Healthy"
+
+// SyncFailure is a namespace whose last synchronization failed.
+type SyncFailure struct {
+	// KubernetesNamespace is the namespace that failed to synchronize.
+	KubernetesNamespace string `json:"kubernetesNamespace"`
+
+	// Message is the error of the last failed synchronization.
+	Message string `json:"message"`
+
+	// Time is when the synchronization failed.
+	Time metav1.Time `json:"time"`
+}
+
+// VaultNamespaceSyncReportStatus summarizes the synchronization of a cluster's namespaces.
+type VaultNamespaceSyncReportStatus struct {
+	// Cluster is the cluster the namespaces belong to.
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+
+	// Shard is the index/total of the replica's shard, when sharding is enabled.
+	// +optional
+	Shard string `json:"shard,omitempty"`
+
+	// Managed is the number of namespaces selected for synchronization.
+	Managed int32 `json:"managed"`
+
+	// Excluded is the number of namespaces excluded from synchronization.
+	Excluded int32 `json:"excluded"`
+
+	// Synced is the number of managed namespaces whose Vault namespace exists.
+	Synced int32 `json:"synced"`
+
+	// Pending is the number of managed namespaces whose Vault namespace does not exist.
+	Pending int32 `json:"pending"`
+
+	// Orphaned is the number of Vault namespaces owned by the controller whose
+	// Kubernetes namespace no longer exists.
+	Orphaned int32 `json:"orphaned"`
+
+	// RecentFailures lists the latest namespaces that failed to synchronize
+	// and have not synchronized since.
+	// +optional
+	RecentFailures []SyncFailure `json:"recentFailures,omitempty"`
+
+	// LastFullScanTime is when every namespace was last checked against Vault,
+	// by the initial sync or a drift scan.
+	// +optional
+	LastFullScanTime *metav1.Time `json:"lastFullScanTime,omitempty"`
+
+	// LastReportTime is when the report was last published.
+	// +optional
+	LastReportTime *metav1.Time `json:"lastReportTime,omitempty"`
+
+	// Conditions holds the Healthy condition.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=vnsreport
+// +kubebuilder:printcolumn:name="Managed",type=integer,JSONPath=`.status.managed`
+// +kubebuilder:printcolumn:name="Pending",type=integer,JSONPath=`.status.pending`
+// +kubebuilder:printcolumn:name="Orphaned",type=integer,JSONPath=`.status.orphaned`
+// +kubebuilder:printcolumn:name="Healthy",type=string,JSONPath=`.status.conditions[?(@.type=="Healthy")].status`
+// +kubebuilder:printcolumn:name="Reported",type=date,JSONPath=`.status.lastReportTime`
+
+// VaultNamespaceSyncReport is a periodic summary of the synchronization of a
+// cluster's namespaces, published by the controller.
+type VaultNamespaceSyncReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status VaultNamespaceSyncReportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultNamespaceSyncReportList is a list of VaultNamespaceSyncReports.
+type VaultNamespaceSyncReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultNamespaceSyncReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultNamespaceSyncReport{}, &VaultNamespaceSyncReportList{})
+}
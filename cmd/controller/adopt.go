@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/controller"
+)
+
+// adoptCommand is the subcommand writing the ownership metadata onto existing
+// Vault namespaces, so the controller manages them without recreating them.
+const adoptCommand = "adopt"
+
+// runAdopt adopts the Vault namespaces of the Kubernetes namespaces named in
+// args, or of every synchronized namespace with --all. It returns the exit
+// code: 1 if any could not be adopted, 2 for invalid arguments.
+func runAdopt(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet(adoptCommand, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] (--all | kubernetes-namespace...)\n", adoptCommand)
+		fs.PrintDefaults()
+	}
+	configPath := fs.String("config", os.Getenv(config.EnvPrefix+"_CONFIG"), "Path to controller config file (env VNC_CONFIG)")
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig, instead of $KUBECONFIG, ~/.kube/config or the in-cluster config")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Time allowed to adopt the namespaces")
+	output := fs.String("output", "table", "Output format: table or json")
+	all := fs.Bool("all", false, "Adopt the Vault namespaces of every synchronized namespace")
+	dryRun := fs.Bool("dry-run", false, "Print what would be adopted without writing to Vault")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "unsupported output format %q\n", *output)
+		return 2
+	}
+	if *all == (fs.NArg() > 0) {
+		fmt.Fprintln(stderr, "pass either --all or Kubernetes namespace names")
+		fs.Usage()
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	reconciler, err := newCommandReconciler(ctx, *configPath, *kubeconfig)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to adopt namespaces: %v\n", err)
+		return 1
+	}
+	adoptions, err := reconciler.AdoptNamespaces(ctx, fs.Args(), *dryRun || reconciler.Config.DryRun)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to adopt namespaces: %v\n", err)
+		return 1
+	}
+
+	if *output == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(adoptions)
+	} else {
+		printAdoptions(stdout, adoptions)
+	}
+	for _, adoption := range adoptions {
+		if adoption.Failed() {
+			return 1
+		}
+	}
+	return 0
+}
+
+// printAdoptions prints the result of every adoption as a table.
+func printAdoptions(w io.Writer, adoptions []controller.Adoption) {
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "KUBERNETES NAMESPACE\tVAULT NAMESPACE\tRESULT\tERROR")
+	for _, adoption := range adoptions {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", adoption.KubernetesNamespace, adoption.VaultNamespace, adoption.Result, adoption.Error)
+	}
+	_ = table.Flush()
+}
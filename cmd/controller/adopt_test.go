@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestRunAdopt tests the ownership metadata is only written outside dry runs,
+// and the exit codes of invalid arguments and namespaces left unadopted.
+func TestRunAdopt(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		dryRun       bool
+		connectErr   bool
+		patchErr     error
+		expectCode   int
+		expectPatch  bool
+		expectStdout string
+		expectStderr string
+	}{
+		{
+			name:         "neither all nor names",
+			expectCode:   2,
+			expectStderr: "pass either --all or Kubernetes namespace names",
+		},
+		{
+			name:         "both all and names",
+			args:         []string{"--all", "app"},
+			expectCode:   2,
+			expectStderr: "Usage: adopt [flags] (--all | kubernetes-namespace...)",
+		},
+		{
+			name:         "unsupported output",
+			args:         []string{"--output=yaml", "--all"},
+			expectCode:   2,
+			expectStderr: `unsupported output format "yaml"`,
+		},
+		{
+			name:         "connection failure",
+			args:         []string{"--all"},
+			connectErr:   true,
+			expectCode:   1,
+			expectStderr: "failed to adopt namespaces: vault address is required",
+		},
+		{
+			name:         "all",
+			args:         []string{"--all"},
+			expectCode:   0,
+			expectPatch:  true,
+			expectStdout: "adopted",
+		},
+		{
+			name:         "dry run",
+			args:         []string{"--dry-run", "app"},
+			expectCode:   0,
+			expectStdout: "would-adopt",
+		},
+		{
+			name:         "dryRun in the config",
+			args:         []string{"app"},
+			dryRun:       true,
+			expectCode:   0,
+			expectStdout: "would-adopt",
+		},
+		{
+			name:         "namespace without a Vault namespace",
+			args:         []string{"--output=json", "missing"},
+			expectCode:   1,
+			expectStdout: `"result": "no-vault-namespace"`,
+		},
+		{
+			name:         "failed write",
+			args:         []string{"app"},
+			patchErr:     errors.New("permission denied"),
+			expectCode:   1,
+			expectPatch:  true,
+			expectStdout: "permission denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The Vault namespace of app predates the controller
+			mockClient := new(mockVaultClient)
+			mockClient.On("ListNamespaces", mock.Anything, "").Return([]string{"k8s-app"}, nil)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-app").Return(map[string]string{}, nil)
+			mockClient.On("PatchNamespaceMetadata", mock.Anything, "k8s-app", mock.Anything).Return(tt.patchErr)
+
+			cfg := &config.ControllerConfig{NamespaceFormat: "k8s-%s", DryRun: tt.dryRun}
+			if tt.connectErr {
+				cfg = nil
+			}
+			useReconciler(t, cfg, mockClient, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}})
+
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, tt.expectCode, runAdopt(tt.args, &stdout, &stderr))
+			assert.Contains(t, stdout.String(), tt.expectStdout)
+			assert.Contains(t, stderr.String(), tt.expectStderr)
+			if tt.expectPatch {
+				mockClient.AssertCalled(t, "PatchNamespaceMetadata", mock.Anything, "k8s-app", ownedMetadata("app"))
+			} else {
+				mockClient.AssertNotCalled(t, "PatchNamespaceMetadata", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/controller"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+)
+
+// auditCommand is the subcommand printing how the Vault namespaces differ from
+// what the controller would make of the Kubernetes namespaces, e.g. from a
+// laptop before enabling the controller.
+const auditCommand = "audit"
+
+// runAudit prints the diff of the cluster's namespaces against Vault. It
+// returns the exit code: 1 if the diff could not be built, 2 for invalid
+// arguments.
+func runAudit(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet(auditCommand, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", os.Getenv(config.EnvPrefix+"_CONFIG"), "Path to controller config file (env VNC_CONFIG)")
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig, instead of $KUBECONFIG, ~/.kube/config or the in-cluster config")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Time allowed to build the diff")
+	output := fs.String("output", "table", "Output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "unsupported output format %q\n", *output)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	reconciler, err := newCommandReconciler(ctx, *configPath, *kubeconfig)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to build the diff: %v\n", err)
+		return 1
+	}
+	diff, err := reconciler.BuildDiff(ctx)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to build the diff: %v\n", err)
+		return 1
+	}
+
+	if *output == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(diff)
+		return 0
+	}
+	printDiff(stdout, diff)
+	return 0
+}
+
+// newCommandReconciler returns the reconciler the subcommands work with. Tests
+// replace it to run them without a cluster or Vault.
+var newCommandReconciler = connectCommandReconciler
+
+// connectCommandReconciler connects to the cluster and Vault the way the
+// controller would with the config file, and returns a reconciler of the local
+// cluster's namespaces for subcommands to inspect them with. It stops reading
+// the cluster when ctx is done.
+func connectCommandReconciler(ctx context.Context, configPath, kubeconfig string) (*controller.NamespaceReconciler, error) {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var restConfig *rest.Config
+	if kubeconfig != "" {
+		restConfig, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	} else {
+		restConfig, err = ctrl.GetConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Read through a cache like the controller's, so the namespace selector
+	// applies the same way
+	var namespaceSelector labels.Selector
+	if cfg.NamespaceSelector != "" {
+		if namespaceSelector, err = labels.Parse(cfg.NamespaceSelector); err != nil {
+			return nil, fmt.Errorf("invalid namespace selector: %w", err)
+		}
+	}
+	kube, err := cluster.New(restConfig, func(o *cluster.Options) {
+		o.Scheme = scheme
+		o.Logger = logr.Discard()
+		if namespaceSelector != nil {
+			o.Cache.ByObject = map[client.Object]cache.ByObject{
+				&corev1.Namespace{}: {Label: namespaceSelector},
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Sync the namespaces before returning, so the subcommands do not read an
+	// empty or unstarted cache
+	namespaceMetadata := &metav1.PartialObjectMetadata{}
+	namespaceMetadata.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+	if _, err := kube.GetCache().GetInformer(ctx, namespaceMetadata); err != nil {
+		return nil, fmt.Errorf("failed to read the cluster: %w", err)
+	}
+	started := make(chan error, 1)
+	go func() { started <- kube.Start(ctx) }()
+	synced := make(chan bool, 1)
+	go func() { synced <- kube.GetCache().WaitForCacheSync(ctx) }()
+	select {
+	case err := <-started:
+		if err == nil {
+			err = ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to read the cluster: %w", err)
+	case ok := <-synced:
+		if !ok {
+			return nil, fmt.Errorf("failed to read the cluster: %w", context.Cause(ctx))
+		}
+	}
+
+	vaultConfig := cfg.Vault
+	if len(cfg.Vault.Auth.SecretRefs()) > 0 {
+		credentials := &controller.SecretCredentials{Reader: kube.GetAPIReader(), Auth: cfg.Vault.Auth, Log: logr.Discard()}
+		if vaultConfig.Auth, err = credentials.Resolve(ctx); err != nil {
+			return nil, fmt.Errorf("failed to read Vault credentials: %w", err)
+		}
+	}
+	vaultClient, err := vault.NewClient(vaultConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	reconciler := &controller.NamespaceReconciler{
+		Client:      kube.GetClient(),
+		APIReader:   kube.GetAPIReader(),
+		Log:         logr.Discard(),
+		VaultClient: vaultClient,
+		Config:      cfg,
+	}
+	if mappingNamespace, mappingName, ok := cfg.MappingConfigMapKey(); ok {
+		reconciler.Mappings = &controller.MappingStore{
+			Client:    kube.GetClient(),
+			Reader:    kube.GetAPIReader(),
+			ConfigMap: types.NamespacedName{Namespace: mappingNamespace, Name: mappingName},
+		}
+	}
+	if cfg.NamespaceClasses {
+		reconciler.Classes = &controller.VaultNamespaceClasses{Reader: kube.GetClient()}
+		reconciler.Blueprints = &controller.BlueprintReconciler{Reader: kube.GetClient(), VaultClient: vaultClient}
+	}
+	return reconciler, nil
+}
+
+// printDiff prints the namespaces needing a change as a table, followed by a
+// summary.
+func printDiff(w io.Writer, diff *controller.Diff) {
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "STATUS\tKUBERNETES NAMESPACE\tVAULT NAMESPACE\tEXPECTED VAULT NAMESPACE")
+	for _, section := range []struct {
+		status  string
+		entries []controller.PlanEntry
+	}{
+		{"to-create", diff.ToCreate},
+		{"to-delete", diff.ToDelete},
+		{"orphan", diff.Orphans},
+	} {
+		for _, entry := range section.entries {
+			fmt.Fprintf(table, "%s\t%s\t%s\t\n", section.status, entry.KubernetesNamespace, entry.VaultNamespace)
+		}
+	}
+	for _, mismatch := range diff.Mismatches {
+		fmt.Fprintf(table, "mismatch\t%s\t%s\t%s\n", mismatch.KubernetesNamespace, mismatch.VaultNamespace, mismatch.ExpectedVaultNamespace)
+	}
+	for _, entry := range diff.Unmanaged {
+		fmt.Fprintf(table, "unmanaged\t\t%s\t\n", entry.VaultNamespace)
+	}
+	_ = table.Flush()
+
+	deletion := "disabled"
+	if diff.DeletionEnabled {
+		deletion = "enabled"
+	}
+	fmt.Fprintf(w, "\n%d in sync, %d to create, %d to delete, %d orphaned, %d mismatched, %d unmanaged (deletion %s)\n",
+		len(diff.InSync), len(diff.ToCreate), len(diff.ToDelete), len(diff.Orphans), len(diff.Mismatches), len(diff.Unmanaged), deletion)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestRunAudit tests the diff is printed in either output format, and the exit
+// codes of invalid arguments and connection failures.
+func TestRunAudit(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		connectErr   bool
+		expectCode   int
+		expectStdout string
+		expectStderr string
+	}{
+		{
+			name:         "unsupported output",
+			args:         []string{"--output=yaml"},
+			expectCode:   2,
+			expectStderr: `unsupported output format "yaml"`,
+		},
+		{
+			name:         "unexpected argument value",
+			args:         []string{"--timeout=soon"},
+			expectCode:   2,
+			expectStderr: `invalid value "soon" for flag -timeout`,
+		},
+		{
+			name:         "connection failure",
+			connectErr:   true,
+			expectCode:   1,
+			expectStderr: "failed to build the diff: vault address is required",
+		},
+		{
+			name:         "table",
+			expectCode:   0,
+			expectStdout: "1 in sync, 1 to create, 0 to delete, 1 orphaned, 0 mismatched, 1 unmanaged (deletion disabled)",
+		},
+		{
+			name:         "json",
+			args:         []string{"--output=json"},
+			expectCode:   0,
+			expectStdout: `"deletionEnabled": false`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// app is synchronized, new has no Vault namespace yet, gone was deleted
+			// and team-x was never managed
+			mockClient := new(mockVaultClient)
+			mockClient.On("ListNamespaces", mock.Anything, "").Return([]string{"k8s-app", "k8s-gone", "team-x"}, nil)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-gone").Return(ownedMetadata("gone"), nil)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "team-x").Return(map[string]string{}, nil)
+
+			cfg := &config.ControllerConfig{NamespaceFormat: "k8s-%s"}
+			if tt.connectErr {
+				cfg = nil
+			}
+			useReconciler(t, cfg, mockClient,
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "new"}})
+
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, tt.expectCode, runAudit(tt.args, &stdout, &stderr))
+			assert.Contains(t, stdout.String(), tt.expectStdout)
+			assert.Contains(t, stderr.String(), tt.expectStderr)
+		})
+	}
+}
+
+// TestConnectCommandReconciler tests the reconciler of the subcommands reads
+// the cluster's namespaces as soon as it is returned.
+func TestConnectCommandReconciler(t *testing.T) {
+	var listFails atomic.Bool
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case req.URL.Path == "/api":
+			fmt.Fprint(w, `{"kind":"APIVersions","versions":["v1"],"serverAddressByClientCIDRs":[]}`)
+		case req.URL.Path == "/apis":
+			fmt.Fprint(w, `{"kind":"APIGroupList","apiVersion":"v1","groups":[]}`)
+		case req.URL.Path == "/api/v1":
+			fmt.Fprint(w, `{"kind":"APIResourceList","groupVersion":"v1","resources":[`+
+				`{"name":"namespaces","singularName":"namespace","namespaced":false,"kind":"Namespace","verbs":["get","list","watch"]}]}`)
+		case req.URL.Path == "/api/v1/namespaces" && req.URL.Query().Get("watch") == "true":
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-req.Context().Done()
+		case req.URL.Path == "/api/v1/namespaces" && listFails.Load():
+			http.Error(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","code":500}`, http.StatusInternalServerError)
+		case req.URL.Path == "/api/v1/namespaces":
+			fmt.Fprint(w, `{"kind":"PartialObjectMetadataList","apiVersion":"meta.k8s.io/v1","metadata":{"resourceVersion":"1"},`+
+				`"items":[{"kind":"PartialObjectMetadata","apiVersion":"meta.k8s.io/v1","metadata":{"name":"app","resourceVersion":"1"}}]}`)
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer apiServer.Close()
+
+	dir := t.TempDir()
+	kubeconfig := filepath.Join(dir, "kubeconfig")
+	assert.NoError(t, os.WriteFile(kubeconfig, []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+current-context: test
+`, apiServer.URL)), 0o600))
+	configPath := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, os.WriteFile(configPath, []byte(`vault:
+  address: https://vault.example.org:8200
+  auth:
+    type: token
+    token: test-token
+`), 0o600))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	reconciler, err := connectCommandReconciler(ctx, configPath, kubeconfig)
+	assert.NoError(t, err)
+	namespaces := &metav1.PartialObjectMetadataList{}
+	namespaces.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("NamespaceList"))
+	assert.NoError(t, reconciler.Client.List(ctx, namespaces))
+	assert.Len(t, namespaces.Items, 1)
+	cancel()
+
+	// A cluster whose namespaces cannot be listed fails instead of returning a
+	// reconciler reading nothing
+	listFails.Store(true)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = connectCommandReconciler(ctx, configPath, kubeconfig)
+	assert.ErrorContains(t, err, "failed to read the cluster")
+}
@@ -2,27 +2,51 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	// Standard library imports
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 
 	// Third-party imports
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	webhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	// Project imports
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/benemon/vault-namespace-controller/pkg/admin"
+	"github.com/benemon/vault-namespace-controller/pkg/audit"
 	"github.com/benemon/vault-namespace-controller/pkg/config"
 	"github.com/benemon/vault-namespace-controller/pkg/controller"
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
 	"github.com/benemon/vault-namespace-controller/pkg/vault"
+	"github.com/benemon/vault-namespace-controller/pkg/version"
 )
 
 // Common error definitions
@@ -39,14 +63,62 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 )
 
+// syncCommand is the subcommand running the controller with --once.
+const syncCommand = "sync"
+
 func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
+	_ = vaultv1alpha1.AddToScheme(scheme)
 }
 
 // main is the entry point for the vault-namespace-controller.
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == validateConfigCommand {
+		os.Exit(runValidateConfig(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == versionCommand {
+		os.Exit(runVersion(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == auditCommand {
+		os.Exit(runAudit(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == orphansCommand {
+		os.Exit(runOrphans(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == adoptCommand {
+		os.Exit(runAdopt(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == pruneCommand {
+		os.Exit(runPrune(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == migrateFormatCommand {
+		os.Exit(runMigrateFormat(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	runSync := len(os.Args) > 1 && os.Args[1] == syncCommand
+	if runSync {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	var configPath string
-	flag.StringVar(&configPath, "config", "", "Path to controller config file")
+	// The flag takes precedence over the environment
+	flag.StringVar(&configPath, "config", os.Getenv(config.EnvPrefix+"_CONFIG"), "Path to controller config file (env VNC_CONFIG)")
+
+	// One-shot mode reconciles every namespace once and exits, for running as a Job
+	var once bool
+	flag.BoolVar(&once, "once", runSync, "Reconcile every namespace once and exit, non-zero when any failed")
+
+	var printVersion bool
+	flag.BoolVar(&printVersion, "version", false, "Print the version and exit")
+
+	// Profiling is for debugging, so it is only enabled by flag
+	var enablePprof bool
+	var pprofBindAddress string
+	flag.BoolVar(&enablePprof, "enable-pprof", false, "Serve net/http/pprof on --pprof-bind-address")
+	flag.StringVar(&pprofBindAddress, "pprof-bind-address", "127.0.0.1:6060", "Address to serve net/http/pprof on with --enable-pprof")
+
+	// Flags for quick runs without a config file, taking precedence over it
+	var overrides config.FlagOverrides
+	overrides.BindFlags(flag.CommandLine)
 
 	opts := zap.Options{
 		Development: false,
@@ -54,14 +126,28 @@ func main() {
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
+	if printVersion {
+		os.Exit(runVersion(nil, os.Stdout, os.Stderr))
+	}
+
+	// Keep the level adjustable at runtime through the admin server
+	logLevel := uberzap.NewAtomicLevelAt(zapcore.InfoLevel)
+	if opts.Level != nil {
+		logLevel.SetLevel(zapcore.LevelOf(opts.Level))
+	}
+	opts.Level = logLevel
 	logger := zap.New(zap.UseFlagOptions(&opts))
 	ctrl.SetLogger(logger)
 
 	// Record start time for initialization metrics
 	startTime := time.Now()
 
+	build := version.Get()
+	metrics.SetBuildInfo(build)
 	setupLog.Info("Starting vault-namespace-controller",
-		"version", getVersion(),
+		"version", build.Version,
+		"commit", build.Commit,
+		"date", build.Date,
 		"configPath", configPath)
 
 	// Load configuration
@@ -72,33 +158,94 @@ func main() {
 			"error", err.Error())
 		os.Exit(1)
 	}
+	if err := overrides.Apply(cfg); err != nil {
+		setupLog.Error(err, "Invalid command line flags")
+		os.Exit(1)
+	}
 
 	logConfig(cfg)
+	// Hot reload compares the file with what was loaded, before any adjustments below
+	fileConfig := *cfg
 
-	// Create vault client
-	setupLog.Info("Creating Vault client", "vaultAddress", cfg.Vault.Address)
-	vaultClient, err := vault.NewClient(cfg.Vault)
+	// With sharding, every replica is active and handles its own slice of namespaces
+	shard, err := controller.ShardFromEnv()
 	if err != nil {
-		setupLog.Error(err, "Failed to create Vault client",
-			"vaultAddress", cfg.Vault.Address,
-			"error", err.Error())
+		setupLog.Error(err, "Failed to read shard configuration")
 		os.Exit(1)
 	}
-	setupLog.Info("Successfully connected to Vault")
+	if shard != nil {
+		setupLog.Info("Sharding enabled, disabling leader election", "shard", shard.String())
+		cfg.LeaderElection = false
+	}
+	if once {
+		setupLog.Info("One-shot mode, disabling leader election and webhooks")
+		cfg.LeaderElection = false
+		cfg.Webhooks = false
+	}
 
 	// Create context with graceful shutdown
 	ctx, cancel := context.WithCancel(ctrl.SetupSignalHandler())
 	defer cancel()
 
+	// Only cache the controller's own ConfigMap, which carries the pause annotation
+	cacheOptions := cache.Options{ByObject: map[client.Object]cache.ByObject{}}
+	configMapNamespace, configMapName, watchConfigMap := cfg.ControllerConfigMapKey()
+	if watchConfigMap {
+		cacheOptions.ByObject[&corev1.ConfigMap{}] = cache.ByObject{
+			Namespaces: map[string]cache.Config{configMapNamespace: {}},
+			Field:      fields.OneTermEqualSelector("metadata.name", configMapName),
+		}
+	}
+	// Only cache the namespaces this controller is responsible for
+	var namespaceSelector labels.Selector
+	if cfg.NamespaceSelector != "" {
+		namespaceSelector, err = labels.Parse(cfg.NamespaceSelector)
+		if err != nil {
+			setupLog.Error(err, "Invalid namespace selector",
+				"namespaceSelector", cfg.NamespaceSelector)
+			os.Exit(1)
+		}
+		cacheOptions.ByObject[&corev1.Namespace{}] = cache.ByObject{Label: namespaceSelector}
+	}
+
+	metrics.SetDurationBuckets(cfg.MetricsBuckets.Reconcile, cfg.MetricsBuckets.Vault)
+
+	if enablePprof {
+		setupLog.Info("Serving pprof", "pprofBindAddress", pprofBindAddress)
+	} else {
+		pprofBindAddress = ""
+	}
+
+	metricsOptions, err := metricsServerOptions(cfg)
+	if err != nil {
+		setupLog.Error(err, "Failed to configure metrics server",
+			"error", err.Error())
+		os.Exit(1)
+	}
+
 	// Create manager for controller
 	setupLog.Info("Setting up controller manager")
+	leaseDuration, renewDeadline, retryPeriod := cfg.LeaderElectionTimings()
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:         scheme,
-		Metrics:        metricsserver.Options{BindAddress: cfg.MetricsBindAddress},
-		WebhookServer:  webhook.NewServer(webhook.Options{Port: 9443}),
+		Scheme:  scheme,
+		Cache:   cacheOptions,
+		Metrics: metricsOptions,
+		// Serve the /healthz and /readyz probes
+		HealthProbeBindAddress: cfg.HealthProbeBindAddress,
+		PprofBindAddress:       pprofBindAddress,
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Port:     cmp.Or(cfg.WebhookServer.Port, 9443),
+			CertDir:  cfg.WebhookServer.CertDir,
+			CertName: cfg.WebhookServer.CertName,
+			KeyName:  cfg.WebhookServer.KeyName,
+		}),
 		LeaderElection: cfg.LeaderElection,
 		// Use a more descriptive leader election ID
-		LeaderElectionID: "vault-namespace-controller-leader",
+		LeaderElectionID:        "vault-namespace-controller-leader",
+		LeaderElectionNamespace: cfg.LeaderElectionNamespace,
+		LeaseDuration:           &leaseDuration,
+		RenewDeadline:           &renewDeadline,
+		RetryPeriod:             &retryPeriod,
 	})
 	if err != nil {
 		setupLog.Error(err, "Failed to create controller manager",
@@ -106,28 +253,440 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Create vault client, reading credentials referenced from Secrets first
+	setupLog.Info("Creating Vault client", "vaultAddress", cfg.Vault.Address)
+	vaultConfig := cfg.Vault
+	var credentials *controller.SecretCredentials
+	if len(cfg.Vault.Auth.SecretRefs()) > 0 {
+		credentials = &controller.SecretCredentials{
+			Reader: mgr.GetAPIReader(),
+			Auth:   cfg.Vault.Auth,
+			Log:    ctrl.Log.WithName("vault-credentials"),
+		}
+		if vaultConfig.Auth, err = credentials.Resolve(ctx); err != nil {
+			setupLog.Error(err, "Failed to read Vault credentials")
+			os.Exit(1)
+		}
+	}
+	vaultClient, err := vault.NewClient(vaultConfig)
+	if err != nil {
+		setupLog.Error(err, "Failed to create Vault client",
+			"vaultAddress", cfg.Vault.Address,
+			"error", err.Error())
+		os.Exit(1)
+	}
+	setupLog.Info("Successfully connected to Vault")
+	if credentials != nil {
+		// Log in again when the Secrets are rotated
+		credentials.Client = vaultClient.(vault.Reauthenticator)
+		if err := mgr.Add(credentials); err != nil {
+			setupLog.Error(err, "Failed to add Vault credentials watcher")
+			os.Exit(1)
+		}
+	}
+
+	pause := &controller.PauseState{}
+	pause.Set(controller.PauseSourceConfig, cfg.Paused)
+	if cfg.Paused {
+		setupLog.Info("Controller is paused by configuration, no changes will be made to Vault")
+	}
+
 	// Create and set up the namespace controller
 	setupLog.Info("Creating namespace controller")
+	// Persist the namespace mappings when a ConfigMap is configured
+	var mappings *controller.MappingStore
+	if mappingNamespace, mappingName, ok := cfg.MappingConfigMapKey(); ok {
+		mappings = &controller.MappingStore{
+			Client:    mgr.GetClient(),
+			Reader:    mgr.GetAPIReader(),
+			ConfigMap: types.NamespacedName{Namespace: mappingNamespace, Name: mappingName},
+		}
+	}
+
+	// Maintain a VaultNamespace resource per namespace when enabled
+	var vaultNamespaces *controller.VaultNamespaceResources
+	if cfg.VaultNamespaceResources {
+		vaultNamespaces = &controller.VaultNamespaceResources{Client: mgr.GetClient()}
+	}
+
+	// Route namespaces to other Vault clusters when enabled
+	if cfg.VaultConnections {
+		vaultClient = &controller.VaultConnections{
+			Default:      vaultClient,
+			Reader:       mgr.GetClient(),
+			SecretReader: mgr.GetAPIReader(),
+		}
+	}
+
+	// Let namespaces select a VaultNamespaceClass, and provision its blueprint, when enabled
+	var classes *controller.VaultNamespaceClasses
+	var blueprints *controller.BlueprintReconciler
+	if cfg.NamespaceClasses {
+		classes = &controller.VaultNamespaceClasses{Reader: mgr.GetClient()}
+		blueprints = &controller.BlueprintReconciler{Reader: mgr.GetClient(), VaultClient: vaultClient}
+	}
+
+	// Apply the VaultNamespaceControllerConfig and configuration file changes without restarts when enabled
+	hotReload := cfg.HotReload && configPath != ""
+	var live *controller.LiveConfig
+	if cfg.LiveConfig || hotReload {
+		live = &controller.LiveConfig{}
+	}
+
+	// The kubernetes auth method bootstrapped in new Vault namespaces trusts this cluster
+	var kubernetesAPI *controller.KubernetesAPI
+	if cfg.Bootstrap.KubernetesAuth.Enabled {
+		kubernetesAPI, err = kubernetesAPIFor(mgr.GetConfig())
+		if err != nil {
+			setupLog.Error(err, "Failed to determine Kubernetes API server for kubernetes auth",
+				"error", err.Error())
+			os.Exit(1)
+		}
+		if cfg.Bootstrap.KubernetesAuth.Host != "" {
+			kubernetesAPI.Host = cfg.Bootstrap.KubernetesAuth.Host
+		}
+		if cfg.Bootstrap.KubernetesAuth.CACertPEM != "" {
+			kubernetesAPI.CACertPEM = cfg.Bootstrap.KubernetesAuth.CACertPEM
+		}
+	}
+
+	// Call the lifecycle hooks with payloads signed by the key in their Secret
+	hooks, err := setupLifecycleHooks(ctx, mgr, cfg.LifecycleHooks)
+	if err != nil {
+		setupLog.Error(err, "Failed to set up lifecycle hooks",
+			"error", err.Error())
+		os.Exit(1)
+	}
+
+	// Stream a record of the Vault namespace changes to the audit sinks
+	auditLog, err := setupAudit(ctx, mgr, cfg.Audit)
+	if err != nil {
+		setupLog.Error(err, "Failed to set up audit sinks",
+			"error", err.Error())
+		os.Exit(1)
+	}
+
+	// Post messages about Vault namespace lifecycle events to the notifiers
+	notifications, err := setupNotifications(mgr, cfg.Notifications)
+	if err != nil {
+		setupLog.Error(err, "Failed to set up notifications",
+			"error", err.Error())
+		os.Exit(1)
+	}
+
+	// Emit CloudEvents for Vault namespace lifecycle events to the sink
+	cloudEvents := controller.NewCloudEvents(cfg.CloudEvents, cfg.ClusterName, ctrl.Log.WithName("cloudevents"))
+	if cloudEvents != nil {
+		if err := mgr.Add(cloudEvents); err != nil {
+			setupLog.Error(err, "Failed to set up CloudEvents",
+				"error", err.Error())
+			os.Exit(1)
+		}
+	}
+
 	namespaceController := &controller.NamespaceReconciler{
-		Client:      mgr.GetClient(),
-		Log:         ctrl.Log.WithName("controllers").WithName("Namespace"),
-		Scheme:      mgr.GetScheme(),
-		VaultClient: vaultClient,
-		Config:      cfg,
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("Namespace"),
+		Scheme:          mgr.GetScheme(),
+		VaultClient:     vaultClient,
+		Config:          cfg,
+		Recorder:        mgr.GetEventRecorderFor("vault-namespace-controller"),
+		Pause:           pause,
+		APIReader:       mgr.GetAPIReader(),
+		Shard:           shard,
+		Mappings:        mappings,
+		VaultNamespaces: vaultNamespaces,
+		Classes:         classes,
+		Blueprints:      blueprints,
+		Live:            live,
+		KubernetesAPI:   kubernetesAPI,
+		Hooks:           hooks,
+		Liveness:        controller.NewReconcileLiveness(cfg.ReconcileTimeout.Duration()),
+		Audit:           auditLog,
+		Notifications:   notifications,
+		CloudEvents:     cloudEvents,
+	}
+
+	// In one-shot mode the namespaces are reconciled by the one-shot sync instead
+	if !once {
+		if err = namespaceController.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "Failed to set up controller",
+				"controller", "Namespace",
+				"error", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if cfg.LiveConfig {
+		liveConfigController := &controller.ControllerConfigReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("ControllerConfig"),
+			Live:   live,
+			Base:   cfg,
+		}
+		if err = liveConfigController.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "Failed to set up controller",
+				"controller", "ControllerConfig",
+				"error", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if hotReload {
+		if err := mgr.Add(&controller.ConfigReloader{
+			Path:      configPath,
+			Current:   &fileConfig,
+			Overrides: overrides,
+			Live:      live,
+			Log:       ctrl.Log.WithName("config"),
+		}); err != nil {
+			setupLog.Error(err, "Failed to add configuration file watcher",
+				"error", err.Error())
+			os.Exit(1)
+		}
 	}
 
-	if err = namespaceController.SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "Failed to set up controller",
-			"controller", "Namespace",
+	if cfg.Webhooks {
+		validator := &controller.ControllerConfigValidator{Base: cfg}
+		if err = validator.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "Failed to set up webhook",
+				"webhook", "VaultNamespaceControllerConfig",
+				"error", err.Error())
+			os.Exit(1)
+		}
+		mgr.GetWebhookServer().Register(controller.NamespaceWebhookPath, &webhook.Admission{
+			Handler: &controller.NamespaceAnnotator{
+				Reconciler:        namespaceController,
+				Decoder:           admission.NewDecoder(mgr.GetScheme()),
+				NamespaceSelector: namespaceSelector,
+				Log:               ctrl.Log.WithName("webhooks").WithName("Namespace"),
+			},
+		})
+		mgr.GetWebhookServer().Register(controller.NamespaceValidationWebhookPath, &webhook.Admission{
+			Handler: &controller.NamespaceValidator{
+				Reconciler:        namespaceController,
+				Decoder:           admission.NewDecoder(mgr.GetScheme()),
+				NamespaceSelector: namespaceSelector,
+				Log:               ctrl.Log.WithName("webhooks").WithName("NamespaceValidation"),
+			},
+		})
+		if cfg.DeletionGuard {
+			mgr.GetWebhookServer().Register(controller.NamespaceDeletionWebhookPath, &webhook.Admission{
+				Handler: &controller.NamespaceDeletionGuard{
+					Reconciler:        namespaceController,
+					Decoder:           admission.NewDecoder(mgr.GetScheme()),
+					NamespaceSelector: namespaceSelector,
+					Log:               ctrl.Log.WithName("webhooks").WithName("NamespaceDeletion"),
+				},
+			})
+		}
+	}
+
+	// Namespaces of remote clusters are reconciled by a controller per cluster
+	reconcilers := []*controller.NamespaceReconciler{namespaceController}
+	for _, remoteCluster := range cfg.RemoteClusters {
+		reconciler, err := setupRemoteCluster(ctx, mgr, remoteCluster, namespaceSelector, namespaceController, !once)
+		if err != nil {
+			setupLog.Error(err, "Failed to set up remote cluster",
+				"cluster", remoteCluster.Name,
+				"error", err.Error())
+			os.Exit(1)
+		}
+		reconcilers = append(reconcilers, reconciler)
+	}
+
+	var oneShot *controller.OneShotSync
+	if once {
+		oneShot = &controller.OneShotSync{
+			Reconcilers: reconcilers,
+			Stop:        cancel,
+			Log:         ctrl.Log.WithName("oneshot"),
+		}
+		if err := mgr.Add(oneShot); err != nil {
+			setupLog.Error(err, "Failed to add one-shot sync",
+				"error", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if err := mgr.Add(&controller.StartupSync{
+		Reconciler: namespaceController,
+		Log:        ctrl.Log.WithName("startup"),
+	}); err != nil {
+		setupLog.Error(err, "Failed to add startup sync tracker",
 			"error", err.Error())
 		os.Exit(1)
 	}
 
+	if watchConfigMap {
+		pauseController := &controller.PauseReconciler{
+			Client:    mgr.GetClient(),
+			Log:       ctrl.Log.WithName("controllers").WithName("Pause"),
+			Pause:     pause,
+			ConfigMap: types.NamespacedName{Namespace: configMapNamespace, Name: configMapName},
+		}
+		if err = pauseController.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "Failed to set up controller",
+				"controller", "Pause",
+				"error", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if cfg.AdminBindAddress != "" {
+		token, err := loadAdminToken(cfg)
+		if err != nil {
+			setupLog.Error(err, "Failed to load admin token",
+				"error", err.Error())
+			os.Exit(1)
+		}
+		adminServer := &admin.Server{
+			BindAddress: cfg.AdminBindAddress,
+			Token:       token,
+			Pause:       pause,
+			Elected:     mgr.Elected(),
+			LogLevel:    &logLevel,
+			Log:         ctrl.Log.WithName("admin"),
+		}
+		if err := mgr.Add(adminServer); err != nil {
+			setupLog.Error(err, "Failed to add admin server",
+				"error", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if cfg.FleetMetricsInterval > 0 {
+		if err := mgr.Add(&controller.FleetMetrics{
+			Reconciler: namespaceController,
+			Log:        ctrl.Log.WithName("metrics"),
+		}); err != nil {
+			setupLog.Error(err, "Failed to add namespace metrics collector",
+				"error", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if cfg.VaultNamespaceMetricsInterval > 0 {
+		if err := mgr.Add(&controller.VaultNamespaceMetrics{
+			Reconciler: namespaceController,
+			Log:        ctrl.Log.WithName("metrics"),
+		}); err != nil {
+			setupLog.Error(err, "Failed to add Vault namespace metrics collector",
+				"error", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if cfg.ReconcileInterval == 0 {
+		if cfg.DriftScanInterval > 0 {
+			setupLog.Info("Periodic reconciles disabled, relying on namespace events and the drift scanner")
+		} else {
+			setupLog.Info("Periodic reconciles and drift scanning are disabled, Vault namespaces deleted out-of-band will not be recreated until their Kubernetes namespace changes")
+		}
+	}
+
+	for _, reconciler := range reconcilers {
+		if cfg.DriftScanInterval > 0 {
+			driftScanner := &controller.DriftScanner{
+				Reconciler: reconciler,
+				Log:        ctrl.Log.WithName("drift").WithValues("cluster", reconciler.Config.ClusterName),
+			}
+			if err := mgr.Add(driftScanner); err != nil {
+				setupLog.Error(err, "Failed to add drift scanner",
+					"error", err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if cfg.OrphanScanInterval > 0 {
+			orphanScanner := &controller.OrphanScanner{
+				Reconciler: reconciler,
+				Log:        ctrl.Log.WithName("orphans").WithValues("cluster", reconciler.Config.ClusterName),
+			}
+			if err := mgr.Add(orphanScanner); err != nil {
+				setupLog.Error(err, "Failed to add orphan scanner",
+					"error", err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if cfg.SyncReportInterval > 0 {
+			syncReporter := &controller.SyncReporter{
+				Reconciler: reconciler,
+				Client:     mgr.GetClient(),
+				Name:       controller.SyncReportName(reconciler.Config.ClusterName, shard),
+				Log:        ctrl.Log.WithName("syncreport").WithValues("cluster", reconciler.Config.ClusterName),
+			}
+			if err := mgr.Add(syncReporter); err != nil {
+				setupLog.Error(err, "Failed to add sync reporter",
+					"error", err.Error())
+				os.Exit(1)
+			}
+		}
+	}
+
+	// Serve the Kubernetes/Vault namespace diff alongside the metrics
+	if err := mgr.AddMetricsServerExtraHandler("/plan", namespaceController.PlanHandler()); err != nil {
+		setupLog.Error(err, "Failed to register plan endpoint",
+			"error", err.Error())
+		os.Exit(1)
+	}
+
+	// Serve the progress of a namespace format migration
+	if err := mgr.AddMetricsServerExtraHandler("/migrate", namespaceController.MigrationHandler()); err != nil {
+		setupLog.Error(err, "Failed to register migration endpoint",
+			"error", err.Error())
+		os.Exit(1)
+	}
+
+	// Ready once authenticated to Vault with the informer caches synced, and
+	// alive while no reconcile is stuck
+	checks := []struct {
+		name    string
+		add     func(string, healthz.Checker) error
+		checker healthz.Checker
+	}{
+		{"ping", mgr.AddHealthzCheck, healthz.Ping},
+		{"reconciler", mgr.AddHealthzCheck, namespaceController.Liveness.Check},
+		{"cache", mgr.AddReadyzCheck, controller.CacheSynced(mgr.GetCache())},
+		{"vault", mgr.AddReadyzCheck, (&controller.VaultReadiness{Client: vaultClient.(vault.TokenChecker)}).Check},
+	}
+	for _, check := range checks {
+		if err := check.add(check.name, check.checker); err != nil {
+			setupLog.Error(err, "Failed to add health check",
+				"check", check.name,
+				"error", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	// Export the Vault token TTL. With a threshold, also renew the token before
+	// it expires, and report not ready while that fails
+	tokenTTL := &controller.TokenTTLMonitor{
+		Client:    vaultClient.(vault.TokenRenewer),
+		Threshold: cfg.Vault.TokenTTLThreshold.Duration(),
+		Log:       ctrl.Log.WithName("vault-token"),
+	}
+	if err := mgr.Add(tokenTTL); err != nil {
+		setupLog.Error(err, "Failed to add Vault token TTL monitor",
+			"error", err.Error())
+		os.Exit(1)
+	}
+	if cfg.Vault.TokenTTLThreshold > 0 {
+		if err := mgr.AddReadyzCheck("vault-token", tokenTTL.Ready); err != nil {
+			setupLog.Error(err, "Failed to add health check",
+				"check", "vault-token",
+				"error", err.Error())
+			os.Exit(1)
+		}
+	}
+
 	// Log successful initialization and timing
 	initDuration := time.Since(startTime)
 	setupLog.Info("Controller initialization complete, starting manager",
 		"initializationTime", initDuration.String(),
 		"metricsBindAddress", cfg.MetricsBindAddress,
+		"healthProbeBindAddress", cfg.HealthProbeBindAddress,
 		"leaderElection", cfg.LeaderElection,
 		"reconcileInterval", cfg.ReconcileInterval)
 
@@ -137,33 +696,294 @@ func main() {
 			"error", err.Error())
 		os.Exit(1)
 	}
+
+	if oneShot != nil {
+		failed, completed := oneShot.Result()
+		if !completed {
+			setupLog.Info("One-shot sync did not complete")
+			os.Exit(1)
+		}
+		if failed > 0 {
+			setupLog.Info("One-shot sync finished with failures", "failed", failed)
+			os.Exit(1)
+		}
+		setupLog.Info("One-shot sync finished")
+	}
 }
 
 // logConfig logs the controller configuration at startup
 func logConfig(cfg *config.ControllerConfig) {
 	setupLog.Info("Controller configuration",
 		"reconcileInterval", cfg.ReconcileInterval,
+		"reconcileTimeout", cfg.ReconcileTimeout,
 		"deleteVaultNamespaces", cfg.DeleteVaultNamespaces,
+		"deletionGracePeriod", cfg.DeletionGracePeriod,
+		"deleteNonEmptyNamespaces", cfg.DeleteNonEmptyNamespaces,
 		"namespaceFormat", cfg.NamespaceFormat,
+		"namespaceTemplate", cfg.NamespaceTemplate,
+		"maxNamespaceNameLength", cfg.MaxNamespaceNameLength,
+		"strictNamespaceNames", cfg.StrictNamespaceNames,
+		"mirrorHierarchy", cfg.MirrorHierarchy,
+		"capsuleTenants", cfg.CapsuleTenants,
+		"remoteClustersCount", len(cfg.RemoteClusters),
+		"staticMappingsCount", len(cfg.StaticMappings),
+		"customMetadataLabels", cfg.CustomMetadata.Labels,
+		"customMetadataAnnotations", cfg.CustomMetadata.Annotations,
+		"policyTemplatesCount", len(cfg.PolicyTemplates),
+		"identityGroupsCount", len(cfg.IdentityGroups),
+		"sentinelPoliciesCount", len(cfg.SentinelPolicies),
+		"vso", cfg.VSO.Enabled,
+		"eso", cfg.ESO.Enabled,
+		"connectionConfigMap", cfg.ConnectionConfigMap,
+		"lifecycleHooksCount", len(cfg.LifecycleHooks.Hooks),
+		"auditFile", cfg.Audit.File != nil,
+		"auditWebhook", cfg.Audit.Webhook != nil,
+		"notifiersCount", len(cfg.Notifications.Notifiers),
+		"cloudEventsSink", cfg.CloudEvents.Sink,
+		"migrationPreviousFormat", cfg.Migration.PreviousFormat,
+		"bootstrapKVMount", cfg.Bootstrap.KVMount,
+		"bootstrapKubernetesAuth", cfg.Bootstrap.KubernetesAuth.Enabled,
+		"bootstrapAuditDevice", cfg.Bootstrap.AuditDevice.Type,
+		"migrationDeleteOld", cfg.Migration.DeleteOld,
+		"mappingConfigMap", cfg.MappingConfigMap,
+		"hotReload", cfg.HotReload,
+		"vaultNamespaceResources", cfg.VaultNamespaceResources,
+		"namespaceClasses", cfg.NamespaceClasses,
+		"vaultConnections", cfg.VaultConnections,
+		"liveConfig", cfg.LiveConfig,
+		"webhooks", cfg.Webhooks,
+		"webhookPort", cmp.Or(cfg.WebhookServer.Port, 9443),
+		"webhookCertDir", cfg.WebhookServer.CertDir,
+		"deletionGuard", cfg.DeletionGuard,
+		"clusterName", cfg.ClusterName,
+		"existingNamespacePolicy", cfg.ExistingNamespacePolicy,
+		"syncWorkers", cfg.SyncWorkers,
+		"errorBackoffBase", cfg.ErrorBackoffBase,
+		"errorBackoffMax", cfg.ErrorBackoffMax,
+		"fleetMetricsInterval", cfg.FleetMetricsInterval,
+		"vaultNamespaceMetricsInterval", cfg.VaultNamespaceMetricsInterval,
+		"namespaceInfoMetrics", cfg.NamespaceInfoMetrics.Enabled,
+		"reconcileBuckets", cfg.MetricsBuckets.Reconcile,
+		"vaultBuckets", cfg.MetricsBuckets.Vault,
+		"driftScanInterval", cfg.DriftScanInterval,
+		"namespaceSelector", cfg.NamespaceSelector,
+		"maxManagedNamespaces", cfg.MaxManagedNamespaces,
+		"orphanPolicy", cfg.OrphanPolicy,
+		"orphanScanInterval", cfg.OrphanScanInterval,
+		"orphanMinAge", cfg.OrphanMinAge,
+		"syncReportInterval", cfg.SyncReportInterval,
 		"includeNamespacesCount", len(cfg.IncludeNamespaces),
 		"excludeNamespacesCount", len(cfg.ExcludeNamespaces),
+		"patternSyntax", cfg.PatternSyntax,
+		"strictMatch", cfg.StrictMatch,
+		"includeExpressionsCount", len(cfg.IncludeExpressions),
+		"excludeExpressionsCount", len(cfg.ExcludeExpressions),
 		"metricsBindAddress", cfg.MetricsBindAddress,
-		"leaderElection", cfg.LeaderElection)
+		"metricsSecure", cfg.MetricsServer.Secure,
+		"metricsAuth", cfg.MetricsServer.Auth,
+		"adminBindAddress", cfg.AdminBindAddress,
+		"leaderElection", cfg.LeaderElection,
+		"leaderElectionNamespace", cfg.LeaderElectionNamespace,
+		"leaderElectionLeaseDuration", cfg.LeaderElectionLeaseDuration,
+		"leaderElectionRenewDeadline", cfg.LeaderElectionRenewDeadline,
+		"leaderElectionRetryPeriod", cfg.LeaderElectionRetryPeriod,
+		"dryRun", cfg.DryRun,
+		"paused", cfg.Paused)
+
+	if cfg.DryRun {
+		setupLog.Info("Dry-run mode enabled, no changes will be made to Vault")
+	}
 
 	// Log Vault configuration without sensitive information
 	setupLog.Info("Vault configuration",
 		"address", cfg.Vault.Address,
 		"namespaceRoot", cfg.Vault.NamespaceRoot,
 		"authType", cfg.Vault.Auth.Type,
+		"timeout", cfg.Vault.Timeout,
+		"tokenTTLThreshold", cfg.Vault.TokenTTLThreshold,
 		"tlsConfigured", (cfg.Vault.CACert != "" || cfg.Vault.ClientCert != ""))
 }
 
-// getVersion returns the controller version
-func getVersion() string {
-	// This would typically be injected at build time via ldflags
-	version := os.Getenv("VERSION")
-	if version == "" {
-		return "dev"
+// setupRemoteCluster connects to a remote cluster using the kubeconfig in its
+// Secret and, with watch, registers a controller for its namespaces
+func setupRemoteCluster(ctx context.Context, mgr ctrl.Manager, remoteCluster config.RemoteClusterConfig,
+	namespaceSelector labels.Selector, local *controller.NamespaceReconciler, watch bool) (*controller.NamespaceReconciler, error) {
+	ref := remoteCluster.KubeconfigSecret
+	secret := &corev1.Secret{}
+	if err := mgr.GetAPIReader().Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, secret); err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig secret %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+	kubeconfig, ok := secret.Data[ref.KubeconfigKey()]
+	if !ok {
+		return nil, fmt.Errorf("kubeconfig secret %s/%s has no key %q", ref.Namespace, ref.Name, ref.KubeconfigKey())
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig in secret %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+
+	remote, err := cluster.New(restConfig, func(o *cluster.Options) {
+		o.Scheme = scheme
+		if namespaceSelector != nil {
+			o.Cache.ByObject = map[client.Object]cache.ByObject{
+				&corev1.Namespace{}: {Label: namespaceSelector},
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := mgr.Add(remote); err != nil {
+		return nil, err
+	}
+	if err := mgr.AddReadyzCheck("cache-"+remoteCluster.Name, controller.CacheSynced(remote.GetCache())); err != nil {
+		return nil, err
+	}
+
+	reconciler := controller.NewRemoteReconciler(local, remoteCluster.Name, remote)
+	if local.KubernetesAPI != nil {
+		if reconciler.KubernetesAPI, err = kubernetesAPIFor(restConfig); err != nil {
+			return nil, err
+		}
+	}
+	if watch {
+		if err := reconciler.SetupWithCluster(mgr, remoteCluster.Name, remote); err != nil {
+			return nil, err
+		}
+	}
+	setupLog.Info("Watching remote cluster", "cluster", remoteCluster.Name, "host", restConfig.Host)
+	return reconciler, nil
+}
+
+// setupLifecycleHooks returns the configured lifecycle hooks, reading their
+// signing key from its Secret
+func setupLifecycleHooks(ctx context.Context, mgr ctrl.Manager, hooksConfig config.LifecycleHooksConfig) (*controller.LifecycleHooks, error) {
+	ref := hooksConfig.SigningKeySecret
+	if len(hooksConfig.Hooks) == 0 || ref == nil {
+		return controller.NewLifecycleHooks(hooksConfig, nil), nil
+	}
+	signingKey, err := readSigningKey(ctx, mgr, *ref)
+	if err != nil {
+		return nil, err
+	}
+	return controller.NewLifecycleHooks(hooksConfig, signingKey), nil
+}
+
+// setupAudit returns the audit log writing to the configured sinks, or nil
+// when none are configured. The webhook sink delivers records once the
+// manager starts.
+func setupAudit(ctx context.Context, mgr ctrl.Manager, auditConfig config.AuditConfig) (*audit.Log, error) {
+	auditLog := &audit.Log{Log: ctrl.Log.WithName("audit")}
+	if file := auditConfig.File; file != nil {
+		sink, err := audit.NewFileSink(file.Path, file.MaxSize(), file.Backups())
+		if err != nil {
+			return nil, err
+		}
+		auditLog.Sinks = append(auditLog.Sinks, sink)
+	}
+	if webhook := auditConfig.Webhook; webhook != nil {
+		var signingKey []byte
+		if ref := webhook.SigningKeySecret; ref != nil {
+			var err error
+			if signingKey, err = readSigningKey(ctx, mgr, *ref); err != nil {
+				return nil, err
+			}
+		}
+		sink := audit.NewWebhookSink(webhook.URL, signingKey, webhook.Buffer(), webhook.Timeout(), ctrl.Log.WithName("audit"))
+		if err := mgr.Add(sink); err != nil {
+			return nil, err
+		}
+		auditLog.Sinks = append(auditLog.Sinks, sink)
+	}
+	if len(auditLog.Sinks) == 0 {
+		return nil, nil
+	}
+	return auditLog, nil
+}
+
+// setupNotifications returns the configured notifications, or nil when no
+// notifiers are configured. Notifications are sent once the manager starts.
+func setupNotifications(mgr ctrl.Manager, notificationsConfig config.NotificationsConfig) (*controller.Notifications, error) {
+	notifications, err := controller.NewNotifications(notificationsConfig, ctrl.Log.WithName("notifications"))
+	if err != nil || notifications == nil {
+		return nil, err
+	}
+	if err := mgr.Add(notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// readSigningKey reads the HMAC signing key in the Secret ref refers to.
+func readSigningKey(ctx context.Context, mgr ctrl.Manager, ref config.SecretKeyRef) ([]byte, error) {
+	secret := &corev1.Secret{}
+	if err := mgr.GetAPIReader().Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, secret); err != nil {
+		return nil, fmt.Errorf("failed to read signing key secret %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+	signingKey, ok := secret.Data[ref.SigningKeyKey()]
+	if !ok || len(signingKey) == 0 {
+		return nil, fmt.Errorf("signing key secret %s/%s has no key %q", ref.Namespace, ref.Name, ref.SigningKeyKey())
+	}
+	return signingKey, nil
+}
+
+// metricsServerOptions returns the metrics server options for the TLS and
+// authentication settings of cfg
+func metricsServerOptions(cfg *config.ControllerConfig) (metricsserver.Options, error) {
+	metrics := cfg.MetricsServer
+	options := metricsserver.Options{
+		BindAddress:   cfg.MetricsBindAddress,
+		SecureServing: metrics.Secure,
+		CertDir:       metrics.CertDir,
+		CertName:      metrics.CertName,
+		KeyName:       metrics.KeyName,
+	}
+	switch metrics.Auth {
+	case config.MetricsAuthToken:
+		// Scrapers need a token allowed to get /metrics
+		options.FilterProvider = filters.WithAuthenticationAndAuthorization
+	case config.MetricsAuthClientCert:
+		caCert, err := os.ReadFile(metrics.ClientCAFile)
+		if err != nil {
+			return options, fmt.Errorf("failed to read metrics client CA %q: %w", metrics.ClientCAFile, err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caCert) {
+			return options, fmt.Errorf("no certificates found in metrics client CA %q", metrics.ClientCAFile)
+		}
+		options.TLSOpts = append(options.TLSOpts, func(tlsConfig *tls.Config) {
+			tlsConfig.ClientCAs = clientCAs
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		})
+	}
+	return options, nil
+}
+
+// kubernetesAPIFor returns the API server and CA certificate of restConfig
+func kubernetesAPIFor(restConfig *rest.Config) (*controller.KubernetesAPI, error) {
+	caCert := restConfig.CAData
+	if len(caCert) == 0 && restConfig.CAFile != "" {
+		data, err := os.ReadFile(restConfig.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Kubernetes CA certificate %q: %w", restConfig.CAFile, err)
+		}
+		caCert = data
+	}
+	return &controller.KubernetesAPI{Host: restConfig.Host, CACertPEM: string(caCert)}, nil
+}
+
+// loadAdminToken returns the admin bearer token from the config or its token file
+func loadAdminToken(cfg *config.ControllerConfig) (string, error) {
+	if cfg.AdminToken != "" {
+		return cfg.AdminToken, nil
+	}
+	data, err := os.ReadFile(cfg.AdminTokenPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read admin token from file %q: %w", cfg.AdminTokenPath, err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("admin token file %q is empty", cfg.AdminTokenPath)
 	}
-	return version
+	return token, nil
 }
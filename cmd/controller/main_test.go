@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/mock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/controller"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+)
+
+// mockVaultClient mocks the Vault client calls of the subcommands. Any other
+// call panics on the nil embedded client.
+type mockVaultClient struct {
+	mock.Mock
+	vault.Client
+}
+
+func (m *mockVaultClient) NamespaceExists(ctx context.Context, path string) (bool, error) {
+	args := m.Called(ctx, path)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockVaultClient) ListNamespaces(ctx context.Context, parent string) ([]string, error) {
+	args := m.Called(ctx, parent)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockVaultClient) CreateNamespace(ctx context.Context, path string, customMetadata map[string]string) error {
+	args := m.Called(ctx, path, customMetadata)
+	return args.Error(0)
+}
+
+func (m *mockVaultClient) GetNamespaceMetadata(ctx context.Context, path string) (map[string]string, error) {
+	args := m.Called(ctx, path)
+	return args.Get(0).(map[string]string), args.Error(1)
+}
+
+func (m *mockVaultClient) PatchNamespaceMetadata(ctx context.Context, path string, customMetadata map[string]string) error {
+	args := m.Called(ctx, path, customMetadata)
+	return args.Error(0)
+}
+
+func (m *mockVaultClient) NamespaceEmpty(ctx context.Context, path string) (bool, error) {
+	args := m.Called(ctx, path)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockVaultClient) DeleteNamespace(ctx context.Context, path string) error {
+	args := m.Called(ctx, path)
+	return args.Error(0)
+}
+
+// ownedMetadata returns the custom metadata the controller stamps on the Vault
+// namespace of namespaceName.
+func ownedMetadata(namespaceName string) map[string]string {
+	return map[string]string{
+		controller.MetadataManagedBy:           "vault-namespace-controller",
+		controller.MetadataKubernetesCluster:   "",
+		controller.MetadataKubernetesNamespace: namespaceName,
+	}
+}
+
+// useReconciler makes the subcommands run against the Kubernetes objects and
+// vaultClient with cfg, instead of connecting to a cluster and Vault. A nil cfg
+// makes connecting fail.
+func useReconciler(t *testing.T, cfg *config.ControllerConfig, vaultClient vault.Client, objects ...client.Object) {
+	t.Helper()
+	previous := newCommandReconciler
+	t.Cleanup(func() { newCommandReconciler = previous })
+
+	kube := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	newCommandReconciler = func(context.Context, string, string) (*controller.NamespaceReconciler, error) {
+		if cfg == nil {
+			return nil, config.ErrMissingVaultAddress
+		}
+		return &controller.NamespaceReconciler{
+			Client:      kube,
+			APIReader:   kube,
+			Log:         logr.Discard(),
+			VaultClient: vaultClient,
+			Config:      cfg,
+		}, nil
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/controller"
+)
+
+// migrateFormatCommand is the subcommand planning the move of the synchronized
+// namespaces from one namespaceFormat to another.
+const migrateFormatCommand = "migrate-format"
+
+// runMigrateFormat prints the transition from --from to --to: the Vault
+// namespaces to create, the old ones left orphaned and the ambiguous moves.
+// With --execute it also creates the new Vault namespaces. It returns the
+// exit code: 1 if the plan could not be built or any creation failed, 2 for
+// invalid arguments.
+func runMigrateFormat(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet(migrateFormatCommand, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", os.Getenv(config.EnvPrefix+"_CONFIG"), "Path to controller config file (env VNC_CONFIG)")
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig, instead of $KUBECONFIG, ~/.kube/config or the in-cluster config")
+	timeout := fs.Duration("timeout", 10*time.Minute, "Time allowed to plan and execute the migration")
+	output := fs.String("output", "table", "Output format: table or json")
+	from := fs.String("from", "", "Format the existing Vault namespaces were created with, defaults to namespaceFormat in the config file")
+	to := fs.String("to", "", "Format to move the namespaces to")
+	execute := fs.Bool("execute", false, "Create the Vault namespaces at the new paths instead of only printing the plan")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "unsupported output format %q\n", *output)
+		return 2
+	}
+	if *to == "" {
+		fmt.Fprintln(stderr, "--to is required")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	reconciler, err := newCommandReconciler(ctx, *configPath, *kubeconfig)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to plan the migration: %v\n", err)
+		return 1
+	}
+	if *from == "" {
+		*from = reconciler.Config.NamespaceFormat
+	}
+	for name, format := range map[string]string{"--from": *from, "--to": *to} {
+		if strings.Contains(format, config.ClusterPlaceholder) && reconciler.Config.ClusterName == "" {
+			fmt.Fprintf(stderr, "%s uses %s but clusterName is not set\n", name, config.ClusterPlaceholder)
+			return 2
+		}
+	}
+
+	plan, err := reconciler.BuildFormatMigrationPlan(ctx, *from, *to)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to plan the migration: %v\n", err)
+		return 1
+	}
+	printFormatMigrationPlan(stdout, *output, plan)
+	if !*execute {
+		return 0
+	}
+	if reconciler.Config.DryRun {
+		fmt.Fprintln(stderr, "dryRun is set in the config file, nothing was created")
+	}
+
+	failed := reconciler.ExecuteFormatMigration(ctx, plan, logr.Discard())
+	if len(failed) == 0 {
+		return 0
+	}
+	fmt.Fprintf(stderr, "failed to create %d Vault namespaces:\n", len(failed))
+	for _, entry := range failed {
+		fmt.Fprintf(stderr, "  %s (%s): %s\n", entry.NewVaultNamespace, entry.KubernetesNamespace, entry.Error)
+	}
+	return 1
+}
+
+// printFormatMigrationPlan prints the entries of the plan, followed by a
+// summary in table output.
+func printFormatMigrationPlan(w io.Writer, output string, plan *controller.FormatMigrationPlan) {
+	if output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(plan)
+		return
+	}
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "STATUS\tKUBERNETES NAMESPACE\tOLD VAULT NAMESPACE\tNEW VAULT NAMESPACE\tREASON")
+	for _, group := range []struct {
+		status  string
+		entries []controller.FormatMigrationEntry
+	}{
+		{"to-create", plan.ToCreate},
+		{"orphaned", plan.Orphaned},
+		{"ambiguous", plan.Ambiguous},
+	} {
+		for _, entry := range group.entries {
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n", group.status, entry.KubernetesNamespace,
+				entry.OldVaultNamespace, entry.NewVaultNamespace, entry.Reason)
+		}
+	}
+	_ = table.Flush()
+	fmt.Fprintf(w, "\n%d to create, %d orphaned, %d ambiguous, %d unchanged\n",
+		len(plan.ToCreate), len(plan.Orphaned), len(plan.Ambiguous), plan.Unchanged)
+}
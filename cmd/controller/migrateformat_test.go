@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestRunMigrateFormat tests the Vault namespaces are only created with
+// --execute outside dry runs, and the exit codes of invalid arguments and
+// failed creations.
+func TestRunMigrateFormat(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		dryRun       bool
+		connectErr   bool
+		createErr    error
+		expectCode   int
+		expectCreate bool
+		expectStdout string
+		expectStderr string
+	}{
+		{
+			name:         "missing to",
+			expectCode:   2,
+			expectStderr: "--to is required",
+		},
+		{
+			name:         "unsupported output",
+			args:         []string{"--to=vault-%s", "--output=yaml"},
+			expectCode:   2,
+			expectStderr: `unsupported output format "yaml"`,
+		},
+		{
+			name:         "cluster placeholder without clusterName",
+			args:         []string{"--to=%{cluster}-%s"},
+			expectCode:   2,
+			expectStderr: "--to uses %{cluster} but clusterName is not set",
+		},
+		{
+			name:         "connection failure",
+			args:         []string{"--to=vault-%s"},
+			connectErr:   true,
+			expectCode:   1,
+			expectStderr: "failed to plan the migration: vault address is required",
+		},
+		{
+			name:         "plan",
+			args:         []string{"--to=vault-%s"},
+			expectCode:   0,
+			expectStdout: "1 to create, 1 orphaned, 0 ambiguous, 0 unchanged",
+		},
+		{
+			name:         "plan from another format",
+			args:         []string{"--from=vault-%s", "--to=vault-%s", "--output=json"},
+			expectCode:   0,
+			expectStdout: `"unchanged": 1`,
+		},
+		{
+			name:         "execute",
+			args:         []string{"--to=vault-%s", "--execute"},
+			expectCode:   0,
+			expectCreate: true,
+		},
+		{
+			name:         "execute with dryRun in the config",
+			args:         []string{"--to=vault-%s", "--execute"},
+			dryRun:       true,
+			expectCode:   0,
+			expectStderr: "dryRun is set in the config file, nothing was created",
+		},
+		{
+			name:         "failed creation",
+			args:         []string{"--to=vault-%s", "--execute"},
+			createErr:    errors.New("permission denied"),
+			expectCode:   1,
+			expectCreate: true,
+			expectStderr: "failed to create 1 Vault namespaces:\n  vault-app (app)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// app moves from k8s-app to vault-app
+			mockClient := new(mockVaultClient)
+			mockClient.On("NamespaceExists", mock.Anything, "k8s-app").Return(true, nil)
+			mockClient.On("NamespaceExists", mock.Anything, "vault-app").Return(false, nil)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-app").Return(ownedMetadata("app"), nil)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "vault-app").Return(ownedMetadata("app"), nil)
+			mockClient.On("CreateNamespace", mock.Anything, "vault-app", ownedMetadata("app")).Return(tt.createErr)
+			mockClient.On("PatchNamespaceMetadata", mock.Anything, "vault-app", mock.Anything).Return(nil)
+
+			cfg := &config.ControllerConfig{NamespaceFormat: "k8s-%s", DryRun: tt.dryRun}
+			if tt.connectErr {
+				cfg = nil
+			}
+			useReconciler(t, cfg, mockClient, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}})
+
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, tt.expectCode, runMigrateFormat(tt.args, &stdout, &stderr))
+			assert.Contains(t, stdout.String(), tt.expectStdout)
+			assert.Contains(t, stderr.String(), tt.expectStderr)
+			if tt.expectCreate {
+				mockClient.AssertCalled(t, "CreateNamespace", mock.Anything, "vault-app", ownedMetadata("app"))
+			} else {
+				mockClient.AssertNotCalled(t, "CreateNamespace", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/controller"
+)
+
+// orphansCommand is the subcommand listing the Vault namespaces without a
+// Kubernetes namespace, for manual cleanup reviews.
+const orphansCommand = "orphans"
+
+// runOrphans prints the Vault namespaces no Kubernetes namespace maps to. It
+// returns the exit code: 1 if they could not be listed, 2 for invalid
+// arguments.
+func runOrphans(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet(orphansCommand, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", os.Getenv(config.EnvPrefix+"_CONFIG"), "Path to controller config file (env VNC_CONFIG)")
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig, instead of $KUBECONFIG, ~/.kube/config or the in-cluster config")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Time allowed to list the orphans")
+	output := fs.String("output", "table", "Output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "unsupported output format %q\n", *output)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	reconciler, err := newCommandReconciler(ctx, *configPath, *kubeconfig)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to list orphans: %v\n", err)
+		return 1
+	}
+	orphans, err := reconciler.ListOrphanedNamespaces(ctx)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to list orphans: %v\n", err)
+		return 1
+	}
+
+	if *output == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(orphans)
+		return 0
+	}
+	printOrphans(stdout, orphans)
+	return 0
+}
+
+// printOrphans prints the orphans as a table, with their custom metadata as
+// sorted key=value pairs.
+func printOrphans(w io.Writer, orphans []controller.OrphanedNamespace) {
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "VAULT NAMESPACE\tKUBERNETES NAMESPACE\tOWNED\tAGE\tCUSTOM METADATA")
+	for _, orphan := range orphans {
+		pairs := make([]string, 0, len(orphan.CustomMetadata))
+		for key, value := range orphan.CustomMetadata {
+			pairs = append(pairs, key+"="+value)
+		}
+		sort.Strings(pairs)
+		fmt.Fprintf(table, "%s\t%s\t%t\t%s\t%s\n", orphan.VaultNamespace, orphan.KubernetesNamespace,
+			orphan.Owned, cmp.Or(orphan.Age, "unknown"), strings.Join(pairs, ","))
+	}
+	_ = table.Flush()
+}
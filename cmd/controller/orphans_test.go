@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestRunOrphans tests the orphans are printed in either output format, and
+// the exit codes of invalid arguments and failures to list them.
+func TestRunOrphans(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		connectErr   bool
+		listErr      error
+		expectCode   int
+		expectStdout string
+		expectStderr string
+	}{
+		{
+			name:         "unsupported output",
+			args:         []string{"--output=yaml"},
+			expectCode:   2,
+			expectStderr: `unsupported output format "yaml"`,
+		},
+		{
+			name:         "unexpected argument",
+			args:         []string{"--all"},
+			expectCode:   2,
+			expectStderr: "flag provided but not defined: -all",
+		},
+		{
+			name:         "connection failure",
+			connectErr:   true,
+			expectCode:   1,
+			expectStderr: "failed to list orphans: vault address is required",
+		},
+		{
+			name:         "vault failure",
+			listErr:      errors.New("permission denied"),
+			expectCode:   1,
+			expectStderr: "failed to list orphans: permission denied",
+		},
+		{
+			name:         "table",
+			expectCode:   0,
+			expectStdout: "kubernetes-cluster=,kubernetes-namespace=gone,managed-by=vault-namespace-controller",
+		},
+		{
+			name:         "json",
+			args:         []string{"--output=json"},
+			expectCode:   0,
+			expectStdout: `"vaultNamespace": "team-x",`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// app is synchronized, gone was deleted and team-x was never managed
+			mockClient := new(mockVaultClient)
+			mockClient.On("ListNamespaces", mock.Anything, "").Return([]string{"k8s-app", "k8s-gone", "team-x"}, tt.listErr)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-gone").Return(ownedMetadata("gone"), nil)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "team-x").Return(map[string]string{}, nil)
+
+			cfg := &config.ControllerConfig{NamespaceFormat: "k8s-%s"}
+			if tt.connectErr {
+				cfg = nil
+			}
+			useReconciler(t, cfg, mockClient, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}})
+
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, tt.expectCode, runOrphans(tt.args, &stdout, &stderr))
+			assert.Contains(t, stdout.String(), tt.expectStdout)
+			assert.Contains(t, stderr.String(), tt.expectStderr)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"text/tabwriter"
+	"time"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/controller"
+)
+
+// pruneCommand is the subcommand deleting orphaned Vault namespaces owned by
+// the controller, for housekeeping outside its orphan policy.
+const pruneCommand = "prune"
+
+// runPrune lists the orphaned Vault namespaces matching the filters and, with
+// --yes and --confirm-count set to their number, deletes them. It returns the
+// exit code: 1 if the confirmation did not match or any deletion failed, 2
+// for invalid arguments.
+func runPrune(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet(pruneCommand, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", os.Getenv(config.EnvPrefix+"_CONFIG"), "Path to controller config file (env VNC_CONFIG)")
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig, instead of $KUBECONFIG, ~/.kube/config or the in-cluster config")
+	timeout := fs.Duration("timeout", 10*time.Minute, "Time allowed to prune the namespaces")
+	output := fs.String("output", "table", "Output format: table or json")
+	minAge := fs.Duration("min-age", 0, "Only prune namespaces created at least this long ago, skipping those of unknown age")
+	pattern := fs.String("pattern", "", "Only prune namespaces whose Vault path matches this regular expression")
+	nonEmpty := fs.Bool("non-empty", false, "Also prune namespaces with secret or auth mounts")
+	yes := fs.Bool("yes", false, "Delete the namespaces instead of listing them")
+	confirmCount := fs.Int("confirm-count", -1, "Number of namespaces expected to be deleted, required with --yes")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "unsupported output format %q\n", *output)
+		return 2
+	}
+	filter := controller.PruneFilter{MinAge: *minAge}
+	if *pattern != "" {
+		re, err := regexp.Compile(*pattern)
+		if err != nil {
+			fmt.Fprintf(stderr, "invalid pattern: %v\n", err)
+			return 2
+		}
+		filter.Pattern = re
+	}
+	if *yes && *confirmCount < 0 {
+		fmt.Fprintln(stderr, "--yes requires --confirm-count with the number of namespaces to delete")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	reconciler, err := newCommandReconciler(ctx, *configPath, *kubeconfig)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to list orphans: %v\n", err)
+		return 1
+	}
+	orphans, err := reconciler.ListOrphanedNamespaces(ctx)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to list orphans: %v\n", err)
+		return 1
+	}
+	now := time.Now()
+	var selected []controller.OrphanedNamespace
+	for _, orphan := range orphans {
+		if filter.Matches(orphan, now) {
+			selected = append(selected, orphan)
+		}
+	}
+
+	if !*yes || reconciler.Config.DryRun {
+		prunes := make([]controller.Prune, 0, len(selected))
+		for _, orphan := range selected {
+			prunes = append(prunes, controller.Prune{VaultNamespace: orphan.VaultNamespace,
+				KubernetesNamespace: orphan.KubernetesNamespace, Result: controller.PruneWouldDelete})
+		}
+		if *yes {
+			fmt.Fprintln(stderr, "dryRun is set in the config file, nothing was deleted")
+		}
+		printPrunes(stdout, *output, prunes)
+		if *output == "table" {
+			fmt.Fprintf(stdout, "\n%d Vault namespaces would be deleted, run again with --yes --confirm-count=%d to delete them\n",
+				len(selected), len(selected))
+		}
+		return 0
+	}
+	if *confirmCount != len(selected) {
+		fmt.Fprintf(stderr, "refusing to delete: %d Vault namespaces match, but --confirm-count is %d\n", len(selected), *confirmCount)
+		return 1
+	}
+
+	failed := false
+	prunes := make([]controller.Prune, 0, len(selected))
+	for _, orphan := range selected {
+		prune := reconciler.PruneNamespace(ctx, orphan, *nonEmpty)
+		failed = failed || prune.Result == controller.PruneFailed
+		prunes = append(prunes, prune)
+	}
+	printPrunes(stdout, *output, prunes)
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// printPrunes prints the result of every prune.
+func printPrunes(w io.Writer, output string, prunes []controller.Prune) {
+	if output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(prunes)
+		return
+	}
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "VAULT NAMESPACE\tKUBERNETES NAMESPACE\tRESULT\tERROR")
+	for _, prune := range prunes {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", prune.VaultNamespace, prune.KubernetesNamespace, prune.Result, prune.Error)
+	}
+	_ = table.Flush()
+}
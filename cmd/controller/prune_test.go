@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestRunPrune tests orphans are only deleted with --yes and a matching
+// --confirm-count, and never with dryRun set.
+func TestRunPrune(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		dryRun       bool
+		connectErr   bool
+		deleteErr    error
+		expectCode   int
+		expectDelete bool
+		expectStdout string
+		expectStderr string
+	}{
+		{
+			name:         "unsupported output",
+			args:         []string{"--output=yaml"},
+			expectCode:   2,
+			expectStderr: `unsupported output format "yaml"`,
+		},
+		{
+			name:         "invalid pattern",
+			args:         []string{"--pattern=("},
+			expectCode:   2,
+			expectStderr: "invalid pattern",
+		},
+		{
+			name:         "unknown flag",
+			args:         []string{"--force"},
+			expectCode:   2,
+			expectStderr: "flag provided but not defined: -force",
+		},
+		{
+			name:         "yes without confirm-count",
+			args:         []string{"--yes"},
+			expectCode:   2,
+			expectStderr: "--yes requires --confirm-count",
+		},
+		{
+			name:         "connection failure",
+			connectErr:   true,
+			expectCode:   1,
+			expectStderr: "failed to list orphans: vault address is required",
+		},
+		{
+			name:         "lists without yes",
+			args:         []string{"--confirm-count=1"},
+			expectCode:   0,
+			expectStdout: "1 Vault namespaces would be deleted, run again with --yes --confirm-count=1",
+		},
+		{
+			name:         "dryRun in the config",
+			args:         []string{"--yes", "--confirm-count=1"},
+			dryRun:       true,
+			expectCode:   0,
+			expectStdout: "would-delete",
+			expectStderr: "dryRun is set in the config file, nothing was deleted",
+		},
+		{
+			name:         "count mismatch",
+			args:         []string{"--yes", "--confirm-count=2"},
+			expectCode:   1,
+			expectStderr: "refusing to delete: 1 Vault namespaces match, but --confirm-count is 2",
+		},
+		{
+			name:         "pattern matching nothing",
+			args:         []string{"--pattern=^k8s-ci-", "--yes", "--confirm-count=0"},
+			expectCode:   0,
+			expectStdout: "VAULT NAMESPACE",
+		},
+		{
+			name:         "confirmed",
+			args:         []string{"--yes", "--confirm-count=1"},
+			expectCode:   0,
+			expectDelete: true,
+			expectStdout: "deleted",
+		},
+		{
+			name:         "failed deletion",
+			args:         []string{"--yes", "--confirm-count=1", "--output=json"},
+			deleteErr:    errors.New("permission denied"),
+			expectCode:   1,
+			expectDelete: true,
+			expectStdout: `"error": "permission denied"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// app is synchronized, gone was deleted and team-x was never managed
+			mockClient := new(mockVaultClient)
+			mockClient.On("ListNamespaces", mock.Anything, "").Return([]string{"k8s-app", "k8s-gone", "team-x"}, nil)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-gone").Return(ownedMetadata("gone"), nil)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "team-x").Return(map[string]string{}, nil)
+			mockClient.On("NamespaceEmpty", mock.Anything, "k8s-gone").Return(true, nil)
+			mockClient.On("DeleteNamespace", mock.Anything, "k8s-gone").Return(tt.deleteErr)
+
+			cfg := &config.ControllerConfig{NamespaceFormat: "k8s-%s", DryRun: tt.dryRun}
+			if tt.connectErr {
+				cfg = nil
+			}
+			useReconciler(t, cfg, mockClient, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}})
+
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, tt.expectCode, runPrune(tt.args, &stdout, &stderr))
+			assert.Contains(t, stdout.String(), tt.expectStdout)
+			assert.Contains(t, stderr.String(), tt.expectStderr)
+			if tt.expectDelete {
+				mockClient.AssertCalled(t, "DeleteNamespace", mock.Anything, "k8s-gone")
+			} else {
+				mockClient.AssertNotCalled(t, "DeleteNamespace", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+)
+
+// validateConfigCommand is the subcommand checking a config file, e.g. in CI
+// before a rollout.
+const validateConfigCommand = "validate-config"
+
+// runValidateConfig checks the config file and prints the findings. It returns
+// the exit code: 1 if any error was found, 2 for invalid arguments.
+func runValidateConfig(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet(validateConfigCommand, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", os.Getenv(config.EnvPrefix+"_CONFIG"), "Path to controller config file (env VNC_CONFIG)")
+	online := fs.Bool("online", false, "Also check that Vault is reachable")
+	output := fs.String("output", "text", "Output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *output != "text" && *output != "json" {
+		fmt.Fprintf(stderr, "unsupported output format %q\n", *output)
+		return 2
+	}
+
+	findings, cfg := config.CheckConfig(*configPath)
+	if *online && cfg != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := vault.CheckHealth(ctx, cfg.Vault); err != nil {
+			findings = append(findings, config.Finding{Severity: config.SeverityError, Field: "vault.address", Message: err.Error()})
+		}
+	}
+
+	failed := false
+	for _, finding := range findings {
+		failed = failed || finding.Severity == config.SeverityError
+	}
+	if *output == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(struct {
+			Valid    bool             `json:"valid"`
+			Findings []config.Finding `json:"findings"`
+		}{Valid: !failed, Findings: append([]config.Finding{}, findings...)})
+	} else {
+		for _, finding := range findings {
+			fmt.Fprintln(stdout, finding)
+		}
+		if !failed {
+			fmt.Fprintf(stdout, "%s is valid\n", *configPath)
+		}
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
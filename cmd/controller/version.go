@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/benemon/vault-namespace-controller/pkg/version"
+)
+
+// versionCommand is the subcommand printing the build of the binary.
+const versionCommand = "version"
+
+// runVersion prints the build of the binary. It returns the exit code: 2 for
+// invalid arguments.
+func runVersion(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet(versionCommand, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("output", "text", "Output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	info := version.Get()
+	switch *output {
+	case "text":
+		fmt.Fprintln(stdout, info)
+	case "json":
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(info)
+	default:
+		fmt.Fprintf(stderr, "unsupported output format %q\n", *output)
+		return 2
+	}
+	return 0
+}
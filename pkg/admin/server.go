@@ -0,0 +1,208 @@
+// Package admin provides the maintenance HTTP endpoint for the vault-namespace-controller.
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/benemon/vault-namespace-controller/pkg/controller"
+)
+
+// Server serves the admin endpoints that pause and resume Vault changes and
+// change the log level.
+type Server struct {
+	// BindAddress is the address the admin server listens on.
+	BindAddress string
+
+	// Token is the bearer token callers must present.
+	Token string
+
+	// Pause is the controller's pause state.
+	Pause *controller.PauseState
+
+	// Elected is closed once this replica becomes the leader.
+	Elected <-chan struct{}
+
+	// LogLevel is the level of the controller's logger. The log level
+	// endpoint is not served when it is nil.
+	LogLevel *zap.AtomicLevel
+
+	Log logr.Logger
+}
+
+// Status is the response body of the status endpoint.
+type Status struct {
+	Paused   bool     `json:"paused"`
+	PausedBy []string `json:"pausedBy"`
+	Leader   bool     `json:"leader"`
+}
+
+// LogLevel is the request and response body of the log level endpoint. Level
+// is a level name, such as info or debug, or a verbosity such as 2 to also
+// log V(2) messages, as in --zap-log-level.
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+// Handler returns the admin HTTP handler.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pause", s.method(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		s.Pause.Set(controller.PauseSourceAdmin, true)
+		s.Log.Info("Controller paused via admin endpoint", "remoteAddr", r.RemoteAddr)
+		s.writeStatus(w)
+	}))
+	mux.HandleFunc("/resume", s.method(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		s.Pause.Set(controller.PauseSourceAdmin, false)
+		s.Log.Info("Controller resumed via admin endpoint", "remoteAddr", r.RemoteAddr)
+		s.writeStatus(w)
+	}))
+	mux.HandleFunc("/status", s.method(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		s.writeStatus(w)
+	}))
+	if s.LogLevel != nil {
+		mux.HandleFunc("/loglevel", s.serveLogLevel)
+	}
+	return s.authenticate(mux)
+}
+
+// Start runs the admin server until ctx is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.Log.Info("Starting admin server", "bindAddress", s.BindAddress)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+// NeedLeaderElection reports that the admin server runs on every replica.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// authenticate rejects requests that do not carry the configured bearer token.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || s.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// method restricts handler to a single HTTP method.
+func (s *Server) method(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// serveLogLevel returns the log level, or changes it with PUT.
+func (s *Server) serveLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var body LogLevel
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		level, err := parseLogLevel(body.Level)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		previous := s.LogLevel.Level()
+		s.LogLevel.SetLevel(level)
+		s.Log.Info("Log level changed via admin endpoint",
+			"previous", formatLogLevel(previous), "level", formatLogLevel(level), "remoteAddr", r.RemoteAddr)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(LogLevel{Level: formatLogLevel(s.LogLevel.Level())})
+}
+
+// parseLogLevel parses a level name, or a verbosity of V(n) messages logged.
+func parseLogLevel(text string) (zapcore.Level, error) {
+	if verbosity, err := strconv.Atoi(text); err == nil {
+		if verbosity < 0 || verbosity > 127 {
+			return 0, fmt.Errorf("verbosity %d must be between 0 and 127", verbosity)
+		}
+		return zapcore.Level(-verbosity), nil
+	}
+	level, err := zapcore.ParseLevel(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid log level %q, must be a level name such as info or debug, or a verbosity", text)
+	}
+	return level, nil
+}
+
+// formatLogLevel names level, or gives the verbosity of levels below debug.
+func formatLogLevel(level zapcore.Level) string {
+	if level < zapcore.DebugLevel {
+		return strconv.Itoa(-int(level))
+	}
+	return level.String()
+}
+
+func (s *Server) writeStatus(w http.ResponseWriter) {
+	status := Status{
+		Paused:   s.Pause.Paused(),
+		PausedBy: s.Pause.Sources(),
+		Leader:   s.isLeader(),
+	}
+	if status.PausedBy == nil {
+		status.PausedBy = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(status)
+}
+
+func (s *Server) isLeader() bool {
+	if s.Elected == nil {
+		return true
+	}
+	select {
+	case <-s.Elected:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/benemon/vault-namespace-controller/pkg/controller"
+)
+
+func TestServer_Handler(t *testing.T) {
+	pause := &controller.PauseState{}
+	server := &Server{
+		Token: "secret",
+		Pause: pause,
+		Log:   testr.New(t),
+	}
+	handler := server.Handler()
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Requests without the right token are rejected
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/pause", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/pause", "wrong").Code)
+	assert.False(t, pause.Paused())
+
+	// Only POST pauses
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodGet, "/pause", "secret").Code)
+
+	rec := do(http.MethodPost, "/pause", "secret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, pause.Paused())
+
+	var status Status
+	assert.NoError(t, json.Unmarshal(do(http.MethodGet, "/status", "secret").Body.Bytes(), &status))
+	assert.Equal(t, Status{Paused: true, PausedBy: []string{controller.PauseSourceAdmin}, Leader: true}, status)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/resume", "secret").Code)
+	assert.False(t, pause.Paused())
+}
+
+func TestServer_LogLevel(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	handler := (&Server{Token: "secret", LogLevel: &level, Log: testr.New(t)}).Handler()
+
+	do := func(method, body string) (*httptest.ResponseRecorder, LogLevel) {
+		req := httptest.NewRequest(method, "/loglevel", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var response LogLevel
+		if rec.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		}
+		return rec, response
+	}
+
+	rec, response := do(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, LogLevel{Level: "info"}, response)
+
+	// A verbosity enables V(n) messages
+	rec, response = do(http.MethodPut, `{"level": "2"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, LogLevel{Level: "2"}, response)
+	assert.Equal(t, zapcore.Level(-2), level.Level())
+
+	rec, response = do(http.MethodPut, `{"level": "debug"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, LogLevel{Level: "debug"}, response)
+
+	rec, _ = do(http.MethodPut, `{"level": "verbose"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec, _ = do(http.MethodPost, `{"level": "info"}`)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+
+	// Without a level the endpoint is not served
+	req := httptest.NewRequest(http.MethodGet, "/loglevel", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	(&Server{Token: "secret", Log: testr.New(t)}).Handler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,76 @@
+// Package audit streams a record of the Vault namespace changes the
+// controller makes to sinks, such as a file or an HTTPS webhook, so they can
+// be collected without scraping pod logs.
+package audit
+
+import (
+	"time"
+
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+	"github.com/go-logr/logr"
+)
+
+// Actions recorded.
+const (
+	ActionCreate = "create"
+	ActionAdopt  = "adopt"
+	ActionMove   = "move"
+	ActionDelete = "delete"
+)
+
+// Outcomes of an action.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Record describes a change the controller made, or failed to make, to a
+// Vault namespace.
+type Record struct {
+	Time           time.Time `json:"time"`
+	Action         string    `json:"action"`
+	Outcome        string    `json:"outcome"`
+	Error          string    `json:"error,omitempty"`
+	Namespace      string    `json:"namespace"`
+	VaultNamespace string    `json:"vaultNamespace"`
+	// PreviousVaultNamespace is the Vault namespace a moved namespace had before.
+	PreviousVaultNamespace string `json:"previousVaultNamespace,omitempty"`
+	Cluster                string `json:"cluster,omitempty"`
+	Connection             string `json:"connection,omitempty"`
+	// CorrelationID is also sent with the Vault requests of the reconcile,
+	// matching the record to Vault's audit log.
+	CorrelationID string `json:"correlationID,omitempty"`
+}
+
+// Sink receives audit records.
+type Sink interface {
+	// Name identifies the sink in logs and metrics.
+	Name() string
+	Write(record Record) error
+}
+
+// Log writes audit records to its sinks. A nil Log discards them.
+type Log struct {
+	Sinks []Sink
+	Log   logr.Logger
+}
+
+// Record writes record to every sink, stamping it with the current time when
+// it has none. Failing sinks are logged and do not fail the change.
+func (l *Log) Record(record Record) {
+	if l == nil {
+		return
+	}
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC()
+	}
+	for _, sink := range l.Sinks {
+		if err := sink.Write(record); err != nil {
+			l.Log.Error(err, "Failed to write audit record", "sink", sink.Name(), "action", record.Action,
+				"kubernetesNamespace", record.Namespace, "vaultNamespace", record.VaultNamespace)
+			metrics.AuditRecordsTotal.WithLabelValues(sink.Name(), "error").Inc()
+			continue
+		}
+		metrics.AuditRecordsTotal.WithLabelValues(sink.Name(), "success").Inc()
+	}
+}
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+)
+
+// memorySink keeps the records written to it, or fails with err.
+type memorySink struct {
+	name    string
+	records []Record
+	err     error
+}
+
+func (s *memorySink) Name() string {
+	return s.name
+}
+
+func (s *memorySink) Write(record Record) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.records = append(s.records, record)
+	return nil
+}
+
+// TestLog tests records are written to every sink despite failing ones.
+func TestLog(t *testing.T) {
+	var nilLog *Log
+	nilLog.Record(Record{Action: ActionCreate})
+
+	failing := &memorySink{name: "failing", err: errors.New("disk full")}
+	working := &memorySink{name: "working"}
+	log := &Log{Sinks: []Sink{failing, working}, Log: testr.New(t)}
+
+	log.Record(Record{Action: ActionCreate, Outcome: OutcomeSuccess, Namespace: "team-a", VaultNamespace: "k8s/team-a"})
+
+	if assert.Len(t, working.records, 1) {
+		assert.Equal(t, "k8s/team-a", working.records[0].VaultNamespace)
+		assert.WithinDuration(t, time.Now(), working.records[0].Time, time.Minute)
+	}
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.AuditRecordsTotal.WithLabelValues("failing", "error")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.AuditRecordsTotal.WithLabelValues("working", "success")))
+}
@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileSink appends records to a file as JSON lines. Once the file reaches
+// MaxSize bytes it is rotated: the file becomes path.1, path.1 becomes
+// path.2 and so on, keeping MaxBackups rotated files.
+type FileSink struct {
+	Path       string
+	MaxSize    int64
+	MaxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewFileSink opens the file at path for appending.
+func NewFileSink(path string, maxSize int64, maxBackups int) (*FileSink, error) {
+	s := &FileSink{Path: path, MaxSize: maxSize, MaxBackups: maxBackups}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Name implements Sink.
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+// Write implements Sink.
+func (s *FileSink) Write(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		// Reopening failed at the last rotation
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	var rotateErr error
+	if s.MaxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.MaxSize {
+		// A file that failed to rotate keeps growing rather than losing records
+		if rotateErr = s.rotate(); s.file == nil {
+			return rotateErr
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return errors.Join(rotateErr, err)
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+func (s *FileSink) open() error {
+	s.file = nil
+	file, err := os.OpenFile(s.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// rotate moves the file to the first backup and opens a new one, reopening
+// the file when moving it fails. The caller must hold s.mu.
+func (s *FileSink) rotate() error {
+	var err error
+	if closeErr := s.file.Close(); closeErr != nil {
+		err = closeErr
+	} else if s.MaxBackups <= 0 {
+		err = os.Remove(s.Path)
+	} else {
+		for i := s.MaxBackups - 1; i > 0 && err == nil; i-- {
+			if renameErr := os.Rename(s.backup(i), s.backup(i+1)); renameErr != nil && !os.IsNotExist(renameErr) {
+				err = renameErr
+			}
+		}
+		if err == nil {
+			err = os.Rename(s.Path, s.backup(1))
+		}
+	}
+	if openErr := s.open(); openErr != nil {
+		return openErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rotate audit file: %w", err)
+	}
+	return nil
+}
+
+func (s *FileSink) backup(i int) string {
+	return fmt.Sprintf("%s.%d", s.Path, i)
+}
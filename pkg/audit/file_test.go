@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readRecords returns the records in the file at path.
+func readRecords(t *testing.T, path string) []Record {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	var records []Record
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var record Record
+		assert.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+// TestFileSink tests records are appended as JSON lines and the file is rotated.
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	line, _ := json.Marshal(Record{Action: ActionCreate, Namespace: "ns-0"})
+	// Two records fit in a file
+	sink, err := NewFileSink(path, int64(2*(len(line)+1)), 2)
+	assert.NoError(t, err)
+
+	for _, name := range []string{"ns-0", "ns-1", "ns-2", "ns-3", "ns-4", "ns-5", "ns-6"} {
+		assert.NoError(t, sink.Write(Record{Action: ActionCreate, Namespace: name}))
+	}
+	assert.NoError(t, sink.Close())
+
+	namespaces := func(records []Record) []string {
+		var names []string
+		for _, record := range records {
+			names = append(names, record.Namespace)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"ns-6"}, namespaces(readRecords(t, path)))
+	assert.Equal(t, []string{"ns-4", "ns-5"}, namespaces(readRecords(t, path+".1")))
+	assert.Equal(t, []string{"ns-2", "ns-3"}, namespaces(readRecords(t, path+".2")))
+	assert.NoFileExists(t, path+".3")
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// Reopening appends to the existing file
+	sink, err = NewFileSink(path, 0, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, sink.Write(Record{Action: ActionDelete, Namespace: "ns-7"}))
+	assert.NoError(t, sink.Close())
+	assert.Equal(t, []string{"ns-6", "ns-7"}, namespaces(readRecords(t, path)))
+}
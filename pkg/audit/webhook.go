@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+	"github.com/go-logr/logr"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of a record POSTed to the webhook,
+// as "sha256=" and the hex encoded MAC, like the lifecycle hook signatures.
+const SignatureHeader = "X-Vault-Namespace-Controller-Signature"
+
+// maxRetryDelay caps the delay between attempts to deliver a record.
+const maxRetryDelay = time.Minute
+
+// ErrBufferFull is returned for records dropped because the webhook has been
+// unavailable for longer than its buffer covers.
+var ErrBufferFull = errors.New("audit webhook buffer is full, record dropped")
+
+// WebhookSink POSTs records to an HTTPS endpoint, one JSON record per
+// request, in the order they were written. Records are buffered while the
+// endpoint is unavailable and retried until they are delivered.
+type WebhookSink struct {
+	URL string
+	// SigningKey signs the records with HMAC-SHA256. Records are not signed when empty.
+	SigningKey []byte
+	Timeout    time.Duration
+	HTTPClient *http.Client
+	Log        logr.Logger
+
+	queue chan []byte
+	// retryDelay is the delay before the second attempt, doubling with every further one.
+	retryDelay time.Duration
+}
+
+// NewWebhookSink returns a sink buffering up to bufferSize records. It
+// delivers them once started.
+func NewWebhookSink(url string, signingKey []byte, bufferSize int, timeout time.Duration, log logr.Logger) *WebhookSink {
+	return &WebhookSink{
+		URL:        url,
+		SigningKey: signingKey,
+		Timeout:    timeout,
+		HTTPClient: &http.Client{},
+		Log:        log,
+		queue:      make(chan []byte, bufferSize),
+		retryDelay: time.Second,
+	}
+}
+
+// Name implements Sink.
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Write implements Sink, buffering the record for delivery.
+func (s *WebhookSink) Write(record Record) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	select {
+	case s.queue <- body:
+		return nil
+	default:
+		return ErrBufferFull
+	}
+}
+
+// Start delivers buffered records until ctx is cancelled.
+func (s *WebhookSink) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			if pending := len(s.queue); pending > 0 {
+				s.Log.Info("Stopping with undelivered audit records", "records", pending)
+			}
+			return nil
+		case body := <-s.queue:
+			s.deliver(ctx, body)
+		}
+	}
+}
+
+// NeedLeaderElection reports that every replica delivers its own records.
+func (s *WebhookSink) NeedLeaderElection() bool {
+	return false
+}
+
+// deliver POSTs body until it succeeds or ctx is cancelled, with a doubling
+// delay between attempts.
+func (s *WebhookSink) deliver(ctx context.Context, body []byte) {
+	delay := s.retryDelay
+	for {
+		err := s.post(ctx, body)
+		if err == nil {
+			return
+		}
+		metrics.AuditRecordsTotal.WithLabelValues(s.Name(), "retry").Inc()
+		s.Log.Error(err, "Failed to deliver audit record, retrying", "delay", delay.String(), "buffered", len(s.queue))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+func (s *WebhookSink) post(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(s.SigningKey) > 0 {
+		mac := hmac.New(sha256.New, s.SigningKey)
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWebhookSink tests records are delivered in order, signed, and retried
+// while the endpoint fails.
+func TestWebhookSink(t *testing.T) {
+	key := []byte("secret")
+	var mu sync.Mutex
+	var received []string
+	failures := 2
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, key)
+		mac.Write(body)
+		assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), r.Header.Get(SignatureHeader))
+		var record Record
+		assert.NoError(t, json.Unmarshal(body, &record))
+		received = append(received, record.Namespace)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, key, 2, time.Second, testr.New(t))
+	sink.retryDelay = time.Millisecond
+	assert.NoError(t, sink.Write(Record{Action: ActionCreate, Namespace: "ns-0"}))
+	assert.NoError(t, sink.Write(Record{Action: ActionCreate, Namespace: "ns-1"}))
+	assert.ErrorIs(t, sink.Write(Record{Action: ActionCreate, Namespace: "ns-2"}), ErrBufferFull)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = sink.Start(ctx)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Equal(t, []string{"ns-0", "ns-1"}, received)
+	assert.False(t, sink.NeedLeaderElection())
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Severities of findings. Only errors make a configuration unusable.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Finding is a problem found by CheckConfig.
+type Finding struct {
+	Severity string `json:"severity"`
+	// Field is the YAML path of the setting, if the finding concerns one.
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	if f.Field == "" {
+		return fmt.Sprintf("%s: %s", f.Severity, f.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Field, f.Message)
+}
+
+// CheckConfig loads the configuration like LoadConfig and returns the
+// problems found. Beyond the validation done at start, it checks settings
+// that would otherwise only fail once used, such as the namespace format,
+// and warns about auth settings that are ignored.
+func CheckConfig(path string) ([]Finding, *ControllerConfig) {
+	config, _, err := readConfig(path)
+	if err != nil {
+		return []Finding{{Severity: SeverityError, Message: err.Error()}}, nil
+	}
+
+	var findings []Finding
+	add := func(severity, field, format string, args ...interface{}) {
+		findings = append(findings, Finding{Severity: severity, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	if err := validateConfig(config); err != nil {
+		add(SeverityError, "", "%v", err)
+	}
+
+	if err := checkNamespaceFormat(config.NamespaceFormat); err != nil {
+		add(SeverityError, "namespaceFormat", "%v", err)
+	}
+	if config.Migration.PreviousFormat != "" {
+		if err := checkNamespaceFormat(config.Migration.PreviousFormat); err != nil {
+			add(SeverityError, "migration.previousFormat", "%v", err)
+		}
+	}
+
+	if config.Vault.Timeout > 0 && config.ReconcileTimeout > 0 && config.Vault.Timeout >= config.ReconcileTimeout {
+		add(SeverityWarning, "vault.timeout", "%s is not shorter than reconcileTimeout %s, so a slow Vault request fails the whole reconcile",
+			config.Vault.Timeout.Duration(), config.ReconcileTimeout.Duration())
+	}
+
+	ignored := ignoredAuthSettings(config.Vault.Auth)
+	for _, field := range slices.Sorted(maps.Keys(ignored)) {
+		add(SeverityWarning, "vault.auth."+field, "%s", ignored[field])
+	}
+	return findings, config
+}
+
+// checkNamespaceFormat checks that format formats a namespace name with a
+// single %s verb. An empty format uses the name as is.
+func checkNamespaceFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	formatted := fmt.Sprintf(strings.ReplaceAll(format, ClusterPlaceholder, "cluster"), "namespace")
+	if strings.Contains(formatted, "%!") {
+		return fmt.Errorf("%q must contain a single %%s verb for the namespace name", format)
+	}
+	return nil
+}
+
+// ignoredAuthSettings returns the auth settings that are set but have no
+// effect, by field, with the reason.
+func ignoredAuthSettings(auth VaultAuthConfig) map[string]string {
+	ignored := map[string]string{}
+	used := map[string][]string{
+		"token":      {"token", "tokenPath", "tokenSecretRef"},
+		"kubernetes": {"role"},
+		"approle":    {"roleId", "roleIdPath", "roleIdSecretRef", "secretId", "secretIdPath", "secretIdSecretRef"},
+	}[auth.Type]
+	isUsed := func(field string) bool { return slices.Contains(used, field) }
+	set := map[string]bool{
+		"token":             auth.Token != "",
+		"tokenPath":         auth.TokenPath != "",
+		"tokenSecretRef":    auth.TokenSecretRef != nil,
+		"role":              auth.Role != "",
+		"roleId":            auth.RoleID != "",
+		"roleIdPath":        auth.RoleIDPath != "",
+		"roleIdSecretRef":   auth.RoleIDSecretRef != nil,
+		"secretId":          auth.SecretID != "",
+		"secretIdPath":      auth.SecretIDPath != "",
+		"secretIdSecretRef": auth.SecretIDSecretRef != nil,
+	}
+	for field, isSet := range set {
+		if isSet && !isUsed(field) {
+			ignored[field] = fmt.Sprintf("not used by the %s auth method", auth.Type)
+		}
+	}
+
+	// A Secret takes precedence over a value, which takes precedence over a file
+	for _, sources := range [][]string{
+		{"tokenSecretRef", "token", "tokenPath"},
+		{"roleIdSecretRef", "roleId", "roleIdPath"},
+		{"secretIdSecretRef", "secretId", "secretIdPath"},
+	} {
+		winner := ""
+		for _, field := range sources {
+			if !set[field] || !isUsed(field) {
+				continue
+			}
+			if winner == "" {
+				winner = field
+				continue
+			}
+			ignored[field] = fmt.Sprintf("ignored because %s is set", winner)
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+vault:
+  address: https://vault.example.com:8200
+  auth:
+    type: approle
+    roleId: role-id
+    secretId: secret-id
+    secretIdPath: /vault/secret-id
+    role: controller
+includeNamespaces: ["(unclosed"]
+namespaceFormat: "k8s-%s-%s"
+`), 0o600))
+
+	findings, config := CheckConfig(path)
+	assert.NotNil(t, config)
+	assert.Equal(t, []Finding{
+		{Severity: SeverityError, Message: "invalid includeNamespaces[0] \"(unclosed\": error parsing regexp: missing closing ): `(unclosed`"},
+		{Severity: SeverityError, Field: "namespaceFormat", Message: `"k8s-%s-%s" must contain a single %s verb for the namespace name`},
+		{Severity: SeverityWarning, Field: "vault.auth.role", Message: "not used by the approle auth method"},
+		{Severity: SeverityWarning, Field: "vault.auth.secretIdPath", Message: "ignored because secretId is set"},
+	}, findings)
+
+	// A Vault timeout outlasting the reconcile is warned about
+	assert.NoError(t, os.WriteFile(path, []byte(`
+vault:
+  address: https://vault.example.com:8200
+  timeout: 1m
+  auth:
+    type: kubernetes
+    role: controller
+reconcileTimeout: 30s
+`), 0o600))
+	findings, _ = CheckConfig(path)
+	assert.Equal(t, []Finding{
+		{Severity: SeverityWarning, Field: "vault.timeout", Message: "1m0s is not shorter than reconcileTimeout 30s, so a slow Vault request fails the whole reconcile"},
+	}, findings)
+
+	// Validation errors and unreadable files are reported too
+	assert.NoError(t, os.WriteFile(path, []byte("vault:\n  address: https://vault.example.com:8200\n"), 0o600))
+	findings, _ = CheckConfig(path)
+	assert.Equal(t, []Finding{{Severity: SeverityError, Message: ErrMissingAuthType.Error()}}, findings)
+
+	findings, config = CheckConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Nil(t, config)
+	if assert.Len(t, findings, 1) {
+		assert.Contains(t, findings[0].Message, "failed to read config file")
+	}
+}
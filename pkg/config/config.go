@@ -3,9 +3,15 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // Common errors
@@ -13,6 +19,36 @@ var (
 	ErrMissingVaultAddress = errors.New("vault address is required")
 	ErrMissingAuthType     = errors.New("vault auth type is required")
 	ErrUnsupportedAuthType = errors.New("unsupported auth method")
+	ErrInvalidPolicy       = errors.New("invalid policy")
+)
+
+// ClusterPlaceholder in NamespaceFormat is replaced with the cluster name, so that
+// clusters sharing a Vault do not collide on identical namespace names.
+const ClusterPlaceholder = "%{cluster}"
+
+// MinNamespaceNameLength is the smallest MaxNamespaceNameLength, leaving room
+// for the hash suffix of a truncated name.
+const MinNamespaceNameLength = 16
+
+// ClusterNameEnv is the environment variable ClusterName defaults to.
+const ClusterNameEnv = "CLUSTER_NAME"
+
+// Policies for orphaned Vault namespaces, whose Kubernetes namespace no longer exists.
+const (
+	// OrphanPolicyReport logs orphaned namespaces.
+	OrphanPolicyReport = "report"
+	// OrphanPolicyDelete deletes orphaned namespaces once they reach the minimum age.
+	OrphanPolicyDelete = "delete"
+)
+
+// Policies for Vault namespaces that exist before the controller manages them.
+const (
+	// ExistingNamespaceAdopt stamps ownership metadata on unowned namespaces and manages them.
+	ExistingNamespaceAdopt = "adopt"
+	// ExistingNamespaceSkip leaves unowned namespaces alone.
+	ExistingNamespaceSkip = "skip"
+	// ExistingNamespaceError reports unowned namespaces as a reconcile error.
+	ExistingNamespaceError = "error"
 )
 
 // VaultAuthConfig contains configuration for Vault authentication.
@@ -29,6 +65,9 @@ type VaultAuthConfig struct {
 	// Token auth
 	Token     string `yaml:"token,omitempty"`
 	TokenPath string `yaml:"tokenPath,omitempty"`
+	// TokenSecretRef reads the token from a Secret, which is watched for
+	// rotation. Key defaults to "token".
+	TokenSecretRef *SecretKeyRef `yaml:"tokenSecretRef,omitempty"`
 
 	// Kubernetes auth
 	Role string `yaml:"role,omitempty"`
@@ -38,6 +77,35 @@ type VaultAuthConfig struct {
 	SecretID     string `yaml:"secretId,omitempty"`
 	RoleIDPath   string `yaml:"roleIdPath,omitempty"`
 	SecretIDPath string `yaml:"secretIdPath,omitempty"`
+	// RoleIDSecretRef and SecretIDSecretRef read the credentials from Secrets,
+	// which are watched for rotation. Key defaults to "role-id" and
+	// "secret-id" respectively.
+	RoleIDSecretRef   *SecretKeyRef `yaml:"roleIdSecretRef,omitempty"`
+	SecretIDSecretRef *SecretKeyRef `yaml:"secretIdSecretRef,omitempty"`
+}
+
+// SecretRefs returns the Secrets the credentials of the auth method are read
+// from, by the field they replace.
+func (c VaultAuthConfig) SecretRefs() map[string]SecretKeyRef {
+	refs := map[string]SecretKeyRef{}
+	add := func(field string, ref *SecretKeyRef, defaultKey string) {
+		if ref == nil {
+			return
+		}
+		resolved := *ref
+		if resolved.Key == "" {
+			resolved.Key = defaultKey
+		}
+		refs[field] = resolved
+	}
+	switch c.Type {
+	case "token":
+		add("token", c.TokenSecretRef, "token")
+	case "approle":
+		add("roleId", c.RoleIDSecretRef, "role-id")
+		add("secretId", c.SecretIDSecretRef, "secret-id")
+	}
+	return refs
 }
 
 // VaultConfig contains configuration for connecting to Vault.
@@ -51,118 +119,1332 @@ type VaultConfig struct {
 	// Auth contains authentication configuration.
 	Auth VaultAuthConfig `yaml:"auth"`
 
-	// TLS config
+	// TLS config. CACertPEM is a PEM-encoded CA bundle used in place of the
+	// CACert file.
 	CACert     string `yaml:"caCert,omitempty"`
+	CACertPEM  string `yaml:"caCertPem,omitempty"`
 	ClientCert string `yaml:"clientCert,omitempty"`
 	ClientKey  string `yaml:"clientKey,omitempty"`
 	Insecure   bool   `yaml:"insecure,omitempty"`
+
+	// Timeout bounds each Vault request. Zero uses the Vault client default of
+	// 60 seconds.
+	Timeout Seconds `yaml:"timeout,omitempty"`
+
+	// TokenTTLThreshold is the remaining token TTL below which the controller
+	// renews its token, and reports not ready while renewing fails. Zero
+	// disables watching the token TTL.
+	TokenTTLThreshold Seconds `yaml:"tokenTTLThreshold,omitempty"`
+}
+
+// Authentication methods of the metrics server.
+const (
+	MetricsAuthNone       = "none"
+	MetricsAuthToken      = "token"
+	MetricsAuthClientCert = "clientCert"
+)
+
+// MetricsServerConfig configures TLS and authentication for the metrics
+// server, for clusters that do not allow plaintext or unauthenticated metrics.
+type MetricsServerConfig struct {
+	// Secure serves the metrics over TLS.
+	Secure bool `yaml:"secure,omitempty"`
+	// CertDir holds the serving certificate and key. Empty uses a generated
+	// self-signed certificate.
+	CertDir string `yaml:"certDir,omitempty"`
+	// CertName and KeyName are the file names in CertDir, defaulting to
+	// tls.crt and tls.key.
+	CertName string `yaml:"certName,omitempty"`
+	KeyName  string `yaml:"keyName,omitempty"`
+	// Auth is how scrapers authenticate: none, token to require a bearer
+	// token allowed to get /metrics by a SubjectAccessReview, or clientCert
+	// to require a client certificate signed by ClientCAFile.
+	Auth string `yaml:"auth,omitempty"`
+	// ClientCAFile is the CA bundle verifying client certificates.
+	ClientCAFile string `yaml:"clientCAFile,omitempty"`
+}
+
+// MetricsBucketsConfig holds histogram buckets, as upper bounds in seconds.
+// Unset buckets keep the Prometheus defaults.
+type MetricsBucketsConfig struct {
+	// Reconcile applies to vault_ns_controller_reconciliation_duration_seconds.
+	Reconcile []float64 `yaml:"reconcile,omitempty"`
+	// Vault applies to the Vault operation and authentication durations.
+	Vault []float64 `yaml:"vault,omitempty"`
+}
+
+// WebhookServerConfig configures the admission webhook server. Settings left
+// empty keep the controller-runtime defaults.
+type WebhookServerConfig struct {
+	// Port defaults to 9443.
+	Port int `yaml:"port,omitempty"`
+	// CertDir holds the serving certificate and key, defaulting to
+	// /tmp/k8s-webhook-server/serving-certs.
+	CertDir string `yaml:"certDir,omitempty"`
+	// CertName and KeyName are the file names in CertDir, defaulting to
+	// tls.crt and tls.key as issued by cert-manager.
+	CertName string `yaml:"certName,omitempty"`
+	KeyName  string `yaml:"keyName,omitempty"`
+}
+
+// RateLimiterConfig tunes the rate limiter delaying the retries of failed
+// reconciles. A Vault failure retried with backoff waits for the longer of the
+// error backoff and the rate limiter's delay.
+type RateLimiterConfig struct {
+	// BaseDelayMilliseconds is the delay before the first retry of a failed item.
+	// The delay doubles with each consecutive failure.
+	BaseDelayMilliseconds Milliseconds `yaml:"baseDelayMilliseconds,omitempty"`
+
+	// MaxDelaySeconds caps the per-item retry delay.
+	MaxDelaySeconds Seconds `yaml:"maxDelaySeconds,omitempty"`
+
+	// QPS and Burst limit the overall rate of retries across all items.
+	QPS   float64 `yaml:"qps,omitempty"`
+	Burst int     `yaml:"burst,omitempty"`
+}
+
+// MappingRule maps the namespaces it matches to Vault namespace paths. A rule
+// matches namespaces meeting all of Match, Selector and Expression that are set,
+// so one with none of them matches every namespace.
+type MappingRule struct {
+	// Match is a regular expression the namespace name must match.
+	Match string `yaml:"match,omitempty"`
+
+	// Selector is a label selector, such as "env=prod", the namespace must match.
+	Selector string `yaml:"selector,omitempty"`
+
+	// Expression is a CEL expression over the namespace name, labels and
+	// annotations the namespace must match.
+	Expression string `yaml:"expression,omitempty"`
+
+	// VaultPath is a template for the Vault namespace path, as for NamespaceTemplate.
+	VaultPath string `yaml:"vaultPath"`
+
+	// Parent is the Vault namespace the path is created under, in place of
+	// the Vault namespaceRoot.
+	Parent string `yaml:"parent,omitempty"`
+}
+
+// ParentRootsConfig routes namespaces to Vault namespace roots by label.
+type ParentRootsConfig struct {
+	// Label is the namespace label whose value selects the root.
+	Label string `yaml:"label,omitempty"`
+
+	// Roots maps label values to Vault namespace roots, e.g. gold: /tenants/gold.
+	Roots map[string]string `yaml:"roots,omitempty"`
+
+	// Default is the root for namespaces without a listed label value. When
+	// empty they use the Vault namespaceRoot.
+	Default string `yaml:"default,omitempty"`
+}
+
+// SecretKeyRef refers to a key of a Secret.
+type SecretKeyRef struct {
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+	// Key defaults to "kubeconfig" for kubeconfig Secrets and "signing-key"
+	// for the lifecycle hook and audit webhook signing keys.
+	Key string `yaml:"key,omitempty"`
+}
+
+// MigrationConfig describes a change of Vault namespace paths in progress.
+type MigrationConfig struct {
+	// PreviousFormat is the NamespaceFormat the existing Vault namespaces were
+	// created with. Migration is enabled when it is set.
+	PreviousFormat string `yaml:"previousFormat"`
+	// DeleteOld deletes a namespace's previous Vault namespace once its new one
+	// exists. Otherwise previous Vault namespaces are only reported.
+	DeleteOld bool `yaml:"deleteOld,omitempty"`
+}
+
+// PolicyTemplate is an ACL policy written into each Vault namespace.
+type PolicyTemplate struct {
+	// Name is the name of the policy.
+	Name string `yaml:"name"`
+	// Policy is the HCL of the policy, a template executed against
+	// NamespaceTemplateData like NamespaceTemplate.
+	Policy string `yaml:"policy"`
+}
+
+// Sentinel policy types.
+const (
+	// SentinelPolicyEGP is an endpoint governing policy, applied to requests to paths.
+	SentinelPolicyEGP = "egp"
+	// SentinelPolicyRGP is a role governing policy, applied to tokens it is attached to.
+	SentinelPolicyRGP = "rgp"
+)
+
+// Sentinel enforcement levels.
+const (
+	EnforcementAdvisory      = "advisory"
+	EnforcementSoftMandatory = "soft-mandatory"
+	EnforcementHardMandatory = "hard-mandatory"
+)
+
+// SentinelPolicy is a Vault Enterprise Sentinel policy written into each
+// Vault namespace.
+type SentinelPolicy struct {
+	// Name is the name of the policy.
+	Name string `yaml:"name"`
+	// Type is egp or rgp.
+	Type string `yaml:"type"`
+	// Policy is the Sentinel source of the policy.
+	Policy string `yaml:"policy"`
+	// EnforcementLevel is advisory, soft-mandatory or hard-mandatory.
+	// Defaults to hard-mandatory.
+	EnforcementLevel string `yaml:"enforcementLevel,omitempty"`
+	// Paths are the request paths, relative to the Vault namespace, an EGP
+	// applies to, such as "*".
+	Paths []string `yaml:"paths,omitempty"`
+}
+
+// Level returns the enforcement level of the policy.
+func (p SentinelPolicy) Level() string {
+	if p.EnforcementLevel == "" {
+		return EnforcementHardMandatory
+	}
+	return p.EnforcementLevel
+}
+
+// IdentityGroup is an internal identity group created in each Vault namespace.
+type IdentityGroup struct {
+	// Name is the name of the group, a template executed against
+	// NamespaceTemplateData like NamespaceTemplate.
+	Name string `yaml:"name"`
+	// Policies are the policies of the group, such as those of PolicyTemplates.
+	Policies []string `yaml:"policies,omitempty"`
+	// ExternalAlias makes an external group, aliased to a group of an OIDC or
+	// LDAP auth method, a member of the group.
+	ExternalAlias *GroupAliasConfig `yaml:"externalAlias,omitempty"`
+}
+
+// GroupAliasConfig is the group of an OIDC or LDAP auth method whose members
+// join an IdentityGroup.
+type GroupAliasConfig struct {
+	// Mount is the path of the auth method.
+	Mount string `yaml:"mount"`
+	// Namespace is the Vault namespace of the auth method. Defaults to the
+	// namespace the controller authenticates in.
+	Namespace string `yaml:"namespace,omitempty"`
+	// Name is the name of the group at the identity provider, a template like
+	// IdentityGroup.Name. The external group holding the alias has the same name.
+	Name string `yaml:"name"`
+}
+
+// BootstrapConfig describes what the controller sets up inside each Vault
+// namespace it creates.
+type BootstrapConfig struct {
+	// KVMount is the path of a kv-v2 secrets engine enabled in each new Vault
+	// namespace. Empty enables none.
+	KVMount string `yaml:"kvMount,omitempty"`
+
+	// KubernetesAuth enables the kubernetes auth method in each new Vault
+	// namespace, with a role for the ServiceAccounts of its Kubernetes namespace.
+	KubernetesAuth KubernetesAuthBootstrapConfig `yaml:"kubernetesAuth,omitempty"`
+
+	// AuditDevice enables an audit device in each new Vault namespace, before
+	// anything else is set up in it.
+	AuditDevice AuditDeviceBootstrapConfig `yaml:"auditDevice,omitempty"`
+}
+
+// AuditDeviceBootstrapConfig describes the audit device enabled in new Vault
+// namespaces.
+type AuditDeviceBootstrapConfig struct {
+	// Type is the type of the audit device, such as file or socket. Empty
+	// enables none.
+	Type string `yaml:"type,omitempty"`
+	// Path is the path the device is enabled at. Defaults to Type.
+	Path string `yaml:"path,omitempty"`
+	// Options are the options of the device, such as file_path. Each value is a
+	// template executed against NamespaceTemplateData like NamespaceTemplate.
+	Options map[string]string `yaml:"options,omitempty"`
+}
+
+// DevicePath returns the path the audit device is enabled at.
+func (a AuditDeviceBootstrapConfig) DevicePath() string {
+	if a.Path == "" {
+		return a.Type
+	}
+	return strings.Trim(a.Path, "/")
+}
+
+// KubernetesAuthBootstrapConfig describes the kubernetes auth method enabled in
+// new Vault namespaces.
+type KubernetesAuthBootstrapConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Mount defaults to "kubernetes".
+	Mount string `yaml:"mount,omitempty"`
+	// Host is the Kubernetes API server Vault validates ServiceAccount tokens
+	// against. Defaults to the API server the controller uses. Remote clusters
+	// always use the API server of their kubeconfig.
+	Host string `yaml:"host,omitempty"`
+	// CACertPEM is the CA certificate of Host. Defaults to the CA the
+	// controller trusts for the API server.
+	CACertPEM string `yaml:"caCertPEM,omitempty"`
+	// Role defaults to "default".
+	Role string `yaml:"role,omitempty"`
+	// ServiceAccounts are the ServiceAccount names the role is bound to, within
+	// the Kubernetes namespace. Defaults to all of them.
+	ServiceAccounts []string `yaml:"serviceAccounts,omitempty"`
+	// Policies are the Vault policies of the role's tokens.
+	Policies []string `yaml:"policies,omitempty"`
+	// TokenTTL is the TTL of the role's tokens, such as "1h". Vault's default applies when empty.
+	TokenTTL string `yaml:"tokenTTL,omitempty"`
+}
+
+// SecretsOperatorConfig describes the resources of a secrets operator, such as
+// the Vault Secrets Operator, created in each synchronized Kubernetes namespace.
+type SecretsOperatorConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Name is the name of the resources. Defaults to "vault".
+	Name string `yaml:"name,omitempty"`
+	// Address is the Vault address the operator connects to. Defaults to vault.address.
+	Address string `yaml:"address,omitempty"`
+	// CACertSecret is a Secret in each namespace holding the CA certificate of
+	// Vault under ca.crt.
+	CACertSecret string `yaml:"caCertSecret,omitempty"`
+	// Mount and Role are the kubernetes auth method and role the operator logs
+	// in with. They default to those of Bootstrap.KubernetesAuth.
+	Mount string `yaml:"mount,omitempty"`
+	Role  string `yaml:"role,omitempty"`
+	// ServiceAccount is the ServiceAccount the operator logs in as. Defaults to "default".
+	ServiceAccount string `yaml:"serviceAccount,omitempty"`
+}
+
+// ESOConfig describes the External Secrets Operator SecretStore created in
+// each synchronized Kubernetes namespace.
+type ESOConfig struct {
+	SecretsOperatorConfig `yaml:",inline"`
+	// KVMount is the kv-v2 secrets engine the SecretStore reads. Defaults to
+	// Bootstrap.KVMount, or "secret".
+	KVMount string `yaml:"kvMount,omitempty"`
+}
+
+// ResourceName returns the name of the operator's resources.
+func (v SecretsOperatorConfig) ResourceName() string {
+	if v.Name == "" {
+		return "vault"
+	}
+	return v.Name
+}
+
+// ServiceAccountName returns the ServiceAccount the operator logs in as.
+func (v SecretsOperatorConfig) ServiceAccountName() string {
+	if v.ServiceAccount == "" {
+		return "default"
+	}
+	return v.ServiceAccount
+}
+
+// Lifecycle events hooks are called for.
+const (
+	HookEventPostCreate = "post-create"
+	HookEventPreDelete  = "pre-delete"
+)
+
+// Failure policies of lifecycle hooks.
+const (
+	// HookFailurePolicyFail blocks the sync or deletion until the hook succeeds.
+	HookFailurePolicyFail = "Fail"
+	// HookFailurePolicyIgnore carries on when the hook fails.
+	HookFailurePolicyIgnore = "Ignore"
+)
+
+// Events notifications are sent for.
+const (
+	NotificationEventCreated         = "created"
+	NotificationEventDeleted         = "deleted"
+	NotificationEventDeletionBlocked = "deletion-blocked"
+	NotificationEventFailing         = "failing"
+)
+
+// Formats of notification payloads.
+const (
+	// NotificationFormatWebhook posts the event as JSON, with the rendered message.
+	NotificationFormatWebhook = "webhook"
+	// NotificationFormatSlack posts the rendered message as a Slack-compatible
+	// {"text": ...} payload, as accepted by Slack, Mattermost and Rocket.Chat
+	// incoming webhooks.
+	NotificationFormatSlack = "slack"
+)
+
+// Events emitted as CloudEvents.
+const (
+	CloudEventCreated       = "created"
+	CloudEventDeleted       = "deleted"
+	CloudEventDriftDetected = "drift-detected"
+)
+
+// Custom metadata keys stamped by the controller itself, which cannot be mirrored.
+var reservedMetadataKeys = []string{"managed-by", "kubernetes-cluster", "kubernetes-namespace"}
+
+// Limits of Vault namespace custom metadata.
+const (
+	MaxMetadataKeyLength   = 128
+	MaxMetadataValueLength = 512
+)
+
+// CustomMetadataConfig lists the namespace labels and annotations mirrored
+// into the custom metadata of Vault namespaces, under the same keys, so
+// Vault-side tooling can see attribution such as team or cost center.
+type CustomMetadataConfig struct {
+	Labels      []string `yaml:"labels,omitempty"`
+	Annotations []string `yaml:"annotations,omitempty"`
+}
+
+// Enabled reports whether any labels or annotations are mirrored.
+func (c CustomMetadataConfig) Enabled() bool {
+	return len(c.Labels) > 0 || len(c.Annotations) > 0
+}
+
+// LifecycleHooksConfig configures HTTPS endpoints called when the controller
+// creates or deletes Vault namespaces.
+type LifecycleHooksConfig struct {
+	// SigningKeySecret holds the key the payloads are signed with using
+	// HMAC-SHA256. Its key defaults to "signing-key". Payloads are not signed
+	// when unset.
+	SigningKeySecret *SecretKeyRef   `yaml:"signingKeySecret,omitempty"`
+	Hooks            []LifecycleHook `yaml:"hooks,omitempty"`
+}
+
+// LifecycleHook is an HTTPS endpoint called on Vault namespace lifecycle events.
+type LifecycleHook struct {
+	Name string `yaml:"name"`
+	// URL is the https:// URL the payload is POSTed to.
+	URL string `yaml:"url"`
+	// Events are the events the hook is called for: post-create, after a Vault
+	// namespace was created and provisioned, and pre-delete, before one is deleted.
+	Events []string `yaml:"events"`
+	// FailurePolicy is Fail or Ignore. Defaults to Fail.
+	FailurePolicy string `yaml:"failurePolicy,omitempty"`
+	// Attempts is how often the hook is tried before it fails. Defaults to 3.
+	Attempts int `yaml:"attempts,omitempty"`
+	// TimeoutSeconds bounds each attempt. Defaults to 10.
+	TimeoutSeconds Seconds `yaml:"timeoutSeconds,omitempty"`
+}
+
+// MaxAttempts returns how often the hook is tried.
+func (h LifecycleHook) MaxAttempts() int {
+	if h.Attempts <= 0 {
+		return 3
+	}
+	return h.Attempts
+}
+
+// Timeout returns the timeout of each attempt.
+func (h LifecycleHook) Timeout() time.Duration {
+	if h.TimeoutSeconds <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(h.TimeoutSeconds) * time.Second
+}
+
+// Ignored reports whether failures of the hook are ignored.
+func (h LifecycleHook) Ignored() bool {
+	return h.FailurePolicy == HookFailurePolicyIgnore
+}
+
+// Subscribes reports whether the hook is called for event.
+func (h LifecycleHook) Subscribes(event string) bool {
+	return slices.Contains(h.Events, event)
+}
+
+// AuditConfig streams a record of every Vault namespace the controller
+// creates, adopts, moves or deletes to a file, an HTTPS webhook, or both.
+type AuditConfig struct {
+	File    *AuditFileConfig    `yaml:"file,omitempty"`
+	Webhook *AuditWebhookConfig `yaml:"webhook,omitempty"`
+}
+
+// AuditFileConfig appends audit records to a file as JSON lines.
+type AuditFileConfig struct {
+	Path string `yaml:"path"`
+	// MaxSizeMB is the size in megabytes the file is rotated at. Defaults to 100.
+	MaxSizeMB int `yaml:"maxSizeMB,omitempty"`
+	// MaxBackups is how many rotated files are kept. Defaults to 5.
+	MaxBackups *int `yaml:"maxBackups,omitempty"`
+}
+
+// MaxSize returns the size in bytes the file is rotated at.
+func (f AuditFileConfig) MaxSize() int64 {
+	if f.MaxSizeMB <= 0 {
+		return 100 << 20
+	}
+	return int64(f.MaxSizeMB) << 20
+}
+
+// Backups returns how many rotated files are kept.
+func (f AuditFileConfig) Backups() int {
+	if f.MaxBackups == nil {
+		return 5
+	}
+	return *f.MaxBackups
+}
+
+// AuditWebhookConfig POSTs audit records to an HTTPS endpoint.
+type AuditWebhookConfig struct {
+	// URL is the https:// URL the records are POSTed to.
+	URL string `yaml:"url"`
+	// SigningKeySecret holds the key the records are signed with using
+	// HMAC-SHA256. Its key defaults to "signing-key". Records are not signed
+	// when unset.
+	SigningKeySecret *SecretKeyRef `yaml:"signingKeySecret,omitempty"`
+	// BufferSize is how many records are kept for delivery while the
+	// endpoint is unavailable. Defaults to 1000.
+	BufferSize int `yaml:"bufferSize,omitempty"`
+	// TimeoutSeconds bounds each attempt. Defaults to 10.
+	TimeoutSeconds Seconds `yaml:"timeoutSeconds,omitempty"`
+}
+
+// Buffer returns how many records are kept for delivery.
+func (w AuditWebhookConfig) Buffer() int {
+	if w.BufferSize <= 0 {
+		return 1000
+	}
+	return w.BufferSize
+}
+
+// Timeout returns the timeout of each attempt.
+func (w AuditWebhookConfig) Timeout() time.Duration {
+	if w.TimeoutSeconds <= 0 {
+		return 10 * time.Second
+	}
+	return w.TimeoutSeconds.Duration()
+}
+
+// NamespaceInfoMetricsConfig configures the vault_ns_controller_namespace_info
+// metric, which has a series per managed namespace and so is capped.
+type NamespaceInfoMetricsConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// MaxSeries is how many namespaces are exported at most, in order of
+	// their names. Defaults to 1000.
+	MaxSeries int `yaml:"maxSeries,omitempty"`
+}
+
+// Limit returns how many namespaces are exported at most.
+func (n NamespaceInfoMetricsConfig) Limit() int {
+	if n.MaxSeries <= 0 {
+		return 1000
+	}
+	return n.MaxSeries
+}
+
+// NotificationsConfig posts messages about Vault namespace lifecycle events
+// to chat channels or webhooks.
+type NotificationsConfig struct {
+	// FailureThreshold is how many consecutive failed reconciles of a namespace
+	// send the failing event. Defaults to 5.
+	FailureThreshold int        `yaml:"failureThreshold,omitempty"`
+	Notifiers        []Notifier `yaml:"notifiers,omitempty"`
+}
+
+// Threshold returns how many consecutive failed reconciles send the failing event.
+func (n NotificationsConfig) Threshold() int {
+	if n.FailureThreshold <= 0 {
+		return 5
+	}
+	return n.FailureThreshold
+}
+
+// Notifier is an HTTPS endpoint notifications are posted to.
+type Notifier struct {
+	Name string `yaml:"name"`
+	// URL is the https:// URL the notifications are POSTed to.
+	URL string `yaml:"url"`
+	// Format is webhook or slack. Defaults to webhook.
+	Format string `yaml:"format,omitempty"`
+	// Events are the events notified: created, deleted, deletion-blocked and
+	// failing. Defaults to all of them.
+	Events []string `yaml:"events,omitempty"`
+	// Message is a Go template rendering the message from the event. A
+	// message describing the event is sent when empty.
+	Message string `yaml:"message,omitempty"`
+	// TimeoutSeconds bounds each notification. Defaults to 10.
+	TimeoutSeconds Seconds `yaml:"timeoutSeconds,omitempty"`
+}
+
+// Subscribes reports whether the notifier is sent event.
+func (n Notifier) Subscribes(event string) bool {
+	return len(n.Events) == 0 || slices.Contains(n.Events, event)
+}
+
+// Timeout returns the timeout of each notification.
+func (n Notifier) Timeout() time.Duration {
+	if n.TimeoutSeconds <= 0 {
+		return 10 * time.Second
+	}
+	return n.TimeoutSeconds.Duration()
+}
+
+// CloudEventsConfig emits CloudEvents over HTTP for Vault namespace
+// lifecycle events, such as to a Knative broker or an Argo Events webhook.
+type CloudEventsConfig struct {
+	// Sink is the http:// or https:// URL the events are POSTed to. Empty
+	// emits none.
+	Sink string `yaml:"sink,omitempty"`
+	// Source is the source attribute of the events. Defaults to
+	// "vault-namespace-controller", followed by "/" and the cluster name when
+	// one is configured.
+	Source string `yaml:"source,omitempty"`
+	// Events are the events emitted: created, deleted and drift-detected.
+	// Defaults to all of them.
+	Events []string `yaml:"events,omitempty"`
+	// TimeoutSeconds bounds each attempt. Defaults to 10.
+	TimeoutSeconds Seconds `yaml:"timeoutSeconds,omitempty"`
+}
+
+// Enabled reports whether CloudEvents are emitted.
+func (c CloudEventsConfig) Enabled() bool {
+	return c.Sink != ""
+}
+
+// Emits reports whether event is emitted.
+func (c CloudEventsConfig) Emits(event string) bool {
+	return len(c.Events) == 0 || slices.Contains(c.Events, event)
+}
+
+// Timeout returns the timeout of each attempt.
+func (c CloudEventsConfig) Timeout() time.Duration {
+	if c.TimeoutSeconds <= 0 {
+		return 10 * time.Second
+	}
+	return c.TimeoutSeconds.Duration()
+}
+
+// RemoteClusterConfig is an additional cluster whose namespaces are synchronized.
+type RemoteClusterConfig struct {
+	// Name identifies the cluster. It is the Vault namespace the cluster's
+	// namespaces are created under, and the cluster recorded in their ownership metadata.
+	Name string `yaml:"name"`
+
+	// KubeconfigSecret holds a kubeconfig for the cluster.
+	KubeconfigSecret SecretKeyRef `yaml:"kubeconfigSecret"`
 }
 
 // ControllerConfig contains all configuration for the controller.
 type ControllerConfig struct {
+	// APIVersion and Kind identify the schema of the config file. Files of
+	// older versions are converted when loaded.
+	APIVersion string `yaml:"apiVersion,omitempty"`
+	Kind       string `yaml:"kind,omitempty"`
+
 	// Vault configuration
 	Vault VaultConfig `yaml:"vault"`
 
 	// ReconcileInterval specifies how often to reconcile namespaces (in seconds).
-	ReconcileInterval int `yaml:"reconcileInterval"`
+	// Zero disables periodic reconciles, leaving only watch events and the drift scanner.
+	ReconcileInterval Seconds `yaml:"reconcileInterval"`
+
+	// ReconcileTimeout bounds a whole reconcile of a namespace, including every
+	// Vault request it makes (in seconds). Defaults to 30.
+	ReconcileTimeout Seconds `yaml:"reconcileTimeout,omitempty"`
 
 	// DeleteVaultNamespaces indicates whether to delete Vault namespaces when
 	// the corresponding Kubernetes namespace is deleted.
 	DeleteVaultNamespaces bool `yaml:"deleteVaultNamespaces"` // Removed omitempty to ensure it's always included in YAML
 
+	// DeletionGracePeriod specifies how long to wait (in seconds) after a Kubernetes
+	// namespace is deleted before deleting the Vault namespace. The deletion is
+	// cancelled if the namespace is recreated within this period. 0 deletes immediately.
+	DeletionGracePeriod Seconds `yaml:"deletionGracePeriod,omitempty"`
+
+	// MaxManagedNamespaces caps the number of Vault namespaces the controller
+	// manages. Once reached, further creations are refused. 0 means no limit.
+	MaxManagedNamespaces int `yaml:"maxManagedNamespaces,omitempty"`
+
+	// DeleteNonEmptyNamespaces allows deleting Vault namespaces that contain secret
+	// or auth mounts beyond the defaults. When false, such deletions are skipped.
+	DeleteNonEmptyNamespaces bool `yaml:"deleteNonEmptyNamespaces"`
+
+	// ClusterName identifies this Kubernetes cluster. It is recorded in the ownership
+	// metadata of every Vault namespace the controller manages, and can be used in
+	// NamespaceFormat. Defaults to the CLUSTER_NAME environment variable.
+	ClusterName string `yaml:"clusterName,omitempty"`
+
+	// ExistingNamespacePolicy controls how a pre-existing Vault namespace that is not
+	// owned by this controller is handled: adopt, skip, or error.
+	ExistingNamespacePolicy string `yaml:"existingNamespacePolicy,omitempty"`
+
+	// SyncWorkers is the number of namespaces reconciled concurrently. It bounds
+	// the load on Vault, particularly during the initial sync after startup.
+	SyncWorkers int `yaml:"syncWorkers,omitempty"`
+
+	// ErrorBackoffBase is the delay before retrying a namespace after its first
+	// failed reconcile (in seconds). The delay doubles with each consecutive failure.
+	ErrorBackoffBase Seconds `yaml:"errorBackoffBase,omitempty"`
+
+	// ErrorBackoffMax caps the delay between retries of a failing namespace (in seconds).
+	ErrorBackoffMax Seconds `yaml:"errorBackoffMax,omitempty"`
+
+	// ErrorBackoffJitter adds up to this fraction of the delay at random, so that
+	// namespaces failing together do not retry in lockstep.
+	ErrorBackoffJitter float64 `yaml:"errorBackoffJitter,omitempty"`
+
+	// FleetMetricsInterval specifies how often to update the metrics describing
+	// all managed namespaces (in seconds).
+	FleetMetricsInterval Seconds `yaml:"fleetMetricsInterval,omitempty"`
+
+	// VaultNamespaceMetricsInterval specifies how often to count the Vault
+	// namespaces below the namespace roots, and those owned by the
+	// controller (in seconds). Counting lists every Vault namespace, so it
+	// is disabled by default.
+	VaultNamespaceMetricsInterval Seconds `yaml:"vaultNamespaceMetricsInterval,omitempty"`
+
+	// NamespaceInfoMetrics exports a series per managed namespace, updated
+	// with the fleet metrics.
+	NamespaceInfoMetrics NamespaceInfoMetricsConfig `yaml:"namespaceInfoMetrics,omitempty"`
+
+	// MetricsBuckets overrides the histogram buckets of the duration metrics.
+	MetricsBuckets MetricsBucketsConfig `yaml:"metricsBuckets,omitempty"`
+
+	// DriftScanInterval specifies how often to run a full comparison of Kubernetes
+	// and Vault namespaces (in seconds). The scan is disabled when unset.
+	DriftScanInterval Seconds `yaml:"driftScanInterval,omitempty"`
+
+	// OrphanPolicy controls what happens to Vault namespaces owned by this controller
+	// whose Kubernetes namespace no longer exists: report or delete.
+	OrphanPolicy string `yaml:"orphanPolicy,omitempty"`
+
+	// OrphanScanInterval specifies how often to scan for orphaned Vault namespaces
+	// (in seconds). The scan is disabled when unset.
+	OrphanScanInterval Seconds `yaml:"orphanScanInterval,omitempty"`
+
+	// OrphanMinAge specifies how long (in seconds) a Vault namespace must have been
+	// seen as orphaned before the delete policy removes it.
+	OrphanMinAge Seconds `yaml:"orphanMinAge,omitempty"`
+
+	// SyncReportInterval specifies how often to publish a VaultNamespaceSyncReport
+	// per cluster (in seconds). Reports are disabled when unset. The
+	// VaultNamespaceSyncReport CRD must be installed.
+	SyncReportInterval Seconds `yaml:"syncReportInterval,omitempty"`
+
 	// NamespaceFormat specifies the format string for Vault namespace names.
+	// ClusterPlaceholder is replaced with ClusterName before formatting.
 	NamespaceFormat string `yaml:"namespaceFormat"`
 
+	// NamespaceTemplate is a Go text/template for Vault namespace names, executed
+	// against NamespaceTemplateData, e.g. "{{ .Labels.team }}/{{ .Name }}". When
+	// set it takes precedence over NamespaceFormat.
+	NamespaceTemplate string `yaml:"namespaceTemplate,omitempty"`
+
+	// StaticMappings map the names of individual namespaces to the exact Vault
+	// namespace path to use for them, taking precedence over every other mapping.
+	// Intended for legacy namespaces whose Vault namespaces pre-date any convention.
+	StaticMappings map[string]string `yaml:"staticMappings,omitempty"`
+
+	// Migration moves namespaces from Vault namespaces created with a previous
+	// NamespaceFormat to the paths of the current mapping.
+	Migration MigrationConfig `yaml:"migration,omitempty"`
+
+	// Bootstrap sets up secrets engines inside newly created Vault namespaces,
+	// so they are usable without a VaultNamespaceBlueprint.
+	Bootstrap BootstrapConfig `yaml:"bootstrap,omitempty"`
+
+	// MappingRules map namespaces to Vault namespace paths. The first rule
+	// matching a namespace applies; namespaces matching no rule fall back to
+	// NamespaceTemplate or NamespaceFormat.
+	MappingRules []MappingRule `yaml:"mappingRules,omitempty"`
+
+	// CustomMetadata mirrors namespace labels and annotations into the custom
+	// metadata of Vault namespaces.
+	CustomMetadata CustomMetadataConfig `yaml:"customMetadata,omitempty"`
+
+	// PolicyTemplates are ACL policies written into every synchronized Vault
+	// namespace, rendered for its Kubernetes namespace.
+	PolicyTemplates []PolicyTemplate `yaml:"policyTemplates,omitempty"`
+
+	// IdentityGroups are identity groups created in every synchronized Vault
+	// namespace, so access to it follows from group membership.
+	IdentityGroups []IdentityGroup `yaml:"identityGroups,omitempty"`
+
+	// SentinelPolicies are Sentinel policies written into every synchronized
+	// Vault namespace, for governance that must apply to every tenant.
+	SentinelPolicies []SentinelPolicy `yaml:"sentinelPolicies,omitempty"`
+
+	// VSO creates a Vault Secrets Operator VaultConnection and VaultAuth in
+	// every synchronized Kubernetes namespace, pointed at its Vault namespace.
+	VSO SecretsOperatorConfig `yaml:"vso,omitempty"`
+
+	// ESO creates an External Secrets Operator SecretStore in every
+	// synchronized Kubernetes namespace, reading from its Vault namespace.
+	ESO ESOConfig `yaml:"eso,omitempty"`
+
+	// LifecycleHooks are called after Vault namespaces are created and before
+	// they are deleted, so external systems can react.
+	LifecycleHooks LifecycleHooksConfig `yaml:"lifecycleHooks,omitempty"`
+
+	// Audit streams a record of the Vault namespace changes to sinks.
+	Audit AuditConfig `yaml:"audit,omitempty"`
+
+	// Notifications posts messages about Vault namespace lifecycle events,
+	// such as to the channels tenants are onboarded in.
+	Notifications NotificationsConfig `yaml:"notifications,omitempty"`
+
+	// CloudEvents emits CloudEvents for Vault namespace lifecycle events, so
+	// downstream automation can chain workflows off the controller.
+	CloudEvents CloudEventsConfig `yaml:"cloudEvents,omitempty"`
+
+	// ConnectionConfigMap is the name of a ConfigMap written into every
+	// synchronized Kubernetes namespace with the Vault address, Vault namespace
+	// and auth method to use. Empty writes none.
+	ConnectionConfigMap string `yaml:"connectionConfigMap,omitempty"`
+
+	// ParentRoots places namespaces under different Vault namespace roots
+	// depending on a label. A mapping rule's own parent takes precedence.
+	ParentRoots ParentRootsConfig `yaml:"parentRoots,omitempty"`
+
+	// MirrorHierarchy nests the Vault namespace of each namespace in a
+	// Hierarchical Namespace Controller (HNC) hierarchy in its parent's.
+	MirrorHierarchy bool `yaml:"mirrorHierarchy,omitempty"`
+
+	// CapsuleTenants groups the Vault namespaces of namespaces owned by a Capsule
+	// Tenant in a Vault namespace per Tenant, created on demand.
+	CapsuleTenants bool `yaml:"capsuleTenants,omitempty"`
+
+	// RemoteClusters are further clusters whose namespaces are synchronized by
+	// this controller, each below a Vault namespace named after the cluster.
+	RemoteClusters []RemoteClusterConfig `yaml:"remoteClusters,omitempty"`
+
+	// MaxNamespaceNameLength is the longest Vault namespace name the controller
+	// creates. Longer path segments are truncated and given a hash suffix of the
+	// full name, so they still map deterministically. Defaults to 64.
+	MaxNamespaceNameLength int `yaml:"maxNamespaceNameLength,omitempty"`
+
+	// StrictNamespaceNames rejects Vault namespace paths with names containing
+	// characters other than letters, digits, '-' and '_'.
+	StrictNamespaceNames bool `yaml:"strictNamespaceNames,omitempty"`
+
 	// IncludeNamespaces specifies patterns of namespaces to include.
 	IncludeNamespaces []string `yaml:"includeNamespaces,omitempty"`
 
 	// ExcludeNamespaces specifies patterns of namespaces to exclude.
 	ExcludeNamespaces []string `yaml:"excludeNamespaces,omitempty"`
 
+	// PatternSyntax is the syntax of IncludeNamespaces and ExcludeNamespaces:
+	// regex for unanchored regular expressions, the default, or glob for
+	// shell-style patterns matching the whole name.
+	PatternSyntax string `yaml:"patternSyntax,omitempty"`
+
+	// StrictMatch anchors regex include and exclude patterns, so that they
+	// must match the whole namespace name rather than a part of it.
+	StrictMatch bool `yaml:"strictMatch,omitempty"`
+
+	// IncludeExpressions and ExcludeExpressions are CEL expressions over the
+	// namespace name, labels and annotations, applied alongside the include
+	// and exclude patterns.
+	IncludeExpressions []string `yaml:"includeExpressions,omitempty"`
+	ExcludeExpressions []string `yaml:"excludeExpressions,omitempty"`
+
+	// NamespaceSelector is a Kubernetes label selector, such as "team=payments",
+	// restricting which namespaces the controller watches and caches at all.
+	NamespaceSelector string `yaml:"namespaceSelector,omitempty"`
+
 	// MetricsBindAddress specifies the address to bind metrics server.
 	MetricsBindAddress string `yaml:"metricsBindAddress"`
 
+	// MetricsServer configures TLS and authentication for the metrics server.
+	MetricsServer MetricsServerConfig `yaml:"metricsServer,omitempty"`
+
+	// HealthProbeBindAddress specifies the address to serve the /healthz and
+	// /readyz probes on. "0" disables them.
+	HealthProbeBindAddress string `yaml:"healthProbeBindAddress,omitempty"`
+
+	// AdminBindAddress specifies the address to bind the admin server. Empty disables it.
+	AdminBindAddress string `yaml:"adminBindAddress,omitempty"`
+
+	// AdminToken is the bearer token required by the admin server.
+	AdminToken string `yaml:"adminToken,omitempty"`
+
+	// AdminTokenPath is a file containing the bearer token required by the admin server.
+	AdminTokenPath string `yaml:"adminTokenPath,omitempty"`
+
 	// LeaderElection indicates whether to use leader election.
 	LeaderElection bool `yaml:"leaderElection"` // Removed omitempty to ensure it's always included in YAML
+
+	// LeaderElectionNamespace is the namespace of the leader election lease.
+	// Empty uses the namespace the controller runs in.
+	LeaderElectionNamespace string `yaml:"leaderElectionNamespace,omitempty"`
+
+	// LeaderElectionLeaseDuration is how long replicas wait before taking over
+	// a lease that was not renewed, LeaderElectionRenewDeadline how long the
+	// leader keeps retrying to renew it before giving up leadership, and
+	// LeaderElectionRetryPeriod how often they try. Zero keeps the
+	// controller-runtime defaults of 15s, 10s and 2s. Raising them avoids
+	// failovers when the API server is slow to respond.
+	LeaderElectionLeaseDuration Seconds `yaml:"leaderElectionLeaseDuration,omitempty"`
+	LeaderElectionRenewDeadline Seconds `yaml:"leaderElectionRenewDeadline,omitempty"`
+	LeaderElectionRetryPeriod   Seconds `yaml:"leaderElectionRetryPeriod,omitempty"`
+
+	// DryRun makes the controller log and record the Vault changes it would make
+	// without calling any Vault API that modifies state.
+	DryRun bool `yaml:"dryRun"`
+
+	// Paused halts all Vault changes while the controller keeps watching namespaces.
+	Paused bool `yaml:"paused"`
+
+	// ControllerConfigMap is the namespace/name of the controller's own ConfigMap.
+	// When set, the controller pauses while it is annotated vault.benemon.io/paused=true.
+	ControllerConfigMap string `yaml:"controllerConfigMap,omitempty"`
+
+	// VaultNamespaceResources maintains a cluster-scoped VaultNamespace resource
+	// per synchronized namespace. The VaultNamespace CRD must be installed.
+	VaultNamespaceResources bool `yaml:"vaultNamespaceResources,omitempty"`
+
+	// NamespaceClasses lets namespaces select a VaultNamespaceClass with the
+	// vault.benemon.io/class annotation. The VaultNamespaceClass CRD must be
+	// installed.
+	NamespaceClasses bool `yaml:"namespaceClasses,omitempty"`
+
+	// VaultConnections lets namespaces be routed to other Vault clusters,
+	// described by VaultConnection resources, through their class or the
+	// vault.benemon.io/connection label. The VaultConnection CRD must be installed.
+	VaultConnections bool `yaml:"vaultConnections,omitempty"`
+
+	// LiveConfig applies the include and exclude patterns, NamespaceFormat and
+	// deletion settings of the VaultNamespaceControllerConfig named default
+	// without a restart. The VaultNamespaceControllerConfig CRD must be installed.
+	LiveConfig bool `yaml:"liveConfig,omitempty"`
+
+	// HotReload watches the configuration file and applies changes to the
+	// include and exclude patterns and how they match, NamespaceFormat, deletion settings and
+	// ReconcileInterval without a restart. Changes to other settings are
+	// refused until the controller restarts.
+	HotReload bool `yaml:"hotReload,omitempty"`
+
+	// Webhooks serves the admission webhooks validating the controller's custom
+	// resources and annotating new namespaces with their Vault namespace path.
+	// They must be registered with a serving certificate.
+	Webhooks bool `yaml:"webhooks,omitempty"`
+
+	// WebhookServer configures the server the admission webhooks are served on.
+	WebhookServer WebhookServerConfig `yaml:"webhookServer,omitempty"`
+
+	// DeletionGuard adds an admission webhook, served with Webhooks, denying
+	// the deletion of namespaces whose Vault namespace the controller would
+	// delete while it still holds secret or auth mounts.
+	DeletionGuard bool `yaml:"deletionGuard,omitempty"`
+
+	// MappingConfigMap is the namespace/name of a ConfigMap in which the
+	// controller persists where each namespace was synchronized to. It is
+	// created if missing.
+	MappingConfigMap string `yaml:"mappingConfigMap,omitempty"`
+
+	// RateLimiter tunes how aggressively failed reconciles are retried.
+	RateLimiter RateLimiterConfig `yaml:"rateLimiter,omitempty"`
+}
+
+// ControllerConfigMapKey splits ControllerConfigMap into its namespace and name.
+func (c *ControllerConfig) ControllerConfigMapKey() (namespace, name string, ok bool) {
+	return splitConfigMapRef(c.ControllerConfigMap)
+}
+
+// MappingConfigMapKey splits MappingConfigMap into its namespace and name.
+func (c *ControllerConfig) MappingConfigMapKey() (namespace, name string, ok bool) {
+	return splitConfigMapRef(c.MappingConfigMap)
+}
+
+// splitConfigMapRef splits a namespace/name reference.
+func splitConfigMapRef(ref string) (namespace, name string, ok bool) {
+	namespace, name, ok = strings.Cut(ref, "/")
+	if !ok || namespace == "" || name == "" {
+		return "", "", false
+	}
+	return namespace, name, true
 }
 
-// LoadConfig loads configuration from a file. If path is empty, default configuration is returned.
+// LoadConfig loads configuration from a file and applies the VNC_ environment
+// variable overrides. If path is empty and no overrides are set, default
+// configuration is returned.
 func LoadConfig(path string) (*ControllerConfig, error) {
+	config, configured, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if configured {
+		if err := validateConfig(config); err != nil {
+			return nil, fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+	return config, nil
+}
+
+// readConfig reads the configuration like LoadConfig, without validating it.
+// It reports whether anything was configured, by the file or the environment.
+func readConfig(path string) (*ControllerConfig, bool, error) {
 	config := &ControllerConfig{
 		// Default values
-		ReconcileInterval:     300, // 5 minutes
-		DeleteVaultNamespaces: true,
-		MetricsBindAddress:    ":8080",
-		LeaderElection:        true,
-		NamespaceFormat:       "%s", // default format is the namespace name
+		APIVersion:              ConfigAPIVersion,
+		Kind:                    ConfigKind,
+		ReconcileInterval:       300, // 5 minutes
+		ReconcileTimeout:        30,
+		DeleteVaultNamespaces:   true,
+		MetricsBindAddress:      ":8080",
+		HealthProbeBindAddress:  ":8081",
+		LeaderElection:          true,
+		NamespaceFormat:         "%s", // default format is the namespace name
+		MaxNamespaceNameLength:  64,
+		ExistingNamespacePolicy: ExistingNamespaceAdopt,
+		OrphanPolicy:            OrphanPolicyReport,
+		OrphanMinAge:            86400, // 24 hours
+		SyncWorkers:             4,
+		FleetMetricsInterval:    60,
+		ErrorBackoffBase:        5,
+		ErrorBackoffMax:         300, // 5 minutes
+		ErrorBackoffJitter:      0.1,
+		// The controller-runtime defaults
+		RateLimiter: RateLimiterConfig{
+			BaseDelayMilliseconds: 5,
+			MaxDelaySeconds:       1000,
+			QPS:                   10,
+			Burst:                 100,
+		},
 	}
 
-	// If path is empty, return default config
+	config.ClusterName = os.Getenv(ClusterNameEnv)
+
+	// If path is empty, return default config with the environment overrides
 	if path == "" {
-		return config, nil
+		overridden, err := applyEnvOverrides(config, os.LookupEnv)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid configuration: %w", err)
+		}
+		return config, overridden, nil
 	}
 
 	// Read config file
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file %q: %w", path, err)
-	}
-
-	// Parse config - use a temporary struct to ensure all fields are properly unmarshaled
-	var tempConfig ControllerConfig
-	if err := yaml.Unmarshal(data, &tempConfig); err != nil {
-		return nil, fmt.Errorf("failed to parse config file %q: %w", path, err)
+		return nil, false, fmt.Errorf("failed to read config file %q: %w", path, err)
 	}
 
-	// Now manually copy the values from tempConfig to config
-	// This ensures that values not present in the YAML don't keep their defaults
-
-	// Vault config is different, only copy if it's set
-	if tempConfig.Vault.Address != "" {
-		config.Vault = tempConfig.Vault
+	data, err = decryptConfig(data, os.LookupEnv)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decrypt config file %q: %w", path, err)
 	}
 
-	// Copy direct fields, checking if they exist in the YAML
-	if tempConfig.ReconcileInterval != 0 {
-		config.ReconcileInterval = tempConfig.ReconcileInterval
+	data, err = convertConfig(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to convert config file %q: %w", path, err)
 	}
 
-	// For boolean fields, we need to use the value from tempConfig
-	// DeleteVaultNamespaces and LeaderElection need to be overridden regardless
-	config.DeleteVaultNamespaces = tempConfig.DeleteVaultNamespaces
-	config.LeaderElection = tempConfig.LeaderElection
-
-	// String fields, check if non-empty
-	if tempConfig.NamespaceFormat != "" {
-		config.NamespaceFormat = tempConfig.NamespaceFormat
+	// The file is decoded over the defaults, so settings it leaves out keep
+	// their defaults while those it sets, even to false or zero, take effect.
+	// Unknown fields are rejected, so that a misspelt setting does not
+	// silently fall back to its default
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, false, fmt.Errorf("failed to parse config file %q: %w", path, suggestFields(err))
 	}
-	if tempConfig.MetricsBindAddress != "" {
-		config.MetricsBindAddress = tempConfig.MetricsBindAddress
+	if err := interpolateEnv(config, os.LookupEnv); err != nil {
+		return nil, false, fmt.Errorf("failed to expand environment variables in config file %q: %w", path, err)
 	}
 
-	// Slice fields, check if non-nil
-	if tempConfig.IncludeNamespaces != nil {
-		config.IncludeNamespaces = tempConfig.IncludeNamespaces
-	}
-	if tempConfig.ExcludeNamespaces != nil {
-		config.ExcludeNamespaces = tempConfig.ExcludeNamespaces
+	// Environment variables take precedence over the file
+	if _, err := applyEnvOverrides(config, os.LookupEnv); err != nil {
+		return nil, false, fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	// Validate config
-	if err := validateConfig(config); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
-	}
+	return config, true, nil
+}
 
-	return config, nil
+// Validate checks that the configuration is valid.
+func (c *ControllerConfig) Validate() error {
+	return validateConfig(c)
 }
 
 // validateConfig checks that the configuration is valid.
 func validateConfig(config *ControllerConfig) error {
+	if config.APIVersion != "" && config.APIVersion != ConfigAPIVersion {
+		return fmt.Errorf("unsupported apiVersion %q, expected %s", config.APIVersion, ConfigAPIVersion)
+	}
+	if config.Kind != "" && config.Kind != ConfigKind {
+		return fmt.Errorf("unsupported kind %q, expected %s", config.Kind, ConfigKind)
+	}
+
 	// Validate Vault address
 	if config.Vault.Address == "" {
 		return ErrMissingVaultAddress
 	}
 
+	if config.ReconcileInterval < 0 {
+		return errors.New("reconcileInterval must not be negative")
+	}
+	if config.ReconcileTimeout < 0 {
+		return errors.New("reconcileTimeout must not be negative")
+	}
+	if config.Vault.Timeout < 0 {
+		return errors.New("vault.timeout must not be negative")
+	}
+	if config.Vault.TokenTTLThreshold < 0 {
+		return errors.New("vault.tokenTTLThreshold must not be negative")
+	}
+
+	if err := validateMetricsServer(config.MetricsServer); err != nil {
+		return err
+	}
+
+	if config.WebhookServer.Port < 0 || config.WebhookServer.Port > 65535 {
+		return fmt.Errorf("webhookServer.port %d is not a valid port", config.WebhookServer.Port)
+	}
+
+	if err := validateLeaderElection(config); err != nil {
+		return err
+	}
+
+	if config.DeletionGracePeriod < 0 {
+		return errors.New("deletionGracePeriod must not be negative")
+	}
+
+	if strings.Contains(config.NamespaceFormat, ClusterPlaceholder) && config.ClusterName == "" {
+		return fmt.Errorf("namespaceFormat uses %s but clusterName is not set", ClusterPlaceholder)
+	}
+
+	if _, err := ParseNamespaceTemplate(config.NamespaceTemplate); err != nil {
+		return fmt.Errorf("invalid namespaceTemplate: %w", err)
+	}
+
+	switch config.PatternSyntax {
+	case "", PatternSyntaxRegex, PatternSyntaxGlob:
+	default:
+		return fmt.Errorf("unsupported patternSyntax %q, expected regex or glob", config.PatternSyntax)
+	}
+	if err := ValidatePatterns("includeNamespaces", config.PatternSyntax, config.IncludeNamespaces); err != nil {
+		return err
+	}
+	if err := ValidatePatterns("excludeNamespaces", config.PatternSyntax, config.ExcludeNamespaces); err != nil {
+		return err
+	}
+
+	for i, expression := range config.IncludeExpressions {
+		if _, err := CompileNamespaceExpression(expression); err != nil {
+			return fmt.Errorf("invalid includeExpressions[%d]: %w", i, err)
+		}
+	}
+	for i, expression := range config.ExcludeExpressions {
+		if _, err := CompileNamespaceExpression(expression); err != nil {
+			return fmt.Errorf("invalid excludeExpressions[%d]: %w", i, err)
+		}
+	}
+
+	if config.ParentRoots.Label == "" && (len(config.ParentRoots.Roots) > 0 || config.ParentRoots.Default != "") {
+		return errors.New("parentRoots requires a label")
+	}
+
+	clusterNames := make(map[string]bool)
+	for i, cluster := range config.RemoteClusters {
+		if cluster.Name == "" || strings.Contains(cluster.Name, "/") {
+			return fmt.Errorf("remoteClusters[%d]: name must be a single Vault namespace name", i)
+		}
+		if clusterNames[cluster.Name] || cluster.Name == config.ClusterName {
+			return fmt.Errorf("remoteClusters[%d]: duplicate cluster name %q", i, cluster.Name)
+		}
+		clusterNames[cluster.Name] = true
+		if cluster.KubeconfigSecret.Namespace == "" || cluster.KubeconfigSecret.Name == "" {
+			return fmt.Errorf("remoteClusters[%d]: kubeconfigSecret namespace and name are required", i)
+		}
+	}
+
+	hookNames := make(map[string]bool)
+	for i, hook := range config.LifecycleHooks.Hooks {
+		if err := validateLifecycleHook(hook); err != nil {
+			return fmt.Errorf("invalid lifecycleHooks.hooks[%d]: %w", i, err)
+		}
+		if hookNames[hook.Name] {
+			return fmt.Errorf("lifecycleHooks.hooks[%d]: duplicate hook name %q", i, hook.Name)
+		}
+		hookNames[hook.Name] = true
+	}
+	if ref := config.LifecycleHooks.SigningKeySecret; ref != nil && (ref.Namespace == "" || ref.Name == "") {
+		return errors.New("lifecycleHooks.signingKeySecret namespace and name are required")
+	}
+	if err := validateAudit(config.Audit); err != nil {
+		return err
+	}
+	if config.Notifications.FailureThreshold < 0 {
+		return errors.New("notifications.failureThreshold must not be negative")
+	}
+	notifierNames := make(map[string]bool)
+	for i, notifier := range config.Notifications.Notifiers {
+		if err := validateNotifier(notifier); err != nil {
+			return fmt.Errorf("invalid notifications.notifiers[%d]: %w", i, err)
+		}
+		if notifierNames[notifier.Name] {
+			return fmt.Errorf("notifications.notifiers[%d]: duplicate notifier name %q", i, notifier.Name)
+		}
+		notifierNames[notifier.Name] = true
+	}
+	if err := validateCloudEvents(config.CloudEvents); err != nil {
+		return err
+	}
+
+	for field, ref := range config.Vault.Auth.SecretRefs() {
+		if ref.Namespace == "" || ref.Name == "" {
+			return fmt.Errorf("vault.auth.%sSecretRef namespace and name are required", field)
+		}
+	}
+
+	if config.Migration.DeleteOld && config.Migration.PreviousFormat == "" {
+		return fmt.Errorf("migration.deleteOld requires migration.previousFormat")
+	}
+	if strings.Contains(config.Migration.PreviousFormat, ClusterPlaceholder) && config.ClusterName == "" {
+		return fmt.Errorf("migration.previousFormat uses %s but clusterName is not set", ClusterPlaceholder)
+	}
+
+	if kvMount := config.Bootstrap.KVMount; kvMount != "" && (strings.Trim(kvMount, "/") == "" || strings.ContainsAny(kvMount, " \t\n")) {
+		return fmt.Errorf("bootstrap.kvMount %q is not a valid mount path", config.Bootstrap.KVMount)
+	}
+	if ttl := config.Bootstrap.KubernetesAuth.TokenTTL; ttl != "" {
+		if _, err := time.ParseDuration(ttl); err != nil {
+			return fmt.Errorf("bootstrap.kubernetesAuth.tokenTTL %q is not a valid duration: %w", ttl, err)
+		}
+	}
+	if audit := config.Bootstrap.AuditDevice; audit.Type == "" && (audit.Path != "" || len(audit.Options) > 0) {
+		return errors.New("bootstrap.auditDevice.type is required")
+	}
+	for key, value := range config.Bootstrap.AuditDevice.Options {
+		if _, err := ParseNamespaceTemplate(value); err != nil {
+			return fmt.Errorf("invalid bootstrap.auditDevice.options.%s: %w", key, err)
+		}
+	}
+
+	for namespaceName, vaultPath := range config.StaticMappings {
+		if namespaceName == "" {
+			return fmt.Errorf("staticMappings: namespace name is required")
+		}
+		for _, segment := range strings.Split(strings.TrimPrefix(vaultPath, "/"), "/") {
+			if segment == "" {
+				return fmt.Errorf("staticMappings[%s]: %q has an empty path segment", namespaceName, vaultPath)
+			}
+		}
+	}
+
+	for i, rule := range config.MappingRules {
+		if err := validateMappingRule(rule); err != nil {
+			return fmt.Errorf("invalid mappingRules[%d]: %w", i, err)
+		}
+	}
+
+	policyNames := make(map[string]bool)
+	for i, policy := range config.PolicyTemplates {
+		if policy.Name == "" || policy.Policy == "" {
+			return fmt.Errorf("policyTemplates[%d]: name and policy are required", i)
+		}
+		if policyNames[policy.Name] {
+			return fmt.Errorf("policyTemplates[%d]: duplicate policy name %q", i, policy.Name)
+		}
+		policyNames[policy.Name] = true
+		if _, err := ParseNamespaceTemplate(policy.Policy); err != nil {
+			return fmt.Errorf("invalid policyTemplates[%d]: %w", i, err)
+		}
+	}
+
+	for i, group := range config.IdentityGroups {
+		if err := validateIdentityGroup(group); err != nil {
+			return fmt.Errorf("invalid identityGroups[%d]: %w", i, err)
+		}
+	}
+
+	sentinelNames := make(map[string]bool)
+	for i, policy := range config.SentinelPolicies {
+		if err := validateSentinelPolicy(policy); err != nil {
+			return fmt.Errorf("invalid sentinelPolicies[%d]: %w", i, err)
+		}
+		if sentinelNames[policy.Type+"/"+policy.Name] {
+			return fmt.Errorf("sentinelPolicies[%d]: duplicate %s name %q", i, policy.Type, policy.Name)
+		}
+		sentinelNames[policy.Type+"/"+policy.Name] = true
+	}
+
+	if config.MaxNamespaceNameLength != 0 && config.MaxNamespaceNameLength < MinNamespaceNameLength {
+		return fmt.Errorf("maxNamespaceNameLength must be at least %d", MinNamespaceNameLength)
+	}
+
+	seenMetadataKeys := make(map[string]bool)
+	for _, key := range append(slices.Clone(config.CustomMetadata.Labels), config.CustomMetadata.Annotations...) {
+		switch {
+		case key == "" || len(key) > MaxMetadataKeyLength:
+			return fmt.Errorf("customMetadata key %q must be 1 to %d characters", key, MaxMetadataKeyLength)
+		case slices.Contains(reservedMetadataKeys, key):
+			return fmt.Errorf("customMetadata key %q is reserved for the ownership metadata", key)
+		case seenMetadataKeys[key]:
+			return fmt.Errorf("customMetadata key %q is listed more than once", key)
+		}
+		seenMetadataKeys[key] = true
+	}
+
+	if config.MaxManagedNamespaces < 0 {
+		return errors.New("maxManagedNamespaces must not be negative")
+	}
+
+	if _, err := labels.Parse(config.NamespaceSelector); err != nil {
+		return fmt.Errorf("invalid namespaceSelector: %w", err)
+	}
+
+	switch config.OrphanPolicy {
+	case "", OrphanPolicyReport, OrphanPolicyDelete:
+	default:
+		return fmt.Errorf("%w: orphanPolicy %q must be one of report, delete",
+			ErrInvalidPolicy, config.OrphanPolicy)
+	}
+	if config.OrphanScanInterval < 0 || config.OrphanMinAge < 0 {
+		return errors.New("orphanScanInterval and orphanMinAge must not be negative")
+	}
+	if config.SyncWorkers < 0 {
+		return errors.New("syncWorkers must not be negative")
+	}
+	if config.ErrorBackoffBase < 0 || config.ErrorBackoffMax < config.ErrorBackoffBase {
+		return errors.New("errorBackoffBase must not be negative or greater than errorBackoffMax")
+	}
+	if config.ErrorBackoffJitter < 0 || config.ErrorBackoffJitter > 1 {
+		return errors.New("errorBackoffJitter must be between 0 and 1")
+	}
+	if config.FleetMetricsInterval < 0 {
+		return errors.New("fleetMetricsInterval must not be negative")
+	}
+	if config.VaultNamespaceMetricsInterval < 0 {
+		return errors.New("vaultNamespaceMetricsInterval must not be negative")
+	}
+	if config.NamespaceInfoMetrics.MaxSeries < 0 {
+		return errors.New("namespaceInfoMetrics.maxSeries must not be negative")
+	}
+	if err := validateBuckets("metricsBuckets.reconcile", config.MetricsBuckets.Reconcile); err != nil {
+		return err
+	}
+	if err := validateBuckets("metricsBuckets.vault", config.MetricsBuckets.Vault); err != nil {
+		return err
+	}
+	if config.SyncReportInterval < 0 {
+		return errors.New("syncReportInterval must not be negative")
+	}
+	if config.RateLimiter.BaseDelayMilliseconds < 0 || config.RateLimiter.MaxDelaySeconds < 0 ||
+		config.RateLimiter.QPS < 0 || config.RateLimiter.Burst < 0 {
+		return errors.New("rateLimiter settings must not be negative")
+	}
+	if config.DriftScanInterval < 0 {
+		return errors.New("driftScanInterval must not be negative")
+	}
+
+	if config.AdminBindAddress != "" && config.AdminToken == "" && config.AdminTokenPath == "" {
+		return errors.New("either adminToken or adminTokenPath is required when adminBindAddress is set")
+	}
+
+	if config.ControllerConfigMap != "" {
+		if _, _, ok := config.ControllerConfigMapKey(); !ok {
+			return fmt.Errorf("controllerConfigMap %q must be in the form namespace/name", config.ControllerConfigMap)
+		}
+	}
+	if config.MappingConfigMap != "" {
+		if _, _, ok := config.MappingConfigMapKey(); !ok {
+			return fmt.Errorf("mappingConfigMap %q must be in the form namespace/name", config.MappingConfigMap)
+		}
+	}
+
+	switch config.ExistingNamespacePolicy {
+	case "", ExistingNamespaceAdopt, ExistingNamespaceSkip, ExistingNamespaceError:
+	default:
+		return fmt.Errorf("%w: existingNamespacePolicy %q must be one of adopt, skip, error",
+			ErrInvalidPolicy, config.ExistingNamespacePolicy)
+	}
+
 	// Validate auth configuration
 	if config.Vault.Auth.Type == "" {
 		return ErrMissingAuthType
@@ -171,8 +1453,8 @@ func validateConfig(config *ControllerConfig) error {
 	// Validate auth method
 	switch config.Vault.Auth.Type {
 	case "token":
-		if config.Vault.Auth.Token == "" && config.Vault.Auth.TokenPath == "" {
-			return errors.New("either token or tokenPath is required for token auth method")
+		if config.Vault.Auth.Token == "" && config.Vault.Auth.TokenPath == "" && config.Vault.Auth.TokenSecretRef == nil {
+			return errors.New("either token, tokenPath or tokenSecretRef is required for token auth method")
 		}
 	case "kubernetes":
 		if config.Vault.Auth.Role == "" {
@@ -183,9 +1465,13 @@ func validateConfig(config *ControllerConfig) error {
 		hasDirectValues := config.Vault.Auth.RoleID != "" && config.Vault.Auth.SecretID != ""
 		// Check path values
 		hasPathValues := config.Vault.Auth.RoleIDPath != "" && config.Vault.Auth.SecretIDPath != ""
+		// A Secret may hold either credential, with the other set directly or by path
+		hasSecretRefs := (config.Vault.Auth.RoleIDSecretRef != nil || config.Vault.Auth.RoleID != "" || config.Vault.Auth.RoleIDPath != "") &&
+			(config.Vault.Auth.SecretIDSecretRef != nil || config.Vault.Auth.SecretID != "" || config.Vault.Auth.SecretIDPath != "") &&
+			(config.Vault.Auth.RoleIDSecretRef != nil || config.Vault.Auth.SecretIDSecretRef != nil)
 
-		if !hasDirectValues && !hasPathValues {
-			return errors.New("either roleId+secretId or roleIdPath+secretIdPath are required for approle auth method")
+		if !hasDirectValues && !hasPathValues && !hasSecretRefs {
+			return errors.New("either roleId+secretId, roleIdPath+secretIdPath or Secret references are required for approle auth method")
 		}
 	default:
 		return fmt.Errorf("%w: %s", ErrUnsupportedAuthType, config.Vault.Auth.Type)
@@ -193,3 +1479,272 @@ func validateConfig(config *ControllerConfig) error {
 
 	return nil
 }
+
+// validateMetricsServer checks that metrics authentication is only enabled
+// over TLS, with the settings the method needs.
+func validateMetricsServer(metrics MetricsServerConfig) error {
+	switch metrics.Auth {
+	case "", MetricsAuthNone:
+		return nil
+	case MetricsAuthToken:
+	case MetricsAuthClientCert:
+		if metrics.ClientCAFile == "" {
+			return errors.New("metricsServer.clientCAFile is required for clientCert authentication")
+		}
+	default:
+		return fmt.Errorf("unsupported metricsServer.auth %q, expected none, token or clientCert", metrics.Auth)
+	}
+	if !metrics.Secure {
+		return fmt.Errorf("metricsServer.auth %s requires metricsServer.secure", metrics.Auth)
+	}
+	return nil
+}
+
+// validateBuckets checks that histogram buckets are positive and increasing.
+func validateBuckets(field string, buckets []float64) error {
+	for i, bucket := range buckets {
+		if bucket <= 0 {
+			return fmt.Errorf("%s[%d] must be positive", field, i)
+		}
+		if i > 0 && bucket <= buckets[i-1] {
+			return fmt.Errorf("%s must be in increasing order", field)
+		}
+	}
+	return nil
+}
+
+// validateLeaderElection checks that the leader election timings are
+// consistent, as client-go requires.
+func validateLeaderElection(config *ControllerConfig) error {
+	if config.LeaderElectionLeaseDuration < 0 || config.LeaderElectionRenewDeadline < 0 || config.LeaderElectionRetryPeriod < 0 {
+		return errors.New("leader election durations must not be negative")
+	}
+	lease, renew, retry := config.LeaderElectionTimings()
+	if lease <= renew {
+		return fmt.Errorf("leaderElectionLeaseDuration (%s) must be greater than leaderElectionRenewDeadline (%s)", lease, renew)
+	}
+	// client-go retries with up to 20% jitter
+	if renew <= retry*12/10 {
+		return fmt.Errorf("leaderElectionRenewDeadline (%s) must be greater than 1.2 times leaderElectionRetryPeriod (%s)", renew, retry)
+	}
+	return nil
+}
+
+// LeaderElectionTimings returns the lease duration, renew deadline and retry
+// period, with the controller-runtime defaults for those not set.
+func (c *ControllerConfig) LeaderElectionTimings() (lease, renew, retry time.Duration) {
+	lease, renew, retry = 15*time.Second, 10*time.Second, 2*time.Second
+	if c.LeaderElectionLeaseDuration > 0 {
+		lease = c.LeaderElectionLeaseDuration.Duration()
+	}
+	if c.LeaderElectionRenewDeadline > 0 {
+		renew = c.LeaderElectionRenewDeadline.Duration()
+	}
+	if c.LeaderElectionRetryPeriod > 0 {
+		retry = c.LeaderElectionRetryPeriod.Duration()
+	}
+	return lease, renew, retry
+}
+
+// validateMappingRule checks that a mapping rule compiles.
+func validateMappingRule(rule MappingRule) error {
+	if rule.VaultPath == "" {
+		return errors.New("vaultPath is required")
+	}
+	if _, err := regexp.Compile(rule.Match); err != nil {
+		return fmt.Errorf("invalid match: %w", err)
+	}
+	if _, err := labels.Parse(rule.Selector); err != nil {
+		return fmt.Errorf("invalid selector: %w", err)
+	}
+	if rule.Expression != "" {
+		if _, err := CompileNamespaceExpression(rule.Expression); err != nil {
+			return fmt.Errorf("invalid expression: %w", err)
+		}
+	}
+	if _, err := ParseNamespaceTemplate(rule.VaultPath); err != nil {
+		return fmt.Errorf("invalid vaultPath: %w", err)
+	}
+	return nil
+}
+
+// validateIdentityGroup checks that an identity group's templates compile.
+func validateIdentityGroup(group IdentityGroup) error {
+	if group.Name == "" {
+		return errors.New("name is required")
+	}
+	if _, err := ParseNamespaceTemplate(group.Name); err != nil {
+		return fmt.Errorf("invalid name: %w", err)
+	}
+	if alias := group.ExternalAlias; alias != nil {
+		if alias.Mount == "" || alias.Name == "" {
+			return errors.New("externalAlias mount and name are required")
+		}
+		if _, err := ParseNamespaceTemplate(alias.Name); err != nil {
+			return fmt.Errorf("invalid externalAlias name: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateLifecycleHook(hook LifecycleHook) error {
+	if hook.Name == "" {
+		return errors.New("name is required")
+	}
+	if u, err := url.Parse(hook.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("url %q must be an https:// URL", hook.URL)
+	}
+	if len(hook.Events) == 0 {
+		return errors.New("events are required")
+	}
+	for _, event := range hook.Events {
+		if event != HookEventPostCreate && event != HookEventPreDelete {
+			return fmt.Errorf("event %q must be one of post-create, pre-delete", event)
+		}
+	}
+	switch hook.FailurePolicy {
+	case "", HookFailurePolicyFail, HookFailurePolicyIgnore:
+	default:
+		return fmt.Errorf("%w: failurePolicy %q must be one of Fail, Ignore", ErrInvalidPolicy, hook.FailurePolicy)
+	}
+	if hook.Attempts < 0 || hook.TimeoutSeconds < 0 {
+		return errors.New("attempts and timeoutSeconds must not be negative")
+	}
+	return nil
+}
+
+func validateAudit(audit AuditConfig) error {
+	if file := audit.File; file != nil {
+		if file.Path == "" {
+			return errors.New("audit.file.path is required")
+		}
+		if file.MaxSizeMB < 0 || file.Backups() < 0 {
+			return errors.New("audit.file.maxSizeMB and maxBackups must not be negative")
+		}
+	}
+	if webhook := audit.Webhook; webhook != nil {
+		if u, err := url.Parse(webhook.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("audit.webhook.url %q must be an https:// URL", webhook.URL)
+		}
+		if ref := webhook.SigningKeySecret; ref != nil && (ref.Namespace == "" || ref.Name == "") {
+			return errors.New("audit.webhook.signingKeySecret namespace and name are required")
+		}
+		if webhook.BufferSize < 0 || webhook.TimeoutSeconds < 0 {
+			return errors.New("audit.webhook.bufferSize and timeoutSeconds must not be negative")
+		}
+	}
+	return nil
+}
+
+func validateNotifier(notifier Notifier) error {
+	if notifier.Name == "" {
+		return errors.New("name is required")
+	}
+	if u, err := url.Parse(notifier.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("url %q must be an https:// URL", notifier.URL)
+	}
+	switch notifier.Format {
+	case "", NotificationFormatWebhook, NotificationFormatSlack:
+	default:
+		return fmt.Errorf("format %q must be one of webhook, slack", notifier.Format)
+	}
+	for _, event := range notifier.Events {
+		switch event {
+		case NotificationEventCreated, NotificationEventDeleted, NotificationEventDeletionBlocked, NotificationEventFailing:
+		default:
+			return fmt.Errorf("event %q must be one of created, deleted, deletion-blocked, failing", event)
+		}
+	}
+	if _, err := ParseNotificationMessage(notifier.Message); err != nil {
+		return fmt.Errorf("invalid message template: %w", err)
+	}
+	if notifier.TimeoutSeconds < 0 {
+		return errors.New("timeoutSeconds must not be negative")
+	}
+	return nil
+}
+
+func validateCloudEvents(cloudEvents CloudEventsConfig) error {
+	if !cloudEvents.Enabled() {
+		return nil
+	}
+	if u, err := url.Parse(cloudEvents.Sink); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("cloudEvents.sink %q must be an http:// or https:// URL", cloudEvents.Sink)
+	}
+	for _, event := range cloudEvents.Events {
+		switch event {
+		case CloudEventCreated, CloudEventDeleted, CloudEventDriftDetected:
+		default:
+			return fmt.Errorf("cloudEvents.events: event %q must be one of created, deleted, drift-detected", event)
+		}
+	}
+	if cloudEvents.TimeoutSeconds < 0 {
+		return errors.New("cloudEvents.timeoutSeconds must not be negative")
+	}
+	return nil
+}
+
+func validateSentinelPolicy(policy SentinelPolicy) error {
+	if policy.Name == "" || policy.Policy == "" {
+		return errors.New("name and policy are required")
+	}
+	switch policy.Type {
+	case SentinelPolicyEGP:
+		if len(policy.Paths) == 0 {
+			return errors.New("paths are required for an egp")
+		}
+	case SentinelPolicyRGP:
+		if len(policy.Paths) > 0 {
+			return errors.New("paths only apply to an egp")
+		}
+	default:
+		return fmt.Errorf("%w: type %q must be one of egp, rgp", ErrInvalidPolicy, policy.Type)
+	}
+	switch policy.EnforcementLevel {
+	case "", EnforcementAdvisory, EnforcementSoftMandatory, EnforcementHardMandatory:
+	default:
+		return fmt.Errorf("%w: enforcementLevel %q must be one of advisory, soft-mandatory, hard-mandatory",
+			ErrInvalidPolicy, policy.EnforcementLevel)
+	}
+	return nil
+}
+
+// MountPath returns the path the kubernetes auth method is mounted at.
+func (k KubernetesAuthBootstrapConfig) MountPath() string {
+	if k.Mount == "" {
+		return "kubernetes"
+	}
+	return strings.Trim(k.Mount, "/")
+}
+
+// RoleName returns the name of the role bound to the namespace's ServiceAccounts.
+func (k KubernetesAuthBootstrapConfig) RoleName() string {
+	if k.Role == "" {
+		return "default"
+	}
+	return k.Role
+}
+
+// BoundServiceAccounts returns the ServiceAccount names the role is bound to.
+func (k KubernetesAuthBootstrapConfig) BoundServiceAccounts() []string {
+	if len(k.ServiceAccounts) == 0 {
+		return []string{"*"}
+	}
+	return k.ServiceAccounts
+}
+
+// SigningKeyKey returns the Secret key holding the hook signing key.
+func (r SecretKeyRef) SigningKeyKey() string {
+	if r.Key == "" {
+		return "signing-key"
+	}
+	return r.Key
+}
+
+// KubeconfigKey returns the Secret key holding the kubeconfig.
+func (r SecretKeyRef) KubeconfigKey() string {
+	if r.Key == "" {
+		return "kubeconfig"
+	}
+	return r.Key
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v2"
@@ -17,11 +18,15 @@ func TestLoadConfig_Default(t *testing.T) {
 	assert.NotNil(t, config)
 
 	// Check default values
-	assert.Equal(t, 300, config.ReconcileInterval)
+	assert.Equal(t, Seconds(300), config.ReconcileInterval)
+	assert.Equal(t, Seconds(30), config.ReconcileTimeout)
+	assert.Equal(t, 4, config.SyncWorkers)
 	assert.True(t, config.DeleteVaultNamespaces)
 	assert.Equal(t, ":8080", config.MetricsBindAddress)
+	assert.Equal(t, ":8081", config.HealthProbeBindAddress)
 	assert.True(t, config.LeaderElection)
 	assert.Equal(t, "%s", config.NamespaceFormat)
+	assert.Equal(t, 64, config.MaxNamespaceNameLength)
 }
 
 func TestLoadConfig_FromFile(t *testing.T) {
@@ -37,6 +42,7 @@ func TestLoadConfig_FromFile(t *testing.T) {
 		},
 		ReconcileInterval:     60,
 		DeleteVaultNamespaces: false,
+		DeletionGracePeriod:   7200,
 		NamespaceFormat:       "env-%s",
 		IncludeNamespaces:     []string{"app-.*"},
 		ExcludeNamespaces:     []string{"system-.*"},
@@ -69,8 +75,9 @@ func TestLoadConfig_FromFile(t *testing.T) {
 	assert.Equal(t, "/admin", config.Vault.NamespaceRoot)
 	assert.Equal(t, "token", config.Vault.Auth.Type)
 	assert.Equal(t, "test-token", config.Vault.Auth.Token)
-	assert.Equal(t, 60, config.ReconcileInterval)
+	assert.Equal(t, Seconds(60), config.ReconcileInterval)
 	assert.Equal(t, false, config.DeleteVaultNamespaces)
+	assert.Equal(t, Seconds(7200), config.DeletionGracePeriod)
 	assert.Equal(t, "env-%s", config.NamespaceFormat)
 	assert.Equal(t, []string{"app-.*"}, config.IncludeNamespaces)
 	assert.Equal(t, []string{"system-.*"}, config.ExcludeNamespaces)
@@ -96,6 +103,106 @@ func TestLoadConfig_InvalidFile(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to parse config file")
 }
 
+// TestLoadConfig_ReconcileInterval tests that an explicit zero interval is kept
+// while an absent one takes the default.
+func TestLoadConfig_ReconcileInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected Seconds
+	}{
+		{
+			name:     "absent interval uses the default",
+			yaml:     "vault:\n  address: https://vault.example.org:8200\n  auth:\n    type: token\n    token: test-token\n",
+			expected: 300,
+		},
+		{
+			name:     "zero interval disables periodic reconciles",
+			yaml:     "reconcileInterval: 0\nvault:\n  address: https://vault.example.org:8200\n  auth:\n    type: token\n    token: test-token\n",
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := os.CreateTemp("", "config-*.yaml")
+			assert.NoError(t, err)
+			defer os.Remove(tempFile.Name())
+
+			_, err = tempFile.Write([]byte(tt.yaml))
+			assert.NoError(t, err)
+			assert.NoError(t, tempFile.Close())
+
+			config, err := LoadConfig(tempFile.Name())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, config.ReconcileInterval)
+		})
+	}
+}
+
+// TestLoadConfig_PartialConfig tests that settings absent from the file keep
+// their defaults, while those it sets take effect even when false or zero.
+func TestLoadConfig_PartialConfig(t *testing.T) {
+	const vault = "vault:\n  address: https://vault.example.org:8200\n  auth:\n    type: token\n    token: test-token\n"
+	tests := []struct {
+		name  string
+		yaml  string
+		check func(t *testing.T, config *ControllerConfig)
+	}{
+		{
+			name: "absent booleans keep their defaults",
+			yaml: vault + "dryRun: true\n",
+			check: func(t *testing.T, config *ControllerConfig) {
+				assert.True(t, config.DeleteVaultNamespaces)
+				assert.True(t, config.LeaderElection)
+				assert.True(t, config.DryRun)
+			},
+		},
+		{
+			name: "false booleans override their defaults",
+			yaml: vault + "deleteVaultNamespaces: false\nleaderElection: false\n",
+			check: func(t *testing.T, config *ControllerConfig) {
+				assert.False(t, config.DeleteVaultNamespaces)
+				assert.False(t, config.LeaderElection)
+			},
+		},
+		{
+			name: "partial nested settings keep the other defaults",
+			yaml: vault + "rateLimiter:\n  qps: 2.5\n",
+			check: func(t *testing.T, config *ControllerConfig) {
+				assert.Equal(t, RateLimiterConfig{BaseDelayMilliseconds: 5, MaxDelaySeconds: 1000, QPS: 2.5, Burst: 100}, config.RateLimiter)
+			},
+		},
+		{
+			name: "zero values override their defaults",
+			yaml: vault + "errorBackoffJitter: 0\nmaxNamespaceNameLength: 0\n",
+			check: func(t *testing.T, config *ControllerConfig) {
+				assert.Zero(t, config.ErrorBackoffJitter)
+				assert.Zero(t, config.MaxNamespaceNameLength)
+				assert.Equal(t, 4, config.SyncWorkers)
+				assert.Equal(t, "%s", config.NamespaceFormat)
+				assert.Equal(t, ":8080", config.MetricsBindAddress)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := os.CreateTemp("", "config-*.yaml")
+			assert.NoError(t, err)
+			defer os.Remove(tempFile.Name())
+
+			_, err = tempFile.Write([]byte(tt.yaml))
+			assert.NoError(t, err)
+			assert.NoError(t, tempFile.Close())
+
+			config, err := LoadConfig(tempFile.Name())
+			assert.NoError(t, err)
+			tt.check(t, config)
+		})
+	}
+}
+
 func TestValidateConfig(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -115,6 +222,90 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			name: "negative token TTL threshold",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+					TokenTTLThreshold: -1,
+				},
+			},
+			expectedErr: errors.New("vault.tokenTTLThreshold must not be negative"),
+		},
+		{
+			name: "audit webhook without https",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				Audit: AuditConfig{Webhook: &AuditWebhookConfig{URL: "http://siem.example.com/vault"}},
+			},
+			expectedErr: errors.New(`audit.webhook.url "http://siem.example.com/vault" must be an https:// URL`),
+		},
+		{
+			name: "audit file without path",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				Audit: AuditConfig{File: &AuditFileConfig{MaxSizeMB: 10}},
+			},
+			expectedErr: errors.New("audit.file.path is required"),
+		},
+		{
+			name: "cloudevents with unknown event",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				CloudEvents: CloudEventsConfig{Sink: "http://broker-ingress.knative-eventing.svc.cluster.local/default/default", Events: []string{"updated"}},
+			},
+			expectedErr: errors.New(`cloudEvents.events: event "updated" must be one of created, deleted, drift-detected`),
+		},
+		{
+			name: "notifier with unknown event",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				Notifications: NotificationsConfig{Notifiers: []Notifier{{Name: "onboarding", URL: "https://hooks.slack.com/services/T000", Events: []string{"updated"}}}},
+			},
+			expectedErr: errors.New(`invalid notifications.notifiers[0]: event "updated" must be one of created, deleted, deletion-blocked, failing`),
+		},
+		{
+			name: "notifier with invalid message",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				Notifications: NotificationsConfig{Notifiers: []Notifier{{Name: "onboarding", URL: "https://hooks.slack.com/services/T000", Message: "{{ .Namespace"}}},
+			},
+			expectedErr: errors.New(`invalid notifications.notifiers[0]: invalid message template: template: message:1: unclosed action`),
+		},
 		{
 			name: "valid kubernetes auth",
 			config: &ControllerConfig{
@@ -164,7 +355,7 @@ func TestValidateConfig(t *testing.T) {
 					},
 				},
 			},
-			expectedErr: errors.New("either token or tokenPath is required for token auth method"),
+			expectedErr: errors.New("either token, tokenPath or tokenSecretRef is required for token auth method"),
 		},
 		{
 			name: "kubernetes auth without role",
@@ -188,7 +379,519 @@ func TestValidateConfig(t *testing.T) {
 					},
 				},
 			},
-			expectedErr: errors.New("either roleId+secretId or roleIdPath+secretIdPath are required for approle auth method"),
+			expectedErr: errors.New("either roleId+secretId, roleIdPath+secretIdPath or Secret references are required for approle auth method"),
+		},
+		{
+			name: "approle auth with secret id secret without name",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:              "approle",
+						RoleID:            "role-id",
+						SecretIDSecretRef: &SecretKeyRef{Namespace: "vault-system"},
+					},
+				},
+			},
+			expectedErr: errors.New("vault.auth.secretIdSecretRef namespace and name are required"),
+		},
+		{
+			name: "invalid exclude pattern",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth:    VaultAuthConfig{Type: "token", Token: "test-token"},
+				},
+				IncludeNamespaces: []string{"^team-.*$"},
+				ExcludeNamespaces: []string{"kube-.*", "[a-"},
+			},
+			expectedErr: errors.New("invalid excludeNamespaces[1] \"[a-\": error parsing regexp: missing closing ]: `[a-`"),
+		},
+		{
+			name: "leader election renew deadline above the lease duration",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth:    VaultAuthConfig{Type: "token", Token: "test-token"},
+				},
+				LeaderElectionRenewDeadline: 20,
+			},
+			expectedErr: errors.New("leaderElectionLeaseDuration (15s) must be greater than leaderElectionRenewDeadline (20s)"),
+		},
+		{
+			name: "leader election retry period too close to the renew deadline",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth:    VaultAuthConfig{Type: "token", Token: "test-token"},
+				},
+				LeaderElectionLeaseDuration: 60,
+				LeaderElectionRenewDeadline: 30,
+				LeaderElectionRetryPeriod:   25,
+			},
+			expectedErr: errors.New("leaderElectionRenewDeadline (30s) must be greater than 1.2 times leaderElectionRetryPeriod (25s)"),
+		},
+		{
+			name: "malformed controller configmap",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				ControllerConfigMap: "vault-namespace-controller",
+			},
+			expectedErr: errors.New("must be in the form namespace/name"),
+		},
+		{
+			name: "cluster placeholder without cluster name",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				NamespaceFormat: "%{cluster}-%s",
+			},
+			expectedErr: errors.New("clusterName is not set"),
+		},
+		{
+			name: "invalid namespace template",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				NamespaceTemplate: "{{ .Labels.team }/{{ .Name }}",
+			},
+			expectedErr: errors.New("invalid namespaceTemplate"),
+		},
+		{
+			name: "duplicate policy template",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				PolicyTemplates: []PolicyTemplate{
+					{Name: "tenant-read", Policy: `path "secret/*" {}`},
+					{Name: "tenant-read", Policy: `path "kv/*" {}`},
+				},
+			},
+			expectedErr: errors.New("duplicate policy name"),
+		},
+		{
+			name: "identity group alias without mount",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				IdentityGroups: []IdentityGroup{{
+					Name:          "{{ .Name }}-admins",
+					ExternalAlias: &GroupAliasConfig{Name: "{{ .Name }}-admins"},
+				}},
+			},
+			expectedErr: errors.New("invalid identityGroups[0]: externalAlias mount and name are required"),
+		},
+		{
+			name: "audit device options without type",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				Bootstrap: BootstrapConfig{AuditDevice: AuditDeviceBootstrapConfig{
+					Options: map[string]string{"file_path": "/vault/audit/{{ .Name }}.log"},
+				}},
+			},
+			expectedErr: errors.New("bootstrap.auditDevice.type is required"),
+		},
+		{
+			name: "sentinel egp without paths",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				SentinelPolicies: []SentinelPolicy{{Name: "cidr-check", Type: "egp", Policy: "main = rule { true }"}},
+			},
+			expectedErr: errors.New("invalid sentinelPolicies[0]: paths are required for an egp"),
+		},
+		{
+			name: "sentinel policy with invalid enforcement level",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				SentinelPolicies: []SentinelPolicy{
+					{Name: "business-hours", Type: "rgp", Policy: "main = rule { true }", EnforcementLevel: "mandatory"},
+				},
+			},
+			expectedErr: errors.New(`invalid policy: enforcementLevel "mandatory" must be one of advisory, soft-mandatory, hard-mandatory`),
+		},
+		{
+			name: "custom metadata mirroring an ownership key",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				CustomMetadata: CustomMetadataConfig{
+					Labels:      []string{"team"},
+					Annotations: []string{"managed-by"},
+				},
+			},
+			expectedErr: errors.New(`customMetadata key "managed-by" is reserved for the ownership metadata`),
+		},
+		{
+			name: "lifecycle hook without https url",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				LifecycleHooks: LifecycleHooksConfig{Hooks: []LifecycleHook{
+					{Name: "cmdb", URL: "http://cmdb.example.com/hooks", Events: []string{HookEventPostCreate}},
+				}},
+			},
+			expectedErr: errors.New(`invalid lifecycleHooks.hooks[0]: url "http://cmdb.example.com/hooks" must be an https:// URL`),
+		},
+		{
+			name: "lifecycle hook with unknown event",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				LifecycleHooks: LifecycleHooksConfig{Hooks: []LifecycleHook{
+					{Name: "cmdb", URL: "https://cmdb.example.com/hooks", Events: []string{"post-delete"}},
+				}},
+			},
+			expectedErr: errors.New(`invalid lifecycleHooks.hooks[0]: event "post-delete" must be one of post-create, pre-delete`),
+		},
+		{
+			name: "mapping rule without vault path",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				MappingRules: []MappingRule{
+					{Match: "^prod-", VaultPath: "prod/{{ .Name }}"},
+					{Selector: "env=dev"},
+				},
+			},
+			expectedErr: errors.New("invalid mappingRules[1]: vaultPath is required"),
+		},
+		{
+			name: "mapping rule with invalid match",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				MappingRules: []MappingRule{
+					{Match: "^prod-(", VaultPath: "prod/{{ .Name }}"},
+				},
+			},
+			expectedErr: errors.New("invalid mappingRules[0]: invalid match"),
+		},
+		{
+			name: "invalid include expression",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				IncludeExpressions: []string{"labels.env"},
+			},
+			expectedErr: errors.New("invalid includeExpressions[0]"),
+		},
+		{
+			name: "migration deleteOld without previous format",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				Migration: MigrationConfig{DeleteOld: true},
+			},
+			expectedErr: errors.New("migration.deleteOld requires migration.previousFormat"),
+		},
+		{
+			name: "bootstrap kv mount without a path",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				Bootstrap: BootstrapConfig{KVMount: "/"},
+			},
+			expectedErr: errors.New("bootstrap.kvMount"),
+		},
+		{
+			name: "static mapping with empty path segment",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				StaticMappings: map[string]string{"legacy": "admin//legacy"},
+			},
+			expectedErr: errors.New("staticMappings[legacy]"),
+		},
+		{
+			name: "parent roots without label",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				ParentRoots: ParentRootsConfig{
+					Roots: map[string]string{"gold": "/tenants/gold"},
+				},
+			},
+			expectedErr: errors.New("parentRoots requires a label"),
+		},
+		{
+			name: "namespace name length too short for a hash suffix",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				MaxNamespaceNameLength: 8,
+			},
+			expectedErr: errors.New("maxNamespaceNameLength must be at least"),
+		},
+		{
+			name: "remote cluster named after the local cluster",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				ClusterName: "east",
+				RemoteClusters: []RemoteClusterConfig{{
+					Name:             "east",
+					KubeconfigSecret: SecretKeyRef{Namespace: "vault", Name: "east-kubeconfig"},
+				}},
+			},
+			expectedErr: errors.New("duplicate cluster name"),
+		},
+		{
+			name: "remote cluster without kubeconfig secret",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				RemoteClusters: []RemoteClusterConfig{{Name: "west"}},
+			},
+			expectedErr: errors.New("kubeconfigSecret namespace and name are required"),
+		},
+		{
+			name: "invalid namespace selector",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				NamespaceSelector: "team in (payments",
+			},
+			expectedErr: errors.New("invalid namespaceSelector"),
+		},
+		{
+			name: "secure metrics with token auth",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				MetricsServer: MetricsServerConfig{Secure: true, Auth: MetricsAuthToken},
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "metrics auth without TLS",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				MetricsServer: MetricsServerConfig{Auth: MetricsAuthToken},
+			},
+			expectedErr: errors.New("metricsServer.auth token requires metricsServer.secure"),
+		},
+		{
+			name: "metrics client cert auth without CA",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				MetricsServer: MetricsServerConfig{Secure: true, Auth: MetricsAuthClientCert},
+			},
+			expectedErr: errors.New("metricsServer.clientCAFile is required"),
+		},
+		{
+			name: "unsupported metrics auth",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				MetricsServer: MetricsServerConfig{Secure: true, Auth: "basic"},
+			},
+			expectedErr: errors.New("unsupported metricsServer.auth"),
+		},
+		{
+			name: "custom metrics buckets",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				MetricsBuckets: MetricsBucketsConfig{Vault: []float64{0.2, 0.4, 0.8}},
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "unordered metrics buckets",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				MetricsBuckets: MetricsBucketsConfig{Vault: []float64{0.4, 0.2}},
+			},
+			expectedErr: errors.New("metricsBuckets.vault must be in increasing order"),
+		},
+		{
+			name: "non-positive metrics bucket",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				MetricsBuckets: MetricsBucketsConfig{Reconcile: []float64{0, 1}},
+			},
+			expectedErr: errors.New("metricsBuckets.reconcile[0] must be positive"),
+		},
+		{
+			name: "negative namespace info max series",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				NamespaceInfoMetrics: NamespaceInfoMetricsConfig{Enabled: true, MaxSeries: -1},
+			},
+			expectedErr: errors.New("namespaceInfoMetrics.maxSeries must not be negative"),
+		},
+		{
+			name: "invalid webhook server port",
+			config: &ControllerConfig{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com:8200",
+					Auth: VaultAuthConfig{
+						Type:  "token",
+						Token: "test-token",
+					},
+				},
+				WebhookServer: WebhookServerConfig{Port: 70000},
+			},
+			expectedErr: errors.New("webhookServer.port 70000 is not a valid port"),
 		},
 		{
 			name: "unsupported auth method",
@@ -223,3 +926,14 @@ func TestValidateConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestLeaderElectionTimings(t *testing.T) {
+	config := &ControllerConfig{}
+	lease, renew, retry := config.LeaderElectionTimings()
+	assert.Equal(t, []time.Duration{15 * time.Second, 10 * time.Second, 2 * time.Second}, []time.Duration{lease, renew, retry})
+
+	config.LeaderElectionLeaseDuration = 60
+	config.LeaderElectionRetryPeriod = 5
+	lease, renew, retry = config.LeaderElectionTimings()
+	assert.Equal(t, []time.Duration{time.Minute, 10 * time.Second, 5 * time.Second}, []time.Duration{lease, renew, retry})
+}
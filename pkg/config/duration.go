@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Seconds is a duration in whole seconds. In the config file it is either an
+// integer number of seconds or a Go duration string such as 30s, 5m or 1h30m.
+type Seconds int
+
+// Duration returns s as a time.Duration.
+func (s Seconds) Duration() time.Duration {
+	return time.Duration(s) * time.Second
+}
+
+// UnmarshalYAML accepts an integer number of seconds or a duration string.
+func (s *Seconds) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	value, err := unmarshalDuration(unmarshal, time.Second)
+	*s = Seconds(value)
+	return err
+}
+
+// Milliseconds is a duration in whole milliseconds. In the config file it is
+// either an integer number of milliseconds or a Go duration string such as
+// 500ms or 2s.
+type Milliseconds int
+
+// Duration returns m as a time.Duration.
+func (m Milliseconds) Duration() time.Duration {
+	return time.Duration(m) * time.Millisecond
+}
+
+// UnmarshalYAML accepts an integer number of milliseconds or a duration string.
+func (m *Milliseconds) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	value, err := unmarshalDuration(unmarshal, time.Millisecond)
+	*m = Milliseconds(value)
+	return err
+}
+
+// unmarshalDuration decodes an integer number of units or a duration string
+// that is a whole number of units.
+func unmarshalDuration(unmarshal func(interface{}) error, unit time.Duration) (int, error) {
+	var number int
+	if err := unmarshal(&number); err == nil {
+		return number, nil
+	}
+	var text string
+	if err := unmarshal(&text); err != nil {
+		return 0, fmt.Errorf("expected a number or a duration such as 5m: %w", err)
+	}
+	return ParseDuration(text, unit)
+}
+
+// ParseDuration parses an integer number of units or a Go duration string,
+// returning the whole number of units.
+func ParseDuration(text string, unit time.Duration) (int, error) {
+	if number, err := strconv.Atoi(text); err == nil {
+		return number, nil
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q, expected a number or a duration such as 5m", text)
+	}
+	if duration%unit != 0 {
+		return 0, fmt.Errorf("invalid duration %q, must be a whole number of %s", text, unitName(unit))
+	}
+	return int(duration / unit), nil
+}
+
+func unitName(unit time.Duration) string {
+	if unit == time.Millisecond {
+		return "milliseconds"
+	}
+	return "seconds"
+}
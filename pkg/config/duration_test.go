@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoadConfig_Durations tests that interval settings accept duration
+// strings as well as integers.
+func TestLoadConfig_Durations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+vault:
+  address: https://vault.example.org:8200
+  auth:
+    type: token
+    token: test-token
+reconcileInterval: 5m
+deletionGracePeriod: 2h
+errorBackoffBase: 10
+errorBackoffMax: 10m
+orphanMinAge: 24h
+rateLimiter:
+  baseDelayMilliseconds: 250ms
+  maxDelaySeconds: 1m30s
+lifecycleHooks:
+  hooks:
+    - name: audit
+      url: https://hooks.example.org/audit
+      events: [post-create]
+      timeoutSeconds: 30s
+`), 0o600))
+	t.Setenv("VNC_DRIFT_SCAN_INTERVAL", "15m")
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, Seconds(300), config.ReconcileInterval)
+	assert.Equal(t, 5*time.Minute, config.ReconcileInterval.Duration())
+	assert.Equal(t, Seconds(7200), config.DeletionGracePeriod)
+	assert.Equal(t, Seconds(10), config.ErrorBackoffBase)
+	assert.Equal(t, Seconds(600), config.ErrorBackoffMax)
+	assert.Equal(t, Seconds(86400), config.OrphanMinAge)
+	assert.Equal(t, Seconds(900), config.DriftScanInterval)
+	assert.Equal(t, Milliseconds(250), config.RateLimiter.BaseDelayMilliseconds)
+	assert.Equal(t, Seconds(90), config.RateLimiter.MaxDelaySeconds)
+	assert.Equal(t, 30*time.Second, config.LifecycleHooks.Hooks[0].Timeout())
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		text     string
+		unit     time.Duration
+		expected int
+		err      string
+	}{
+		{text: "300", unit: time.Second, expected: 300},
+		{text: "1h30m", unit: time.Second, expected: 5400},
+		{text: "-1m", unit: time.Second, expected: -60},
+		{text: "1.5s", unit: time.Millisecond, expected: 1500},
+		{text: "1500ms", unit: time.Second, err: "must be a whole number of seconds"},
+		{text: "5 minutes", unit: time.Second, err: "expected a number or a duration such as 5m"},
+	}
+	for _, tt := range tests {
+		value, err := ParseDuration(tt.text, tt.unit)
+		if tt.err != "" {
+			assert.ErrorContains(t, err, tt.err, tt.text)
+			continue
+		}
+		assert.NoError(t, err, tt.text)
+		assert.Equal(t, tt.expected, value, tt.text)
+	}
+}
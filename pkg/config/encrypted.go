@@ -0,0 +1,289 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v2"
+)
+
+// The environment variables holding the age identities that decrypt an
+// encrypted config file, either inline or in a file. The SOPS variables are
+// read when these are not set.
+const (
+	AgeKeyEnv     = EnvPrefix + "_AGE_KEY"
+	AgeKeyFileEnv = EnvPrefix + "_AGE_KEY_FILE"
+)
+
+// ErrNoAgeKey is returned for an encrypted config file when no age identity
+// is set.
+var ErrNoAgeKey = fmt.Errorf("config file is encrypted but neither %s nor %s is set", AgeKeyEnv, AgeKeyFileEnv)
+
+// sopsMetadata is the part of the sops key of a SOPS encrypted file needed
+// to decrypt it with age.
+type sopsMetadata struct {
+	Age []struct {
+		Recipient string `yaml:"recipient"`
+		Enc       string `yaml:"enc"`
+	} `yaml:"age"`
+	KeyGroups        []interface{} `yaml:"key_groups"`
+	LastModified     string        `yaml:"lastmodified"`
+	MAC              string        `yaml:"mac"`
+	MACOnlyEncrypted bool          `yaml:"mac_only_encrypted"`
+}
+
+// sopsValue matches a value encrypted by SOPS.
+var sopsValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// decryptConfig returns the plaintext of a config file encrypted with age or
+// with SOPS using age keys, or data itself when it is not encrypted.
+func decryptConfig(data []byte, lookup func(string) (string, bool)) ([]byte, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte(armor.Header)) || bytes.HasPrefix(data, []byte("age-encryption.org/")) {
+		identities, err := ageIdentities(lookup)
+		if err != nil {
+			return nil, err
+		}
+		return decryptAge(data, identities)
+	}
+
+	var file struct {
+		Sops *sopsMetadata `yaml:"sops"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil || file.Sops == nil {
+		// Not encrypted, or left for decoding the file to report
+		return data, nil
+	}
+	identities, err := ageIdentities(lookup)
+	if err != nil {
+		return nil, err
+	}
+	return decryptSops(data, file.Sops, identities)
+}
+
+// ageIdentities reads the age identities from the environment.
+func ageIdentities(lookup func(string) (string, bool)) ([]age.Identity, error) {
+	for _, env := range [][2]string{{AgeKeyEnv, AgeKeyFileEnv}, {"SOPS_AGE_KEY", "SOPS_AGE_KEY_FILE"}} {
+		if key, ok := lookup(env[0]); ok && key != "" {
+			identities, err := age.ParseIdentities(strings.NewReader(key))
+			if err != nil {
+				return nil, fmt.Errorf("invalid age key in %s: %w", env[0], err)
+			}
+			return identities, nil
+		}
+		if path, ok := lookup(env[1]); ok && path != "" {
+			keyFile, err := os.Open(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read age key file %q: %w", path, err)
+			}
+			defer keyFile.Close()
+			identities, err := age.ParseIdentities(keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("invalid age key file %q: %w", path, err)
+			}
+			return identities, nil
+		}
+	}
+	return nil, ErrNoAgeKey
+}
+
+// decryptAge decrypts an age file, armored or not.
+func decryptAge(data []byte, identities []age.Identity) ([]byte, error) {
+	var src io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(armor.Header)) {
+		src = armor.NewReader(bytes.NewReader(bytes.TrimSpace(data)))
+	}
+	r, err := age.Decrypt(src, identities...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt config file with age: %w", err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt config file with age: %w", err)
+	}
+	return plaintext, nil
+}
+
+// decryptSops decrypts the values of a SOPS file whose data key is encrypted
+// with age, and checks its MAC. Other SOPS key types are not supported.
+func decryptSops(data []byte, metadata *sopsMetadata, identities []age.Identity) ([]byte, error) {
+	if len(metadata.KeyGroups) > 0 {
+		return nil, errors.New("SOPS key groups are not supported, encrypt the config file with age recipients only")
+	}
+	if len(metadata.Age) == 0 {
+		return nil, errors.New("config file is encrypted with SOPS without an age recipient, only age keys are supported")
+	}
+	var dataKey []byte
+	for _, recipient := range metadata.Age {
+		key, err := decryptAge([]byte(recipient.Enc), identities)
+		if err == nil {
+			dataKey = key
+			break
+		}
+	}
+	if dataKey == nil {
+		return nil, errors.New("failed to decrypt SOPS data key: no age key matches the file's recipients")
+	}
+
+	var file yaml.MapSlice
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	mac := sha512.New()
+	if metadata.MACOnlyEncrypted {
+		// SOPS starts such MACs with the SHA-256 of "sops"
+		initialization := sha256.Sum256([]byte("sops"))
+		mac.Write(initialization[:])
+	}
+	plaintext := yaml.MapSlice{}
+	for _, item := range file {
+		if item.Key == "sops" {
+			continue
+		}
+		key, ok := item.Key.(string)
+		if !ok {
+			return nil, fmt.Errorf("SOPS file has a non-string key %v", item.Key)
+		}
+		value, err := decryptSopsValue(item.Value, []string{key}, dataKey, metadata.MACOnlyEncrypted, mac)
+		if err != nil {
+			return nil, err
+		}
+		plaintext = append(plaintext, yaml.MapItem{Key: key, Value: value})
+	}
+
+	lastModified, err := time.Parse(time.RFC3339, metadata.LastModified)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOPS lastmodified %q: %w", metadata.LastModified, err)
+	}
+	expected, err := decryptSopsString(metadata.MAC, dataKey, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt SOPS MAC: %w", err)
+	}
+	if expected != fmt.Sprintf("%X", mac.Sum(nil)) {
+		return nil, errors.New("SOPS MAC mismatch, the config file was modified after it was encrypted")
+	}
+	return yaml.Marshal(plaintext)
+}
+
+// decryptSopsValue decrypts the encrypted values in value, at path, and adds
+// them to the MAC like SOPS does. List items share the path of their list.
+func decryptSopsValue(value interface{}, path []string, dataKey []byte, macOnlyEncrypted bool, mac io.Writer) (interface{}, error) {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		decrypted := yaml.MapSlice{}
+		for _, item := range v {
+			key, ok := item.Key.(string)
+			if !ok {
+				return nil, fmt.Errorf("SOPS file has a non-string key %v at %s", item.Key, strings.Join(path, "."))
+			}
+			itemValue, err := decryptSopsValue(item.Value, append(path[:len(path):len(path)], key), dataKey, macOnlyEncrypted, mac)
+			if err != nil {
+				return nil, err
+			}
+			decrypted = append(decrypted, yaml.MapItem{Key: key, Value: itemValue})
+		}
+		return decrypted, nil
+	case []interface{}:
+		decrypted := make([]interface{}, len(v))
+		for i, item := range v {
+			itemValue, err := decryptSopsValue(item, path, dataKey, macOnlyEncrypted, mac)
+			if err != nil {
+				return nil, err
+			}
+			decrypted[i] = itemValue
+		}
+		return decrypted, nil
+	case nil:
+		return nil, nil
+	}
+
+	encrypted := false
+	if text, ok := value.(string); ok && sopsValue.MatchString(text) {
+		encrypted = true
+		decrypted, err := decryptSopsTyped(text, dataKey, strings.Join(path, ":")+":")
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", strings.Join(path, "."), err)
+		}
+		value = decrypted
+	}
+	if !macOnlyEncrypted || encrypted {
+		switch v := value.(type) {
+		case bool:
+			// As formatted by SOPS, which is written in Python originally
+			if v {
+				io.WriteString(mac, "True")
+			} else {
+				io.WriteString(mac, "False")
+			}
+		case float64:
+			io.WriteString(mac, strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			fmt.Fprint(mac, v)
+		}
+	}
+	return value, nil
+}
+
+// decryptSopsTyped decrypts a SOPS value and converts it to its type.
+func decryptSopsTyped(text string, dataKey []byte, additionalData string) (interface{}, error) {
+	plaintext, err := decryptSopsString(text, dataKey, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	switch datatype := sopsValue.FindStringSubmatch(text)[4]; datatype {
+	case "str", "bytes":
+		return plaintext, nil
+	case "int":
+		return strconv.Atoi(plaintext)
+	case "float":
+		return strconv.ParseFloat(plaintext, 64)
+	case "bool":
+		return strconv.ParseBool(plaintext)
+	default:
+		return nil, fmt.Errorf("unknown SOPS data type %q", datatype)
+	}
+}
+
+// decryptSopsString decrypts a SOPS value with AES-GCM.
+func decryptSopsString(text string, dataKey []byte, additionalData string) (string, error) {
+	match := sopsValue.FindStringSubmatch(text)
+	if match == nil {
+		return "", errors.New("not a SOPS encrypted value")
+	}
+	var parts [3][]byte
+	for i := range parts {
+		decoded, err := base64.StdEncoding.DecodeString(match[i+1])
+		if err != nil {
+			return "", fmt.Errorf("invalid SOPS encrypted value: %w", err)
+		}
+		parts[i] = decoded
+	}
+	ciphertext, iv, tag := parts[0], parts[1], parts[2]
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := gcm.Open(nil, iv, append(ciphertext, tag...), []byte(additionalData))
+	if err != nil {
+		return "", errors.New("authentication failed, the value or its path was modified")
+	}
+	return string(plaintext), nil
+}
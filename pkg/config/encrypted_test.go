@@ -0,0 +1,157 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testAgeKey decrypts the encrypted configs below, which were created with
+// sops and age from the same plaintext config.
+const testAgeKey = "AGE-SECRET-KEY-1KVXMP8AAWJAJUPUNWVYJLWJUUQ3NRFDRMTKLQRVPF2L33L6S2W3QSTQTJV"
+
+const sopsEncryptedConfig = `vault:
+    address: ENC[AES256_GCM,data:kngYiupXVU+XHmMDa97d8ik5/PRdn0kUg+bvTvHn,iv:JhZ2EcOcVj4ANzsezFhEQW7Ydj3zwF36gpulqUvFKBg=,tag:Aj/OivGw2Ub9EtryxOlrEg==,type:str]
+    auth:
+        type: ENC[AES256_GCM,data:kgbAozBPyw==,iv:bKiN6mLdkbuFz9oUfTkg38UhcVTQ1jWL1uqDFkgj6LU=,tag:nVhAVTDfRSleJS1AGXF4sA==,type:str]
+        roleId: ENC[AES256_GCM,data:kRH1GRGOJw==,iv:A9PR/ZiGw33ezs/DbuHEKC9zKyaErn3JbY86XV/9iNw=,tag:l2MRae2HjWjHHQA6FYqH2w==,type:str]
+        secretId: ENC[AES256_GCM,data:UBTvK9nEDlyL,iv:BKsUnjLnRKe6DoQY7V3c6Q9e/rNLBLhjoRzcDq+1cAA=,tag:C7tyHLgtB4doV8GrXGYNmA==,type:str]
+reconcileInterval: ENC[AES256_GCM,data:qhA=,iv:XhpK6RpWOqHUIoyDtjzlhfNK3b5vrCEoAF4hch1Efj4=,tag:jmO7fLPcOiPzX5e8neAYDg==,type:int]
+deleteVaultNamespaces: ENC[AES256_GCM,data:PwR20Dg=,iv:SayCzPquTcAIgNyW+CYgZg/DBwBGCqteQ09rCpwX30A=,tag:joRAw6733daNy+U2QU/xKg==,type:bool]
+metricsBuckets:
+    vault:
+        - ENC[AES256_GCM,data:zK8E,iv:WxKrMe2WsAcagdfng+QdBXIuz4QFhL9mh6tWUkEjirQ=,tag:Ly2Sra3BLimeFwxEQI3DQA==,type:float]
+        - ENC[AES256_GCM,data:iE42,iv:HCAQNuOhb+tkY0QKi1duHkccIP5zs6mh2ADmaAgSAMs=,tag:NXpj3EN/LZZnFJf4ZwpKXA==,type:float]
+includeNamespaces:
+    - ENC[AES256_GCM,data:NljR1M0pfg==,iv:N8NgKdJNfykJ6Q+434xkCXPGWbgQG0ICb83WzVZWB68=,tag:qx4qVZYeBxSzUp1ZfY9obw==,type:str]
+sops:
+    kms: []
+    gcp_kms: []
+    azure_kv: []
+    hc_vault: []
+    age:
+        - recipient: age17c9xls7z3svpn4mjhdpesnncyqcqdumvweg6ytfc766xc3k9z92sak7xju
+          enc: |
+            -----BEGIN AGE ENCRYPTED FILE-----
+            YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSAzdlhpK3dUajBEekE1MHNp
+            L0I1WmVCRXRiRmw3OFR4MXREdmVwemNtUGdVClg5eHU5Uk94NGNCWHlwSGlpd2FW
+            aFhySXJaUFVuaTlTSVdVb0pwdHU5VEUKLS0tIGlaa1N0ZUp4bnJ2NUNTeXY1UDJF
+            bzk1eG91VHJnTVB0dlVzTWc1ZEhBM0kKfnExc7HLM6QwSu5KxXbFa4TowelC8KAe
+            xZfdZa6/t3t4DxuVgiAWnscsTNli2ux38yFityShA83qw2OdLRUFSQ==
+            -----END AGE ENCRYPTED FILE-----
+    lastmodified: "2026-10-18T02:34:05Z"
+    mac: ENC[AES256_GCM,data:JP8GwxeulKBUkxbOkKFhBYtXqz1P3LiNGQq1UoqZu8opfYLGL7KdMdZULrR+fdSGs3u3skJ6Tgaf7kptqcXdJ9AU5oKmOPV70J2baovaN0tyjqyqguXxp2o1PKXK8/WWL+LAgDj0r+aGfFts4pHNKvufrx5YeBzu2tPP9E17nHo=,iv:I/hJQUQAjxf7JVVkQoaBqGhvLjMdRj60Uid4DIQspUo=,tag:t1egbQ3ziJod5fSFApc7iw==,type:str]
+    pgp: []
+    unencrypted_suffix: _unencrypted
+    version: 3.9.4
+`
+
+// Only the AppRole credentials are encrypted, with --encrypted-regex.
+const sopsPartiallyEncryptedConfig = `vault:
+    address: https://vault.example.com:8200
+    auth:
+        type: approle
+        roleId: ENC[AES256_GCM,data:HurQGR84Pg==,iv:kFnAs0neF+DrFB0xyh0g5wWMah3RG053AgV+Uzv2X+U=,tag:yUj4XXmugDDNr9T1U0kqPQ==,type:str]
+        secretId: ENC[AES256_GCM,data:zejf13vDEVi0,iv:P4X9CBjpgQaoxO+8Wx+HsPqNv+/Rpu6SNlmZDUbesSY=,tag:Mw/cXl1FP5wynplqMSrT/g==,type:str]
+reconcileInterval: 60
+deleteVaultNamespaces: false
+metricsBuckets:
+    vault:
+        - 0.2
+        - 0.4
+includeNamespaces:
+    - team-.*
+sops:
+    kms: []
+    gcp_kms: []
+    azure_kv: []
+    hc_vault: []
+    age:
+        - recipient: age17c9xls7z3svpn4mjhdpesnncyqcqdumvweg6ytfc766xc3k9z92sak7xju
+          enc: |
+            -----BEGIN AGE ENCRYPTED FILE-----
+            YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSA2RGdsL0M1UGNZZ3dLczZF
+            T2s1QVdsMy9lSVJ2bEZVUFpuSk5CaEFFZFVBCm9iYXY4cWN5SUdwOXQvVDlPRWV6
+            K1d0UUQyc2tCUFpNQjA4M1poaWRYck0KLS0tIGFkd0l4ZVRPcEV0VjZGT0V5SkQ2
+            Z01HUzhjV3pCRVdLZkdUSGlzVFlxRzgKMosfS19tzvAAfow3JpwJqg24NxlcizRm
+            Z0J+7HvOuYSP54v6wuBctNdeq8wBPeQAjBZbFeMUkNobJLq99JzT4Q==
+            -----END AGE ENCRYPTED FILE-----
+    lastmodified: "2026-10-18T02:34:20Z"
+    mac: ENC[AES256_GCM,data:HqEF2WYOBq9rnPdU/yn+MehnLt5eMLnITrUKgZPc5bq6MKKBiXrXKaIpSGcz2xjZ7YTKiekAgR1udnXawt1tMcDHalYejFHu23JaIDkaKRq7RpY8Clpebdld+Un95jwRM8Rwwwj2yQ7olgq7mR7KHRwwKCFM+ittqtQoiOk0Sdc=,iv:D73J4M0ubKLdTlu6qJbxr+T7lbPEM2d+RiY7QQ1p2UM=,tag:CbYU/COQZMWuNR+RTaP7UQ==,type:str]
+    pgp: []
+    encrypted_regex: ^(roleId|secretId)$
+    version: 3.9.4
+`
+
+const ageEncryptedConfig = `-----BEGIN AGE ENCRYPTED FILE-----
+YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSBSeEc0dmV1OERRRUdnb2ww
+L2k5Uk1oWmcyd0xwM0JRbVAyNXMxN1E2SVRBClNqYnNFc2hsYXUrdUV1VWFhZkp6
+MTlnMW1wNWlZejJrMW9Za1RVUVJwelEKLS0tIDZEUFpjVjIrd2N0VGJlV1hEMHQ1
+NURpNUlXYXk4aEJkU3dWZkxTMFBDZ1EKqZjEfPGJqDhCtghfwHUGVkgpwhZu0L86
+bVZ3TzqavTGMNl9NOdP2buHcY33ysna7mhMBW13zzHTeZPO2x903BkP/8LJVcBBP
+a/iyGMMw/b2P0HHoqmB3YBzNNNUBdY9SjkzdDu8FgnvaXg5hEwaSKtfXPu/N6SZ0
+UykBsbkx2VeNTqLuWC10roFRmP7JvW82y+ReWgQh5piEO+JA98CF3sbIyYV4x0oV
+JuG5zJTxk3OV0tYalLmHg9UE+DvKGGF41m93tOPlT9Ak5/347pv6FtSsxXwQ/x7K
+oojyRvboSRsHxk5Qd6HP42bnXOmc9LyfBUfP5eqb/eB11/yfV1UkhEy3dWoaDm+1
+zeZiV+E=
+-----END AGE ENCRYPTED FILE-----
+`
+
+// TestLoadConfig_Encrypted tests loading config files encrypted with SOPS or age.
+func TestLoadConfig_Encrypted(t *testing.T) {
+	t.Setenv(AgeKeyEnv, testAgeKey)
+	for name, data := range map[string]string{
+		"sops":           sopsEncryptedConfig,
+		"sops partially": sopsPartiallyEncryptedConfig,
+		"age":            ageEncryptedConfig,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			assert.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+			config, err := LoadConfig(path)
+			assert.NoError(t, err)
+			assert.Equal(t, "https://vault.example.com:8200", config.Vault.Address)
+			assert.Equal(t, "role-id", config.Vault.Auth.RoleID)
+			assert.Equal(t, "secret-id", config.Vault.Auth.SecretID)
+			assert.Equal(t, Seconds(60), config.ReconcileInterval)
+			assert.False(t, config.DeleteVaultNamespaces)
+			assert.Equal(t, []float64{0.2, 0.4}, config.MetricsBuckets.Vault)
+			assert.Equal(t, []string{"team-.*"}, config.IncludeNamespaces)
+		})
+	}
+}
+
+// TestDecryptConfig_Errors tests that encrypted files are not loaded without
+// the key or after they were modified.
+func TestDecryptConfig_Errors(t *testing.T) {
+	noKey := func(string) (string, bool) { return "", false }
+	_, err := decryptConfig([]byte(sopsEncryptedConfig), noKey)
+	assert.ErrorIs(t, err, ErrNoAgeKey)
+	_, err = decryptConfig([]byte(ageEncryptedConfig), noKey)
+	assert.ErrorIs(t, err, ErrNoAgeKey)
+
+	// The SOPS variables are used as well
+	sopsKey := func(name string) (string, bool) { return testAgeKey, name == "SOPS_AGE_KEY" }
+	_, err = decryptConfig([]byte(sopsEncryptedConfig), sopsKey)
+	assert.NoError(t, err)
+
+	otherKey := func(name string) (string, bool) {
+		return "AGE-SECRET-KEY-1GFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPQ4EGAEX", name == AgeKeyEnv
+	}
+	_, err = decryptConfig([]byte(sopsEncryptedConfig), otherKey)
+	assert.ErrorContains(t, err, "no age key matches")
+
+	tampered := strings.Replace(sopsPartiallyEncryptedConfig, "reconcileInterval: 60", "reconcileInterval: 61", 1)
+	_, err = decryptConfig([]byte(tampered), sopsKey)
+	assert.ErrorContains(t, err, "SOPS MAC mismatch")
+
+	// Plain files are left as they are
+	plain := []byte("vault:\n  address: https://vault.example.com:8200\n")
+	data, err := decryptConfig(plain, noKey)
+	assert.NoError(t, err)
+	assert.Equal(t, plain, data)
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix prefixes the environment variables overriding configuration
+// fields. The rest of the name is the field's YAML path in upper snake case,
+// e.g. VNC_VAULT_ADDRESS for vault.address and VNC_DELETE_VAULT_NAMESPACES
+// for deleteVaultNamespaces.
+const EnvPrefix = "VNC"
+
+// applyEnvOverrides sets the fields of config named by environment variables,
+// taking precedence over the config file. String fields take the value as is,
+// string lists a comma separated list, and all other fields a YAML value. It
+// reports whether any field was overridden.
+func applyEnvOverrides(config *ControllerConfig, lookup func(string) (string, bool)) (bool, error) {
+	return applyEnvToStruct(reflect.ValueOf(config).Elem(), EnvPrefix, lookup)
+}
+
+func applyEnvToStruct(v reflect.Value, prefix string, lookup func(string) (string, bool)) (bool, error) {
+	applied := false
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" && options == "inline" {
+			ok, err := applyEnvToStruct(v.Field(i), prefix, lookup)
+			if err != nil {
+				return false, err
+			}
+			applied = applied || ok
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		ok, err := applyEnvToField(v.Field(i), prefix+"_"+envName(name), lookup)
+		if err != nil {
+			return false, err
+		}
+		applied = applied || ok
+	}
+	return applied, nil
+}
+
+func applyEnvToField(v reflect.Value, name string, lookup func(string) (string, bool)) (bool, error) {
+	applied := false
+	if value, ok := lookup(name); ok {
+		if err := setFromEnv(v, value); err != nil {
+			return false, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		applied = true
+	}
+
+	// The fields of nested structs are overridden individually as well
+	switch {
+	case v.Kind() == reflect.Struct:
+		ok, err := applyEnvToStruct(v, name, lookup)
+		if err != nil {
+			return false, err
+		}
+		applied = applied || ok
+	case v.Kind() == reflect.Pointer && v.Type().Elem().Kind() == reflect.Struct:
+		elem := reflect.New(v.Type().Elem())
+		if !v.IsNil() {
+			elem.Elem().Set(v.Elem())
+		}
+		ok, err := applyEnvToStruct(elem.Elem(), name, lookup)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			v.Set(elem)
+			applied = true
+		}
+	}
+	return applied, nil
+}
+
+func setFromEnv(v reflect.Value, value string) error {
+	switch {
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+		return nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items).Convert(v.Type()))
+		return nil
+	}
+	out := reflect.New(v.Type())
+	if err := yaml.UnmarshalStrict([]byte(value), out.Interface()); err != nil {
+		return err
+	}
+	v.Set(out.Elem())
+	return nil
+}
+
+// envName converts a camelCase YAML key to upper snake case, keeping
+// acronyms together: caCertPEM becomes CA_CERT_PEM.
+func envName(key string) string {
+	runes := []rune(key)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoadConfig_EnvOverrides tests that VNC_ environment variables take
+// precedence over the config file.
+func TestLoadConfig_EnvOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+vault:
+  address: https://vault.example.org:8200
+  auth:
+    type: token
+    token: test-token
+deleteVaultNamespaces: true
+includeNamespaces: ["app-.*"]
+reconcileInterval: 60
+`), 0o600))
+
+	t.Setenv("VNC_VAULT_ADDRESS", "https://vault.internal:8200")
+	t.Setenv("VNC_DELETE_VAULT_NAMESPACES", "false")
+	t.Setenv("VNC_INCLUDE_NAMESPACES", "team-.*, payments")
+	t.Setenv("VNC_RECONCILE_INTERVAL", "0")
+	t.Setenv("VNC_RATE_LIMITER_QPS", "2.5")
+	t.Setenv("VNC_BOOTSTRAP_KUBERNETES_AUTH_CA_CERT_PEM", "pem")
+	t.Setenv("VNC_LIFECYCLE_HOOKS_SIGNING_KEY_SECRET_NAME", "hooks")
+	t.Setenv("VNC_LIFECYCLE_HOOKS_SIGNING_KEY_SECRET_NAMESPACE", "vault-namespace-controller")
+	t.Setenv("VNC_VSO_NAME", "vault-connection")
+	t.Setenv("VNC_STATIC_MAPPINGS", "{legacy: admin/legacy}")
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://vault.internal:8200", config.Vault.Address)
+	assert.Equal(t, "test-token", config.Vault.Auth.Token)
+	assert.False(t, config.DeleteVaultNamespaces)
+	assert.Equal(t, []string{"team-.*", "payments"}, config.IncludeNamespaces)
+	assert.Zero(t, config.ReconcileInterval)
+	assert.Equal(t, 2.5, config.RateLimiter.QPS)
+	assert.Equal(t, "pem", config.Bootstrap.KubernetesAuth.CACertPEM)
+	assert.Equal(t, &SecretKeyRef{Namespace: "vault-namespace-controller", Name: "hooks"}, config.LifecycleHooks.SigningKeySecret)
+	assert.Equal(t, "vault-connection", config.VSO.ResourceName())
+	assert.Equal(t, map[string]string{"legacy": "admin/legacy"}, config.StaticMappings)
+
+	t.Setenv("VNC_SYNC_WORKERS", "many")
+	_, err = LoadConfig(path)
+	assert.ErrorContains(t, err, "invalid VNC_SYNC_WORKERS")
+}
+
+// TestLoadConfig_EnvOnly tests configuring the controller without a config file.
+func TestLoadConfig_EnvOnly(t *testing.T) {
+	t.Setenv("VNC_VAULT_ADDRESS", "https://vault.internal:8200")
+	_, err := LoadConfig("")
+	assert.ErrorIs(t, err, ErrMissingAuthType)
+
+	t.Setenv("VNC_VAULT_AUTH_TYPE", "token")
+	t.Setenv("VNC_VAULT_AUTH_TOKEN", "test-token")
+	config, err := LoadConfig("")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://vault.internal:8200", config.Vault.Address)
+	assert.Equal(t, Seconds(300), config.ReconcileInterval)
+}
+
+func TestEnvName(t *testing.T) {
+	for key, expected := range map[string]string{
+		"address":               "ADDRESS",
+		"deleteVaultNamespaces": "DELETE_VAULT_NAMESPACES",
+		"qps":                   "QPS",
+		"caCertPEM":             "CA_CERT_PEM",
+		"wrapTTL":               "WRAP_TTL",
+		"kubeconfigSecret":      "KUBECONFIG_SECRET",
+		"s3Bucket":              "S3_BUCKET",
+	} {
+		assert.Equal(t, expected, envName(key), key)
+	}
+}
+
+// TestLoadConfig_Interpolation tests expanding environment variable references
+// in config values.
+func TestLoadConfig_Interpolation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+vault:
+  address: https://${VAULT_HOST}:8200
+  auth:
+    type: token
+    token: ${VAULT_TOKEN}
+namespaceFormat: ${NAMESPACE_PREFIX:-k8s}-%s
+includeNamespaces: ["^team-.*$"]
+staticMappings:
+  legacy: $${literal}
+`), 0o600))
+	t.Setenv("VAULT_HOST", "vault.internal")
+	t.Setenv("VAULT_TOKEN", "hvs.secret")
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://vault.internal:8200", config.Vault.Address)
+	assert.Equal(t, "hvs.secret", config.Vault.Auth.Token)
+	assert.Equal(t, "k8s-%s", config.NamespaceFormat)
+	assert.Equal(t, []string{"^team-.*$"}, config.IncludeNamespaces)
+	assert.Equal(t, "${literal}", config.StaticMappings["legacy"])
+
+	t.Setenv("NAMESPACE_PREFIX", "")
+	config, err = LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "k8s-%s", config.NamespaceFormat)
+
+	assert.NoError(t, os.Unsetenv("VAULT_TOKEN"))
+	_, err = LoadConfig(path)
+	assert.ErrorContains(t, err, "vault.auth.token: environment variable VAULT_TOKEN is not set")
+}
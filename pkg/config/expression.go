@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+)
+
+// NamespaceExpression is a compiled CEL expression over a namespace, evaluated
+// with the variables name, labels and annotations.
+type NamespaceExpression struct {
+	program cel.Program
+}
+
+// CompileNamespaceExpression compiles a CEL expression, such as
+// "labels.env == 'prod' && !name.startsWith('tmp-')", which must evaluate to a bool.
+func CompileNamespaceExpression(expression string) (*NamespaceExpression, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("name", cel.StringType),
+		cel.Variable("labels", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("annotations", cel.MapType(cel.StringType, cel.StringType)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("expression must evaluate to a bool, not %s", ast.OutputType())
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	return &NamespaceExpression{program: program}, nil
+}
+
+// Matches evaluates the expression for a namespace. Evaluation fails when the
+// expression indexes a label or annotation the namespace does not have; use
+// has(labels.env) or labels.?env to allow for missing keys.
+func (e *NamespaceExpression) Matches(name string, labels, annotations map[string]string) (bool, error) {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	out, _, err := e.program.Eval(map[string]interface{}{
+		"name":        name,
+		"labels":      labels,
+		"annotations": annotations,
+	})
+	if err != nil {
+		return false, err
+	}
+	matched, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression evaluated to %v, not a bool", out.Value())
+	}
+	return matched, nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaceExpression_Matches(t *testing.T) {
+	tests := []struct {
+		name        string
+		expression  string
+		namespace   string
+		labels      map[string]string
+		annotations map[string]string
+		expected    bool
+		expectedErr bool
+	}{
+		{
+			name:       "label and name",
+			expression: "labels.env == 'prod' && !name.startsWith('tmp-')",
+			namespace:  "payments",
+			labels:     map[string]string{"env": "prod"},
+			expected:   true,
+		},
+		{
+			name:       "name excluded",
+			expression: "labels.env == 'prod' && !name.startsWith('tmp-')",
+			namespace:  "tmp-payments",
+			labels:     map[string]string{"env": "prod"},
+			expected:   false,
+		},
+		{
+			name:        "annotation",
+			expression:  "annotations['example.com/tenant'] == 'acme'",
+			namespace:   "payments",
+			annotations: map[string]string{"example.com/tenant": "acme"},
+			expected:    true,
+		},
+		{
+			name:       "has guards a missing label",
+			expression: "has(labels.env) && labels.env == 'prod'",
+			namespace:  "payments",
+			expected:   false,
+		},
+		{
+			name:        "missing label",
+			expression:  "labels.env == 'prod'",
+			namespace:   "payments",
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expression, err := CompileNamespaceExpression(tt.expression)
+			assert.NoError(t, err)
+
+			matched, err := expression.Matches(tt.namespace, tt.labels, tt.annotations)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, matched)
+		})
+	}
+}
+
+func TestCompileNamespaceExpression_Invalid(t *testing.T) {
+	for _, expression := range []string{
+		"labels.env ==",
+		"name + 'suffix'",
+		"namespace == 'payments'",
+	} {
+		_, err := CompileNamespaceExpression(expression)
+		assert.Error(t, err, expression)
+	}
+}
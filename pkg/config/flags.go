@@ -0,0 +1,101 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FlagOverrides holds the settings given on the command line, which take
+// precedence over the config file and the environment. Only the flags that
+// were given are applied.
+type FlagOverrides struct {
+	VaultAddress          *string
+	ReconcileInterval     *Seconds
+	DeleteVaultNamespaces *bool
+	IncludeNamespaces     []string
+	ExcludeNamespaces     []string
+}
+
+// BindFlags registers the override flags in fs. --include and --exclude take
+// a comma separated list and can be repeated.
+func (o *FlagOverrides) BindFlags(fs *flag.FlagSet) {
+	fs.Func("vault-address", "Vault server address, overriding vault.address", func(value string) error {
+		o.VaultAddress = &value
+		return nil
+	})
+	fs.Func("reconcile-interval", "Interval between reconciles of a namespace, in seconds or as a duration such as 5m, overriding reconcileInterval", func(value string) error {
+		interval, err := ParseDuration(value, time.Second)
+		if err != nil {
+			return err
+		}
+		seconds := Seconds(interval)
+		o.ReconcileInterval = &seconds
+		return nil
+	})
+	fs.BoolFunc("delete-vault-namespaces", "Delete Vault namespaces with their Kubernetes namespaces, overriding deleteVaultNamespaces", func(value string) error {
+		deleteNamespaces, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		o.DeleteVaultNamespaces = &deleteNamespaces
+		return nil
+	})
+	fs.Func("include", "Namespace patterns to include, overriding includeNamespaces", func(value string) error {
+		o.IncludeNamespaces = appendList(o.IncludeNamespaces, value)
+		return nil
+	})
+	fs.Func("exclude", "Namespace patterns to exclude, overriding excludeNamespaces", func(value string) error {
+		o.ExcludeNamespaces = appendList(o.ExcludeNamespaces, value)
+		return nil
+	})
+}
+
+// appendList appends the comma separated items of value to list, returning
+// a non-nil list so that an empty flag still overrides.
+func appendList(list []string, value string) []string {
+	if list == nil {
+		list = []string{}
+	}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// IsSet reports whether any override flag was given.
+func (o FlagOverrides) IsSet() bool {
+	return o.VaultAddress != nil || o.ReconcileInterval != nil || o.DeleteVaultNamespaces != nil ||
+		o.IncludeNamespaces != nil || o.ExcludeNamespaces != nil
+}
+
+// Apply sets the overridden settings in config. When any flag was given, the
+// result is validated again.
+func (o FlagOverrides) Apply(config *ControllerConfig) error {
+	if !o.IsSet() {
+		return nil
+	}
+	if o.VaultAddress != nil {
+		config.Vault.Address = *o.VaultAddress
+	}
+	if o.ReconcileInterval != nil {
+		config.ReconcileInterval = *o.ReconcileInterval
+	}
+	if o.DeleteVaultNamespaces != nil {
+		config.DeleteVaultNamespaces = *o.DeleteVaultNamespaces
+	}
+	if o.IncludeNamespaces != nil {
+		config.IncludeNamespaces = o.IncludeNamespaces
+	}
+	if o.ExcludeNamespaces != nil {
+		config.ExcludeNamespaces = o.ExcludeNamespaces
+	}
+	if err := validateConfig(config); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	return nil
+}
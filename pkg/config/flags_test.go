@@ -0,0 +1,64 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFlagOverrides tests that command line flags take precedence over the
+// config file and the environment.
+func TestFlagOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+vault:
+  address: https://vault.example.org:8200
+  auth:
+    type: token
+    token: test-token
+includeNamespaces: ["app-.*"]
+excludeNamespaces: ["kube-.*"]
+`), 0o600))
+	t.Setenv("VNC_VAULT_ADDRESS", "https://vault.env:8200")
+
+	var overrides FlagOverrides
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	overrides.BindFlags(fs)
+	assert.NoError(t, fs.Parse([]string{
+		"--vault-address", "http://127.0.0.1:8200",
+		"--reconcile-interval", "30s",
+		"--delete-vault-namespaces=false",
+		"--include", "team-.*, payments",
+		"--include", "ops",
+		"--exclude", "",
+	}))
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.NoError(t, overrides.Apply(config))
+	assert.Equal(t, "http://127.0.0.1:8200", config.Vault.Address)
+	assert.Equal(t, Seconds(30), config.ReconcileInterval)
+	assert.False(t, config.DeleteVaultNamespaces)
+	assert.Equal(t, []string{"team-.*", "payments", "ops"}, config.IncludeNamespaces)
+	assert.Empty(t, config.ExcludeNamespaces)
+	assert.Equal(t, "test-token", config.Vault.Auth.Token)
+
+	// Overridden settings are validated
+	assert.Error(t, fs.Parse([]string{"--reconcile-interval", "soon"}))
+	assert.NoError(t, fs.Parse([]string{"--reconcile-interval", "-1"}))
+	assert.Error(t, overrides.Apply(config))
+}
+
+// TestFlagOverrides_NotSet tests that no flags leave the config untouched.
+func TestFlagOverrides_NotSet(t *testing.T) {
+	config := &ControllerConfig{ReconcileInterval: 300}
+	var overrides FlagOverrides
+	assert.False(t, overrides.IsSet())
+	assert.NoError(t, overrides.Apply(config))
+	assert.Equal(t, &ControllerConfig{ReconcileInterval: 300}, config)
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// envReference matches ${NAME} and ${NAME:-default}, and $${ escaping a
+// literal ${. A bare $ is left alone, as it is common in regular expressions.
+var envReference = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolateEnv expands environment variable references in every string of
+// config, so secrets can be passed as environment variables instead of being
+// written into the file. A reference to an unset variable without a default
+// is an error.
+func interpolateEnv(config *ControllerConfig, lookup func(string) (string, bool)) error {
+	return interpolateValue(reflect.ValueOf(config).Elem(), "", lookup)
+}
+
+func interpolateValue(v reflect.Value, path string, lookup func(string) (string, bool)) error {
+	switch v.Kind() {
+	case reflect.String:
+		expanded, err := expandEnv(v.String(), lookup)
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.TrimPrefix(path, "."), err)
+		}
+		v.SetString(expanded)
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return interpolateValue(v.Elem(), path, lookup)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			fieldPath := path
+			if name != "" {
+				fieldPath = path + "." + name
+			}
+			if err := interpolateValue(v.Field(i), fieldPath, lookup); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := interpolateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), lookup); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		for _, key := range v.MapKeys() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(v.MapIndex(key))
+			if err := interpolateValue(value, fmt.Sprintf("%s[%v]", path, key), lookup); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, value)
+		}
+	}
+	return nil
+}
+
+// expandEnv expands the environment variable references in s.
+func expandEnv(s string, lookup func(string) (string, bool)) (string, error) {
+	var err error
+	expanded := envReference.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+		match := envReference.FindStringSubmatch(ref)
+		// Like in a shell, the default also replaces an empty value
+		value, ok := lookup(match[1])
+		if match[2] != "" && value == "" {
+			return match[3]
+		}
+		if ok {
+			return value
+		}
+		if err == nil {
+			err = fmt.Errorf("environment variable %s is not set", match[1])
+		}
+		return ref
+	})
+	return expanded, err
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Syntaxes of the include and exclude namespace patterns.
+const (
+	PatternSyntaxRegex = "regex"
+	PatternSyntaxGlob  = "glob"
+)
+
+// ValidatePatterns checks that the namespace patterns of the setting named
+// field compile in syntax, naming the offending pattern otherwise.
+func ValidatePatterns(field, syntax string, patterns []string) error {
+	for i, pattern := range patterns {
+		if _, err := CompilePattern(pattern, syntax, false); err != nil {
+			return fmt.Errorf("invalid %s[%d] %q: %w", field, i, pattern, err)
+		}
+	}
+	return nil
+}
+
+// CompilePattern compiles a namespace pattern in syntax. A regex pattern
+// matches anywhere in the name unless anchored, in the pattern or by
+// anchored, while a glob pattern matches the whole name: * matches any run
+// of characters, ? a single character, and [...] a character class, negated
+// by a leading !.
+func CompilePattern(pattern, syntax string, anchored bool) (*regexp.Regexp, error) {
+	if syntax != PatternSyntaxGlob {
+		if anchored {
+			// Validate the pattern alone, so errors do not show the anchors
+			if _, err := regexp.Compile(pattern); err != nil {
+				return nil, err
+			}
+			pattern = "^(?:" + pattern + ")$"
+		}
+		return regexp.Compile(pattern)
+	}
+	expr, err := globToRegexp(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile(expr)
+}
+
+// globToRegexp returns the anchored regular expression equivalent to glob.
+func globToRegexp(glob string) (string, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return "", errors.New("missing closing ] in character class")
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return expr.String(), nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompilePattern_Glob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		matches bool
+	}{
+		{pattern: "team-*", name: "team-payments", matches: true},
+		{pattern: "team-*", name: "my-team-payments", matches: false},
+		{pattern: "prod", name: "not-prod-test", matches: false},
+		{pattern: "prod-?", name: "prod-1", matches: true},
+		{pattern: "prod-?", name: "prod-12", matches: false},
+		{pattern: "prod-[0-9]", name: "prod-7", matches: true},
+		{pattern: "prod-[!0-9]", name: "prod-7", matches: false},
+		{pattern: "app.v1", name: "appxv1", matches: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			re, err := CompilePattern(tt.pattern, PatternSyntaxGlob, false)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.matches, re.MatchString(tt.name))
+		})
+	}
+
+	_, err := CompilePattern("team-[a-z", PatternSyntaxGlob, false)
+	assert.EqualError(t, err, "missing closing ] in character class")
+}
+
+func TestCompilePattern_Anchored(t *testing.T) {
+	re, err := CompilePattern("prod|staging", PatternSyntaxRegex, true)
+	assert.NoError(t, err)
+	assert.True(t, re.MatchString("prod"))
+	assert.True(t, re.MatchString("staging"))
+	assert.False(t, re.MatchString("not-prod-test"))
+
+	re, err = CompilePattern("prod|staging", PatternSyntaxRegex, false)
+	assert.NoError(t, err)
+	assert.True(t, re.MatchString("not-prod-test"))
+
+	_, err = CompilePattern("(prod", PatternSyntaxRegex, true)
+	assert.EqualError(t, err, "error parsing regexp: missing closing ): `(prod`")
+}
+
+func TestValidateConfig_PatternSyntax(t *testing.T) {
+	config := &ControllerConfig{
+		Vault: VaultConfig{
+			Address: "https://vault.example.com:8200",
+			Auth:    VaultAuthConfig{Type: "token", Token: "test-token"},
+		},
+		PatternSyntax:     PatternSyntaxGlob,
+		IncludeNamespaces: []string{"team-*"},
+	}
+	assert.NoError(t, validateConfig(config))
+
+	// A valid glob is not necessarily a valid regular expression
+	config.IncludeNamespaces = []string{"*-team"}
+	assert.NoError(t, validateConfig(config))
+	config.PatternSyntax = PatternSyntaxRegex
+	assert.ErrorContains(t, validateConfig(config), `invalid includeNamespaces[0] "*-team"`)
+
+	config.PatternSyntax = "wildcard"
+	assert.EqualError(t, validateConfig(config), `unsupported patternSyntax "wildcard", expected regex or glob`)
+}
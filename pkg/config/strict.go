@@ -0,0 +1,106 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// unknownField matches the yaml.v2 error for an unknown field.
+var unknownField = regexp.MustCompile(`field (\S+) not found in type (\S+)`)
+
+// suggestFields rewrites the unknown field errors in err to name the closest
+// known setting, e.g. namespaceFormat for namepaceFormat.
+func suggestFields(err error) error {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+	known := yamlFieldNames(reflect.TypeOf(ControllerConfig{}), map[string]map[string]bool{})
+	messages := make([]string, len(typeErr.Errors))
+	for i, message := range typeErr.Errors {
+		messages[i] = unknownField.ReplaceAllStringFunc(message, func(match string) string {
+			submatch := unknownField.FindStringSubmatch(match)
+			field := submatch[1]
+			if suggestion := closestName(field, known[submatch[2]]); suggestion != "" {
+				return "unknown field " + field + ", did you mean " + suggestion + "?"
+			}
+			return "unknown field " + field
+		})
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// yamlFieldNames returns the YAML names of the fields of t and of the structs
+// it contains, by the type name used in yaml.v2 errors.
+func yamlFieldNames(t reflect.Type, names map[string]map[string]bool) map[string]map[string]bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		return yamlFieldNames(t.Elem(), names)
+	case reflect.Struct:
+		if names[t.String()] != nil {
+			return names
+		}
+		fields := map[string]bool{}
+		names[t.String()] = fields
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			yamlFieldNames(field.Type, names)
+			if name == "" && options == "inline" {
+				// The fields of an inlined struct belong to t
+				for inlined := range names[field.Type.String()] {
+					fields[inlined] = true
+				}
+			} else if name != "" && name != "-" {
+				fields[name] = true
+			}
+		}
+	}
+	return names
+}
+
+// closestName returns the name closest to field, if it is close enough to be
+// a typo.
+func closestName(field string, names map[string]bool) string {
+	best, bestDistance := "", len(field)/3+1
+	for name := range names {
+		distance := editDistance(strings.ToLower(field), strings.ToLower(name))
+		if distance < bestDistance || (distance == bestDistance && best != "" && name < best) {
+			best, bestDistance = name, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the edit distance between a and b, counting swapped
+// adjacent characters as a single edit.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoadConfig_UnknownFields tests that misspelt settings fail the load
+// with a suggestion instead of being ignored.
+func TestLoadConfig_UnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+vault:
+  address: https://vault.example.com:8200
+  auth:
+    type: kubernetes
+    roel: controller
+namepaceFormat: k8s-%s
+vso:
+  nmae: vault-connection
+somethingElse: true
+`), 0o600))
+
+	_, err := LoadConfig(path)
+	assert.ErrorContains(t, err, "line 6: unknown field roel, did you mean role?")
+	assert.ErrorContains(t, err, "line 7: unknown field namepaceFormat, did you mean namespaceFormat?")
+	// Inlined fields are suggested too
+	assert.ErrorContains(t, err, "line 9: unknown field nmae, did you mean name?")
+	assert.ErrorContains(t, err, "did you mean name?; line 10: unknown field somethingElse")
+	assert.NotContains(t, err.Error(), "somethingElse,")
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("role", "role"))
+	assert.Equal(t, 1, editDistance("namepaceFormat", "namespaceFormat"))
+	assert.Equal(t, 1, editDistance("roel", "role"))
+	assert.Equal(t, 2, editDistance("lore", "role"))
+	assert.Equal(t, 3, editDistance("", "abc"))
+}
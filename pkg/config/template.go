@@ -0,0 +1,64 @@
+package config
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"text/template"
+)
+
+// NamespaceTemplateData is the data NamespaceTemplate is executed against.
+type NamespaceTemplateData struct {
+	// Name is the Kubernetes namespace name.
+	Name string
+	// Labels and Annotations are those of the Kubernetes namespace.
+	Labels      map[string]string
+	Annotations map[string]string
+	// ClusterName is the configured cluster name.
+	ClusterName string
+}
+
+// namespaceTemplateFuncs is the subset of sprig functions available to
+// NamespaceTemplate, with the same names, argument order and behavior, so that
+// names can be normalized and shortened within the template.
+var namespaceTemplateFuncs = template.FuncMap{
+	// lower converts s to lower case: {{ .Name | lower }}
+	"lower": strings.ToLower,
+	// replace replaces every old in s with repl: {{ .Name | replace "-" "_" }}
+	"replace": func(old, repl, s string) string {
+		return strings.ReplaceAll(s, old, repl)
+	},
+	// trunc keeps the first n bytes of s, or the last -n when n is negative: {{ .Name | trunc 8 }}
+	"trunc": func(n int, s string) string {
+		if n < 0 && len(s)+n > 0 {
+			return s[len(s)+n:]
+		}
+		if n >= 0 && len(s) > n {
+			return s[:n]
+		}
+		return s
+	},
+	// sha1sum returns the hex SHA-1 digest of s: {{ .Name | sha1sum | trunc 8 }}
+	"sha1sum": func(s string) string {
+		sum := sha1.Sum([]byte(s))
+		return hex.EncodeToString(sum[:])
+	},
+}
+
+// ParseNamespaceTemplate parses a NamespaceTemplate. Referencing a label or
+// annotation the namespace does not have is an error when the template is
+// executed, rather than silently producing an empty path segment.
+func ParseNamespaceTemplate(text string) (*template.Template, error) {
+	return template.New("namespaceTemplate").
+		Option("missingkey=error").
+		Funcs(namespaceTemplateFuncs).
+		Parse(text)
+}
+
+// ParseNotificationMessage parses the Message of a notifier, which has the
+// same functions as NamespaceTemplate.
+func ParseNotificationMessage(text string) (*template.Template, error) {
+	return template.New("message").
+		Funcs(namespaceTemplateFuncs).
+		Parse(text)
+}
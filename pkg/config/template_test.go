@@ -0,0 +1,67 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNamespaceTemplate_Funcs(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{
+			name:     "lower",
+			template: "{{ .Name | lower }}",
+			expected: "payments-api",
+		},
+		{
+			name:     "replace",
+			template: `{{ .Name | replace "-" "_" }}`,
+			expected: "Payments_API",
+		},
+		{
+			name:     "trunc",
+			template: "{{ .Name | trunc 8 }}",
+			expected: "Payments",
+		},
+		{
+			name:     "negative trunc keeps the end",
+			template: "{{ .Name | trunc -3 }}",
+			expected: "API",
+		},
+		{
+			name:     "trunc longer than the string",
+			template: "{{ .Name | trunc 64 }}",
+			expected: "Payments-API",
+		},
+		{
+			name:     "sha1sum",
+			template: "{{ .Name | sha1sum }}",
+			expected: "d8f4d93daef6c523d7e1f6bf4154dab6e9b01f64",
+		},
+		{
+			name:     "combined",
+			template: "{{ .Labels.team | lower }}/{{ .Name | lower | trunc 8 }}",
+			expected: "payments/payments",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseNamespaceTemplate(tt.template)
+			assert.NoError(t, err)
+
+			var b strings.Builder
+			err = tmpl.Execute(&b, NamespaceTemplateData{
+				Name:   "Payments-API",
+				Labels: map[string]string{"team": "Payments"},
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, b.String())
+		})
+	}
+}
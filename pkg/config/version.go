@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The apiVersion and kind of the config file. A file without them is read as
+// the current version, which is what files were before versioning.
+const (
+	ConfigAPIVersion = "config.vault.benemon.io/v1"
+	ConfigKind       = "ControllerConfig"
+)
+
+// conversion upgrades a config file from one schema version to the next.
+type conversion struct {
+	next    string
+	convert func(file map[interface{}]interface{}) error
+}
+
+// conversions holds the conversion from each older schema version to the next
+// one, by version. When a breaking change to the schema introduces a new
+// version, the previous one gets a conversion here, so existing files keep
+// loading.
+var conversions = map[string]conversion{}
+
+// configHeader is the part of the config file identifying its schema.
+type configHeader struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+}
+
+// convertConfig returns data converted to the current schema version.
+func convertConfig(data []byte) ([]byte, error) {
+	var header configHeader
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		// Left for decoding the file to report
+		return data, nil
+	}
+	if header.Kind != "" && header.Kind != ConfigKind {
+		return nil, fmt.Errorf("unsupported kind %q, expected %s", header.Kind, ConfigKind)
+	}
+	if header.APIVersion == "" || header.APIVersion == ConfigAPIVersion {
+		return data, nil
+	}
+	if _, ok := conversions[header.APIVersion]; !ok {
+		return nil, fmt.Errorf("unsupported apiVersion %q, supported versions are %s",
+			header.APIVersion, strings.Join(supportedVersions(), ", "))
+	}
+
+	var file map[interface{}]interface{}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	for version := header.APIVersion; version != ConfigAPIVersion; {
+		step := conversions[version]
+		if err := step.convert(file); err != nil {
+			return nil, fmt.Errorf("failed to convert from %s to %s: %w", version, step.next, err)
+		}
+		version = step.next
+	}
+	file["apiVersion"] = ConfigAPIVersion
+	return yaml.Marshal(file)
+}
+
+// supportedVersions returns the schema versions that can be loaded.
+func supportedVersions() []string {
+	versions := []string{ConfigAPIVersion}
+	for version := range conversions {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions[1:])
+	return versions
+}
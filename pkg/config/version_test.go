@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoadConfig_Versions tests reading the apiVersion and kind header and
+// converting files of older versions.
+func TestLoadConfig_Versions(t *testing.T) {
+	const body = `
+vault:
+  address: https://vault.example.org:8200
+  auth:
+    type: token
+    token: test-token
+`
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	load := func(header string) (*ControllerConfig, error) {
+		assert.NoError(t, os.WriteFile(path, []byte(header+body), 0o600))
+		return LoadConfig(path)
+	}
+
+	// Files without a header are read as the current version
+	config, err := load("")
+	assert.NoError(t, err)
+	assert.Equal(t, ConfigAPIVersion, config.APIVersion)
+	assert.Equal(t, ConfigKind, config.Kind)
+
+	config, err = load("apiVersion: config.vault.benemon.io/v1\nkind: ControllerConfig\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "test-token", config.Vault.Auth.Token)
+
+	_, err = load("apiVersion: config.vault.benemon.io/v9\n")
+	assert.ErrorContains(t, err, `unsupported apiVersion "config.vault.benemon.io/v9", supported versions are config.vault.benemon.io/v1`)
+	_, err = load("kind: NamespaceConfig\n")
+	assert.ErrorContains(t, err, `unsupported kind "NamespaceConfig"`)
+
+	// Older versions are converted step by step
+	conversions["config.vault.benemon.io/v0"] = conversion{
+		next: "config.vault.benemon.io/v0.5",
+		convert: func(file map[interface{}]interface{}) error {
+			file["namespaceFormat"] = file["format"]
+			delete(file, "format")
+			return nil
+		},
+	}
+	conversions["config.vault.benemon.io/v0.5"] = conversion{
+		next: ConfigAPIVersion,
+		convert: func(file map[interface{}]interface{}) error {
+			file["dryRun"] = true
+			return nil
+		},
+	}
+	defer func() {
+		delete(conversions, "config.vault.benemon.io/v0")
+		delete(conversions, "config.vault.benemon.io/v0.5")
+	}()
+	config, err = load("apiVersion: config.vault.benemon.io/v0\nformat: k8s-%s\n")
+	assert.NoError(t, err)
+	assert.Equal(t, ConfigAPIVersion, config.APIVersion)
+	assert.Equal(t, "k8s-%s", config.NamespaceFormat)
+	assert.True(t, config.DryRun)
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/benemon/vault-namespace-controller/pkg/audit"
+)
+
+// Results of adopting a Vault namespace.
+const (
+	AdoptionAdopted     = "adopted"
+	AdoptionWouldAdopt  = "would-adopt"
+	AdoptionAlreadyOwns = "already-owned"
+	AdoptionConflict    = "owned-by-other-cluster"
+	AdoptionNotFound    = "no-vault-namespace"
+	AdoptionFailed      = "failed"
+)
+
+// Adoption is the result of adopting the Vault namespace of a Kubernetes namespace.
+type Adoption struct {
+	KubernetesNamespace string `json:"kubernetesNamespace"`
+	VaultNamespace      string `json:"vaultNamespace,omitempty"`
+	Result              string `json:"result"`
+	Error               string `json:"error,omitempty"`
+}
+
+// Failed reports whether the Vault namespace was left without this
+// controller's ownership.
+func (a Adoption) Failed() bool {
+	switch a.Result {
+	case AdoptionConflict, AdoptionNotFound, AdoptionFailed:
+		return true
+	}
+	return false
+}
+
+// AdoptNamespaces writes the ownership metadata onto the existing Vault
+// namespaces of the Kubernetes namespaces names, or of every synchronized
+// namespace when names is empty, so they are managed like namespaces the
+// controller created. Vault namespaces claimed by another cluster are left
+// alone. With dryRun nothing is written.
+func (r *NamespaceReconciler) AdoptNamespaces(ctx context.Context, names []string, dryRun bool) ([]Adoption, error) {
+	plan, err := r.BuildPlan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]string, len(plan.InSync))
+	for _, entry := range plan.InSync {
+		existing[entry.KubernetesNamespace] = entry.VaultNamespace
+	}
+	var entries []PlanEntry
+	if len(names) == 0 {
+		entries = plan.InSync
+	} else {
+		for _, name := range names {
+			entries = append(entries, PlanEntry{KubernetesNamespace: name, VaultNamespace: existing[name]})
+		}
+	}
+
+	adoptions := make([]Adoption, 0, len(entries))
+	for _, entry := range entries {
+		adoption := Adoption{KubernetesNamespace: entry.KubernetesNamespace, VaultNamespace: entry.VaultNamespace}
+		if entry.VaultNamespace == "" {
+			adoption.Result = AdoptionNotFound
+			adoptions = append(adoptions, adoption)
+			continue
+		}
+
+		customMetadata, err := r.VaultClient.GetNamespaceMetadata(ctx, entry.VaultNamespace)
+		managed, ours := r.ownedBy(customMetadata)
+		switch {
+		case err != nil:
+			adoption.Result, adoption.Error = AdoptionFailed, err.Error()
+		case ours:
+			adoption.Result = AdoptionAlreadyOwns
+		case managed:
+			adoption.Result = AdoptionConflict
+		case dryRun:
+			adoption.Result = AdoptionWouldAdopt
+		default:
+			err := r.VaultClient.PatchNamespaceMetadata(ctx, entry.VaultNamespace, r.ownershipMetadata(entry.KubernetesNamespace))
+			r.audit(ctx, audit.Record{Action: audit.ActionAdopt, Namespace: entry.KubernetesNamespace, VaultNamespace: entry.VaultNamespace}, err)
+			if err != nil {
+				adoption.Result, adoption.Error = AdoptionFailed, err.Error()
+			} else {
+				adoption.Result = AdoptionAdopted
+			}
+		}
+		adoptions = append(adoptions, adoption)
+	}
+	return adoptions, nil
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestAdoptNamespaces tests only unowned Vault namespaces are adopted, and
+// nothing is written in a dry run.
+func TestAdoptNamespaces(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	otherCluster := ownedMetadata("app-c")
+	otherCluster[MetadataKubernetesCluster] = "west"
+
+	tests := []struct {
+		name         string
+		names        []string
+		dryRun       bool
+		expected     []Adoption
+		expectPatch  bool
+		expectFailed bool
+	}{
+		{
+			name: "all synchronized namespaces",
+			expected: []Adoption{
+				{KubernetesNamespace: "app-a", VaultNamespace: "k8s-app-a", Result: AdoptionAdopted},
+				{KubernetesNamespace: "app-b", VaultNamespace: "k8s-app-b", Result: AdoptionAlreadyOwns},
+				{KubernetesNamespace: "app-c", VaultNamespace: "k8s-app-c", Result: AdoptionConflict},
+			},
+			expectPatch:  true,
+			expectFailed: true,
+		},
+		{
+			name:   "dry run",
+			names:  []string{"app-a"},
+			dryRun: true,
+			expected: []Adoption{
+				{KubernetesNamespace: "app-a", VaultNamespace: "k8s-app-a", Result: AdoptionWouldAdopt},
+			},
+		},
+		{
+			name:  "named namespaces",
+			names: []string{"app-a", "app-d"},
+			expected: []Adoption{
+				{KubernetesNamespace: "app-a", VaultNamespace: "k8s-app-a", Result: AdoptionAdopted},
+				{KubernetesNamespace: "app-d", Result: AdoptionNotFound},
+			},
+			expectPatch:  true,
+			expectFailed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-a"}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-b"}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-c"}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-d"}},
+			).Build()
+
+			mockClient := new(mockVaultClient)
+			mockClient.On("ListNamespaces", mock.Anything, "").Return([]string{"k8s-app-a", "k8s-app-b", "k8s-app-c"}, nil)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-app-a").Return(map[string]string{"team": "a"}, nil)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-app-b").Return(ownedMetadata("app-b"), nil).Maybe()
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-app-c").Return(otherCluster, nil).Maybe()
+			if tt.expectPatch {
+				mockClient.On("PatchNamespaceMetadata", mock.Anything, "k8s-app-a", ownedMetadata("app-a")).Return(nil)
+			}
+
+			reconciler := &NamespaceReconciler{
+				Client:      fakeClient,
+				Log:         testr.New(t),
+				VaultClient: mockClient,
+				Config:      &config.ControllerConfig{NamespaceFormat: "k8s-%s"},
+			}
+
+			adoptions, err := reconciler.AdoptNamespaces(context.Background(), tt.names, tt.dryRun)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, adoptions)
+			failed := false
+			for _, adoption := range adoptions {
+				failed = failed || adoption.Failed()
+			}
+			assert.Equal(t, tt.expectFailed, failed)
+			mockClient.AssertExpectations(t)
+			if !tt.expectPatch {
+				mockClient.AssertNotCalled(t, "PatchNamespaceMetadata", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
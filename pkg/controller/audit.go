@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/benemon/vault-namespace-controller/pkg/audit"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+)
+
+// audit records a change to a Vault namespace, and err when it failed,
+// adding the cluster, connection and correlation ID of ctx.
+func (r *NamespaceReconciler) audit(ctx context.Context, record audit.Record, err error) {
+	if r.Audit == nil {
+		return
+	}
+	record.Cluster = r.Config.ClusterName
+	record.Connection = connectionFrom(ctx)
+	record.CorrelationID = vault.CorrelationID(ctx)
+	record.Outcome = audit.OutcomeSuccess
+	if err != nil {
+		record.Outcome = audit.OutcomeFailure
+		record.Error = err.Error()
+	}
+	r.Audit.Record(record)
+}
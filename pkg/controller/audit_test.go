@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/benemon/vault-namespace-controller/pkg/audit"
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+)
+
+// recordingSink keeps the audit records written to it.
+type recordingSink struct {
+	records []audit.Record
+}
+
+func (s *recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Write(record audit.Record) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+// TestAudit_NamespaceCreation tests creations are audited with their outcome.
+func TestAudit_NamespaceCreation(t *testing.T) {
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, mock.Anything).Return(false, nil)
+	mockClient.On("CreateNamespace", mock.Anything, "k8s-team-a", mock.Anything).Return(nil).Once()
+	mockClient.On("CreateNamespace", mock.Anything, "k8s-team-b", mock.Anything).Return(errors.New("permission denied")).Once()
+
+	sink := &recordingSink{}
+	reconciler := &NamespaceReconciler{
+		Log:         testr.New(t),
+		VaultClient: mockClient,
+		Config:      &config.ControllerConfig{NamespaceFormat: "k8s-%s", ClusterName: "east"},
+		Audit:       &audit.Log{Sinks: []audit.Sink{sink}, Log: testr.New(t)},
+	}
+
+	ctx := vault.WithCorrelationID(context.Background(), "3f2b8c1e")
+	_, err := reconciler.handleNamespaceCreation(ctx, "team-a", "k8s-team-a", reconciler.Log)
+	assert.NoError(t, err)
+	_, err = reconciler.handleNamespaceCreation(ctx, "team-b", "k8s-team-b", reconciler.Log)
+	assert.Error(t, err)
+
+	if assert.Len(t, sink.records, 2) {
+		created := sink.records[0]
+		assert.Equal(t, audit.ActionCreate, created.Action)
+		assert.Equal(t, audit.OutcomeSuccess, created.Outcome)
+		assert.Equal(t, "team-a", created.Namespace)
+		assert.Equal(t, "k8s-team-a", created.VaultNamespace)
+		assert.Equal(t, "east", created.Cluster)
+		assert.Equal(t, "3f2b8c1e", created.CorrelationID)
+		assert.Empty(t, created.Error)
+
+		failed := sink.records[1]
+		assert.Equal(t, audit.OutcomeFailure, failed.Outcome)
+		assert.Equal(t, "permission denied", failed.Error)
+	}
+	mockClient.AssertExpectations(t)
+}
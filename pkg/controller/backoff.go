@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+	"github.com/go-logr/logr"
+)
+
+// Defaults used when the error backoff is not configured.
+const (
+	defaultErrorBackoffBase = 5 * time.Second
+	defaultErrorBackoffMax  = 5 * time.Minute
+)
+
+// errorBackoff records another consecutive failure for the Kubernetes namespace
+// name and returns how long to wait before retrying it.
+func (r *NamespaceReconciler) errorBackoff(name string) time.Duration {
+	r.mu.Lock()
+	if r.failures == nil {
+		r.failures = make(map[string]int)
+	}
+	r.failures[name]++
+	attempt := r.failures[name]
+	r.mu.Unlock()
+
+	base, maxDelay := r.backoffLimits()
+	return backoffDelay(attempt, base, maxDelay, r.Config.ErrorBackoffJitter)
+}
+
+// backoffLimits returns the configured base and maximum error backoff.
+func (r *NamespaceReconciler) backoffLimits() (base, maxDelay time.Duration) {
+	base = time.Duration(r.Config.ErrorBackoffBase) * time.Second
+	if base <= 0 {
+		base = defaultErrorBackoffBase
+	}
+	maxDelay = time.Duration(r.Config.ErrorBackoffMax) * time.Second
+	if maxDelay <= 0 {
+		maxDelay = defaultErrorBackoffMax
+	}
+	return base, maxDelay
+}
+
+// retryResult decides how to requeue the Kubernetes namespace name after its
+// reconcile failed with err:
+//   - requests Vault rejects (permission denied, invalid path) are not retried
+//     with backoff, since retrying soon cannot fix a configuration mistake, but
+//     at the next periodic reconcile, as credentials being rotated can also
+//     cause them
+//   - a sealed or rate-limited Vault is retried after the maximum backoff
+//   - anything else, such as 5xx responses and network errors, follows the
+//     exponential backoff
+//
+// Retries with backoff are also delayed by the configured rate limiter, and
+// wait for the longer of the two.
+func (r *NamespaceReconciler) retryResult(ctx context.Context, name string, err error, log logr.Logger) ctrl.Result {
+	defer r.recordFailure(name, err)
+
+	switch {
+	case errors.Is(err, vault.ErrVaultPermissionDenied), errors.Is(err, vault.ErrVaultInvalidRequest):
+		log.Info("Vault rejected the request, retrying at the next periodic reconcile", "retryStrategy", "interval")
+		r.recordEvent(name, corev1.EventTypeWarning, "VaultRequestRejected",
+			"Vault rejected the request and it will not be retried with backoff: %v", err)
+		r.resetBackoff(name)
+		// In event-driven-only mode the namespace is only revisited on change or by the drift scanner
+		return ctrl.Result{RequeueAfter: r.reconcileInterval()}
+
+	case errors.Is(err, vault.ErrVaultSealed), errors.Is(err, vault.ErrVaultRateLimited):
+		_, maxDelay := r.backoffLimits()
+		delay := max(backoffDelay(1, maxDelay, maxDelay, r.Config.ErrorBackoffJitter), r.rateLimitRetry(name))
+		log.Info("Vault is sealed or rate limiting, retrying later", "retryStrategy", "long", "retryAfter", delay.String())
+		return ctrl.Result{RequeueAfter: delay}
+
+	default:
+		delay := max(r.errorBackoff(name), r.rateLimitRetry(name))
+		r.notifyFailing(ctx, name, err)
+		log.V(1).Info("Retrying after backoff", "retryStrategy", "backoff", "retryAfter", delay.String())
+		return ctrl.Result{RequeueAfter: delay}
+	}
+}
+
+// resetBackoff forgets the failures recorded for name after it reconciles successfully.
+func (r *NamespaceReconciler) resetBackoff(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.failures, name)
+	r.recentFailures = removeFailure(r.recentFailures, name)
+	if r.retryLimiter != nil {
+		r.retryLimiter.Forget(retryRequest(name))
+	}
+}
+
+// rateLimitRetry records another retry of the Kubernetes namespace name with
+// the rate limiter built from the configuration, and returns how long it
+// delays the retry.
+func (r *NamespaceReconciler) rateLimitRetry(name string) time.Duration {
+	r.mu.Lock()
+	if r.retryLimiter == nil {
+		r.retryLimiter = newRateLimiter(r.Config.RateLimiter)
+	}
+	limiter := r.retryLimiter
+	r.mu.Unlock()
+	return limiter.When(retryRequest(name))
+}
+
+// retryRequest is the rate limiter item of the Kubernetes namespace name.
+func retryRequest(name string) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{Name: name}}
+}
+
+// backoffDelay doubles base for each attempt after the first, caps the result at
+// maxDelay, and then adds up to jitter (a fraction of the delay) at random so
+// namespaces failing together do not retry in lockstep.
+func backoffDelay(attempt int, base, maxDelay time.Duration, jitter float64) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	if jitter > 0 {
+		delay += time.Duration(rand.Float64() * jitter * float64(delay))
+	}
+	return delay
+}
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+)
+
+// TestBackoffDelay tests the exponential backoff calculation.
+func TestBackoffDelay(t *testing.T) {
+	base := 5 * time.Second
+	maxDelay := time.Minute
+
+	assert.Equal(t, 5*time.Second, backoffDelay(1, base, maxDelay, 0))
+	assert.Equal(t, 10*time.Second, backoffDelay(2, base, maxDelay, 0))
+	assert.Equal(t, 40*time.Second, backoffDelay(4, base, maxDelay, 0))
+	assert.Equal(t, time.Minute, backoffDelay(10, base, maxDelay, 0))
+
+	for i := 0; i < 100; i++ {
+		delay := backoffDelay(1, base, maxDelay, 0.5)
+		assert.GreaterOrEqual(t, delay, base)
+		assert.Less(t, delay, base+base/2)
+	}
+}
+
+// TestNamespaceReconciler_ErrorBackoff tests that failures back off and success resets.
+func TestNamespaceReconciler_ErrorBackoff(t *testing.T) {
+	reconciler := &NamespaceReconciler{
+		Config: &config.ControllerConfig{ErrorBackoffBase: 5, ErrorBackoffMax: 60},
+	}
+
+	assert.Equal(t, 5*time.Second, reconciler.errorBackoff("app"))
+	assert.Equal(t, 10*time.Second, reconciler.errorBackoff("app"))
+	assert.Equal(t, 5*time.Second, reconciler.errorBackoff("other"))
+
+	reconciler.resetBackoff("app")
+	assert.Equal(t, 5*time.Second, reconciler.errorBackoff("app"))
+}
+
+// TestNamespaceReconciler_RetryResult tests choosing a retry strategy from the Vault error class.
+func TestNamespaceReconciler_RetryResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected ctrl.Result
+	}{
+		{
+			name:     "permission denied waits for the reconcile interval",
+			err:      fmt.Errorf("%w: %w", ErrNamespaceCreation, vault.ErrVaultPermissionDenied),
+			expected: ctrl.Result{RequeueAfter: 10 * time.Minute},
+		},
+		{
+			name:     "invalid path waits for the reconcile interval",
+			err:      fmt.Errorf("%w: %w", ErrNamespaceCreation, vault.ErrVaultInvalidRequest),
+			expected: ctrl.Result{RequeueAfter: 10 * time.Minute},
+		},
+		{
+			name:     "sealed Vault waits for the maximum backoff",
+			err:      fmt.Errorf("%w: %w", ErrNamespaceCreation, vault.ErrVaultSealed),
+			expected: ctrl.Result{RequeueAfter: time.Minute},
+		},
+		{
+			name:     "rate limiting waits for the maximum backoff",
+			err:      fmt.Errorf("%w: %w", ErrNamespaceDeletion, vault.ErrVaultRateLimited),
+			expected: ctrl.Result{RequeueAfter: time.Minute},
+		},
+		{
+			name:     "unavailable Vault follows the backoff",
+			err:      fmt.Errorf("%w: %w", ErrNamespaceCreation, vault.ErrVaultUnavailable),
+			expected: ctrl.Result{RequeueAfter: 5 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler := &NamespaceReconciler{
+				Config: &config.ControllerConfig{ErrorBackoffBase: 5, ErrorBackoffMax: 60, ReconcileInterval: 600},
+			}
+			assert.Equal(t, tt.expected, reconciler.retryResult(context.Background(), "app", tt.err, testr.New(t)))
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+	"github.com/go-logr/logr"
+)
+
+// BlueprintReconciler provisions the resources a VaultNamespaceBlueprint
+// describes inside Vault namespaces. Every entry is written idempotently, so a
+// blueprint is applied again after a restart or a change, and never removes
+// what a tenant added. A nil BlueprintReconciler provisions nothing.
+type BlueprintReconciler struct {
+	// Reader reads VaultNamespaceBlueprints from the local cluster, also for the
+	// namespaces of remote clusters.
+	Reader      client.Reader
+	VaultClient vault.Client
+
+	mu sync.Mutex
+	// applied records the blueprint name and generation, and the digest of its
+	// rendered requests, last applied to each Vault namespace, keyed by
+	// connection and path, so unchanged blueprints are not written again.
+	applied map[string]string
+}
+
+// Reconcile applies the blueprint called name inside vaultNamespace, with its
+// requests rendered for data, unless that generation of it has been applied
+// already.
+func (b *BlueprintReconciler) Reconcile(ctx context.Context, vaultNamespace, name string, data config.NamespaceTemplateData, log logr.Logger) error {
+	if b == nil || name == "" {
+		return nil
+	}
+
+	blueprint := &vaultv1alpha1.VaultNamespaceBlueprint{}
+	if err := b.Reader.Get(ctx, types.NamespacedName{Name: name}, blueprint); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return fmt.Errorf("VaultNamespaceBlueprint %q not found", name)
+		}
+		return err
+	}
+	requests, err := renderRequests(blueprint.Spec.Requests, data)
+	if err != nil {
+		return fmt.Errorf("VaultNamespaceBlueprint %q: %w", name, err)
+	}
+	digest, err := json.Marshal(requests)
+	if err != nil {
+		return err
+	}
+	key := appliedKey(ctx, vaultNamespace)
+	applied := fmt.Sprintf("%s/%d/%x", blueprint.Name, blueprint.Generation, sha1.Sum(digest))
+	b.mu.Lock()
+	done := b.applied[key] == applied
+	b.mu.Unlock()
+	if done {
+		return nil
+	}
+
+	log = log.WithValues("blueprint", name)
+	log.Info("Provisioning Vault namespace from blueprint")
+	if err := b.apply(ctx, vaultNamespace, &blueprint.Spec, requests); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.applied == nil {
+		b.applied = make(map[string]string)
+	}
+	b.applied[key] = applied
+	return nil
+}
+
+// apply writes every entry of spec inside vaultNamespace, auth methods before
+// their roles, and its quotas, then sends the rendered requests.
+func (b *BlueprintReconciler) apply(ctx context.Context, vaultNamespace string, spec *vaultv1alpha1.VaultNamespaceBlueprintSpec, requests []blueprintRequest) error {
+	for _, policy := range spec.Policies {
+		if err := b.VaultClient.PutPolicy(ctx, vaultNamespace, policy.Name, policy.Policy); err != nil {
+			return err
+		}
+	}
+	for _, mount := range spec.SecretsEngines {
+		if err := b.VaultClient.EnsureSecretsEngine(ctx, vaultNamespace, mount.Path, mount.Type, mount.Description); err != nil {
+			return err
+		}
+	}
+	for _, mount := range spec.AuthMethods {
+		if err := b.VaultClient.EnsureAuthMethod(ctx, vaultNamespace, mount.Path, mount.Type, mount.Description); err != nil {
+			return err
+		}
+	}
+	for _, role := range spec.AuthRoles {
+		if err := b.VaultClient.WriteAuthRole(ctx, vaultNamespace, role.Mount, role.Name, role.Data); err != nil {
+			return err
+		}
+	}
+	if quota := spec.RateLimitQuota; quota != nil {
+		data := map[string]string{
+			"path": quotaPath(vaultNamespace),
+			"rate": strconv.Itoa(int(quota.Rate)),
+		}
+		if quota.Interval != "" {
+			data["interval"] = quota.Interval
+		}
+		if quota.BlockInterval != "" {
+			data["block_interval"] = quota.BlockInterval
+		}
+		if err := b.VaultClient.WriteQuota(ctx, quotaRateLimit, quotaName(vaultNamespace), data); err != nil {
+			return err
+		}
+	}
+	if quota := spec.LeaseCountQuota; quota != nil {
+		data := map[string]string{
+			"path":       quotaPath(vaultNamespace),
+			"max_leases": strconv.Itoa(int(quota.MaxLeases)),
+		}
+		if err := b.VaultClient.WriteQuota(ctx, quotaLeaseCount, quotaName(vaultNamespace), data); err != nil {
+			return err
+		}
+	}
+	for _, request := range requests {
+		if err := b.VaultClient.Request(ctx, vaultNamespace, request.Method, request.Path, request.Body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blueprintRequest is a BlueprintRequest rendered for a namespace.
+type blueprintRequest struct {
+	Method string
+	Path   string
+	Body   map[string]interface{}
+}
+
+// renderRequests executes the templates in the path and string payload values
+// of requests for data.
+func renderRequests(requests []vaultv1alpha1.BlueprintRequest, data config.NamespaceTemplateData) ([]blueprintRequest, error) {
+	rendered := make([]blueprintRequest, 0, len(requests))
+	for i, request := range requests {
+		path, err := renderString(request.Path, data)
+		if err != nil {
+			return nil, fmt.Errorf("requests[%d].path: %w", i, err)
+		}
+		var body map[string]interface{}
+		if request.Payload != nil && len(request.Payload.Raw) > 0 {
+			if err := json.Unmarshal(request.Payload.Raw, &body); err != nil {
+				return nil, fmt.Errorf("requests[%d].payload must be a JSON object: %w", i, err)
+			}
+			if _, err := renderValue(body, data); err != nil {
+				return nil, fmt.Errorf("requests[%d].payload: %w", i, err)
+			}
+		}
+		rendered = append(rendered, blueprintRequest{Method: request.Method, Path: path, Body: body})
+	}
+	return rendered, nil
+}
+
+// renderValue renders the strings in a decoded JSON value, replacing those
+// nested in objects and arrays in place.
+func renderValue(value interface{}, data config.NamespaceTemplateData) (interface{}, error) {
+	var err error
+	switch v := value.(type) {
+	case string:
+		return renderString(v, data)
+	case map[string]interface{}:
+		for key, element := range v {
+			if v[key], err = renderValue(element, data); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, element := range v {
+			if v[i], err = renderValue(element, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return value, nil
+}
+
+// renderString executes text as a namespace template for data.
+func renderString(text string, data config.NamespaceTemplateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := config.ParseNamespaceTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	err = tmpl.Execute(&b, data)
+	return b.String(), err
+}
+
+// Quota types a blueprint can set on a Vault namespace.
+const (
+	quotaRateLimit  = "rate-limit"
+	quotaLeaseCount = "lease-count"
+)
+
+// quotaTypes lists every quota type, for removing the quotas of a deleted Vault namespace.
+var quotaTypes = []string{quotaRateLimit, quotaLeaseCount}
+
+// quotaName returns the name of the quotas on vaultNamespace. Quotas live in
+// the namespace the controller authenticates in, so the name is the whole path.
+func quotaName(vaultNamespace string) string {
+	return strings.ReplaceAll(strings.Trim(vaultNamespace, "/"), "/", "_")
+}
+
+// quotaPath returns the path a quota on vaultNamespace applies to.
+func quotaPath(vaultNamespace string) string {
+	return strings.Trim(vaultNamespace, "/") + "/"
+}
+
+// RemoveQuotas deletes the quotas blueprints may have set on vaultNamespace,
+// which Vault keeps after the namespace itself is deleted.
+func (b *BlueprintReconciler) RemoveQuotas(ctx context.Context, vaultNamespace string) error {
+	if b == nil {
+		return nil
+	}
+	for _, quotaType := range quotaTypes {
+		if err := b.VaultClient.DeleteQuota(ctx, quotaType, quotaName(vaultNamespace)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Forget drops the record of what was applied to vaultNamespace, so its
+// blueprint is applied again in full, such as after it was recreated.
+func (b *BlueprintReconciler) Forget(ctx context.Context, vaultNamespace string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.applied, appliedKey(ctx, vaultNamespace))
+}
+
+// appliedKey identifies vaultNamespace in the Vault cluster ctx routes to.
+func appliedKey(ctx context.Context, vaultNamespace string) string {
+	return connectionFrom(ctx) + "|" + vaultNamespace
+}
+
+// provisionBlueprint applies the blueprint of a namespace's VaultNamespaceClass
+// inside its Vault namespace.
+func (r *NamespaceReconciler) provisionBlueprint(ctx context.Context, namespace metav1.Object, vaultNamespace string, log logr.Logger) error {
+	if r.Blueprints == nil {
+		return nil
+	}
+	class, err := r.Classes.Get(ctx, namespace)
+	if err != nil || class == nil || class.Spec.Blueprint == "" {
+		return err
+	}
+	if r.skipForDryRun(namespace.GetName(), "provision", vaultNamespace, log) {
+		return nil
+	}
+	if err := r.Blueprints.Reconcile(ctx, vaultNamespace, class.Spec.Blueprint, r.templateData(namespace), log); err != nil {
+		return err
+	}
+	return r.ensureBootstrapToken(ctx, namespace.GetName(), vaultNamespace, class.Spec.Blueprint, log)
+}
+
+// defaultBootstrapTokenSecret is the Secret a blueprint's bootstrap token is
+// written to by default.
+const defaultBootstrapTokenSecret = "vault-bootstrap-token"
+
+// ensureBootstrapToken creates the bootstrap token of the blueprint called
+// name in vaultNamespace and writes it into a Secret in namespaceName, unless
+// the Secret exists. The token is under the key token, or wrapping_token when
+// it is wrapped.
+func (r *NamespaceReconciler) ensureBootstrapToken(ctx context.Context, namespaceName, vaultNamespace, name string, log logr.Logger) error {
+	blueprint := &vaultv1alpha1.VaultNamespaceBlueprint{}
+	if err := r.Blueprints.Reader.Get(ctx, types.NamespacedName{Name: name}, blueprint); err != nil {
+		return err
+	}
+	spec := blueprint.Spec.BootstrapToken
+	if spec == nil {
+		return nil
+	}
+	secretName := spec.SecretName
+	if secretName == "" {
+		secretName = defaultBootstrapTokenSecret
+	}
+	err := r.uncachedReader().Get(ctx, types.NamespacedName{Namespace: namespaceName, Name: secretName}, &corev1.Secret{})
+	if !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	token, err := r.VaultClient.CreateToken(ctx, vaultNamespace, spec.Policies, spec.TTL, spec.WrapTTL)
+	if err != nil {
+		return err
+	}
+	key := "token"
+	if spec.WrapTTL != "" {
+		key = "wrapping_token"
+	}
+	log.Info("Writing bootstrap token", "secret", secretName, "wrapped", spec.WrapTTL != "")
+	return r.Client.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespaceName,
+			Name:      secretName,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": managedByValue},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{key: []byte(token)},
+	})
+}
@@ -0,0 +1,254 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestNamespaceReconciler_Blueprint tests that a namespace's Vault namespace is
+// provisioned from its class's blueprint once, and again after a change.
+func TestNamespaceReconciler_Blueprint(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = vaultv1alpha1.AddToScheme(scheme)
+
+	roleData := map[string]string{"bound_service_account_names": "app", "token_policies": "app-read"}
+	blueprint := &vaultv1alpha1.VaultNamespaceBlueprint{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-defaults"},
+		Spec: vaultv1alpha1.VaultNamespaceBlueprintSpec{
+			Policies:       []vaultv1alpha1.BlueprintPolicy{{Name: "app-read", Policy: `path "secret/*" {}`}},
+			SecretsEngines: []vaultv1alpha1.BlueprintMount{{Path: "secret", Type: "kv-v2"}},
+			AuthMethods:    []vaultv1alpha1.BlueprintMount{{Path: "kubernetes", Type: "kubernetes"}},
+			AuthRoles:      []vaultv1alpha1.BlueprintAuthRole{{Mount: "kubernetes", Name: "app", Data: roleData}},
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "app",
+			Annotations: map[string]string{vaultv1alpha1.ClassAnnotation: "tenant"},
+		}},
+		&vaultv1alpha1.VaultNamespaceClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant"},
+			Spec:       vaultv1alpha1.VaultNamespaceClassSpec{Blueprint: "team-defaults"},
+		},
+		blueprint,
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "app").Return(false, nil).Once()
+	mockClient.On("CreateNamespace", mock.Anything, "app", ownedMetadata("app")).Return(nil).Once()
+	mockClient.On("PutPolicy", mock.Anything, "app", "app-read", `path "secret/*" {}`).Return(nil).Once()
+	mockClient.On("EnsureSecretsEngine", mock.Anything, "app", "secret", "kv-v2", "").Return(nil).Once()
+	mockClient.On("EnsureAuthMethod", mock.Anything, "app", "kubernetes", "kubernetes", "").Return(nil).Once()
+	mockClient.On("WriteAuthRole", mock.Anything, "app", "kubernetes", "app", roleData).Return(nil).Once()
+
+	reconciler := &NamespaceReconciler{
+		Client:      k8sClient,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Classes:     &VaultNamespaceClasses{Reader: k8sClient},
+		Blueprints:  &BlueprintReconciler{Reader: k8sClient, VaultClient: mockClient},
+		Config:      &config.ControllerConfig{NamespaceClasses: true},
+		syncChecker: func(string) bool { return true },
+	}
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+
+	// An unchanged blueprint is not applied again
+	mockClient.On("NamespaceExists", mock.Anything, "app").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "app").Return(ownedMetadata("app"), nil)
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	mockClient.AssertNumberOfCalls(t, "PutPolicy", 1)
+
+	// A changed blueprint is, stopping at the first failure
+	blueprint.Spec.Policies[0].Policy = `path "kv/*" {}`
+	blueprint.Generation++
+	assert.NoError(t, k8sClient.Update(ctx, blueprint))
+	mockClient.On("PutPolicy", mock.Anything, "app", "app-read", `path "kv/*" {}`).Return(errors.New("permission denied")).Once()
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	mockClient.AssertNumberOfCalls(t, "PutPolicy", 2)
+	mockClient.AssertNumberOfCalls(t, "EnsureSecretsEngine", 1)
+}
+
+func TestBlueprintReconciler_MissingBlueprint(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = vaultv1alpha1.AddToScheme(scheme)
+
+	blueprints := &BlueprintReconciler{
+		Reader:      fake.NewClientBuilder().WithScheme(scheme).Build(),
+		VaultClient: new(mockVaultClient),
+	}
+	err := blueprints.Reconcile(context.Background(), "app", "missing", config.NamespaceTemplateData{}, testr.New(t))
+	assert.ErrorContains(t, err, `VaultNamespaceBlueprint "missing" not found`)
+
+	// A nil BlueprintReconciler provisions nothing
+	var none *BlueprintReconciler
+	assert.NoError(t, none.Reconcile(context.Background(), "app", "missing", config.NamespaceTemplateData{}, testr.New(t)))
+}
+
+// TestBlueprintReconciler_Quotas tests setting a blueprint's quotas on a Vault
+// namespace and removing them.
+func TestBlueprintReconciler_Quotas(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = vaultv1alpha1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&vaultv1alpha1.VaultNamespaceBlueprint{
+			ObjectMeta: metav1.ObjectMeta{Name: "limited"},
+			Spec: vaultv1alpha1.VaultNamespaceBlueprintSpec{
+				RateLimitQuota:  &vaultv1alpha1.BlueprintRateLimitQuota{Rate: 100, BlockInterval: "1m"},
+				LeaseCountQuota: &vaultv1alpha1.BlueprintLeaseCountQuota{MaxLeases: 5000},
+			},
+		},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("WriteQuota", mock.Anything, "rate-limit", "admin_app", map[string]string{
+		"path":           "admin/app/",
+		"rate":           "100",
+		"block_interval": "1m",
+	}).Return(nil).Once()
+	mockClient.On("WriteQuota", mock.Anything, "lease-count", "admin_app", map[string]string{
+		"path":       "admin/app/",
+		"max_leases": "5000",
+	}).Return(nil).Once()
+	mockClient.On("DeleteQuota", mock.Anything, "rate-limit", "admin_app").Return(nil).Once()
+	mockClient.On("DeleteQuota", mock.Anything, "lease-count", "admin_app").Return(nil).Once()
+
+	blueprints := &BlueprintReconciler{Reader: k8sClient, VaultClient: mockClient}
+	ctx := context.Background()
+	assert.NoError(t, blueprints.Reconcile(ctx, "/admin/app", "limited", config.NamespaceTemplateData{}, testr.New(t)))
+	assert.NoError(t, blueprints.RemoveQuotas(ctx, "/admin/app"))
+	mockClient.AssertExpectations(t)
+
+	// A nil BlueprintReconciler has no quotas to remove
+	var none *BlueprintReconciler
+	assert.NoError(t, none.RemoveQuotas(ctx, "/admin/app"))
+}
+
+// TestBlueprintReconciler_Requests tests that a blueprint's requests are
+// rendered for the namespace and sent again when the namespace changes.
+func TestBlueprintReconciler_Requests(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = vaultv1alpha1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&vaultv1alpha1.VaultNamespaceBlueprint{
+			ObjectMeta: metav1.ObjectMeta{Name: "pki"},
+			Spec: vaultv1alpha1.VaultNamespaceBlueprintSpec{
+				Requests: []vaultv1alpha1.BlueprintRequest{
+					{Method: "POST", Path: "pki/root/generate/internal", Payload: &runtime.RawExtension{
+						Raw: []byte(`{"common_name": "{{ .Name }}.{{ .Labels.team }}.example.com", "ttl": 8760, "alt_names": ["{{ .Name }}"]}`),
+					}},
+					{Method: "DELETE", Path: "pki/roles/{{ .Name }}-legacy"},
+				},
+			},
+		},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("Request", mock.Anything, "app", "POST", "pki/root/generate/internal", map[string]interface{}{
+		"common_name": "app.payments.example.com",
+		"ttl":         float64(8760),
+		"alt_names":   []interface{}{"app"},
+	}).Return(nil).Once()
+	mockClient.On("Request", mock.Anything, "app", "DELETE", "pki/roles/app-legacy", map[string]interface{}(nil)).Return(nil).Twice()
+
+	blueprints := &BlueprintReconciler{Reader: k8sClient, VaultClient: mockClient}
+	ctx := context.Background()
+	data := config.NamespaceTemplateData{Name: "app", Labels: map[string]string{"team": "payments"}}
+	assert.NoError(t, blueprints.Reconcile(ctx, "app", "pki", data, testr.New(t)))
+
+	// Unchanged requests are not sent again
+	assert.NoError(t, blueprints.Reconcile(ctx, "app", "pki", data, testr.New(t)))
+
+	// A changed label renders different requests, which are sent again
+	mockClient.On("Request", mock.Anything, "app", "POST", "pki/root/generate/internal", map[string]interface{}{
+		"common_name": "app.billing.example.com",
+		"ttl":         float64(8760),
+		"alt_names":   []interface{}{"app"},
+	}).Return(nil).Once()
+	data.Labels = map[string]string{"team": "billing"}
+	assert.NoError(t, blueprints.Reconcile(ctx, "app", "pki", data, testr.New(t)))
+	mockClient.AssertExpectations(t)
+
+	// A template referencing a missing label fails
+	err := blueprints.Reconcile(ctx, "app", "pki", config.NamespaceTemplateData{Name: "app"}, testr.New(t))
+	assert.ErrorContains(t, err, "requests[0].payload")
+}
+
+// TestNamespaceReconciler_BootstrapToken tests that a blueprint's bootstrap
+// token is written into a Secret once.
+func TestNamespaceReconciler_BootstrapToken(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = vaultv1alpha1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "app",
+			Annotations: map[string]string{vaultv1alpha1.ClassAnnotation: "tenant"},
+		}},
+		&vaultv1alpha1.VaultNamespaceClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant"},
+			Spec:       vaultv1alpha1.VaultNamespaceClassSpec{Blueprint: "bootstrap"},
+		},
+		&vaultv1alpha1.VaultNamespaceBlueprint{
+			ObjectMeta: metav1.ObjectMeta{Name: "bootstrap"},
+			Spec: vaultv1alpha1.VaultNamespaceBlueprintSpec{
+				BootstrapToken: &vaultv1alpha1.BlueprintBootstrapToken{
+					Policies: []string{"bootstrap"},
+					TTL:      "1h",
+					WrapTTL:  "10m",
+				},
+			},
+		},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "app").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "app").Return(ownedMetadata("app"), nil)
+	mockClient.On("CreateToken", mock.Anything, "app", []string{"bootstrap"}, "1h", "10m").Return("hvs.wrapped", nil).Once()
+
+	reconciler := &NamespaceReconciler{
+		Client:      k8sClient,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Classes:     &VaultNamespaceClasses{Reader: k8sClient},
+		Blueprints:  &BlueprintReconciler{Reader: k8sClient, VaultClient: mockClient},
+		Config:      &config.ControllerConfig{NamespaceClasses: true},
+		syncChecker: func(string) bool { return true },
+	}
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	secret := &corev1.Secret{}
+	assert.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Namespace: "app", Name: "vault-bootstrap-token"}, secret))
+	assert.Equal(t, map[string][]byte{"wrapping_token": []byte("hvs.wrapped")}, secret.Data)
+
+	// No further token is created while the Secret exists
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/go-logr/logr"
+)
+
+// KubernetesAPI is the API server of a cluster, which the kubernetes auth
+// method bootstrapped in its Vault namespaces validates ServiceAccount tokens against.
+type KubernetesAPI struct {
+	Host      string
+	CACertPEM string
+}
+
+// bootstrapEnabled reports whether new Vault namespaces are bootstrapped.
+func (r *NamespaceReconciler) bootstrapEnabled() bool {
+	bootstrap := r.Config.Bootstrap
+	return bootstrap.KVMount != "" || bootstrap.KubernetesAuth.Enabled || bootstrap.AuditDevice.Type != ""
+}
+
+// markForBootstrap records that vaultNamespace was just created and still has
+// to be bootstrapped. Only Vault namespaces the controller created are
+// bootstrapped, so existing ones and mounts their tenants removed are left alone.
+func (r *NamespaceReconciler) markForBootstrap(ctx context.Context, vaultNamespace string) {
+	if !r.bootstrapEnabled() {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.unbootstrapped == nil {
+		r.unbootstrapped = make(map[string]bool)
+	}
+	r.unbootstrapped[appliedKey(ctx, vaultNamespace)] = true
+}
+
+// bootstrapVaultNamespace sets up the configured audit device, secrets engine
+// and auth method inside a Vault namespace created by the controller for
+// namespace. A failed bootstrap is retried by the next reconcile.
+func (r *NamespaceReconciler) bootstrapVaultNamespace(ctx context.Context, namespace metav1.Object, vaultNamespace string, log logr.Logger) error {
+	key := appliedKey(ctx, vaultNamespace)
+	r.mu.Lock()
+	pending := r.unbootstrapped[key]
+	r.mu.Unlock()
+	if !pending {
+		return nil
+	}
+
+	bootstrap := r.Config.Bootstrap
+	log.Info("Bootstrapping Vault namespace", "kvMount", bootstrap.KVMount,
+		"kubernetesAuth", bootstrap.KubernetesAuth.Enabled, "auditDevice", bootstrap.AuditDevice.Type)
+	if bootstrap.AuditDevice.Type != "" {
+		if err := r.bootstrapAuditDevice(ctx, namespace, vaultNamespace); err != nil {
+			return err
+		}
+	}
+	if kvMount := strings.Trim(bootstrap.KVMount, "/"); kvMount != "" {
+		if err := r.VaultClient.EnsureSecretsEngine(ctx, vaultNamespace, kvMount, "kv-v2",
+			"Key/value secrets, enabled by vault-namespace-controller"); err != nil {
+			return err
+		}
+	}
+	if bootstrap.KubernetesAuth.Enabled {
+		if err := r.bootstrapKubernetesAuth(ctx, namespace.GetName(), vaultNamespace); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.unbootstrapped, key)
+	return nil
+}
+
+// bootstrapAuditDevice enables the audit device inside vaultNamespace, with
+// its options rendered for namespace.
+func (r *NamespaceReconciler) bootstrapAuditDevice(ctx context.Context, namespace metav1.Object, vaultNamespace string) error {
+	audit := r.Config.Bootstrap.AuditDevice
+	options := make(map[string]string, len(audit.Options))
+	for key, value := range audit.Options {
+		rendered, err := renderString(value, r.templateData(namespace))
+		if err != nil {
+			return fmt.Errorf("audit device option %s: %w", key, err)
+		}
+		options[key] = rendered
+	}
+	return r.VaultClient.EnsureAuditDevice(ctx, vaultNamespace, audit.DevicePath(), audit.Type,
+		"Kubernetes namespace "+namespace.GetName()+", enabled by vault-namespace-controller", options)
+}
+
+// bootstrapKubernetesAuth enables and configures the kubernetes auth method
+// inside vaultNamespace, with a role for the ServiceAccounts of namespaceName.
+func (r *NamespaceReconciler) bootstrapKubernetesAuth(ctx context.Context, namespaceName, vaultNamespace string) error {
+	if r.KubernetesAPI == nil {
+		return errors.New("kubernetes auth bootstrap requires the Kubernetes API server")
+	}
+	auth := r.Config.Bootstrap.KubernetesAuth
+	mount := auth.MountPath()
+
+	if err := r.VaultClient.EnsureAuthMethod(ctx, vaultNamespace, mount, "kubernetes",
+		"Kubernetes namespace "+namespaceName+", enabled by vault-namespace-controller"); err != nil {
+		return err
+	}
+	authConfig := map[string]string{"kubernetes_host": r.KubernetesAPI.Host}
+	if r.KubernetesAPI.CACertPEM != "" {
+		authConfig["kubernetes_ca_cert"] = r.KubernetesAPI.CACertPEM
+	}
+	if err := r.VaultClient.WriteAuthConfig(ctx, vaultNamespace, mount, authConfig); err != nil {
+		return err
+	}
+
+	role := map[string]string{
+		"bound_service_account_names":      strings.Join(auth.BoundServiceAccounts(), ","),
+		"bound_service_account_namespaces": namespaceName,
+	}
+	if len(auth.Policies) > 0 {
+		role["token_policies"] = strings.Join(auth.Policies, ",")
+	}
+	if auth.TokenTTL != "" {
+		role["token_ttl"] = auth.TokenTTL
+	}
+	return r.VaultClient.WriteAuthRole(ctx, vaultNamespace, mount, auth.RoleName(), role)
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestNamespaceReconciler_Bootstrap tests that Vault namespaces the controller
+// creates get the configured kv-v2 mount, until it is enabled once.
+func TestNamespaceReconciler_Bootstrap(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "legacy"}},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "app").Return(false, nil).Once()
+	mockClient.On("CreateNamespace", mock.Anything, "app", ownedMetadata("app")).Return(nil).Once()
+	mockClient.On("EnsureSecretsEngine", mock.Anything, "app", "secret", "kv-v2", mock.Anything).
+		Return(errors.New("permission denied")).Once()
+
+	reconciler := &NamespaceReconciler{
+		Client:      k8sClient,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Config:      &config.ControllerConfig{Bootstrap: config.BootstrapConfig{KVMount: "/secret/"}},
+		syncChecker: func(string) bool { return true },
+	}
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+
+	// A failed bootstrap is retried although the Vault namespace exists now
+	mockClient.On("NamespaceExists", mock.Anything, "app").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "app").Return(ownedMetadata("app"), nil)
+	mockClient.On("EnsureSecretsEngine", mock.Anything, "app", "secret", "kv-v2", mock.Anything).Return(nil).Once()
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+
+	// A bootstrapped Vault namespace is left alone
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	mockClient.AssertNumberOfCalls(t, "EnsureSecretsEngine", 2)
+
+	// Vault namespaces the controller did not create are not bootstrapped
+	mockClient.On("NamespaceExists", mock.Anything, "legacy").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "legacy").Return(ownedMetadata("legacy"), nil)
+	_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "legacy"}})
+	assert.NoError(t, err)
+	mockClient.AssertNumberOfCalls(t, "EnsureSecretsEngine", 2)
+}
+
+// TestNamespaceReconciler_BootstrapKubernetesAuth tests enabling the kubernetes
+// auth method with a role for the namespace's ServiceAccounts.
+func TestNamespaceReconciler_BootstrapKubernetesAuth(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "app").Return(false, nil).Once()
+	mockClient.On("CreateNamespace", mock.Anything, "app", ownedMetadata("app")).Return(nil).Once()
+	mockClient.On("EnsureAuthMethod", mock.Anything, "app", "k8s", "kubernetes", mock.Anything).Return(nil).Once()
+	mockClient.On("WriteAuthConfig", mock.Anything, "app", "k8s", map[string]string{
+		"kubernetes_host":    "https://kubernetes.example.com",
+		"kubernetes_ca_cert": "-----BEGIN CERTIFICATE-----",
+	}).Return(nil).Once()
+	mockClient.On("WriteAuthRole", mock.Anything, "app", "k8s", "default", map[string]string{
+		"bound_service_account_names":      "*",
+		"bound_service_account_namespaces": "app",
+		"token_policies":                   "app-read,app-write",
+		"token_ttl":                        "1h",
+	}).Return(nil).Once()
+
+	reconciler := &NamespaceReconciler{
+		Client:      k8sClient,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{Bootstrap: config.BootstrapConfig{
+			KubernetesAuth: config.KubernetesAuthBootstrapConfig{
+				Enabled:  true,
+				Mount:    "k8s",
+				Policies: []string{"app-read", "app-write"},
+				TokenTTL: "1h",
+			},
+		}},
+		KubernetesAPI: &KubernetesAPI{Host: "https://kubernetes.example.com", CACertPEM: "-----BEGIN CERTIFICATE-----"},
+		syncChecker:   func(string) bool { return true },
+	}
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}})
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+// TestNamespaceReconciler_BootstrapAuditDevice tests enabling an audit device
+// with options rendered for the namespace.
+func TestNamespaceReconciler_BootstrapAuditDevice(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app", Labels: map[string]string{"team": "payments"}}},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "app").Return(false, nil).Once()
+	mockClient.On("CreateNamespace", mock.Anything, "app", ownedMetadata("app")).Return(nil).Once()
+	mockClient.On("EnsureAuditDevice", mock.Anything, "app", "file", "file", mock.Anything, map[string]string{
+		"file_path": "/vault/audit/payments/app.log",
+		"mode":      "0600",
+	}).Return(nil).Once()
+
+	reconciler := &NamespaceReconciler{
+		Client:      k8sClient,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{Bootstrap: config.BootstrapConfig{
+			AuditDevice: config.AuditDeviceBootstrapConfig{
+				Type: "file",
+				Options: map[string]string{
+					"file_path": "/vault/audit/{{ .Labels.team }}/{{ .Name }}.log",
+					"mode":      "0600",
+				},
+			},
+		}},
+		syncChecker: func(string) bool { return true },
+	}
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}})
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	// capsuleTenantLabel is the label Capsule puts on every namespace a Tenant owns.
+	capsuleTenantLabel = "capsule.clastix.io/tenant"
+
+	// MetadataCapsuleTenant marks a Vault namespace created as the parent of a
+	// Capsule Tenant's namespaces, recording the Tenant name.
+	MetadataCapsuleTenant = "capsule-tenant"
+)
+
+// capsuleTenant returns the Capsule Tenant owning a namespace, or "" when
+// CapsuleTenants is disabled or the namespace belongs to no Tenant.
+func (r *NamespaceReconciler) capsuleTenant(namespace metav1.Object) string {
+	if !r.Config.CapsuleTenants {
+		return ""
+	}
+	return namespace.GetLabels()[capsuleTenantLabel]
+}
+
+// withTenant nests a Vault namespace path in a Vault namespace named after the
+// Capsule Tenant owning the namespace, next to where it would otherwise be.
+func (r *NamespaceReconciler) withTenant(namespace metav1.Object, vaultNamespace string) string {
+	tenant := r.capsuleTenant(namespace)
+	if tenant == "" {
+		return vaultNamespace
+	}
+	parent, leaf := splitVaultPath(vaultNamespace)
+	tenantNamespace := r.truncateNames(tenant)
+	if parent != "" {
+		tenantNamespace = parent + "/" + tenantNamespace
+	}
+	if strings.HasPrefix(vaultNamespace, "/") {
+		tenantNamespace = "/" + tenantNamespace
+	}
+	return tenantNamespace + "/" + leaf
+}
+
+// ensureTenantNamespace creates the Vault namespace of the Capsule Tenant owning
+// a namespace, so the namespace's own Vault namespace can be created inside it.
+func (r *NamespaceReconciler) ensureTenantNamespace(ctx context.Context, namespace metav1.Object, vaultNamespace string, log logr.Logger) error {
+	tenant := r.capsuleTenant(namespace)
+	if tenant == "" {
+		return nil
+	}
+
+	tenantNamespace, _ := splitVaultPath(vaultNamespace)
+	customMetadata := r.containerMetadata()
+	customMetadata[MetadataCapsuleTenant] = tenant
+	return r.ensureContainerNamespace(ctx, namespace.GetName(), tenantNamespace, customMetadata,
+		log.WithValues("tenant", tenant))
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+func TestNamespaceReconciler_withTenant(t *testing.T) {
+	tenantNamespace := &metav1.ObjectMeta{Name: "oil-dev", Labels: map[string]string{capsuleTenantLabel: "oil"}}
+	plainNamespace := &metav1.ObjectMeta{Name: "plain"}
+
+	tests := []struct {
+		name      string
+		enabled   bool
+		namespace metav1.Object
+		path      string
+		expected  string
+	}{
+		{
+			name:      "tenant namespace is nested",
+			enabled:   true,
+			namespace: tenantNamespace,
+			path:      "/admin/k8s-oil-dev",
+			expected:  "/admin/oil/k8s-oil-dev",
+		},
+		{
+			name:      "tenant namespace without a root",
+			enabled:   true,
+			namespace: tenantNamespace,
+			path:      "oil-dev",
+			expected:  "oil/oil-dev",
+		},
+		{
+			name:      "namespace without a tenant is unchanged",
+			enabled:   true,
+			namespace: plainNamespace,
+			path:      "/admin/plain",
+			expected:  "/admin/plain",
+		},
+		{
+			name:      "tenants are ignored unless enabled",
+			enabled:   false,
+			namespace: tenantNamespace,
+			path:      "/admin/k8s-oil-dev",
+			expected:  "/admin/k8s-oil-dev",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &NamespaceReconciler{
+				Config: &config.ControllerConfig{CapsuleTenants: tt.enabled},
+			}
+			assert.Equal(t, tt.expected, r.withTenant(tt.namespace, tt.path))
+		})
+	}
+}
+
+// TestNamespaceReconciler_CapsuleTenants tests that the Tenant's Vault namespace
+// is created before the namespace's own.
+func TestNamespaceReconciler_CapsuleTenants(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "oil").Return(false, nil)
+	mockClient.On("CreateNamespace", mock.Anything, "oil", map[string]string{
+		MetadataManagedBy:         "vault-namespace-controller",
+		MetadataKubernetesCluster: "",
+		MetadataCapsuleTenant:     "oil",
+	}).Return(nil)
+	mockClient.On("NamespaceExists", mock.Anything, "oil/oil-dev").Return(false, nil)
+	mockClient.On("CreateNamespace", mock.Anything, "oil/oil-dev", ownedMetadata("oil-dev")).Return(nil)
+
+	r := &NamespaceReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "oil-dev", Labels: map[string]string{capsuleTenantLabel: "oil"}},
+		}).Build(),
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{
+			NamespaceFormat: "%s",
+			CapsuleTenants:  true,
+		},
+	}
+
+	_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "oil-dev"}})
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
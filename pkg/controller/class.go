@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"text/template"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// VaultNamespaceClasses resolves the VaultNamespaceClass a namespace selects with
+// the vault.benemon.io/class annotation. A nil VaultNamespaceClasses resolves no
+// class, leaving every namespace to the controller's configuration.
+type VaultNamespaceClasses struct {
+	// Reader reads VaultNamespaceClasses from the local cluster, also for the
+	// namespaces of remote clusters.
+	Reader client.Reader
+
+	mu sync.Mutex
+	// templates caches compiled path templates by class name.
+	templates map[string]classTemplate
+}
+
+// classTemplate is the compiled path template of a generation of a class.
+type classTemplate struct {
+	generation int64
+	template   *template.Template
+	err        error
+}
+
+// Get returns the class a namespace selects, or nil when it selects none. A
+// class that does not exist (yet) is an error that is worth retrying.
+func (c *VaultNamespaceClasses) Get(ctx context.Context, namespace metav1.Object) (*vaultv1alpha1.VaultNamespaceClass, error) {
+	if c == nil {
+		return nil, nil
+	}
+	name := namespace.GetAnnotations()[vaultv1alpha1.ClassAnnotation]
+	if name == "" {
+		return nil, nil
+	}
+
+	class := &vaultv1alpha1.VaultNamespaceClass{}
+	if err := c.Reader.Get(ctx, types.NamespacedName{Name: name}, class); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, fmt.Errorf("VaultNamespaceClass %q not found", name)
+		}
+		return nil, err
+	}
+	return class, nil
+}
+
+// pathTemplate returns the compiled path template of class, or nil when it has
+// none. Templates are compiled once per class generation.
+func (c *VaultNamespaceClasses) pathTemplate(class *vaultv1alpha1.VaultNamespaceClass) (*template.Template, error) {
+	if class.Spec.PathTemplate == "" {
+		return nil, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.templates[class.Name]; ok && cached.generation == class.Generation {
+		return cached.template, cached.err
+	}
+	compiled := classTemplate{generation: class.Generation}
+	compiled.template, compiled.err = config.ParseNamespaceTemplate(class.Spec.PathTemplate)
+	if compiled.err != nil {
+		compiled.err = fmt.Errorf("%w: VaultNamespaceClass %s: %w", ErrInvalidNamespacePath, class.Name, compiled.err)
+	}
+	if c.templates == nil {
+		c.templates = make(map[string]classTemplate)
+	}
+	c.templates[class.Name] = compiled
+	return compiled.template, compiled.err
+}
+
+// classPath maps a namespace to its Vault namespace path under class: its path
+// template, or NamespaceFormat without one, below the class's parent when set.
+func (r *NamespaceReconciler) classPath(namespace metav1.Object, class *vaultv1alpha1.VaultNamespaceClass) (string, error) {
+	root := class.Spec.Parent
+	if root == "" {
+		root = r.namespaceRootFor(namespace)
+	}
+
+	tmpl, err := r.Classes.pathTemplate(class)
+	if err != nil {
+		return "", err
+	}
+	if tmpl == nil {
+		return joinNamespaceRoot(root, r.formatNamespaceName(namespace.GetName())), nil
+	}
+	formatted, err := r.executePathTemplate(tmpl, namespace)
+	if err != nil {
+		return "", err
+	}
+	return joinNamespaceRoot(root, r.truncateNames(formatted)), nil
+}
+
+// rememberClass records the class a synchronized namespace selects, so its
+// deletion policy still applies once the namespace and its annotations are gone.
+func (r *NamespaceReconciler) rememberClass(namespaceName, className string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if className == "" {
+		delete(r.classes, namespaceName)
+		return
+	}
+	if r.classes == nil {
+		r.classes = make(map[string]string)
+	}
+	r.classes[namespaceName] = className
+}
+
+// deleteVaultNamespace reports whether the Vault namespace of a deleted namespace
+// is deleted: as its class's deletion policy says, else as DeleteVaultNamespaces
+// says. A class deleted in the meantime no longer overrides the configuration.
+func (r *NamespaceReconciler) deleteVaultNamespace(ctx context.Context, namespaceName string) (bool, error) {
+	r.mu.Lock()
+	className, ok := r.classes[namespaceName]
+	r.mu.Unlock()
+
+	if !ok || r.Classes == nil {
+		return r.deleteVaultNamespaces(), nil
+	}
+	class := &vaultv1alpha1.VaultNamespaceClass{}
+	if err := r.Classes.Reader.Get(ctx, types.NamespacedName{Name: className}, class); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return r.deleteVaultNamespaces(), nil
+		}
+		return false, err
+	}
+	switch class.Spec.DeletionPolicy {
+	case vaultv1alpha1.DeletionPolicyDelete:
+		return true, nil
+	case vaultv1alpha1.DeletionPolicyRetain:
+		return false, nil
+	}
+	return r.deleteVaultNamespaces(), nil
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+func TestNamespaceReconciler_classPath(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = vaultv1alpha1.AddToScheme(scheme)
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&vaultv1alpha1.VaultNamespaceClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "regulated"},
+			Spec: vaultv1alpha1.VaultNamespaceClassSpec{
+				Parent:       "admin/regulated",
+				PathTemplate: `{{ index .Labels "team" }}-{{ .Name }}`,
+			},
+		},
+		&vaultv1alpha1.VaultNamespaceClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "plain"},
+		},
+		&vaultv1alpha1.VaultNamespaceClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "broken"},
+			Spec:       vaultv1alpha1.VaultNamespaceClassSpec{PathTemplate: "{{ .Name"},
+		},
+	).Build()
+
+	r := &NamespaceReconciler{
+		Config: &config.ControllerConfig{
+			NamespaceFormat:   "k8s-%s",
+			NamespaceTemplate: "apps/{{ .Name }}",
+			Vault:             config.VaultConfig{NamespaceRoot: "admin"},
+		},
+		Classes: &VaultNamespaceClasses{Reader: k8sClient},
+	}
+
+	tests := []struct {
+		name     string
+		class    string
+		expected string
+		wantErr  bool
+		invalid  bool
+	}{
+		{name: "no class uses the template", expected: "admin/apps/payments"},
+		{name: "class template and parent", class: "regulated", expected: "admin/regulated/finance-payments"},
+		{name: "class without template uses the format", class: "plain", expected: "admin/k8s-payments"},
+		{name: "invalid class template", class: "broken", wantErr: true, invalid: true},
+		{name: "missing class", class: "missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace := &metav1.ObjectMeta{
+				Name:   "payments",
+				Labels: map[string]string{"team": "finance"},
+			}
+			if tt.class != "" {
+				namespace.Annotations = map[string]string{vaultv1alpha1.ClassAnnotation: tt.class}
+			}
+
+			result, err := r.vaultNamespacePath(context.Background(), namespace)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.invalid, errors.Is(err, ErrInvalidNamespacePath))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// TestNamespaceReconciler_ClassDeletionPolicy tests that a class's deletion
+// policy overrides DeleteVaultNamespaces.
+func TestNamespaceReconciler_ClassDeletionPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		policy  vaultv1alpha1.DeletionPolicy
+		global  bool
+		deleted bool
+	}{
+		{policy: vaultv1alpha1.DeletionPolicyRetain, global: true, deleted: false},
+		{policy: vaultv1alpha1.DeletionPolicyDelete, global: false, deleted: true},
+		{policy: "", global: true, deleted: true},
+	} {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = corev1.AddToScheme(scheme)
+			_ = vaultv1alpha1.AddToScheme(scheme)
+
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        "app",
+				Annotations: map[string]string{vaultv1alpha1.ClassAnnotation: "tenant"},
+			}}
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				namespace,
+				&vaultv1alpha1.VaultNamespaceClass{
+					ObjectMeta: metav1.ObjectMeta{Name: "tenant"},
+					Spec:       vaultv1alpha1.VaultNamespaceClassSpec{DeletionPolicy: tt.policy},
+				},
+			).Build()
+
+			mockClient := new(mockVaultClient)
+			mockClient.On("NamespaceExists", mock.Anything, "app").Return(false, nil).Once()
+			mockClient.On("CreateNamespace", mock.Anything, "app", ownedMetadata("app")).Return(nil).Once()
+
+			reconciler := &NamespaceReconciler{
+				Client:      k8sClient,
+				Log:         testr.New(t),
+				Scheme:      scheme,
+				VaultClient: mockClient,
+				Classes:     &VaultNamespaceClasses{Reader: k8sClient},
+				Config: &config.ControllerConfig{
+					NamespaceClasses:      true,
+					DeleteVaultNamespaces: tt.global,
+				},
+				syncChecker: func(string) bool { return true },
+			}
+			ctx := context.Background()
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}}
+
+			_, err := reconciler.Reconcile(ctx, req)
+			assert.NoError(t, err)
+
+			if tt.deleted {
+				mockClient.On("NamespaceExists", mock.Anything, "app").Return(true, nil).Once()
+				mockClient.On("GetNamespaceMetadata", mock.Anything, "app").Return(ownedMetadata("app"), nil).Once()
+				mockClient.On("NamespaceEmpty", mock.Anything, "app").Return(true, nil).Once()
+				mockClient.On("DeleteNamespace", mock.Anything, "app").Return(nil).Once()
+			}
+			assert.NoError(t, k8sClient.Delete(ctx, namespace))
+			_, err = reconciler.Reconcile(ctx, req)
+			assert.NoError(t, err)
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+	"github.com/go-logr/logr"
+)
+
+// CloudEventTypePrefix prefixes the type attribute of the emitted events,
+// e.g. io.github.benemon.vault-namespace-controller.created.
+const CloudEventTypePrefix = "io.github.benemon.vault-namespace-controller."
+
+// cloudEventQueueSize is how many events wait for delivery before further
+// ones are dropped.
+const cloudEventQueueSize = 1000
+
+// cloudEventAttempts is how often an event is tried before it is dropped.
+const cloudEventAttempts = 3
+
+// Reasons drift is detected, as in the drift detected metric.
+const (
+	DriftMissing      = "missing"
+	DriftPathMismatch = "path_mismatch"
+)
+
+// CloudEventData is the data of the emitted events.
+type CloudEventData struct {
+	Namespace      string `json:"namespace"`
+	VaultNamespace string `json:"vaultNamespace"`
+	// ExpectedVaultNamespace is the path a Vault namespace found at another
+	// path was expected at.
+	ExpectedVaultNamespace string `json:"expectedVaultNamespace,omitempty"`
+	// Reason is the kind of drift detected: missing or path_mismatch.
+	Reason     string `json:"reason,omitempty"`
+	Cluster    string `json:"cluster,omitempty"`
+	Connection string `json:"connection,omitempty"`
+}
+
+// CloudEvents emits CloudEvents 1.0 in the binary content mode of the HTTP
+// protocol binding: the attributes are sent as ce- headers and the data as a
+// JSON body. Events are delivered in the background, in order, and dropped
+// after failing cloudEventAttempts times.
+type CloudEvents struct {
+	Config     config.CloudEventsConfig
+	Source     string
+	HTTPClient *http.Client
+	Log        logr.Logger
+
+	queue chan cloudEvent
+	// retryDelay is the delay before the second attempt, doubling with every further one.
+	retryDelay time.Duration
+}
+
+// cloudEvent is an event waiting for delivery.
+type cloudEvent struct {
+	id            string
+	eventType     string
+	subject       string
+	time          time.Time
+	correlationID string
+	data          []byte
+}
+
+// NewCloudEvents returns the configured CloudEvents emitter, or nil when no
+// sink is configured.
+func NewCloudEvents(cfg config.CloudEventsConfig, clusterName string, log logr.Logger) *CloudEvents {
+	if !cfg.Enabled() {
+		return nil
+	}
+	source := cfg.Source
+	if source == "" {
+		source = "vault-namespace-controller"
+		if clusterName != "" {
+			source += "/" + clusterName
+		}
+	}
+	return &CloudEvents{
+		Config:     cfg,
+		Source:     source,
+		HTTPClient: &http.Client{},
+		Log:        log,
+		queue:      make(chan cloudEvent, cloudEventQueueSize),
+		retryDelay: time.Second,
+	}
+}
+
+// Emit queues event about the Kubernetes namespace data describes, when it is
+// emitted, sending the correlation ID of ctx as the correlationid extension.
+func (c *CloudEvents) Emit(ctx context.Context, event string, data CloudEventData) {
+	if c == nil || !c.Config.Emits(event) {
+		return
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		c.Log.Error(err, "Failed to encode CloudEvent", "event", event)
+		return
+	}
+	ce := cloudEvent{
+		id:            string(uuid.NewUUID()),
+		eventType:     CloudEventTypePrefix + event,
+		subject:       data.Namespace,
+		time:          time.Now().UTC(),
+		correlationID: vault.CorrelationID(ctx),
+		data:          body,
+	}
+	select {
+	case c.queue <- ce:
+	default:
+		c.Log.Info("Too many CloudEvents waiting, dropping event", "type", ce.eventType, "kubernetesNamespace", data.Namespace)
+		metrics.CloudEventsTotal.WithLabelValues(ce.eventType, "dropped").Inc()
+	}
+}
+
+// Start delivers queued events until ctx is cancelled.
+func (c *CloudEvents) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			if pending := len(c.queue); pending > 0 {
+				c.Log.Info("Stopping with undelivered CloudEvents", "events", pending)
+			}
+			return nil
+		case ce := <-c.queue:
+			if err := c.deliver(ctx, ce); err != nil {
+				c.Log.Error(err, "Failed to emit CloudEvent, dropping it", "type", ce.eventType, "id", ce.id)
+				metrics.CloudEventsTotal.WithLabelValues(ce.eventType, "error").Inc()
+				continue
+			}
+			metrics.CloudEventsTotal.WithLabelValues(ce.eventType, "success").Inc()
+		}
+	}
+}
+
+// NeedLeaderElection reports that every replica emits the events of the
+// namespaces it reconciles.
+func (c *CloudEvents) NeedLeaderElection() bool {
+	return false
+}
+
+// deliver POSTs ce, retrying failed attempts with a doubling delay.
+func (c *CloudEvents) deliver(ctx context.Context, ce cloudEvent) error {
+	delay := c.retryDelay
+	var err error
+	for attempt := 1; attempt <= cloudEventAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%w (last error: %w)", ctx.Err(), err)
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+		if err = c.post(ctx, ce); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed after %d attempts: %w", cloudEventAttempts, err)
+}
+
+func (c *CloudEvents) post(ctx context.Context, ce cloudEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, c.Config.Timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Config.Sink, bytes.NewReader(ce.data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("ce-specversion", "1.0")
+	req.Header.Set("ce-id", ce.id)
+	req.Header.Set("ce-source", c.Source)
+	req.Header.Set("ce-type", ce.eventType)
+	req.Header.Set("ce-subject", ce.subject)
+	req.Header.Set("ce-time", ce.time.Format(time.RFC3339Nano))
+	if ce.correlationID != "" {
+		req.Header.Set("ce-correlationid", ce.correlationID)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// emitCloudEvent emits event about the Kubernetes namespace data describes,
+// adding the cluster and connection of ctx.
+func (r *NamespaceReconciler) emitCloudEvent(ctx context.Context, event string, data CloudEventData) {
+	if r.CloudEvents == nil {
+		return
+	}
+	data.Cluster = r.Config.ClusterName
+	data.Connection = connectionFrom(ctx)
+	r.CloudEvents.Emit(ctx, event, data)
+}
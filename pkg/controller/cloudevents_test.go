@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+)
+
+// cloudEventSink records the events POSTed to it and answers with the next
+// of statuses, then with 202.
+type cloudEventSink struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	headers  []http.Header
+	data     []CloudEventData
+}
+
+func newCloudEventSink(t *testing.T, statuses ...int) *cloudEventSink {
+	s := &cloudEventSink{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var data CloudEventData
+		assert.NoError(t, json.Unmarshal(body, &data))
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.headers = append(s.headers, r.Header.Clone())
+		s.data = append(s.data, data)
+		status := http.StatusAccepted
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *cloudEventSink) received() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.data)
+}
+
+// TestCloudEvents_Emit tests the attributes and data of emitted events, retries
+// of failed deliveries and event filtering.
+func TestCloudEvents_Emit(t *testing.T) {
+	sink := newCloudEventSink(t, http.StatusServiceUnavailable)
+	cloudEvents := NewCloudEvents(config.CloudEventsConfig{
+		Sink:   sink.URL,
+		Events: []string{config.CloudEventCreated, config.CloudEventDriftDetected},
+	}, "east", testr.New(t))
+	cloudEvents.retryDelay = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = cloudEvents.Start(ctx) }()
+
+	reconciler := &NamespaceReconciler{
+		Config:      &config.ControllerConfig{ClusterName: "east"},
+		CloudEvents: cloudEvents,
+	}
+	eventCtx := vault.WithCorrelationID(ctx, "3f2b8c1e")
+	reconciler.emitCloudEvent(eventCtx, config.CloudEventDeleted, CloudEventData{Namespace: "old", VaultNamespace: "old"})
+	reconciler.emitCloudEvent(eventCtx, config.CloudEventCreated, CloudEventData{Namespace: "team-a", VaultNamespace: "tenants/team-a"})
+
+	assert.Eventually(t, func() bool { return sink.received() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	header := sink.headers[1]
+	assert.Equal(t, "1.0", header.Get("ce-specversion"))
+	assert.Equal(t, "io.github.benemon.vault-namespace-controller.created", header.Get("ce-type"))
+	assert.Equal(t, "vault-namespace-controller/east", header.Get("ce-source"))
+	assert.Equal(t, "team-a", header.Get("ce-subject"))
+	assert.Equal(t, "3f2b8c1e", header.Get("ce-correlationid"))
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.NotEmpty(t, header.Get("ce-id"))
+	assert.Equal(t, header.Get("ce-id"), sink.headers[0].Get("ce-id"), "a retried event keeps its ID")
+	_, err := time.Parse(time.RFC3339Nano, header.Get("ce-time"))
+	assert.NoError(t, err)
+	assert.Equal(t, CloudEventData{Namespace: "team-a", VaultNamespace: "tenants/team-a", Cluster: "east"}, sink.data[1])
+}
+
+// TestCloudEvents_Disabled tests no emitter is created without a sink, and
+// that a reconciler without one emits nothing.
+func TestCloudEvents_Disabled(t *testing.T) {
+	assert.Nil(t, NewCloudEvents(config.CloudEventsConfig{}, "east", testr.New(t)))
+
+	reconciler := &NamespaceReconciler{Config: &config.ControllerConfig{}}
+	reconciler.emitCloudEvent(context.Background(), config.CloudEventCreated, CloudEventData{Namespace: "app"})
+}
+
+// TestCloudEvents_Source tests the configured source overrides the default.
+func TestCloudEvents_Source(t *testing.T) {
+	assert.Equal(t, "vault-namespace-controller",
+		NewCloudEvents(config.CloudEventsConfig{Sink: "http://broker"}, "", testr.New(t)).Source)
+	assert.Equal(t, "/platform/vault",
+		NewCloudEvents(config.CloudEventsConfig{Sink: "http://broker", Source: "/platform/vault"}, "east", testr.New(t)).Source)
+}
+
+// TestCloudEvents_NamespaceCreation tests created Vault namespaces are emitted.
+func TestCloudEvents_NamespaceCreation(t *testing.T) {
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "k8s-team-a").Return(false, nil)
+	mockClient.On("CreateNamespace", mock.Anything, "k8s-team-a", mock.Anything).Return(nil)
+
+	cloudEvents := NewCloudEvents(config.CloudEventsConfig{Sink: "http://broker"}, "", testr.New(t))
+	reconciler := &NamespaceReconciler{
+		Log:         testr.New(t),
+		VaultClient: mockClient,
+		Config:      &config.ControllerConfig{NamespaceFormat: "k8s-%s"},
+		CloudEvents: cloudEvents,
+	}
+
+	_, err := reconciler.handleNamespaceCreation(context.Background(), "team-a", "k8s-team-a", reconciler.Log)
+	assert.NoError(t, err)
+	if assert.Len(t, cloudEvents.queue, 1) {
+		event := <-cloudEvents.queue
+		assert.Equal(t, CloudEventTypePrefix+config.CloudEventCreated, event.eventType)
+		assert.Equal(t, "team-a", event.subject)
+		assert.JSONEq(t, `{"namespace": "team-a", "vaultNamespace": "k8s-team-a"}`, string(event.data))
+	}
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+	"maps"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/go-logr/logr"
+)
+
+// Keys of the connection ConfigMap, named like the environment variables of
+// the Vault CLI so the ConfigMap can be used with envFrom.
+const (
+	ConnectionKeyAddress   = "VAULT_ADDR"
+	ConnectionKeyNamespace = "VAULT_NAMESPACE"
+	ConnectionKeyAuthMount = "VAULT_AUTH_MOUNT"
+	ConnectionKeyAuthRole  = "VAULT_AUTH_ROLE"
+)
+
+// syncConnectionConfigMap writes the ConnectionConfigMap into namespaceName,
+// describing how to reach vaultNamespace, so applications can configure their
+// Vault clients from it.
+func (r *NamespaceReconciler) syncConnectionConfigMap(ctx context.Context, namespaceName, vaultNamespace string, log logr.Logger) error {
+	name := r.Config.ConnectionConfigMap
+	if name == "" {
+		return nil
+	}
+	data := map[string]string{
+		ConnectionKeyAddress:   r.Config.Vault.Address,
+		ConnectionKeyNamespace: strings.Trim(vaultNamespace, "/"),
+	}
+	if auth := r.Config.Bootstrap.KubernetesAuth; auth.Enabled {
+		data[ConnectionKeyAuthMount] = auth.MountPath()
+		data[ConnectionKeyAuthRole] = auth.RoleName()
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err := r.uncachedReader().Get(ctx, types.NamespacedName{Namespace: namespaceName, Name: name}, configMap)
+	if k8serrors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespaceName,
+				Name:      name,
+				Labels:    map[string]string{"app.kubernetes.io/managed-by": managedByValue},
+			},
+			Data: data,
+		}
+		log.Info("Creating Vault connection ConfigMap", "configMap", name)
+		return r.Client.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+	if maps.Equal(configMap.Data, data) {
+		return nil
+	}
+	configMap.Data = data
+	log.Info("Updating Vault connection ConfigMap", "configMap", name)
+	return r.Client.Update(ctx, configMap)
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestNamespaceReconciler_ConnectionConfigMap tests that a synchronized
+// namespace gets a ConfigMap describing its Vault namespace, kept up to date.
+func TestNamespaceReconciler_ConnectionConfigMap(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "/admin/app").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "/admin/app").Return(ownedMetadata("app"), nil)
+
+	reconciler := &NamespaceReconciler{
+		Client:      k8sClient,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{
+			Vault:               config.VaultConfig{Address: "https://vault.example.com:8200"},
+			NamespaceFormat:     "/admin/%s",
+			Bootstrap:           config.BootstrapConfig{KubernetesAuth: config.KubernetesAuthBootstrapConfig{Enabled: true}},
+			ConnectionConfigMap: "vault-connection",
+		},
+		KubernetesAPI: &KubernetesAPI{Host: "https://kubernetes.default.svc"},
+		syncChecker:   func(string) bool { return true },
+	}
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+
+	configMap := &corev1.ConfigMap{}
+	assert.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Namespace: "app", Name: "vault-connection"}, configMap))
+	assert.Equal(t, map[string]string{
+		"VAULT_ADDR":       "https://vault.example.com:8200",
+		"VAULT_NAMESPACE":  "admin/app",
+		"VAULT_AUTH_MOUNT": "kubernetes",
+		"VAULT_AUTH_ROLE":  "default",
+	}, configMap.Data)
+
+	// Edits are reverted
+	configMap.Data["VAULT_NAMESPACE"] = "admin/other"
+	assert.NoError(t, k8sClient.Update(ctx, configMap))
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Namespace: "app", Name: "vault-connection"}, configMap))
+	assert.Equal(t, "admin/app", configMap.Data["VAULT_NAMESPACE"])
+}
@@ -0,0 +1,372 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+)
+
+// connectionKey is the context key of the VaultConnection Vault calls are made with.
+type connectionKey struct{}
+
+// withConnection returns a context whose Vault calls go to the VaultConnection
+// called name, or to the controller's own Vault when name is "".
+func withConnection(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, connectionKey{}, name)
+}
+
+// connectionFrom returns the VaultConnection ctx routes Vault calls to.
+func connectionFrom(ctx context.Context) string {
+	name, _ := ctx.Value(connectionKey{}).(string)
+	return name
+}
+
+// VaultConnections is a vault.Client routing every call to the Vault cluster of
+// the VaultConnection its context names, and to Default otherwise. A client is
+// built per connection on first use, and again when the connection changes.
+type VaultConnections struct {
+	Default vault.Client
+	// Reader reads VaultConnections, and SecretReader the Secrets holding their
+	// credentials, from the local cluster.
+	Reader       client.Reader
+	SecretReader client.Reader
+
+	// newClient builds a client for a connection, vault.NewClient unless set.
+	newClient func(config.VaultConfig) (vault.Client, error)
+
+	mu sync.Mutex
+	// clients caches the client of each connection with the generation it was built from.
+	clients map[string]connectionClient
+}
+
+var (
+	_ vault.TokenChecker = &VaultConnections{}
+	_ vault.TokenRenewer = &VaultConnections{}
+)
+
+// connectionClient is the client built from a generation of a VaultConnection.
+type connectionClient struct {
+	generation int64
+	client     vault.Client
+}
+
+// client returns the client the context of a call routes it to.
+func (v *VaultConnections) client(ctx context.Context) (vault.Client, error) {
+	name := connectionFrom(ctx)
+	if name == "" {
+		return v.Default, nil
+	}
+
+	connection := &vaultv1alpha1.VaultConnection{}
+	if err := v.Reader.Get(ctx, types.NamespacedName{Name: name}, connection); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, fmt.Errorf("VaultConnection %q not found", name)
+		}
+		return nil, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if cached, ok := v.clients[name]; ok && cached.generation == connection.Generation {
+		return cached.client, nil
+	}
+	vaultConfig, err := v.vaultConfig(ctx, connection)
+	if err != nil {
+		return nil, err
+	}
+	newClient := v.newClient
+	if newClient == nil {
+		newClient = vault.NewClient
+	}
+	vaultClient, err := newClient(vaultConfig)
+	if err != nil {
+		return nil, fmt.Errorf("VaultConnection %q: %w", name, err)
+	}
+	if v.clients == nil {
+		v.clients = make(map[string]connectionClient)
+	}
+	v.clients[name] = connectionClient{generation: connection.Generation, client: vaultClient}
+	return vaultClient, nil
+}
+
+// vaultConfig returns the Vault configuration of a connection, with the
+// credentials read from its Secret.
+func (v *VaultConnections) vaultConfig(ctx context.Context, connection *vaultv1alpha1.VaultConnection) (config.VaultConfig, error) {
+	spec := connection.Spec
+	vaultConfig := config.VaultConfig{
+		Address:   spec.Address,
+		CACertPEM: spec.TLS.CACert,
+		Insecure:  spec.TLS.Insecure,
+		Auth: config.VaultAuthConfig{
+			Type:      spec.Auth.Type,
+			Path:      spec.Auth.Path,
+			Namespace: spec.Auth.Namespace,
+			Role:      spec.Auth.Role,
+		},
+	}
+	if spec.Auth.SecretRef == nil {
+		return vaultConfig, nil
+	}
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: spec.Auth.SecretRef.Namespace, Name: spec.Auth.SecretRef.Name}
+	if err := v.SecretReader.Get(ctx, key, secret); err != nil {
+		return vaultConfig, fmt.Errorf("VaultConnection %q: failed to read Secret %s: %w", connection.Name, key, err)
+	}
+	vaultConfig.Auth.Token = string(secret.Data["token"])
+	vaultConfig.Auth.RoleID = string(secret.Data["role-id"])
+	vaultConfig.Auth.SecretID = string(secret.Data["secret-id"])
+	return vaultConfig, nil
+}
+
+func (v *VaultConnections) NamespaceExists(ctx context.Context, path string) (bool, error) {
+	c, err := v.client(ctx)
+	if err != nil {
+		return false, err
+	}
+	return c.NamespaceExists(ctx, path)
+}
+
+func (v *VaultConnections) ListNamespaces(ctx context.Context, parent string) ([]string, error) {
+	c, err := v.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.ListNamespaces(ctx, parent)
+}
+
+func (v *VaultConnections) CreateNamespace(ctx context.Context, path string, customMetadata map[string]string) error {
+	c, err := v.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.CreateNamespace(ctx, path, customMetadata)
+}
+
+func (v *VaultConnections) DeleteNamespace(ctx context.Context, path string) error {
+	c, err := v.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.DeleteNamespace(ctx, path)
+}
+
+func (v *VaultConnections) NamespaceEmpty(ctx context.Context, path string) (bool, error) {
+	c, err := v.client(ctx)
+	if err != nil {
+		return false, err
+	}
+	return c.NamespaceEmpty(ctx, path)
+}
+
+func (v *VaultConnections) GetNamespaceMetadata(ctx context.Context, path string) (map[string]string, error) {
+	c, err := v.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetNamespaceMetadata(ctx, path)
+}
+
+func (v *VaultConnections) PatchNamespaceMetadata(ctx context.Context, path string, customMetadata map[string]string) error {
+	c, err := v.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.PatchNamespaceMetadata(ctx, path, customMetadata)
+}
+
+func (v *VaultConnections) RemoveNamespaceMetadata(ctx context.Context, path string, keys []string) error {
+	c, err := v.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.RemoveNamespaceMetadata(ctx, path, keys)
+}
+
+func (v *VaultConnections) PutPolicy(ctx context.Context, namespacePath, name, policy string) error {
+	c, err := v.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.PutPolicy(ctx, namespacePath, name, policy)
+}
+
+func (v *VaultConnections) PutSentinelPolicy(ctx context.Context, namespacePath, policyType, name, policy, enforcementLevel string, paths []string) error {
+	c, err := v.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.PutSentinelPolicy(ctx, namespacePath, policyType, name, policy, enforcementLevel, paths)
+}
+
+func (v *VaultConnections) EnsureAuditDevice(ctx context.Context, namespacePath, devicePath, deviceType, description string, options map[string]string) error {
+	c, err := v.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.EnsureAuditDevice(ctx, namespacePath, devicePath, deviceType, description, options)
+}
+
+func (v *VaultConnections) EnsureSecretsEngine(ctx context.Context, namespacePath, mountPath, mountType, description string) error {
+	c, err := v.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.EnsureSecretsEngine(ctx, namespacePath, mountPath, mountType, description)
+}
+
+func (v *VaultConnections) EnsureAuthMethod(ctx context.Context, namespacePath, mountPath, methodType, description string) error {
+	c, err := v.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.EnsureAuthMethod(ctx, namespacePath, mountPath, methodType, description)
+}
+
+func (v *VaultConnections) WriteAuthRole(ctx context.Context, namespacePath, mountPath, role string, data map[string]string) error {
+	c, err := v.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.WriteAuthRole(ctx, namespacePath, mountPath, role, data)
+}
+
+func (v *VaultConnections) WriteAuthConfig(ctx context.Context, namespacePath, mountPath string, data map[string]string) error {
+	c, err := v.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.WriteAuthConfig(ctx, namespacePath, mountPath, data)
+}
+
+func (v *VaultConnections) EnsureGroup(ctx context.Context, namespacePath, name, groupType string, policies, memberGroupIDs []string) (string, error) {
+	c, err := v.client(ctx)
+	if err != nil {
+		return "", err
+	}
+	return c.EnsureGroup(ctx, namespacePath, name, groupType, policies, memberGroupIDs)
+}
+
+func (v *VaultConnections) EnsureGroupAlias(ctx context.Context, namespacePath, groupID, mountPath, name string) error {
+	c, err := v.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.EnsureGroupAlias(ctx, namespacePath, groupID, mountPath, name)
+}
+
+func (v *VaultConnections) WriteQuota(ctx context.Context, quotaType, name string, data map[string]string) error {
+	c, err := v.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.WriteQuota(ctx, quotaType, name, data)
+}
+
+func (v *VaultConnections) DeleteQuota(ctx context.Context, quotaType, name string) error {
+	c, err := v.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.DeleteQuota(ctx, quotaType, name)
+}
+
+func (v *VaultConnections) Request(ctx context.Context, namespacePath, method, path string, body map[string]interface{}) error {
+	c, err := v.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Request(ctx, namespacePath, method, path, body)
+}
+
+func (v *VaultConnections) CreateToken(ctx context.Context, namespacePath string, policies []string, ttl, wrapTTL string) (string, error) {
+	c, err := v.client(ctx)
+	if err != nil {
+		return "", err
+	}
+	return c.CreateToken(ctx, namespacePath, policies, ttl, wrapTTL)
+}
+
+// CheckToken checks the token of the controller's own Vault for the readiness
+// probe. The clients of the VaultConnections are not checked.
+func (v *VaultConnections) CheckToken(ctx context.Context) error {
+	checker, ok := v.Default.(vault.TokenChecker)
+	if !ok {
+		return nil
+	}
+	return checker.CheckToken(ctx)
+}
+
+// GetTokenTTL returns the TTL of the token of the controller's own Vault, 0
+// when its client cannot report it.
+func (v *VaultConnections) GetTokenTTL() (int64, error) {
+	renewer, ok := v.Default.(vault.TokenRenewer)
+	if !ok {
+		return 0, nil
+	}
+	return renewer.GetTokenTTL()
+}
+
+// RenewToken renews the token of the controller's own Vault. The clients of
+// the VaultConnections authenticate on their own.
+func (v *VaultConnections) RenewToken(ctx context.Context) error {
+	renewer, ok := v.Default.(vault.TokenRenewer)
+	if !ok {
+		return nil
+	}
+	return renewer.RenewToken(ctx)
+}
+
+// connectionFor returns the VaultConnection a namespace is routed to: the one
+// its class names, else the one its vault.benemon.io/connection label names.
+// "" is the controller's own Vault.
+func (r *NamespaceReconciler) connectionFor(ctx context.Context, namespace metav1.Object) (string, error) {
+	if !r.Config.VaultConnections {
+		return "", nil
+	}
+	class, err := r.Classes.Get(ctx, namespace)
+	if err != nil {
+		return "", err
+	}
+	if class != nil && class.Spec.Connection != "" {
+		return class.Spec.Connection, nil
+	}
+	return namespace.GetLabels()[vaultv1alpha1.ConnectionLabel], nil
+}
+
+// rememberConnection records the VaultConnection a synchronized namespace is
+// routed to, so its Vault namespace is deleted from the same Vault cluster.
+func (r *NamespaceReconciler) rememberConnection(namespaceName, connection string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if connection == "" {
+		delete(r.connections, namespaceName)
+		return
+	}
+	if r.connections == nil {
+		r.connections = make(map[string]string)
+	}
+	r.connections[namespaceName] = connection
+}
+
+// deletedNamespaceConnection returns the VaultConnection a deleted namespace was
+// routed to when it was last synchronized.
+func (r *NamespaceReconciler) deletedNamespaceConnection(namespaceName string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.connections[namespaceName]
+}
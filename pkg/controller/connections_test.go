@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+)
+
+func TestVaultConnections(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = vaultv1alpha1.AddToScheme(scheme)
+
+	connection := &vaultv1alpha1.VaultConnection{
+		ObjectMeta: metav1.ObjectMeta{Name: "eu"},
+		Spec: vaultv1alpha1.VaultConnectionSpec{
+			Address: "https://vault.eu.example.com:8200",
+			Auth: vaultv1alpha1.VaultConnectionAuth{
+				Type:      "approle",
+				SecretRef: &vaultv1alpha1.SecretReference{Namespace: "vault-system", Name: "vault-eu"},
+			},
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		connection,
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "vault-system", Name: "vault-eu"},
+			Data:       map[string][]byte{"role-id": []byte("role"), "secret-id": []byte("secret")},
+		},
+	).Build()
+
+	defaultClient := new(mockVaultClient)
+	defaultClient.On("NamespaceExists", mock.Anything, "app").Return(true, nil)
+	euClient := new(mockVaultClient)
+	euClient.On("NamespaceExists", mock.Anything, "app").Return(false, nil)
+
+	var built []config.VaultConfig
+	connections := &VaultConnections{
+		Default:      defaultClient,
+		Reader:       k8sClient,
+		SecretReader: k8sClient,
+		newClient: func(vaultConfig config.VaultConfig) (vault.Client, error) {
+			built = append(built, vaultConfig)
+			return euClient, nil
+		},
+	}
+	ctx := context.Background()
+
+	// Calls without a connection go to the default client
+	exists, err := connections.NamespaceExists(ctx, "app")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	// Calls with one go to its client, built once with the Secret's credentials
+	for i := 0; i < 2; i++ {
+		exists, err = connections.NamespaceExists(withConnection(ctx, "eu"), "app")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	}
+	assert.Len(t, built, 1)
+	assert.Equal(t, "https://vault.eu.example.com:8200", built[0].Address)
+	assert.Equal(t, "role", built[0].Auth.RoleID)
+	assert.Equal(t, "secret", built[0].Auth.SecretID)
+
+	// A changed connection gets a new client
+	connection.Spec.Address = "https://vault2.eu.example.com:8200"
+	connection.Generation++
+	assert.NoError(t, k8sClient.Update(ctx, connection))
+	_, err = connections.NamespaceExists(withConnection(ctx, "eu"), "app")
+	assert.NoError(t, err)
+	assert.Len(t, built, 2)
+
+	_, err = connections.NamespaceExists(withConnection(ctx, "missing"), "app")
+	assert.ErrorContains(t, err, `VaultConnection "missing" not found`)
+}
+
+// TestNamespaceReconciler_VaultConnection tests that a namespace is created in,
+// and deleted from, the Vault cluster its label routes it to.
+func TestNamespaceReconciler_VaultConnection(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = vaultv1alpha1.AddToScheme(scheme)
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "app",
+		Labels: map[string]string{vaultv1alpha1.ConnectionLabel: "eu"},
+	}}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		namespace,
+		&vaultv1alpha1.VaultConnection{
+			ObjectMeta: metav1.ObjectMeta{Name: "eu"},
+			Spec: vaultv1alpha1.VaultConnectionSpec{
+				Address: "https://vault.eu.example.com:8200",
+				Auth:    vaultv1alpha1.VaultConnectionAuth{Type: "kubernetes", Role: "controller"},
+			},
+		},
+	).Build()
+
+	defaultClient := new(mockVaultClient)
+	euClient := new(mockVaultClient)
+	euClient.On("NamespaceExists", mock.Anything, "app").Return(false, nil).Once()
+	euClient.On("CreateNamespace", mock.Anything, "app", ownedMetadata("app")).Return(nil).Once()
+
+	reconciler := &NamespaceReconciler{
+		Client: k8sClient,
+		Log:    testr.New(t),
+		Scheme: scheme,
+		VaultClient: &VaultConnections{
+			Default:      defaultClient,
+			Reader:       k8sClient,
+			SecretReader: k8sClient,
+			newClient:    func(config.VaultConfig) (vault.Client, error) { return euClient, nil },
+		},
+		Config: &config.ControllerConfig{
+			VaultConnections:      true,
+			DeleteVaultNamespaces: true,
+		},
+		syncChecker: func(string) bool { return true },
+	}
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+
+	euClient.On("NamespaceExists", mock.Anything, "app").Return(true, nil).Once()
+	euClient.On("GetNamespaceMetadata", mock.Anything, "app").Return(ownedMetadata("app"), nil).Once()
+	euClient.On("NamespaceEmpty", mock.Anything, "app").Return(true, nil).Once()
+	euClient.On("DeleteNamespace", mock.Anything, "app").Return(nil).Once()
+	assert.NoError(t, k8sClient.Delete(ctx, namespace))
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+
+	euClient.AssertExpectations(t)
+	defaultClient.AssertNotCalled(t, "NamespaceExists", mock.Anything, mock.Anything)
+}
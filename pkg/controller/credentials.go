@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+	"github.com/go-logr/logr"
+)
+
+// credentialsRefreshInterval is how often the Secrets holding Vault auth
+// credentials are checked for rotation.
+const credentialsRefreshInterval = time.Minute
+
+// SecretCredentials reads the Vault auth credentials referenced from Secrets
+// and logs in to Vault again when they are rotated.
+type SecretCredentials struct {
+	// Reader reads the Secrets, bypassing the cache.
+	Reader client.Reader
+	// Auth is the configured auth method, with its Secret references.
+	Auth   config.VaultAuthConfig
+	Client vault.Reauthenticator
+	Log    logr.Logger
+
+	resolved config.VaultAuthConfig
+}
+
+// Resolve returns Auth with the credentials read from their Secrets.
+func (s *SecretCredentials) Resolve(ctx context.Context) (config.VaultAuthConfig, error) {
+	auth := s.Auth
+	for field, ref := range auth.SecretRefs() {
+		secret := &corev1.Secret{}
+		if err := s.Reader.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, secret); err != nil {
+			return auth, fmt.Errorf("failed to read %s secret %s/%s: %w", field, ref.Namespace, ref.Name, err)
+		}
+		value := strings.TrimSpace(string(secret.Data[ref.Key]))
+		if value == "" {
+			return auth, fmt.Errorf("%s secret %s/%s has no key %q", field, ref.Namespace, ref.Name, ref.Key)
+		}
+		switch field {
+		case "token":
+			auth.Token = value
+		case "roleId":
+			auth.RoleID = value
+		case "secretId":
+			auth.SecretID = value
+		}
+	}
+	s.resolved = auth
+	return auth, nil
+}
+
+// Start checks the Secrets for rotation until ctx is cancelled. Resolve must
+// have been called first.
+func (s *SecretCredentials) Start(ctx context.Context) error {
+	ticker := time.NewTicker(credentialsRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s.Refresh(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection reports that every replica keeps its credentials current.
+func (s *SecretCredentials) NeedLeaderElection() bool {
+	return false
+}
+
+// Refresh reads the Secrets and, when the credentials changed, logs in with
+// them. On failure the current token is kept and the next refresh retries.
+func (s *SecretCredentials) Refresh(ctx context.Context) {
+	previous := s.resolved
+	auth, err := s.Resolve(ctx)
+	if err != nil {
+		s.Log.Error(err, "Failed to read Vault credentials, keeping the current token")
+		return
+	}
+	if reflect.DeepEqual(auth, previous) {
+		return
+	}
+	if err := s.Client.Reauthenticate(auth); err != nil {
+		s.Log.Error(err, "Failed to authenticate to Vault with rotated credentials, keeping the current token")
+		// Retry with the same credentials on the next refresh
+		s.resolved = previous
+		return
+	}
+	s.Log.Info("Authenticated to Vault with rotated credentials", "authType", auth.Type)
+}
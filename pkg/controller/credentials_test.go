@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// fakeReauthenticator records the credentials it is asked to log in with.
+type fakeReauthenticator struct {
+	logins []config.VaultAuthConfig
+	err    error
+}
+
+func (f *fakeReauthenticator) Reauthenticate(auth config.VaultAuthConfig) error {
+	f.logins = append(f.logins, auth)
+	return f.err
+}
+
+// TestSecretCredentials tests resolving credentials from Secrets and logging
+// in again when they are rotated.
+func TestSecretCredentials(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "vault-system", Name: "approle"},
+		Data:       map[string][]byte{"secret-id": []byte("secret-1\n")},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+	vaultClient := &fakeReauthenticator{}
+	credentials := &SecretCredentials{
+		Reader: k8sClient,
+		Auth: config.VaultAuthConfig{
+			Type:              "approle",
+			RoleID:            "role-id",
+			SecretIDSecretRef: &config.SecretKeyRef{Namespace: "vault-system", Name: "approle"},
+		},
+		Client: vaultClient,
+		Log:    testr.New(t),
+	}
+	ctx := context.Background()
+
+	auth, err := credentials.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "role-id", auth.RoleID)
+	assert.Equal(t, "secret-1", auth.SecretID)
+
+	// Unchanged credentials do not log in again
+	credentials.Refresh(ctx)
+	assert.Empty(t, vaultClient.logins)
+
+	secret.Data["secret-id"] = []byte("secret-2")
+	assert.NoError(t, k8sClient.Update(ctx, secret))
+	vaultClient.err = errors.New("invalid secret id")
+	credentials.Refresh(ctx)
+	// A failed login is retried on the next refresh
+	vaultClient.err = nil
+	credentials.Refresh(ctx)
+	if assert.Len(t, vaultClient.logins, 2) {
+		assert.Equal(t, "secret-2", vaultClient.logins[1].SecretID)
+	}
+	credentials.Refresh(ctx)
+	assert.Len(t, vaultClient.logins, 2)
+
+	// A missing key keeps the current token
+	secret.Data = map[string][]byte{}
+	assert.NoError(t, k8sClient.Update(ctx, secret))
+	_, err = credentials.Resolve(ctx)
+	assert.ErrorContains(t, err, `has no key "secret-id"`)
+}
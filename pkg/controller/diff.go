@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+	"sort"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// PathMismatch is a Vault namespace owned by this controller for a synchronized
+// Kubernetes namespace that now maps to another path, usually because the
+// namespace format changed.
+type PathMismatch struct {
+	KubernetesNamespace    string `json:"kubernetesNamespace"`
+	VaultNamespace         string `json:"vaultNamespace"`
+	ExpectedVaultNamespace string `json:"expectedVaultNamespace"`
+}
+
+// Diff breaks the plan down the way an operator reviews it before enabling
+// the controller.
+type Diff struct {
+	// DeletionEnabled reports whether ToDelete and Orphans would actually be deleted.
+	DeletionEnabled bool `json:"deletionEnabled"`
+
+	// ToCreate lists synchronized Kubernetes namespaces with no Vault namespace.
+	ToCreate []PlanEntry `json:"toCreate"`
+
+	// ToDelete lists owned Vault namespaces whose Kubernetes namespace still
+	// exists but is no longer synchronized.
+	ToDelete []PlanEntry `json:"toDelete"`
+
+	// Orphans lists owned Vault namespaces whose Kubernetes namespace is gone.
+	Orphans []PlanEntry `json:"orphans"`
+
+	// Mismatches lists owned Vault namespaces at another path than their
+	// Kubernetes namespace maps to.
+	Mismatches []PathMismatch `json:"mismatches"`
+
+	// InSync lists synchronized Kubernetes namespaces whose Vault namespace exists.
+	InSync []PlanEntry `json:"inSync"`
+
+	// Unmanaged lists Vault namespaces not owned by this controller that no
+	// Kubernetes namespace maps to.
+	Unmanaged []PlanEntry `json:"unmanaged"`
+}
+
+// BuildDiff builds the plan and splits the Vault namespaces it would delete
+// into orphans, path mismatches and namespaces no longer synchronized.
+func (r *NamespaceReconciler) BuildDiff(ctx context.Context) (*Diff, error) {
+	plan, err := r.BuildPlan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &Diff{
+		DeletionEnabled: plan.DeletionEnabled,
+		ToCreate:        plan.ToCreate,
+		ToDelete:        []PlanEntry{},
+		Orphans:         []PlanEntry{},
+		Mismatches:      []PathMismatch{},
+		InSync:          plan.InSync,
+		Unmanaged:       plan.Unmanaged,
+	}
+
+	expected := make(map[string]string, len(plan.ToCreate)+len(plan.InSync))
+	for _, entries := range [][]PlanEntry{plan.ToCreate, plan.InSync} {
+		for _, entry := range entries {
+			expected[entry.KubernetesNamespace] = entry.VaultNamespace
+		}
+	}
+
+	for _, entry := range plan.ToDelete {
+		if expectedPath, ok := expected[entry.KubernetesNamespace]; ok {
+			diff.Mismatches = append(diff.Mismatches, PathMismatch{
+				KubernetesNamespace:    entry.KubernetesNamespace,
+				VaultNamespace:         entry.VaultNamespace,
+				ExpectedVaultNamespace: expectedPath,
+			})
+			continue
+		}
+
+		exists, err := r.namespaceExists(ctx, entry.KubernetesNamespace)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			diff.ToDelete = append(diff.ToDelete, entry)
+		} else {
+			diff.Orphans = append(diff.Orphans, entry)
+		}
+	}
+
+	sort.Slice(diff.Mismatches, func(i, j int) bool {
+		return diff.Mismatches[i].VaultNamespace < diff.Mismatches[j].VaultNamespace
+	})
+	return diff, nil
+}
+
+// namespaceExists reports whether the Kubernetes namespace name exists, whether
+// or not it matches the namespace selector.
+func (r *NamespaceReconciler) namespaceExists(ctx context.Context, name string) (bool, error) {
+	err := r.Get(ctx, types.NamespacedName{Name: name}, newNamespaceMetadata())
+	if err == nil {
+		return true, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return false, err
+	}
+	return r.existsOutsideCache(ctx, name)
+}
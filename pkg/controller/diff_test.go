@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestBuildDiff tests the Vault namespaces the plan would delete are told
+// apart as orphans, path mismatches and excluded namespaces.
+func TestBuildDiff(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-b"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-c"}},
+	).Build()
+
+	// app-b lives at an old path, app-c is excluded and gone was deleted
+	mockClient := new(mockVaultClient)
+	mockClient.On("ListNamespaces", mock.Anything, "").
+		Return([]string{"k8s-app-a", "old-app-b", "k8s-app-c", "k8s-gone", "team-x"}, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "old-app-b").Return(ownedMetadata("app-b"), nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-app-c").Return(ownedMetadata("app-c"), nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-gone").Return(ownedMetadata("gone"), nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "team-x").Return(map[string]string{}, nil)
+
+	reconciler := &NamespaceReconciler{
+		Client:      fakeClient,
+		Log:         testr.New(t),
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{
+			NamespaceFormat:   "k8s-%s",
+			ExcludeNamespaces: []string{"app-c"},
+		},
+	}
+
+	diff, err := reconciler.BuildDiff(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, diff.DeletionEnabled)
+	assert.Equal(t, []PlanEntry{{KubernetesNamespace: "app-b", VaultNamespace: "k8s-app-b"}}, diff.ToCreate)
+	assert.Equal(t, []PlanEntry{{KubernetesNamespace: "app-a", VaultNamespace: "k8s-app-a"}}, diff.InSync)
+	assert.Equal(t, []PlanEntry{{KubernetesNamespace: "app-c", VaultNamespace: "k8s-app-c"}}, diff.ToDelete)
+	assert.Equal(t, []PlanEntry{{KubernetesNamespace: "gone", VaultNamespace: "k8s-gone"}}, diff.Orphans)
+	assert.Equal(t, []PathMismatch{{KubernetesNamespace: "app-b", VaultNamespace: "old-app-b", ExpectedVaultNamespace: "k8s-app-b"}}, diff.Mismatches)
+	assert.Equal(t, []PlanEntry{{VaultNamespace: "team-x"}}, diff.Unmanaged)
+}
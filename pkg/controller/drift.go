@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+	"github.com/go-logr/logr"
+)
+
+// DriftScanner periodically compares every synchronized Kubernetes namespace with
+// Vault, independently of namespace events. It recreates Vault namespaces that were
+// deleted out-of-band and flags owned Vault namespaces found at an unexpected path.
+type DriftScanner struct {
+	Reconciler *NamespaceReconciler
+	Log        logr.Logger
+}
+
+// Start runs a scan every DriftScanInterval until ctx is cancelled.
+func (s *DriftScanner) Start(ctx context.Context) error {
+	interval := time.Duration(s.Reconciler.Config.DriftScanInterval) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.Scan(ctx); err != nil {
+				s.Log.Error(err, "Drift scan failed")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection reports that only the leader corrects drift.
+func (s *DriftScanner) NeedLeaderElection() bool {
+	return true
+}
+
+// Scan corrects missing Vault namespaces and reports path mismatches.
+func (s *DriftScanner) Scan(ctx context.Context) error {
+	r := s.Reconciler
+	if r.Pause.Paused() {
+		s.Log.V(1).Info("Reconciliation paused, skipping drift scan")
+		return nil
+	}
+
+	plan, err := r.BuildPlan(ctx)
+	if err != nil {
+		return err
+	}
+
+	expected := make(map[string]string, len(plan.ToCreate)+len(plan.InSync))
+	for _, entries := range [][]PlanEntry{plan.ToCreate, plan.InSync} {
+		for _, entry := range entries {
+			expected[entry.KubernetesNamespace] = entry.VaultNamespace
+		}
+	}
+
+	// An owned namespace for a synchronized Kubernetes namespace at another path
+	// usually means the namespace format changed
+	mismatched := make(map[string]string)
+	for _, entry := range plan.ToDelete {
+		expectedPath, ok := expected[entry.KubernetesNamespace]
+		if !ok {
+			continue
+		}
+		s.Log.Info("Vault namespace path does not match the current format",
+			"kubernetesNamespace", entry.KubernetesNamespace,
+			"vaultNamespace", entry.VaultNamespace,
+			"expectedVaultNamespace", expectedPath)
+		metrics.DriftDetectedTotal.WithLabelValues(DriftPathMismatch).Inc()
+		r.emitCloudEvent(ctx, config.CloudEventDriftDetected, CloudEventData{
+			Namespace:              entry.KubernetesNamespace,
+			VaultNamespace:         entry.VaultNamespace,
+			ExpectedVaultNamespace: expectedPath,
+			Reason:                 DriftPathMismatch,
+		})
+		r.recordEvent(entry.KubernetesNamespace, corev1.EventTypeWarning, "VaultNamespacePathMismatch",
+			"Vault namespace %s is owned by this namespace but the expected path is %s",
+			entry.VaultNamespace, expectedPath)
+		mismatched[entry.KubernetesNamespace] = entry.VaultNamespace
+	}
+
+	for _, entry := range plan.InSync {
+		if vaultNamespace, ok := mismatched[entry.KubernetesNamespace]; ok {
+			s.setDrifted(ctx, entry.KubernetesNamespace, metav1.ConditionTrue, "PathMismatch",
+				fmt.Sprintf("Vault namespace %s is owned by this namespace but the expected path is %s",
+					vaultNamespace, entry.VaultNamespace))
+			continue
+		}
+		s.setDrifted(ctx, entry.KubernetesNamespace, metav1.ConditionFalse, "InSync", "")
+	}
+
+	for _, entry := range plan.ToCreate {
+		log := s.Log.WithValues(
+			"kubernetesNamespace", entry.KubernetesNamespace,
+			"vaultNamespace", entry.VaultNamespace,
+		)
+		log.Info("Vault namespace is missing, recreating")
+		metrics.DriftDetectedTotal.WithLabelValues(DriftMissing).Inc()
+		r.emitCloudEvent(ctx, config.CloudEventDriftDetected, CloudEventData{
+			Namespace:      entry.KubernetesNamespace,
+			VaultNamespace: entry.VaultNamespace,
+			Reason:         DriftMissing,
+		})
+		manage, err := r.handleNamespaceCreation(ctx, entry.KubernetesNamespace, entry.VaultNamespace, log)
+		if err != nil {
+			log.Error(err, "Failed to recreate Vault namespace")
+			s.setDrifted(ctx, entry.KubernetesNamespace, metav1.ConditionTrue, "Missing",
+				fmt.Sprintf("Vault namespace was deleted out-of-band and could not be recreated: %v", err))
+			continue
+		}
+		if !manage {
+			// Created outside the controller since the scan, and left alone
+			continue
+		}
+		s.setDrifted(ctx, entry.KubernetesNamespace, metav1.ConditionFalse, "Recreated",
+			"Vault namespace was deleted out-of-band and recreated")
+	}
+
+	r.recordFullScan(time.Now())
+	return nil
+}
+
+// setDrifted sets the Drifted condition of a namespace's VaultNamespace. A
+// drifted Vault namespace is also no longer Synced.
+func (s *DriftScanner) setDrifted(ctx context.Context, namespaceName string, status metav1.ConditionStatus, reason, message string) {
+	r := s.Reconciler
+	if err := r.VaultNamespaces.SetCondition(ctx, r.vaultNamespaceName(namespaceName),
+		vaultv1alpha1.ConditionDrifted, status, reason, message); err != nil {
+		s.Log.Error(err, "Failed to update VaultNamespace status", "kubernetesNamespace", namespaceName)
+	}
+	if status != metav1.ConditionTrue {
+		return
+	}
+	if err := r.VaultNamespaces.SetCondition(ctx, r.vaultNamespaceName(namespaceName),
+		vaultv1alpha1.ConditionSynced, metav1.ConditionFalse, reason, message); err != nil {
+		s.Log.Error(err, "Failed to update VaultNamespace status", "kubernetesNamespace", namespaceName)
+	}
+}
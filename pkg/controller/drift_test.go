@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestDriftScanner_Scan tests recreation of missing namespaces and path mismatch detection.
+func TestDriftScanner_Scan(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-b"}},
+	).Build()
+
+	// app-a was deleted from Vault out-of-band, app-b lives at an old path
+	mockClient := new(mockVaultClient)
+	mockClient.On("ListNamespaces", mock.Anything, "").Return([]string{"old-app-b", "k8s-app-b"}, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "old-app-b").Return(ownedMetadata("app-b"), nil)
+	mockClient.On("NamespaceExists", mock.Anything, "k8s-app-a").Return(false, nil)
+	mockClient.On("CreateNamespace", mock.Anything, "k8s-app-a", ownedMetadata("app-a")).Return(nil)
+
+	recorder := record.NewFakeRecorder(10)
+	scanner := &DriftScanner{
+		Reconciler: &NamespaceReconciler{
+			Client:      fakeClient,
+			Log:         testr.New(t),
+			VaultClient: mockClient,
+			Recorder:    recorder,
+			Config: &config.ControllerConfig{
+				NamespaceFormat: "k8s-%s",
+			},
+			syncChecker: func(string) bool { return true },
+		},
+		Log: testr.New(t),
+	}
+
+	assert.NoError(t, scanner.Scan(context.Background()))
+	assert.Contains(t, <-recorder.Events, "VaultNamespacePathMismatch")
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// compileExpressions compiles CEL namespace expressions.
+func compileExpressions(sources []string) ([]*config.NamespaceExpression, error) {
+	expressions := make([]*config.NamespaceExpression, 0, len(sources))
+	for _, source := range sources {
+		expression, err := config.CompileNamespaceExpression(source)
+		if err != nil {
+			return nil, err
+		}
+		expressions = append(expressions, expression)
+	}
+	return expressions, nil
+}
+
+// matchesExpression reports whether namespace matches a CEL expression. An
+// expression that cannot be evaluated, for example because it indexes a label
+// the namespace does not have, does not match.
+func matchesExpression(namespace metav1.Object, expression *config.NamespaceExpression) bool {
+	matched, err := expression.Matches(namespace.GetName(), namespace.GetLabels(), namespace.GetAnnotations())
+	return err == nil && matched
+}
+
+// matchesAnyExpression reports whether namespace matches any of the expressions.
+func matchesAnyExpression(namespace metav1.Object, expressions []*config.NamespaceExpression) bool {
+	for _, expression := range expressions {
+		if matchesExpression(namespace, expression) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,341 @@
+package controller
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+	"github.com/go-logr/logr"
+)
+
+// FleetMetrics periodically updates the gauges describing all namespaces, using
+// the cached namespace list and a single Vault list call per parent namespace
+// rather than an existence check per namespace on every reconcile.
+type FleetMetrics struct {
+	Reconciler *NamespaceReconciler
+	Log        logr.Logger
+
+	// namespaceInfo holds the exported series of the namespace info metric.
+	namespaceInfo map[namespaceInfo]bool
+}
+
+// Start collects the metrics immediately and then every FleetMetricsInterval until ctx is cancelled.
+func (m *FleetMetrics) Start(ctx context.Context) error {
+	interval := time.Duration(m.Reconciler.Config.FleetMetricsInterval) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Collect(ctx); err != nil {
+			m.Log.Error(err, "Failed to collect namespace metrics")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection reports that only the leader, which does the reconciling, reports fleet metrics.
+func (m *FleetMetrics) NeedLeaderElection() bool {
+	return true
+}
+
+// Collect updates the managed, excluded and pending sync namespace gauges.
+func (m *FleetMetrics) Collect(ctx context.Context) error {
+	counts, err := m.Reconciler.countNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.Log.V(2).Info("Updated namespace metrics",
+		"managed", counts.managed, "excluded", counts.excluded, "pending", counts.pending)
+	metrics.NamespacesManaged.Set(float64(counts.managed))
+	metrics.NamespacesExcluded.Set(float64(counts.excluded))
+	metrics.NamespacesPendingSync.Set(float64(counts.pending))
+	if m.Reconciler.Config.NamespaceInfoMetrics.Enabled {
+		m.setNamespaceInfo(counts.infos)
+	}
+	return nil
+}
+
+// setNamespaceInfo exports infos, up to the series limit in order of the
+// namespace names, and removes the series of namespaces no longer exported.
+func (m *FleetMetrics) setNamespaceInfo(infos []namespaceInfo) {
+	sort.Slice(infos, func(i, j int) bool { return infos[i].namespace < infos[j].namespace })
+	dropped := 0
+	if limit := m.Reconciler.Config.NamespaceInfoMetrics.Limit(); len(infos) > limit {
+		dropped = len(infos) - limit
+		infos = infos[:limit]
+		m.Log.V(1).Info("Too many namespaces for the namespace info metric, dropping some",
+			"limit", limit, "dropped", dropped)
+	}
+
+	exported := make(map[namespaceInfo]bool, len(infos))
+	for _, info := range infos {
+		metrics.NamespaceInfo.WithLabelValues(info.namespace, info.vaultPath, info.status).Set(1)
+		exported[info] = true
+	}
+	for info := range m.namespaceInfo {
+		if !exported[info] {
+			metrics.NamespaceInfo.DeleteLabelValues(info.namespace, info.vaultPath, info.status)
+		}
+	}
+	m.namespaceInfo = exported
+	metrics.NamespaceInfoDropped.Set(float64(dropped))
+}
+
+// Statuses of the namespace info metric.
+const (
+	NamespaceStatusSynced  = "synced"
+	NamespaceStatusPending = "pending"
+	NamespaceStatusFailing = "failing"
+)
+
+// namespaceCounts summarizes the Kubernetes namespaces handled by this replica.
+type namespaceCounts struct {
+	// managed and excluded count Kubernetes namespaces by whether they are synchronized.
+	managed  int
+	excluded int
+	// synced counts synchronized namespaces whose Vault namespace exists, and
+	// pending those whose Vault namespace does not.
+	synced  int
+	pending int
+	// infos describes each managed namespace, when the namespace info metric is enabled.
+	infos []namespaceInfo
+}
+
+// namespaceInfo is a series of the namespace info metric.
+type namespaceInfo struct {
+	namespace string
+	vaultPath string
+	status    string
+}
+
+// countNamespaces counts namespaces from the cached namespace list and a single
+// Vault list call per parent namespace. Namespaces routed to VaultConnections
+// are checked one at a time.
+func (r *NamespaceReconciler) countNamespaces(ctx context.Context) (namespaceCounts, error) {
+	var counts namespaceCounts
+
+	nsList := newNamespaceMetadataList()
+	if err := r.List(ctx, nsList); err != nil {
+		return counts, err
+	}
+
+	withInfo := r.Config.NamespaceInfoMetrics.Enabled
+	// info records the namespace info of a managed namespace, failing while its
+	// reconciles fail
+	info := func(name, vaultPath string, synced bool) {
+		if !withInfo {
+			return
+		}
+		status := NamespaceStatusPending
+		if synced {
+			status = NamespaceStatusSynced
+		}
+		r.mu.Lock()
+		if r.failures[name] > 0 {
+			status = NamespaceStatusFailing
+		}
+		r.mu.Unlock()
+		counts.infos = append(counts.infos, namespaceInfo{namespace: name, vaultPath: vaultPath, status: status})
+	}
+
+	// expected maps the Vault namespaces expected on the default connection to
+	// their Kubernetes namespaces
+	expected := make(map[string][]string)
+	parents := make(map[string]bool)
+	for _, ns := range nsList.Items {
+		if !r.Shard.Owns(ns.Name) {
+			continue
+		}
+		if !r.shouldSyncNamespace(&ns) {
+			counts.excluded++
+			continue
+		}
+		counts.managed++
+		connection, err := r.connectionFor(ctx, &ns)
+		if err != nil {
+			counts.pending++
+			info(ns.Name, "", false)
+			continue
+		}
+		vaultNamespacePath, err := r.resolveVaultNamespacePath(ctx, &ns)
+		if err != nil {
+			// Counted as pending, since no Vault namespace can be created for it
+			counts.pending++
+			info(ns.Name, "", false)
+			continue
+		}
+		vaultNamespace := strings.Trim(vaultNamespacePath, "/")
+		if connection != "" {
+			// Other Vault clusters are checked one namespace at a time
+			exists, err := r.VaultClient.NamespaceExists(withConnection(ctx, connection), vaultNamespacePath)
+			if err != nil {
+				return counts, err
+			}
+			if exists {
+				counts.synced++
+			} else {
+				counts.pending++
+			}
+			info(ns.Name, vaultNamespace, exists)
+			continue
+		}
+		expected[vaultNamespace] = append(expected[vaultNamespace], ns.Name)
+		parent, _ := splitVaultPath(vaultNamespace)
+		parents[parent] = true
+	}
+
+	existing := make(map[string]bool)
+	for parent := range parents {
+		children, err := r.VaultClient.ListNamespaces(ctx, parent)
+		if err != nil {
+			return counts, err
+		}
+		for _, child := range children {
+			if parent != "" {
+				child = parent + "/" + child
+			}
+			existing[child] = true
+		}
+	}
+
+	for vaultNamespace, names := range expected {
+		if existing[vaultNamespace] {
+			counts.synced++
+		} else {
+			counts.pending++
+		}
+		for _, name := range names {
+			info(name, vaultNamespace, existing[vaultNamespace])
+		}
+	}
+	return counts, nil
+}
+
+// VaultNamespaceMetrics periodically counts the Vault namespaces below the
+// namespace roots, and those owned by the controller, for capacity planning
+// against Vault's namespace limits.
+type VaultNamespaceMetrics struct {
+	Reconciler *NamespaceReconciler
+	Log        logr.Logger
+}
+
+// Start counts the namespaces immediately and then every
+// VaultNamespaceMetricsInterval until ctx is cancelled.
+func (m *VaultNamespaceMetrics) Start(ctx context.Context) error {
+	interval := time.Duration(m.Reconciler.Config.VaultNamespaceMetricsInterval) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Collect(ctx); err != nil {
+			m.Log.Error(err, "Failed to count Vault namespaces")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection reports that only the leader counts, so Vault is listed once.
+func (m *VaultNamespaceMetrics) NeedLeaderElection() bool {
+	return true
+}
+
+// Collect updates the total and owned Vault namespace gauges.
+func (m *VaultNamespaceMetrics) Collect(ctx context.Context) error {
+	total, owned, err := m.Reconciler.countVaultNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.Log.V(2).Info("Updated Vault namespace metrics", "total", total, "owned", owned)
+	metrics.VaultNamespaces.Set(float64(total))
+	metrics.VaultNamespacesOwned.Set(float64(owned))
+	return nil
+}
+
+// countVaultNamespaces walks the Vault namespaces below the namespace roots,
+// counting every namespace and those owned by this controller.
+func (r *NamespaceReconciler) countVaultNamespaces(ctx context.Context) (total, owned int, err error) {
+	err = r.walkVaultNamespaces(ctx, func(_ string, customMetadata map[string]string) {
+		total++
+		if _, ours := r.ownedBy(customMetadata); ours {
+			owned++
+		}
+	})
+	return total, owned, err
+}
+
+// walkVaultNamespaces calls visit with every Vault namespace below the
+// namespace roots and its custom metadata. Clients that list custom metadata
+// along with the names take a single call per namespace.
+func (r *NamespaceReconciler) walkVaultNamespaces(ctx context.Context, visit func(vaultNamespace string, customMetadata map[string]string)) error {
+	lister, _ := r.VaultClient.(vault.NamespaceMetadataLister)
+
+	seen := make(map[string]bool)
+	var queue []string
+	for _, root := range r.namespaceRoots() {
+		queue = append(queue, strings.Trim(root, "/"))
+	}
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+
+		var children map[string]map[string]string
+		var err error
+		if lister != nil {
+			children, err = lister.ListNamespaceMetadata(ctx, parent)
+		} else {
+			children, err = r.listNamespaceMetadata(ctx, parent)
+		}
+		if err != nil {
+			return err
+		}
+		for child, customMetadata := range children {
+			vaultNamespace := child
+			if parent != "" {
+				vaultNamespace = parent + "/" + child
+			}
+			// Nested namespace roots are counted once
+			if seen[vaultNamespace] {
+				continue
+			}
+			seen[vaultNamespace] = true
+			visit(vaultNamespace, customMetadata)
+			queue = append(queue, vaultNamespace)
+		}
+	}
+	return nil
+}
+
+// listNamespaceMetadata lists the children of parent and reads their custom
+// metadata one at a time.
+func (r *NamespaceReconciler) listNamespaceMetadata(ctx context.Context, parent string) (map[string]map[string]string, error) {
+	names, err := r.VaultClient.ListNamespaces(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[string]map[string]string, len(names))
+	for _, name := range names {
+		vaultNamespace := name
+		if parent != "" {
+			vaultNamespace = parent + "/" + name
+		}
+		customMetadata, err := r.VaultClient.GetNamespaceMetadata(ctx, vaultNamespace)
+		if err != nil {
+			return nil, err
+		}
+		children[name] = customMetadata
+	}
+	return children, nil
+}
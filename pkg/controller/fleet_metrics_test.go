@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+)
+
+// TestFleetMetrics_Collect tests the namespace gauges are computed from one list call.
+func TestFleetMetrics_Collect(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-b"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("ListNamespaces", mock.Anything, "").Return([]string{"k8s-app-a"}, nil).Once()
+
+	collector := &FleetMetrics{
+		Reconciler: &NamespaceReconciler{
+			Client:      fakeClient,
+			Log:         testr.New(t),
+			VaultClient: mockClient,
+			Config:      &config.ControllerConfig{NamespaceFormat: "k8s-%s"},
+			syncChecker: func(name string) bool { return !strings.HasPrefix(name, "kube-") },
+		},
+		Log: testr.New(t),
+	}
+
+	assert.NoError(t, collector.Collect(context.Background()))
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.NamespacesManaged))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.NamespacesExcluded))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.NamespacesPendingSync))
+	mockClient.AssertNotCalled(t, "NamespaceExists", mock.Anything, mock.Anything)
+	mockClient.AssertExpectations(t)
+}
+
+// TestFleetMetrics_NamespaceInfo tests the namespace info series, their cap
+// and the removal of series of namespaces no longer exported.
+func TestFleetMetrics_NamespaceInfo(t *testing.T) {
+	metrics.NamespaceInfo.Reset()
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-b"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-c"}},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("ListNamespaces", mock.Anything, "").Return([]string{"k8s-app-a"}, nil)
+
+	reconciler := &NamespaceReconciler{
+		Client:      fakeClient,
+		Log:         testr.New(t),
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{
+			NamespaceFormat:      "k8s-%s",
+			NamespaceInfoMetrics: config.NamespaceInfoMetricsConfig{Enabled: true},
+		},
+		syncChecker: func(string) bool { return true },
+		failures:    map[string]int{"app-c": 2},
+	}
+	collector := &FleetMetrics{Reconciler: reconciler, Log: testr.New(t)}
+
+	assert.NoError(t, collector.Collect(context.Background()))
+	assert.Equal(t, 3, testutil.CollectAndCount(metrics.NamespaceInfo))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.NamespaceInfo.WithLabelValues("app-a", "k8s-app-a", NamespaceStatusSynced)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.NamespaceInfo.WithLabelValues("app-b", "k8s-app-b", NamespaceStatusPending)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.NamespaceInfo.WithLabelValues("app-c", "k8s-app-c", NamespaceStatusFailing)))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.NamespaceInfoDropped))
+
+	reconciler.Config.NamespaceInfoMetrics.MaxSeries = 2
+	reconciler.resetBackoff("app-c")
+	assert.NoError(t, collector.Collect(context.Background()))
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.NamespaceInfo))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.NamespaceInfoDropped))
+}
+
+// metadataListingClient lists the custom metadata of namespaces along with
+// their names, like the Vault client.
+type metadataListingClient struct {
+	*mockVaultClient
+	children map[string]map[string]map[string]string
+}
+
+func (c *metadataListingClient) ListNamespaceMetadata(_ context.Context, parent string) (map[string]map[string]string, error) {
+	return c.children[parent], nil
+}
+
+// TestVaultNamespaceMetrics_Collect tests every Vault namespace below the
+// roots is counted, and those owned by this controller separately.
+func TestVaultNamespaceMetrics_Collect(t *testing.T) {
+	otherCluster := map[string]string{MetadataManagedBy: "vault-namespace-controller", MetadataKubernetesCluster: "west"}
+
+	t.Run("list and read", func(t *testing.T) {
+		mockClient := new(mockVaultClient)
+		mockClient.On("ListNamespaces", mock.Anything, "tenants").Return([]string{"app-a", "shared"}, nil)
+		mockClient.On("ListNamespaces", mock.Anything, "tenants/app-a").Return([]string(nil), nil)
+		mockClient.On("ListNamespaces", mock.Anything, "tenants/shared").Return([]string{"west"}, nil)
+		mockClient.On("ListNamespaces", mock.Anything, "tenants/shared/west").Return([]string(nil), nil)
+		mockClient.On("GetNamespaceMetadata", mock.Anything, "tenants/app-a").Return(ownedMetadata("app-a"), nil)
+		mockClient.On("GetNamespaceMetadata", mock.Anything, "tenants/shared").Return(map[string]string{}, nil)
+		mockClient.On("GetNamespaceMetadata", mock.Anything, "tenants/shared/west").Return(otherCluster, nil)
+
+		collector := &VaultNamespaceMetrics{
+			Reconciler: &NamespaceReconciler{
+				VaultClient: mockClient,
+				Config:      &config.ControllerConfig{Vault: config.VaultConfig{NamespaceRoot: "/tenants/"}},
+			},
+			Log: testr.New(t),
+		}
+
+		assert.NoError(t, collector.Collect(context.Background()))
+		assert.Equal(t, float64(3), testutil.ToFloat64(metrics.VaultNamespaces))
+		assert.Equal(t, float64(1), testutil.ToFloat64(metrics.VaultNamespacesOwned))
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("list with metadata", func(t *testing.T) {
+		mockClient := new(mockVaultClient)
+		client := &metadataListingClient{mockVaultClient: mockClient, children: map[string]map[string]map[string]string{
+			"":      {"k8s-app-a": ownedMetadata("app-a"), "k8s-app-b": ownedMetadata("app-b"), "prod": {}},
+			"prod":  {"team": otherCluster},
+			"other": {"unrelated": {}},
+		}}
+
+		collector := &VaultNamespaceMetrics{
+			Reconciler: &NamespaceReconciler{
+				VaultClient: client,
+				Config: &config.ControllerConfig{
+					// The prod root is nested in the default root, and counted once
+					ParentRoots: config.ParentRootsConfig{Label: "env", Roots: map[string]string{"prod": "prod"}},
+				},
+			},
+			Log: testr.New(t),
+		}
+
+		assert.NoError(t, collector.Collect(context.Background()))
+		assert.Equal(t, float64(4), testutil.ToFloat64(metrics.VaultNamespaces))
+		assert.Equal(t, float64(2), testutil.ToFloat64(metrics.VaultNamespacesOwned))
+		mockClient.AssertNotCalled(t, "GetNamespaceMetadata", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// Reasons a namespace cannot be moved to its new Vault path unambiguously.
+const (
+	// AmbiguousCollision means other namespaces map to the same new path.
+	AmbiguousCollision = "collision"
+	// AmbiguousCurrentPath means the new path is another namespace's current path.
+	AmbiguousCurrentPath = "current-path-of-other"
+	// AmbiguousTaken means a Vault namespace not owned by the namespace
+	// already exists at the new path.
+	AmbiguousTaken = "taken"
+	// AmbiguousInvalidPath means the old or new path cannot be determined.
+	AmbiguousInvalidPath = "invalid-path"
+)
+
+// FormatMigrationEntry describes the move of a single Kubernetes namespace to
+// the Vault path of the new format.
+type FormatMigrationEntry struct {
+	KubernetesNamespace string `json:"kubernetesNamespace"`
+	OldVaultNamespace   string `json:"oldVaultNamespace"`
+	NewVaultNamespace   string `json:"newVaultNamespace"`
+	// Reason explains why an ambiguous entry cannot be moved.
+	Reason string `json:"reason,omitempty"`
+	// Error is the error of an entry that could not be moved when executing.
+	Error string `json:"error,omitempty"`
+}
+
+// FormatMigrationPlan is the transition of the synchronized namespaces from
+// one NamespaceFormat to another.
+type FormatMigrationPlan struct {
+	PreviousFormat string `json:"previousFormat"`
+	NewFormat      string `json:"newFormat"`
+
+	// ToCreate lists the namespaces whose Vault namespace at the new path does
+	// not exist yet.
+	ToCreate []FormatMigrationEntry `json:"toCreate"`
+
+	// Orphaned lists the Vault namespaces at the old paths, owned by their
+	// namespace, that nothing maps to once the new format is in effect.
+	Orphaned []FormatMigrationEntry `json:"orphaned"`
+
+	// Ambiguous lists the namespaces that cannot be moved to their new path
+	// unambiguously, and are left out of ToCreate and Orphaned.
+	Ambiguous []FormatMigrationEntry `json:"ambiguous"`
+
+	// Unchanged counts the synchronized namespaces whose path does not change,
+	// for example because a static mapping or a template decides it.
+	Unchanged int `json:"unchanged"`
+}
+
+// withNamespaceFormat returns a reconciler mapping namespaces like r, with
+// format as the NamespaceFormat.
+func (r *NamespaceReconciler) withNamespaceFormat(format string) *NamespaceReconciler {
+	cfg := *r.Config
+	cfg.NamespaceFormat = format
+	return &NamespaceReconciler{
+		Client:           r.Client,
+		Log:              r.Log,
+		VaultClient:      r.VaultClient,
+		Config:           &cfg,
+		Shard:            r.Shard,
+		APIReader:        r.APIReader,
+		Mappings:         r.Mappings,
+		Classes:          r.Classes,
+		Audit:            r.Audit,
+		clusterNamespace: r.clusterNamespace,
+	}
+}
+
+// BuildFormatMigrationPlan maps every synchronized namespace with the
+// previous and the new format, and compares the paths with Vault, without
+// changing anything. Namespaces routed to a VaultConnection are left out.
+func (r *NamespaceReconciler) BuildFormatMigrationPlan(ctx context.Context, previousFormat, newFormat string) (*FormatMigrationPlan, error) {
+	previous := r.withNamespaceFormat(previousFormat)
+	next := r.withNamespaceFormat(newFormat)
+	plan := &FormatMigrationPlan{
+		PreviousFormat: previousFormat,
+		NewFormat:      newFormat,
+		ToCreate:       []FormatMigrationEntry{},
+		Orphaned:       []FormatMigrationEntry{},
+		Ambiguous:      []FormatMigrationEntry{},
+	}
+
+	nsList := newNamespaceMetadataList()
+	if err := r.Client.List(ctx, nsList); err != nil {
+		return nil, err
+	}
+
+	var moves []FormatMigrationEntry
+	currentPaths := make(map[string]string)
+	newPaths := make(map[string]int)
+	for _, ns := range nsList.Items {
+		if !r.Shard.Owns(ns.Name) || !r.shouldSyncNamespace(&ns) {
+			continue
+		}
+		if connection, err := r.connectionFor(ctx, &ns); err != nil || connection != "" {
+			continue
+		}
+
+		entry := FormatMigrationEntry{KubernetesNamespace: ns.Name}
+		oldPath, oldErr := previous.resolveVaultNamespacePath(ctx, &ns)
+		newPath, newErr := next.resolveVaultNamespacePath(ctx, &ns)
+		entry.OldVaultNamespace, entry.NewVaultNamespace = strings.Trim(oldPath, "/"), strings.Trim(newPath, "/")
+		if oldErr != nil || newErr != nil {
+			entry.Reason = AmbiguousInvalidPath
+			plan.Ambiguous = append(plan.Ambiguous, entry)
+			continue
+		}
+		currentPaths[entry.OldVaultNamespace] = ns.Name
+		if entry.OldVaultNamespace == entry.NewVaultNamespace {
+			plan.Unchanged++
+			continue
+		}
+		newPaths[entry.NewVaultNamespace]++
+		moves = append(moves, entry)
+	}
+
+	for _, entry := range moves {
+		if newPaths[entry.NewVaultNamespace] > 1 {
+			entry.Reason = AmbiguousCollision
+			plan.Ambiguous = append(plan.Ambiguous, entry)
+			continue
+		}
+		if other, ok := currentPaths[entry.NewVaultNamespace]; ok && other != entry.KubernetesNamespace {
+			entry.Reason = AmbiguousCurrentPath
+			plan.Ambiguous = append(plan.Ambiguous, entry)
+			continue
+		}
+
+		newOwned, newExists, err := r.ownedByNamespace(ctx, entry.NewVaultNamespace, entry.KubernetesNamespace)
+		if err != nil {
+			return nil, err
+		}
+		if newExists && !newOwned {
+			entry.Reason = AmbiguousTaken
+			plan.Ambiguous = append(plan.Ambiguous, entry)
+			continue
+		}
+		if !newExists {
+			plan.ToCreate = append(plan.ToCreate, entry)
+		}
+
+		oldOwned, _, err := r.ownedByNamespace(ctx, entry.OldVaultNamespace, entry.KubernetesNamespace)
+		if err != nil {
+			return nil, err
+		}
+		if oldOwned {
+			plan.Orphaned = append(plan.Orphaned, entry)
+		}
+	}
+
+	for _, entries := range [][]FormatMigrationEntry{plan.ToCreate, plan.Orphaned, plan.Ambiguous} {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].KubernetesNamespace < entries[j].KubernetesNamespace })
+	}
+	return plan, nil
+}
+
+// ownedByNamespace reports whether vaultNamespace exists, and whether it was
+// created by this controller for the Kubernetes namespace namespaceName.
+func (r *NamespaceReconciler) ownedByNamespace(ctx context.Context, vaultNamespace, namespaceName string) (owned, exists bool, err error) {
+	if exists, err = r.VaultClient.NamespaceExists(ctx, vaultNamespace); err != nil || !exists {
+		return false, exists, err
+	}
+	customMetadata, err := r.VaultClient.GetNamespaceMetadata(ctx, vaultNamespace)
+	if err != nil {
+		return false, true, err
+	}
+	_, ours := r.ownedBy(customMetadata)
+	return ours && customMetadata[MetadataKubernetesNamespace] == namespaceName, true, nil
+}
+
+// ExecuteFormatMigration creates the Vault namespaces plan.ToCreate lists at
+// their new path, recording the old path they move from when that is owned by
+// their namespace. It returns the entries that failed, with their error. The
+// Vault namespaces at the old paths are left in place.
+func (r *NamespaceReconciler) ExecuteFormatMigration(ctx context.Context, plan *FormatMigrationPlan, log logr.Logger) []FormatMigrationEntry {
+	next := r.withNamespaceFormat(plan.NewFormat)
+	orphaned := make(map[string]bool, len(plan.Orphaned))
+	for _, entry := range plan.Orphaned {
+		orphaned[entry.KubernetesNamespace] = true
+	}
+
+	var failed []FormatMigrationEntry
+	for _, entry := range plan.ToCreate {
+		entryLog := log.WithValues("kubernetesNamespace", entry.KubernetesNamespace, "vaultNamespace", entry.NewVaultNamespace)
+		manage, err := next.handleNamespaceCreation(ctx, entry.KubernetesNamespace, entry.NewVaultNamespace, entryLog)
+		if err == nil && !manage {
+			// Created outside the controller since the plan was built
+			err = fmt.Errorf("%w: %s", ErrNamespaceConflict, entry.NewVaultNamespace)
+		}
+		if err == nil && orphaned[entry.KubernetesNamespace] && !r.Config.DryRun {
+			err = next.recordMigration(ctx, entry.KubernetesNamespace, entry.OldVaultNamespace, entry.NewVaultNamespace, entryLog)
+		}
+		if err != nil {
+			entry.Error = err.Error()
+			failed = append(failed, entry)
+		}
+	}
+	return failed
+}
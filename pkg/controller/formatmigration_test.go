@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestFormatMigrationPlan tests the namespaces to create, the old Vault
+// namespaces left orphaned and the ambiguous moves, and that executing the
+// plan creates the new Vault namespaces.
+func TestFormatMigrationPlan(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-b"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-c"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-d"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "k8s-app-d"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shared"}},
+	).Build()
+
+	// app-a has not moved yet, app-b has, and k8s-app-c was created by hand
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "app-a").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "app-a").Return(ownedMetadata("app-a"), nil)
+	mockClient.On("NamespaceExists", mock.Anything, "k8s-app-a").Return(false, nil)
+	mockClient.On("NamespaceExists", mock.Anything, "app-b").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "app-b").Return(ownedMetadata("app-b"), nil)
+	mockClient.On("NamespaceExists", mock.Anything, "k8s-app-b").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-app-b").Return(ownedMetadata("app-b"), nil)
+	mockClient.On("NamespaceExists", mock.Anything, "k8s-app-c").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-app-c").Return(map[string]string{}, nil)
+	mockClient.On("NamespaceExists", mock.Anything, "k8s-k8s-app-d").Return(false, nil)
+	mockClient.On("NamespaceExists", mock.Anything, "k8s-app-d").Return(false, nil)
+
+	reconciler := &NamespaceReconciler{
+		Client:      fakeClient,
+		Log:         testr.New(t),
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{
+			NamespaceFormat: "%s",
+			StaticMappings:  map[string]string{"shared": "platform/shared"},
+		},
+	}
+
+	plan, err := reconciler.BuildFormatMigrationPlan(context.Background(), "%s", "k8s-%s")
+	assert.NoError(t, err)
+	assert.Equal(t, []FormatMigrationEntry{
+		{KubernetesNamespace: "app-a", OldVaultNamespace: "app-a", NewVaultNamespace: "k8s-app-a"},
+		{KubernetesNamespace: "k8s-app-d", OldVaultNamespace: "k8s-app-d", NewVaultNamespace: "k8s-k8s-app-d"},
+	}, plan.ToCreate)
+	assert.Equal(t, []FormatMigrationEntry{
+		{KubernetesNamespace: "app-a", OldVaultNamespace: "app-a", NewVaultNamespace: "k8s-app-a"},
+		{KubernetesNamespace: "app-b", OldVaultNamespace: "app-b", NewVaultNamespace: "k8s-app-b"},
+	}, plan.Orphaned)
+	assert.Equal(t, []FormatMigrationEntry{
+		{KubernetesNamespace: "app-c", OldVaultNamespace: "app-c", NewVaultNamespace: "k8s-app-c", Reason: AmbiguousTaken},
+		{KubernetesNamespace: "app-d", OldVaultNamespace: "app-d", NewVaultNamespace: "k8s-app-d", Reason: AmbiguousCurrentPath},
+	}, plan.Ambiguous)
+	assert.Equal(t, 1, plan.Unchanged)
+
+	mockClient.On("CreateNamespace", mock.Anything, "k8s-app-a", ownedMetadata("app-a")).Return(nil)
+	mockClient.On("CreateNamespace", mock.Anything, "k8s-k8s-app-d", ownedMetadata("k8s-app-d")).Return(nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-app-a").Return(ownedMetadata("app-a"), nil)
+	mockClient.On("PatchNamespaceMetadata", mock.Anything, "k8s-app-a", map[string]string{MetadataMigratedFrom: "app-a"}).Return(nil)
+
+	assert.Empty(t, reconciler.ExecuteFormatMigration(context.Background(), plan, testr.New(t)))
+	mockClient.AssertExpectations(t)
+}
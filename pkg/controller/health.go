@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+)
+
+// vaultReadinessInterval is how long a Vault token check answers the
+// readiness probe, so that frequent probes don't each call Vault.
+const vaultReadinessInterval = 30 * time.Second
+
+// cacheSyncTimeout bounds how long the readiness probe waits on the informer
+// caches.
+const cacheSyncTimeout = time.Second
+
+// minReconcileStall is the shortest time a reconcile may run before the
+// liveness probe reports the reconcile loop as wedged.
+const minReconcileStall = time.Minute
+
+// VaultReadiness reports ready while the controller's Vault token is
+// accepted by Vault.
+type VaultReadiness struct {
+	Client vault.TokenChecker
+
+	mu      sync.Mutex
+	checked time.Time
+	err     error
+	now     func() time.Time
+}
+
+// Check implements healthz.Checker. The result of a token check is reused
+// for vaultReadinessInterval.
+func (v *VaultReadiness) Check(req *http.Request) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := time.Now
+	if v.now != nil {
+		now = v.now
+	}
+	if !v.checked.IsZero() && now().Sub(v.checked) < vaultReadinessInterval {
+		return v.err
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+	defer cancel()
+	v.err = v.Client.CheckToken(ctx)
+	v.checked = now()
+	return v.err
+}
+
+// CacheSynced returns a checker that reports ready once the informers of
+// informerCache have synced.
+func CacheSynced(informerCache cache.Cache) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), cacheSyncTimeout)
+		defer cancel()
+		if !informerCache.WaitForCacheSync(ctx) {
+			return errors.New("informer caches have not synced")
+		}
+		return nil
+	}
+}
+
+// ReconcileLiveness reports the reconcile loop as wedged when a reconcile
+// has been running for longer than MaxStall. Reconciles are bounded by the
+// reconcile timeout, so one running far beyond it is stuck.
+type ReconcileLiveness struct {
+	MaxStall time.Duration
+
+	mu       sync.Mutex
+	inFlight map[uint64]time.Time
+	next     uint64
+	now      func() time.Time
+}
+
+// NewReconcileLiveness returns a ReconcileLiveness allowing reconciles twice
+// reconcileTimeout, and at least a minute.
+func NewReconcileLiveness(reconcileTimeout time.Duration) *ReconcileLiveness {
+	return &ReconcileLiveness{MaxStall: max(2*reconcileTimeout, minReconcileStall)}
+}
+
+// Track records a reconcile starting and returns the function recording it
+// finished.
+func (l *ReconcileLiveness) Track() func() {
+	if l == nil {
+		return func() {}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight == nil {
+		l.inFlight = make(map[uint64]time.Time)
+	}
+	id := l.next
+	l.next++
+	l.inFlight[id] = l.clock()
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.inFlight, id)
+	}
+}
+
+// Check implements healthz.Checker.
+func (l *ReconcileLiveness) Check(_ *http.Request) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock()
+	for _, started := range l.inFlight {
+		if running := now.Sub(started); running > l.MaxStall {
+			return fmt.Errorf("a reconcile has been running for %s, longer than %s", running.Round(time.Second), l.MaxStall)
+		}
+	}
+	return nil
+}
+
+func (l *ReconcileLiveness) clock() time.Time {
+	if l.now != nil {
+		return l.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+)
+
+// tokenChecker counts token checks and fails them with err.
+type tokenChecker struct {
+	calls int
+	err   error
+}
+
+func (t *tokenChecker) CheckToken(_ context.Context) error {
+	t.calls++
+	return t.err
+}
+
+// TestVaultReadiness tests caching Vault token checks for the readiness probe.
+func TestVaultReadiness(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	checker := &tokenChecker{}
+	readiness := &VaultReadiness{Client: checker, now: func() time.Time { return now }}
+	req := httptest.NewRequest("GET", "/readyz", nil)
+
+	assert.NoError(t, readiness.Check(req))
+	assert.Equal(t, 1, checker.calls)
+
+	// Vault is not asked again within the interval
+	checker.err = errors.New("permission denied")
+	assert.NoError(t, readiness.Check(req))
+	assert.Equal(t, 1, checker.calls)
+
+	now = now.Add(vaultReadinessInterval)
+	assert.EqualError(t, readiness.Check(req), "permission denied")
+	assert.EqualError(t, readiness.Check(req), "permission denied")
+	assert.Equal(t, 2, checker.calls)
+}
+
+// TestVaultReadiness_VaultConnections tests the readiness probe checks the
+// token of the controller's own Vault when namespaces are routed to others.
+func TestVaultReadiness_VaultConnections(t *testing.T) {
+	checker := &tokenChecker{err: errors.New("permission denied")}
+	var client vault.Client = &VaultConnections{Default: struct {
+		*mockVaultClient
+		*tokenChecker
+	}{new(mockVaultClient), checker}}
+
+	tokenClient, ok := client.(vault.TokenChecker)
+	assert.True(t, ok)
+	readiness := &VaultReadiness{Client: tokenClient}
+	assert.EqualError(t, readiness.Check(httptest.NewRequest("GET", "/readyz", nil)), "permission denied")
+	assert.Equal(t, 1, checker.calls)
+
+	// A default client unable to check its token is assumed ready
+	assert.NoError(t, (&VaultConnections{Default: new(mockVaultClient)}).CheckToken(context.Background()))
+}
+
+// TestReconcileLiveness tests detecting a stuck reconcile.
+func TestReconcileLiveness(t *testing.T) {
+	assert.Equal(t, time.Minute, NewReconcileLiveness(10*time.Second).MaxStall)
+	assert.Equal(t, 2*time.Minute, NewReconcileLiveness(time.Minute).MaxStall)
+
+	var nilLiveness *ReconcileLiveness
+	nilLiveness.Track()()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	liveness := NewReconcileLiveness(30 * time.Second)
+	liveness.now = func() time.Time { return now }
+	req := httptest.NewRequest("GET", "/healthz", nil)
+
+	assert.NoError(t, liveness.Check(req))
+
+	done := liveness.Track()
+	now = now.Add(50 * time.Second)
+	finished := liveness.Track()
+	finished()
+	assert.NoError(t, liveness.Check(req))
+
+	now = now.Add(11 * time.Second)
+	assert.EqualError(t, liveness.Check(req), "a reconcile has been running for 1m1s, longer than 1m0s")
+
+	done()
+	assert.NoError(t, liveness.Check(req))
+}
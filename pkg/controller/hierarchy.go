@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// hncTreeLabelSuffix suffixes the labels the Hierarchical Namespace Controller
+	// puts on every namespace, one per ancestor, valued with the ancestor's depth.
+	hncTreeLabelSuffix = ".tree.hnc.x-k8s.io/depth"
+	// hncSubnamespaceOfAnnotation names the parent of an HNC subnamespace.
+	hncSubnamespaceOfAnnotation = "hnc.x-k8s.io/subnamespace-of"
+	// maxHierarchyDepth bounds the walk up a namespace hierarchy.
+	maxHierarchyDepth = 32
+)
+
+// ErrParentNotSynced is returned when the Vault namespace of a namespace's HNC
+// parent has not been created yet, so the namespace cannot be nested in it.
+var ErrParentNotSynced = errors.New("parent vault namespace does not exist yet")
+
+// hncParent returns the name of a namespace's parent in an HNC hierarchy, or "".
+func hncParent(namespace metav1.Object) string {
+	for key, value := range namespace.GetLabels() {
+		if value == "1" && strings.HasSuffix(key, hncTreeLabelSuffix) {
+			return strings.TrimSuffix(key, hncTreeLabelSuffix)
+		}
+	}
+	return namespace.GetAnnotations()[hncSubnamespaceOfAnnotation]
+}
+
+// resolveVaultNamespacePath maps a Kubernetes namespace to its Vault namespace
+// path and checks that Vault accepts it.
+func (r *NamespaceReconciler) resolveVaultNamespacePath(ctx context.Context, namespace metav1.Object) (string, error) {
+	vaultNamespace, err := r.mapVaultNamespacePath(ctx, namespace)
+	if err != nil {
+		return "", err
+	}
+	if err := r.checkVaultNamespacePath(vaultNamespace); err != nil {
+		return "", err
+	}
+	return vaultNamespace, nil
+}
+
+// mapVaultNamespacePath maps a Kubernetes namespace to its Vault namespace
+// path, nested in its Capsule Tenant's Vault namespace with CapsuleTenants.
+// With MirrorHierarchy, a namespace whose HNC parent is synchronized is nested
+// in its parent's Vault namespace, named by the last segment of its own path.
+func (r *NamespaceReconciler) mapVaultNamespacePath(ctx context.Context, namespace metav1.Object) (string, error) {
+	// Statically mapped namespaces are neither grouped by Tenant nor nested
+	if vaultNamespace, ok := r.staticPath(namespace.GetName()); ok {
+		return vaultNamespace, nil
+	}
+
+	vaultNamespace, err := r.vaultNamespacePath(ctx, namespace)
+	if err != nil {
+		return "", err
+	}
+	vaultNamespace = r.withTenant(namespace, vaultNamespace)
+	if !r.Config.MirrorHierarchy {
+		return vaultNamespace, nil
+	}
+
+	// Walk up to the topmost synchronized ancestor, collecting the names to nest below it
+	var leaves []string
+	for depth := 0; ; depth++ {
+		if depth == maxHierarchyDepth {
+			return "", fmt.Errorf("%w: hierarchy of %s is deeper than %d", ErrInvalidNamespacePath, namespace.GetName(), maxHierarchyDepth)
+		}
+
+		parent, err := r.syncedParent(ctx, namespace)
+		if err != nil {
+			return "", err
+		}
+		if parent == nil {
+			break
+		}
+		_, leaf := splitVaultPath(vaultNamespace)
+		leaves = append(leaves, leaf)
+		if vaultNamespace, err = r.vaultNamespacePath(ctx, parent); err != nil {
+			return "", err
+		}
+		vaultNamespace = r.withTenant(parent, vaultNamespace)
+		namespace = parent
+	}
+
+	for i := len(leaves) - 1; i >= 0; i-- {
+		vaultNamespace = strings.TrimRight(vaultNamespace, "/") + "/" + leaves[i]
+	}
+	return vaultNamespace, nil
+}
+
+// syncedParent returns the HNC parent of a namespace when that parent exists
+// and is synchronized, and nil otherwise.
+func (r *NamespaceReconciler) syncedParent(ctx context.Context, namespace metav1.Object) (metav1.Object, error) {
+	parentName := hncParent(namespace)
+	if parentName == "" {
+		return nil, nil
+	}
+
+	parent := newNamespaceMetadata()
+	if err := r.Get(ctx, types.NamespacedName{Name: parentName}, parent); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !r.shouldSyncNamespace(parent) {
+		return nil, nil
+	}
+	return parent, nil
+}
+
+// checkParentSynced returns ErrParentNotSynced when vaultNamespace is nested in
+// the Vault namespace of an HNC parent that does not exist yet. The parent is
+// reconciled independently, so the namespace is retried until it does.
+func (r *NamespaceReconciler) checkParentSynced(ctx context.Context, namespace metav1.Object, vaultNamespace string) error {
+	if !r.Config.MirrorHierarchy {
+		return nil
+	}
+	parent, err := r.syncedParent(ctx, namespace)
+	if err != nil || parent == nil {
+		return err
+	}
+
+	parentPath, _ := splitVaultPath(vaultNamespace)
+	exists, err := r.VaultClient.NamespaceExists(ctx, parentPath)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNamespaceCheck, err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s for parent %s", ErrParentNotSynced, parentPath, parent.GetName())
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// hncNamespace returns a namespace with the HNC tree labels of the given ancestors, nearest first.
+func hncNamespace(name string, ancestors ...string) *corev1.Namespace {
+	labels := map[string]string{name + hncTreeLabelSuffix: "0"}
+	for i, ancestor := range ancestors {
+		labels[ancestor+hncTreeLabelSuffix] = string(rune('1' + i))
+	}
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestHncParent(t *testing.T) {
+	assert.Equal(t, "team", hncParent(&hncNamespace("service", "team", "org").ObjectMeta))
+	assert.Equal(t, "", hncParent(&hncNamespace("org").ObjectMeta))
+	assert.Equal(t, "team", hncParent(&metav1.ObjectMeta{
+		Name:        "service",
+		Annotations: map[string]string{hncSubnamespaceOfAnnotation: "team"},
+	}))
+}
+
+func TestNamespaceReconciler_resolveVaultNamespacePath(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		hncNamespace("org"),
+		hncNamespace("team", "org"),
+		hncNamespace("service", "team", "org"),
+		hncNamespace("kube-tools"),
+		hncNamespace("tools-ns", "kube-tools"),
+	).Build()
+
+	tests := []struct {
+		name      string
+		namespace *corev1.Namespace
+		mirror    bool
+		expected  string
+	}{
+		{
+			name:      "hierarchy is nested",
+			namespace: hncNamespace("service", "team", "org"),
+			mirror:    true,
+			expected:  "/admin/k8s-org/k8s-team/k8s-service",
+		},
+		{
+			name:      "root of a hierarchy is not nested",
+			namespace: hncNamespace("org"),
+			mirror:    true,
+			expected:  "/admin/k8s-org",
+		},
+		{
+			name:      "excluded parent is skipped",
+			namespace: hncNamespace("tools-ns", "kube-tools"),
+			mirror:    true,
+			expected:  "/admin/k8s-tools-ns",
+		},
+		{
+			name:      "hierarchy is ignored unless mirrored",
+			namespace: hncNamespace("service", "team", "org"),
+			mirror:    false,
+			expected:  "/admin/k8s-service",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &NamespaceReconciler{
+				Client: k8sClient,
+				Log:    testr.New(t),
+				Config: &config.ControllerConfig{
+					NamespaceFormat: "k8s-%s",
+					MirrorHierarchy: tt.mirror,
+					Vault:           config.VaultConfig{NamespaceRoot: "/admin"},
+				},
+			}
+
+			result, err := r.resolveVaultNamespacePath(context.Background(), tt.namespace)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// TestNamespaceReconciler_MirrorHierarchy tests that a child namespace waits for
+// its parent's Vault namespace before being created.
+func TestNamespaceReconciler_MirrorHierarchy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		hncNamespace("team"),
+		hncNamespace("service", "team"),
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "team").Return(false, nil).Once()
+
+	r := &NamespaceReconciler{
+		Client:      k8sClient,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{
+			NamespaceFormat:  "%s",
+			MirrorHierarchy:  true,
+			ErrorBackoffBase: 5,
+			ErrorBackoffMax:  300,
+		},
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "service"}}
+
+	// The parent has not been created yet, so the child is retried
+	result, err := r.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Greater(t, result.RequeueAfter.Seconds(), 0.0)
+	mockClient.AssertNotCalled(t, "CreateNamespace", mock.Anything, mock.Anything, mock.Anything)
+	mockClient.AssertNumberOfCalls(t, "NamespaceExists", 1)
+
+	// Once it exists the child is created inside it
+	mockClient.On("NamespaceExists", mock.Anything, "team").Return(true, nil)
+	mockClient.On("NamespaceExists", mock.Anything, "team/service").Return(false, nil)
+	mockClient.On("CreateNamespace", mock.Anything, "team/service", ownedMetadata("service")).Return(nil)
+
+	_, err = r.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	mockClient.AssertCalled(t, "CreateNamespace", mock.Anything, "team/service", ownedMetadata("service"))
+}
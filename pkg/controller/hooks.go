@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+	"github.com/go-logr/logr"
+)
+
+// Headers sent with every lifecycle hook call.
+const (
+	HookEventHeader     = "X-Vault-Namespace-Controller-Event"
+	HookSignatureHeader = "X-Vault-Namespace-Controller-Signature"
+)
+
+// HookPayload is the JSON body POSTed to lifecycle hooks.
+type HookPayload struct {
+	Event          string    `json:"event"`
+	Namespace      string    `json:"namespace"`
+	VaultNamespace string    `json:"vaultNamespace"`
+	Cluster        string    `json:"cluster,omitempty"`
+	Connection     string    `json:"connection,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// LifecycleHooks calls the configured HTTPS endpoints after Vault namespaces
+// are created and before they are deleted.
+type LifecycleHooks struct {
+	Hooks []config.LifecycleHook
+	// SigningKey signs the payloads with HMAC-SHA256. Payloads are not signed when empty.
+	SigningKey []byte
+	HTTPClient *http.Client
+	// retryDelay is the delay before the second attempt, doubling with every further one.
+	retryDelay time.Duration
+}
+
+// NewLifecycleHooks returns the configured lifecycle hooks, or nil when none are configured.
+func NewLifecycleHooks(cfg config.LifecycleHooksConfig, signingKey []byte) *LifecycleHooks {
+	if len(cfg.Hooks) == 0 {
+		return nil
+	}
+	return &LifecycleHooks{
+		Hooks:      cfg.Hooks,
+		SigningKey: signingKey,
+		HTTPClient: &http.Client{},
+		retryDelay: time.Second,
+	}
+}
+
+// Subscribed returns the names of the hooks called for event.
+func (h *LifecycleHooks) Subscribed(event string) []string {
+	if h == nil {
+		return nil
+	}
+	var names []string
+	for _, hook := range h.Hooks {
+		if hook.Subscribes(event) {
+			names = append(names, hook.Name)
+		}
+	}
+	return names
+}
+
+// Call calls the named hooks subscribed to the payload's event and returns
+// the names of those that succeeded or whose failures are ignored. The error
+// joins the failures of hooks with the Fail policy.
+func (h *LifecycleHooks) Call(ctx context.Context, payload HookPayload, names map[string]bool, log logr.Logger) ([]string, error) {
+	if h == nil {
+		return nil, nil
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []string
+	var errs []error
+	for _, hook := range h.Hooks {
+		if !hook.Subscribes(payload.Event) || (names != nil && !names[hook.Name]) {
+			continue
+		}
+		err := h.call(ctx, hook, payload.Event, body)
+		switch {
+		case err == nil:
+			metrics.HookCallsTotal.WithLabelValues(hook.Name, payload.Event, "success").Inc()
+			done = append(done, hook.Name)
+		case hook.Ignored():
+			log.Error(err, "Lifecycle hook failed, ignoring", "hook", hook.Name, "event", payload.Event)
+			metrics.HookCallsTotal.WithLabelValues(hook.Name, payload.Event, "ignored").Inc()
+			done = append(done, hook.Name)
+		default:
+			metrics.HookCallsTotal.WithLabelValues(hook.Name, payload.Event, "error").Inc()
+			errs = append(errs, fmt.Errorf("lifecycle hook %s: %w", hook.Name, err))
+		}
+	}
+	return done, errors.Join(errs...)
+}
+
+// call POSTs body to hook, retrying failed attempts with a doubling delay.
+func (h *LifecycleHooks) call(ctx context.Context, hook config.LifecycleHook, event string, body []byte) error {
+	delay := h.retryDelay
+	var err error
+	for attempt := 1; attempt <= hook.MaxAttempts(); attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%w (last error: %w)", ctx.Err(), err)
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+		if err = h.post(ctx, hook, event, body); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed after %d attempts: %w", hook.MaxAttempts(), err)
+}
+
+func (h *LifecycleHooks) post(ctx context.Context, hook config.LifecycleHook, event string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, hook.Timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HookEventHeader, event)
+	if len(h.SigningKey) > 0 {
+		req.Header.Set(HookSignatureHeader, "sha256="+SignHookPayload(h.SigningKey, body))
+	}
+
+	resp, err := h.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// SignHookPayload returns the hex encoded HMAC-SHA256 of body, as sent in the
+// signature header, so receivers can verify payloads.
+func SignHookPayload(key, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// hookPayload describes a lifecycle event of the Vault namespace of namespaceName.
+func (r *NamespaceReconciler) hookPayload(ctx context.Context, event, namespaceName, vaultNamespace string) HookPayload {
+	return HookPayload{
+		Event:          event,
+		Namespace:      namespaceName,
+		VaultNamespace: vaultNamespace,
+		Cluster:        r.Config.ClusterName,
+		Connection:     connectionFrom(ctx),
+		Timestamp:      time.Now().UTC(),
+	}
+}
+
+// markForPostCreate records that the post-create hooks still have to be
+// called for the just created vaultNamespace.
+func (r *NamespaceReconciler) markForPostCreate(ctx context.Context, vaultNamespace string) {
+	names := r.Hooks.Subscribed(config.HookEventPostCreate)
+	if len(names) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.unannounced == nil {
+		r.unannounced = make(map[string]map[string]bool)
+	}
+	pending := make(map[string]bool, len(names))
+	for _, name := range names {
+		pending[name] = true
+	}
+	r.unannounced[appliedKey(ctx, vaultNamespace)] = pending
+}
+
+// callPostCreateHooks calls the post-create hooks for a Vault namespace the
+// controller created, once it is fully provisioned. Hooks with the Fail policy
+// are retried by the next reconcile until they succeed; hooks that already
+// succeeded are not called again.
+func (r *NamespaceReconciler) callPostCreateHooks(ctx context.Context, namespaceName, vaultNamespace string, log logr.Logger) error {
+	key := appliedKey(ctx, vaultNamespace)
+	r.mu.Lock()
+	pending := maps.Clone(r.unannounced[key])
+	r.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	log.Info("Calling post-create lifecycle hooks")
+	done, err := r.Hooks.Call(ctx, r.hookPayload(ctx, config.HookEventPostCreate, namespaceName, vaultNamespace), pending, log)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range done {
+		delete(r.unannounced[key], name)
+	}
+	if len(r.unannounced[key]) == 0 {
+		delete(r.unannounced, key)
+	}
+	return err
+}
+
+// callPreDeleteHooks calls the pre-delete hooks before a Vault namespace is
+// deleted. A failing hook with the Fail policy blocks the deletion.
+func (r *NamespaceReconciler) callPreDeleteHooks(ctx context.Context, namespaceName, vaultNamespace string, log logr.Logger) error {
+	if len(r.Hooks.Subscribed(config.HookEventPreDelete)) == 0 {
+		return nil
+	}
+	log.Info("Calling pre-delete lifecycle hooks")
+	_, err := r.Hooks.Call(ctx, r.hookPayload(ctx, config.HookEventPreDelete, namespaceName, vaultNamespace), nil, log)
+	return err
+}
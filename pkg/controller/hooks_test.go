@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// hookServer records the payloads POSTed to it and answers with the next of
+// statuses, then with 200.
+type hookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	payloads []HookPayload
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func newHookServer(t *testing.T, statuses ...int) *hookServer {
+	s := &hookServer{statuses: statuses}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload HookPayload
+		assert.NoError(t, json.Unmarshal(body, &payload))
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.payloads = append(s.payloads, payload)
+		s.bodies = append(s.bodies, body)
+		s.headers = append(s.headers, r.Header.Clone())
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *hookServer) calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.payloads)
+}
+
+func testHooks(server *hookServer, hooks ...config.LifecycleHook) *LifecycleHooks {
+	return &LifecycleHooks{Hooks: hooks, SigningKey: []byte("secret"), HTTPClient: server.Client()}
+}
+
+// TestLifecycleHooks_Call tests signing, retries and the failure policies.
+func TestLifecycleHooks_Call(t *testing.T) {
+	ctx := context.Background()
+	payload := HookPayload{Event: config.HookEventPostCreate, Namespace: "app", VaultNamespace: "app"}
+
+	server := newHookServer(t, http.StatusBadGateway)
+	hooks := testHooks(server, config.LifecycleHook{Name: "cmdb", URL: server.URL, Events: []string{config.HookEventPostCreate}})
+	done, err := hooks.Call(ctx, payload, nil, testr.New(t))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cmdb"}, done)
+	assert.Equal(t, 2, server.calls())
+	assert.Equal(t, "post-create", server.headers[1].Get(HookEventHeader))
+	assert.Equal(t, "sha256="+SignHookPayload([]byte("secret"), server.bodies[1]), server.headers[1].Get(HookSignatureHeader))
+	assert.Equal(t, "app", server.payloads[1].VaultNamespace)
+
+	// Hooks not subscribed to the event are not called
+	_, err = hooks.Call(ctx, HookPayload{Event: config.HookEventPreDelete}, nil, testr.New(t))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, server.calls())
+
+	server = newHookServer(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	hooks = testHooks(server,
+		config.LifecycleHook{Name: "pipeline", URL: server.URL, Events: []string{config.HookEventPostCreate}, Attempts: 2},
+		config.LifecycleHook{Name: "audit", URL: server.URL, Events: []string{config.HookEventPostCreate},
+			Attempts: 1, FailurePolicy: config.HookFailurePolicyIgnore},
+	)
+	done, err = hooks.Call(ctx, payload, nil, testr.New(t))
+	assert.ErrorContains(t, err, "lifecycle hook pipeline: failed after 2 attempts: unexpected status 500")
+	assert.Equal(t, []string{"audit"}, done)
+	assert.Equal(t, 3, server.calls())
+}
+
+// TestNamespaceReconciler_LifecycleHooks tests that post-create hooks are
+// called once for created Vault namespaces and retried until they succeed,
+// and that failing pre-delete hooks block deletion.
+func TestNamespaceReconciler_LifecycleHooks(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+	).Build()
+
+	server := newHookServer(t, http.StatusServiceUnavailable)
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "app").Return(false, nil).Once()
+	mockClient.On("CreateNamespace", mock.Anything, "app", ownedMetadata("app")).Return(nil).Once()
+
+	reconciler := &NamespaceReconciler{
+		Client:      k8sClient,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Config:      &config.ControllerConfig{DeleteVaultNamespaces: true},
+		Hooks: testHooks(server, config.LifecycleHook{
+			Name: "cmdb", URL: server.URL, Events: []string{config.HookEventPostCreate, config.HookEventPreDelete}, Attempts: 1,
+		}),
+		syncChecker: func(string) bool { return true },
+	}
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, server.calls())
+
+	// The failed hook is retried although the Vault namespace exists now
+	mockClient.On("NamespaceExists", mock.Anything, "app").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "app").Return(ownedMetadata("app"), nil)
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, server.calls())
+	assert.Equal(t, config.HookEventPostCreate, server.payloads[1].Event)
+
+	// A successful hook is not called again
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, server.calls())
+
+	// A failing pre-delete hook blocks the deletion
+	mockClient.On("NamespaceEmpty", mock.Anything, "app").Return(true, nil)
+	server.mu.Lock()
+	server.statuses = []int{http.StatusForbidden}
+	server.mu.Unlock()
+	_, err = reconciler.handleNamespaceDeletion(ctx, "app", "app", testr.New(t))
+	assert.ErrorContains(t, err, "lifecycle hook cmdb")
+	mockClient.AssertNotCalled(t, "DeleteNamespace", mock.Anything, "app")
+
+	mockClient.On("DeleteNamespace", mock.Anything, "app").Return(nil).Once()
+	deleted, err := reconciler.handleNamespaceDeletion(ctx, "app", "app", testr.New(t))
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+	assert.Equal(t, 4, server.calls())
+	assert.Equal(t, config.HookEventPreDelete, server.payloads[3].Event)
+	mockClient.AssertExpectations(t)
+}
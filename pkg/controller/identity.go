@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/go-logr/logr"
+)
+
+// applyIdentityGroups writes the configured identity groups, named for
+// namespace, into vaultNamespace, together with the external groups whose
+// aliases make members of an identity provider's groups their members. Groups
+// already written unchanged are skipped.
+func (r *NamespaceReconciler) applyIdentityGroups(ctx context.Context, namespace metav1.Object, vaultNamespace string, log logr.Logger) error {
+	if len(r.Config.IdentityGroups) == 0 {
+		return nil
+	}
+	if err := r.compileConfig(); err != nil {
+		return err
+	}
+
+	key := appliedKey(ctx, vaultNamespace)
+	for i, group := range r.Config.IdentityGroups {
+		name, err := r.renderTemplate(r.groupNames[i], namespace)
+		if err != nil {
+			return fmt.Errorf("identity group %d: %w", i, err)
+		}
+		aliasName := ""
+		if group.ExternalAlias != nil {
+			if aliasName, err = r.renderTemplate(r.groupAliasNames[i], namespace); err != nil {
+				return fmt.Errorf("identity group %q: %w", name, err)
+			}
+		}
+		applied := aliasName + "|" + strings.Join(group.Policies, ",")
+
+		r.mu.Lock()
+		written := r.appliedGroups[key][name] == applied
+		r.mu.Unlock()
+		if written {
+			continue
+		}
+		if r.skipForDryRun(namespace.GetName(), "provision", vaultNamespace, log) {
+			return nil
+		}
+
+		var members []string
+		if alias := group.ExternalAlias; alias != nil {
+			log.V(1).Info("Writing external identity group", "group", aliasName, "mount", alias.Mount)
+			externalID, err := r.VaultClient.EnsureGroup(ctx, alias.Namespace, aliasName, "external", nil, nil)
+			if err != nil {
+				return err
+			}
+			if err := r.VaultClient.EnsureGroupAlias(ctx, alias.Namespace, externalID, alias.Mount, aliasName); err != nil {
+				return err
+			}
+			members = []string{externalID}
+		}
+		log.V(1).Info("Writing identity group", "group", name)
+		policies := append([]string{}, group.Policies...)
+		if _, err := r.VaultClient.EnsureGroup(ctx, vaultNamespace, name, "internal", policies, members); err != nil {
+			return err
+		}
+
+		r.mu.Lock()
+		if r.appliedGroups == nil {
+			r.appliedGroups = make(map[string]map[string]string)
+		}
+		if r.appliedGroups[key] == nil {
+			r.appliedGroups[key] = make(map[string]string)
+		}
+		r.appliedGroups[key][name] = applied
+		r.mu.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestNamespaceReconciler_IdentityGroups tests creating an identity group per
+// namespace with an external group of the identity provider as member.
+func TestNamespaceReconciler_IdentityGroups(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app", Labels: map[string]string{"team": "payments"}}},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "app").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "app").Return(ownedMetadata("app"), nil)
+	mockClient.On("EnsureGroup", mock.Anything, "", "payments-admins", "external", []string(nil), []string(nil)).
+		Return("ext-1", nil).Once()
+	mockClient.On("EnsureGroupAlias", mock.Anything, "", "ext-1", "oidc", "payments-admins").Return(nil).Once()
+	mockClient.On("EnsureGroup", mock.Anything, "app", "app-admins", "internal", []string{"tenant-admin"}, []string{"ext-1"}).
+		Return("group-1", nil).Once()
+	mockClient.On("EnsureGroup", mock.Anything, "app", "app-readers", "internal", []string{"tenant-read"}, []string(nil)).
+		Return("group-2", nil).Once()
+
+	reconciler := &NamespaceReconciler{
+		Client:      k8sClient,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{IdentityGroups: []config.IdentityGroup{
+			{
+				Name:          "{{ .Name }}-admins",
+				Policies:      []string{"tenant-admin"},
+				ExternalAlias: &config.GroupAliasConfig{Mount: "oidc", Name: "{{ .Labels.team }}-admins"},
+			},
+			{Name: "{{ .Name }}-readers", Policies: []string{"tenant-read"}},
+		}},
+		syncChecker: func(string) bool { return true },
+	}
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	// Unchanged groups are not written again
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+	mockClient.AssertNumberOfCalls(t, "EnsureGroup", 3)
+}
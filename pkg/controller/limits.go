@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+	"github.com/go-logr/logr"
+)
+
+// ErrNamespaceLimitReached is returned when creating a Vault namespace would
+// exceed MaxManagedNamespaces.
+var ErrNamespaceLimitReached = errors.New("maximum managed vault namespaces reached")
+
+// limitRecountInterval is how long a count of the managed Vault namespaces is
+// trusted before it is taken again from Vault, picking up the creations and
+// deletions of other shards and controller instances.
+const limitRecountInterval = 5 * time.Minute
+
+// limitCloseRecountInterval is how long a count is trusted once it is close to
+// the limit, where the changes made elsewhere matter.
+const limitCloseRecountInterval = 15 * time.Second
+
+// NamespaceLimit enforces MaxManagedNamespaces. It counts the Vault namespaces
+// carrying the ownership metadata of the controller, whichever shard or cluster
+// created them, per Vault cluster, and keeps the count up to date as the
+// reconcilers create, adopt and delete Vault namespaces. A single
+// NamespaceLimit is shared by the reconcilers of the local and remote clusters.
+type NamespaceLimit struct {
+	// Reconciler is the local cluster's reconciler, whose namespace roots hold
+	// the Vault namespaces of every cluster.
+	Reconciler *NamespaceReconciler
+
+	mu sync.Mutex
+	// counts holds the managed Vault namespaces per VaultConnection, as last
+	// counted and updated since.
+	counts map[string]*managedCount
+}
+
+// managedCount is the number of managed Vault namespaces in a Vault cluster.
+type managedCount struct {
+	managed int
+	// creating are the creations allowed but not finished yet.
+	creating  int
+	countedAt time.Time
+	// recounting is closed once the count being taken from Vault is applied,
+	// and nil when none is being taken.
+	recounting chan struct{}
+	// createdWhileRecounting are the creations finished while the count is
+	// being taken, which it may have missed.
+	createdWhileRecounting int
+}
+
+// reserve reports whether another Vault namespace may be created in the
+// Vault cluster ctx routes to, along with the number already managed. An
+// allowed creation must be followed by created. The count is taken again from
+// Vault when it is older than limitRecountInterval, or than
+// limitCloseRecountInterval once it is close enough to limit that changes made
+// elsewhere matter. Only one count is taken at a time, and other creations go
+// on with the previous count meanwhile; they only wait for the first one.
+func (l *NamespaceLimit) reserve(ctx context.Context, limit int) (int, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := connectionFrom(ctx)
+	count := l.counts[key]
+	if count == nil {
+		if l.counts == nil {
+			l.counts = make(map[string]*managedCount)
+		}
+		count = &managedCount{}
+		l.counts[key] = count
+	}
+	for {
+		if count.recounting == nil && count.stale(limit) {
+			if err := l.recount(ctx, count); err != nil {
+				return 0, false, err
+			}
+		}
+		if !count.countedAt.IsZero() {
+			break
+		}
+		recounting := count.recounting
+		l.mu.Unlock()
+		select {
+		case <-recounting:
+			l.mu.Lock()
+		case <-ctx.Done():
+			l.mu.Lock()
+			return 0, false, ctx.Err()
+		}
+	}
+
+	if count.managed+count.creating >= limit {
+		return count.managed + count.creating, false, nil
+	}
+	count.creating++
+	return count.managed + count.creating - 1, true, nil
+}
+
+// stale reports whether count must be taken again from Vault before a creation.
+func (c *managedCount) stale(limit int) bool {
+	age := time.Since(c.countedAt)
+	margin := max(limit/10, 1)
+	return c.countedAt.IsZero() || age > limitRecountInterval ||
+		(limit-c.managed-c.creating <= margin && age > limitCloseRecountInterval)
+}
+
+// recount takes count again from Vault. It is called with l.mu held, which it
+// releases while Vault is walked.
+func (l *NamespaceLimit) recount(ctx context.Context, count *managedCount) error {
+	recounting := make(chan struct{})
+	count.recounting = recounting
+	l.mu.Unlock()
+
+	managed := 0
+	err := l.Reconciler.walkVaultNamespaces(ctx, func(_ string, customMetadata map[string]string) {
+		if managedBy, _ := l.Reconciler.ownedBy(customMetadata); managedBy {
+			managed++
+		}
+	})
+
+	l.mu.Lock()
+	count.recounting = nil
+	close(recounting)
+	if err != nil {
+		count.createdWhileRecounting = 0
+		return err
+	}
+	// Creations finished or still in flight may already be listed, and are
+	// counted twice until the next count rather than not at all
+	count.managed = managed + count.createdWhileRecounting
+	count.createdWhileRecounting = 0
+	count.countedAt = time.Now()
+	return nil
+}
+
+// created finishes a creation allowed by reserve.
+func (l *NamespaceLimit) created(ctx context.Context, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if count := l.counts[connectionFrom(ctx)]; count != nil {
+		count.creating--
+		if ok {
+			count.managed++
+			if count.recounting != nil {
+				count.createdWhileRecounting++
+			}
+		}
+	}
+}
+
+// add adjusts the count of managed Vault namespaces in the Vault cluster ctx
+// routes to, once one is adopted or deleted.
+func (l *NamespaceLimit) add(ctx context.Context, delta int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if count := l.counts[connectionFrom(ctx)]; count != nil {
+		count.managed = max(count.managed+delta, 0)
+	}
+}
+
+// namespaceLimit returns the NamespaceLimit of the reconciler, counting from
+// its own namespace roots when none was shared with it.
+func (r *NamespaceReconciler) namespaceLimit() *NamespaceLimit {
+	r.limitOnce.Do(func() {
+		if r.Limit == nil {
+			r.Limit = &NamespaceLimit{Reconciler: r}
+		}
+	})
+	return r.Limit
+}
+
+// checkNamespaceLimit refuses the creation of vaultNamespace once the
+// controller already manages MaxManagedNamespaces Vault namespaces. An allowed
+// creation must be followed by a call to created on the namespace limit.
+func (r *NamespaceReconciler) checkNamespaceLimit(ctx context.Context, namespaceName, vaultNamespace string, log logr.Logger) error {
+	limit := r.Config.MaxManagedNamespaces
+	managed, ok, err := r.namespaceLimit().reserve(ctx, limit)
+	if err != nil {
+		log.Error(err, "Failed to count managed Vault namespaces")
+		return fmt.Errorf("%w: %w", ErrNamespaceCheck, err)
+	}
+	if ok {
+		return nil
+	}
+
+	log.Info("Maximum managed Vault namespaces reached, refusing to create", "limit", limit, "managed", managed)
+	metrics.CreationsBlockedTotal.WithLabelValues("limit_reached").Inc()
+	metrics.ReconcilesSkippedTotal.WithLabelValues(SkipGuardrail).Inc()
+	r.recordEvent(namespaceName, corev1.EventTypeWarning, "VaultNamespaceLimitReached",
+		"Vault namespace %s was not created: %d of at most %d Vault namespaces are already managed",
+		vaultNamespace, managed, limit)
+	return fmt.Errorf("%w: %d", ErrNamespaceLimitReached, limit)
+}
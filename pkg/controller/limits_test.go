@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// limitVaultClient returns a Vault client holding a Vault namespace owned by
+// this cluster, one owned by another cluster and one created outside the
+// controller.
+func limitVaultClient() *mockVaultClient {
+	mockClient := new(mockVaultClient)
+	mockClient.On("ListNamespaces", mock.Anything, "").Return([]string{"k8s-app-a", "k8s-app-b", "team-x"}, nil)
+	for _, name := range []string{"k8s-app-a", "k8s-app-b", "team-x"} {
+		mockClient.On("ListNamespaces", mock.Anything, name).Return([]string{}, nil)
+	}
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-app-a").Return(ownedMetadata("app-a"), nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-app-b").Return(map[string]string{
+		MetadataManagedBy:         "vault-namespace-controller",
+		MetadataKubernetesCluster: "west",
+	}, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "team-x").Return(map[string]string{}, nil)
+	return mockClient
+}
+
+// TestHandleNamespaceCreation_MaxManagedNamespaces tests the creation
+// guardrail counts the Vault namespaces managed by every cluster.
+func TestHandleNamespaceCreation_MaxManagedNamespaces(t *testing.T) {
+	tests := []struct {
+		name         string
+		limit        int
+		expectCreate bool
+	}{
+		{name: "below the limit", limit: 3, expectCreate: true},
+		{name: "at the limit", limit: 2, expectCreate: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := limitVaultClient()
+			mockClient.On("NamespaceExists", mock.Anything, "k8s-app-c").Return(false, nil)
+			if tt.expectCreate {
+				mockClient.On("CreateNamespace", mock.Anything, "k8s-app-c", ownedMetadata("app-c")).Return(nil)
+			}
+
+			recorder := record.NewFakeRecorder(10)
+			reconciler := &NamespaceReconciler{
+				Log:         testr.New(t),
+				VaultClient: mockClient,
+				Recorder:    recorder,
+				Config: &config.ControllerConfig{
+					NamespaceFormat:      "k8s-%s",
+					MaxManagedNamespaces: tt.limit,
+				},
+			}
+
+			_, err := reconciler.handleNamespaceCreation(context.Background(), "app-c", "k8s-app-c", reconciler.Log)
+			if tt.expectCreate {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, ErrNamespaceLimitReached))
+				assert.Contains(t, <-recorder.Events, "VaultNamespaceLimitReached")
+				mockClient.AssertNotCalled(t, "CreateNamespace", mock.Anything, mock.Anything, mock.Anything)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+// TestNamespaceLimit tests the count is kept up to date as Vault namespaces
+// are created and deleted, and only taken again from Vault near the limit.
+func TestNamespaceLimit(t *testing.T) {
+	mockClient := limitVaultClient()
+	limit := &NamespaceLimit{Reconciler: &NamespaceReconciler{
+		VaultClient: mockClient,
+		Config:      &config.ControllerConfig{},
+	}}
+	ctx := context.Background()
+
+	managed, ok, err := limit.reserve(ctx, 10)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, managed)
+	limit.created(ctx, true)
+	mockClient.AssertNumberOfCalls(t, "ListNamespaces", 4)
+
+	// A failed creation and a deletion are not counted
+	_, ok, _ = limit.reserve(ctx, 10)
+	assert.True(t, ok)
+	limit.created(ctx, false)
+	limit.add(ctx, -1)
+	managed, ok, err = limit.reserve(ctx, 10)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, managed)
+	mockClient.AssertNumberOfCalls(t, "ListNamespaces", 4)
+
+	// Creations in flight count against the limit, which is close enough now to
+	// count again once the count is older than limitCloseRecountInterval
+	managed, ok, err = limit.reserve(ctx, 3)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 3, managed)
+	mockClient.AssertNumberOfCalls(t, "ListNamespaces", 4)
+
+	limit.counts[""].countedAt = time.Now().Add(-limitCloseRecountInterval - time.Second)
+	managed, ok, err = limit.reserve(ctx, 3)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 3, managed)
+	mockClient.AssertNumberOfCalls(t, "ListNamespaces", 8)
+}
+
+// TestNamespaceLimit_Recount tests creations go on with the previous count
+// while it is taken again from Vault, and the creations finished meanwhile
+// are kept.
+func TestNamespaceLimit_Recount(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mockClient := new(mockVaultClient)
+	mockClient.On("ListNamespaces", mock.Anything, "").Run(func(mock.Arguments) {
+		close(started)
+		<-release
+	}).Return([]string{"k8s-app-a"}, nil).Once()
+	mockClient.On("ListNamespaces", mock.Anything, "k8s-app-a").Return([]string{}, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-app-a").Return(ownedMetadata("app-a"), nil)
+
+	limit := &NamespaceLimit{
+		Reconciler: &NamespaceReconciler{
+			VaultClient: mockClient,
+			Config:      &config.ControllerConfig{},
+		},
+		counts: map[string]*managedCount{
+			"": {managed: 2, countedAt: time.Now().Add(-limitRecountInterval - time.Second)},
+		},
+	}
+	ctx := context.Background()
+
+	type result struct {
+		managed int
+		ok      bool
+		err     error
+	}
+	recounted := make(chan result, 1)
+	go func() {
+		managed, ok, err := limit.reserve(ctx, 10)
+		recounted <- result{managed, ok, err}
+	}()
+	<-started
+
+	managed, ok, err := limit.reserve(ctx, 10)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, managed)
+	limit.created(ctx, true)
+
+	close(release)
+	r := <-recounted
+	assert.NoError(t, r.err)
+	assert.True(t, r.ok)
+	assert.Equal(t, 2, r.managed)
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,300 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/go-logr/logr"
+)
+
+// LiveConfig holds the settings of the VaultNamespaceControllerConfig, which
+// override the configuration file while the controller runs, and the
+// settings reloaded from the configuration file when hot reload is enabled.
+type LiveConfig struct {
+	mu          sync.RWMutex
+	spec        *vaultv1alpha1.VaultNamespaceControllerConfigSpec
+	file        *config.ControllerConfig
+	subscribers []func()
+}
+
+// Set replaces the overrides, or removes them when spec is nil, and notifies
+// the subscribed reconcilers.
+func (l *LiveConfig) Set(spec *vaultv1alpha1.VaultNamespaceControllerConfigSpec) {
+	l.mu.Lock()
+	if spec != nil {
+		spec = spec.DeepCopy()
+	}
+	l.spec = spec
+	l.notify()
+}
+
+// SetFile replaces the settings reloaded from the configuration file and
+// notifies the subscribed reconcilers.
+func (l *LiveConfig) SetFile(cfg *config.ControllerConfig) {
+	l.mu.Lock()
+	l.file = cfg
+	l.notify()
+}
+
+// notify unlocks l and calls the subscribers.
+func (l *LiveConfig) notify() {
+	subscribers := append([]func(){}, l.subscribers...)
+	l.mu.Unlock()
+
+	for _, notify := range subscribers {
+		notify()
+	}
+}
+
+// Spec returns the current overrides, or nil when there are none.
+func (l *LiveConfig) Spec() *vaultv1alpha1.VaultNamespaceControllerConfigSpec {
+	if l == nil {
+		return nil
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.spec
+}
+
+// File returns the configuration last reloaded from the configuration file,
+// or nil when it was not reloaded.
+func (l *LiveConfig) File() *config.ControllerConfig {
+	if l == nil {
+		return nil
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.file
+}
+
+// subscribe calls notify whenever the overrides change.
+func (l *LiveConfig) subscribe(notify func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.subscribers = append(l.subscribers, notify)
+}
+
+// applyControllerConfig returns a copy of base with the overrides of spec.
+func applyControllerConfig(base *config.ControllerConfig, spec *vaultv1alpha1.VaultNamespaceControllerConfigSpec) *config.ControllerConfig {
+	cfg := *base
+	if spec == nil {
+		return &cfg
+	}
+	if spec.IncludeNamespaces != nil {
+		cfg.IncludeNamespaces = spec.IncludeNamespaces
+	}
+	if spec.ExcludeNamespaces != nil {
+		cfg.ExcludeNamespaces = spec.ExcludeNamespaces
+	}
+	if spec.NamespaceFormat != "" {
+		cfg.NamespaceFormat = spec.NamespaceFormat
+	}
+	if spec.DeleteVaultNamespaces != nil {
+		cfg.DeleteVaultNamespaces = *spec.DeleteVaultNamespaces
+	}
+	if spec.DeleteNonEmptyNamespaces != nil {
+		cfg.DeleteNonEmptyNamespaces = *spec.DeleteNonEmptyNamespaces
+	}
+	return &cfg
+}
+
+// ValidateControllerConfig checks that spec is valid on top of base.
+func ValidateControllerConfig(base *config.ControllerConfig, spec *vaultv1alpha1.VaultNamespaceControllerConfigSpec) error {
+	return applyControllerConfig(base, spec).Validate()
+}
+
+// includeNamespaces returns the include patterns in effect.
+func (r *NamespaceReconciler) includeNamespaces() []string {
+	if spec := r.Live.Spec(); spec != nil && spec.IncludeNamespaces != nil {
+		return spec.IncludeNamespaces
+	}
+	return r.fileConfig().IncludeNamespaces
+}
+
+// excludeNamespaces returns the exclude patterns in effect.
+func (r *NamespaceReconciler) excludeNamespaces() []string {
+	if spec := r.Live.Spec(); spec != nil && spec.ExcludeNamespaces != nil {
+		return spec.ExcludeNamespaces
+	}
+	return r.fileConfig().ExcludeNamespaces
+}
+
+// patternSyntax returns the syntax of the include and exclude patterns.
+func (r *NamespaceReconciler) patternSyntax() string {
+	return r.fileConfig().PatternSyntax
+}
+
+// strictMatch reports whether regex patterns must match the whole name.
+func (r *NamespaceReconciler) strictMatch() bool {
+	return r.fileConfig().StrictMatch
+}
+
+// namespaceFormat returns the NamespaceFormat in effect.
+func (r *NamespaceReconciler) namespaceFormat() string {
+	if spec := r.Live.Spec(); spec != nil && spec.NamespaceFormat != "" {
+		return spec.NamespaceFormat
+	}
+	return r.fileConfig().NamespaceFormat
+}
+
+// deleteVaultNamespaces reports whether DeleteVaultNamespaces is in effect.
+func (r *NamespaceReconciler) deleteVaultNamespaces() bool {
+	if spec := r.Live.Spec(); spec != nil && spec.DeleteVaultNamespaces != nil {
+		return *spec.DeleteVaultNamespaces
+	}
+	return r.fileConfig().DeleteVaultNamespaces
+}
+
+// deleteNonEmptyNamespaces reports whether DeleteNonEmptyNamespaces is in effect.
+func (r *NamespaceReconciler) deleteNonEmptyNamespaces() bool {
+	if spec := r.Live.Spec(); spec != nil && spec.DeleteNonEmptyNamespaces != nil {
+		return *spec.DeleteNonEmptyNamespaces
+	}
+	return r.fileConfig().DeleteNonEmptyNamespaces
+}
+
+// reconcileInterval returns the ReconcileInterval in effect.
+func (r *NamespaceReconciler) reconcileInterval() time.Duration {
+	return time.Duration(r.fileConfig().ReconcileInterval) * time.Second
+}
+
+// fileConfig returns the configuration file in effect, which is the reloaded
+// one when hot reload is enabled. Only the settings that can be reloaded may
+// be read from it.
+func (r *NamespaceReconciler) fileConfig() *config.ControllerConfig {
+	if file := r.Live.File(); file != nil {
+		return file
+	}
+	return r.Config
+}
+
+// liveConfigSource enqueues every namespace the reconciler owns whenever the
+// overrides change, so that they apply without waiting for the next reconcile.
+func (r *NamespaceReconciler) liveConfigSource() source.Source {
+	return source.Func(func(ctx context.Context, queue workqueue.TypedRateLimitingInterface[reconcile.Request]) error {
+		r.Live.subscribe(func() {
+			nsList := newNamespaceMetadataList()
+			if err := r.Client.List(ctx, nsList); err != nil {
+				r.Log.Error(err, "Failed to list namespaces after a configuration change")
+				return
+			}
+			for _, ns := range nsList.Items {
+				if r.Shard.Owns(ns.Name) {
+					queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{Name: ns.Name}})
+				}
+			}
+		})
+		return nil
+	})
+}
+
+// ControllerConfigReconciler applies the VaultNamespaceControllerConfig to the
+// running controller and reports the outcome in its Applied condition.
+type ControllerConfigReconciler struct {
+	client.Client
+	Log  logr.Logger
+	Live *LiveConfig
+	// Base is the configuration loaded from the configuration file.
+	Base *config.ControllerConfig
+}
+
+func (r *ControllerConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var controllerConfig vaultv1alpha1.VaultNamespaceControllerConfig
+	if err := r.Get(ctx, req.NamespacedName, &controllerConfig); err != nil {
+		if k8serrors.IsNotFound(err) {
+			if r.Live.Spec() != nil {
+				r.Log.Info("VaultNamespaceControllerConfig removed, using the configuration file")
+				r.Live.Set(nil)
+			}
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	condition := metav1.Condition{
+		Type:               vaultv1alpha1.ConditionApplied,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: controllerConfig.Generation,
+		Reason:             "Applied",
+	}
+	if err := ValidateControllerConfig(r.Base, &controllerConfig.Spec); err != nil {
+		// The previous settings stay in effect until the spec is fixed
+		r.Log.Error(err, "Invalid VaultNamespaceControllerConfig, keeping the previous settings")
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Invalid"
+		condition.Message = err.Error()
+	} else {
+		r.Log.Info("Applying VaultNamespaceControllerConfig", "generation", controllerConfig.Generation)
+		r.Live.Set(&controllerConfig.Spec)
+	}
+
+	if !meta.SetStatusCondition(&controllerConfig.Status.Conditions, condition) {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{}, r.Status().Update(ctx, &controllerConfig)
+}
+
+func (r *ControllerConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("controllerconfig").
+		For(&vaultv1alpha1.VaultNamespaceControllerConfig{},
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+// ControllerConfigValidator rejects VaultNamespaceControllerConfigs the
+// controller could not apply.
+type ControllerConfigValidator struct {
+	// Base is the configuration loaded from the configuration file.
+	Base *config.ControllerConfig
+}
+
+var _ admission.CustomValidator = &ControllerConfigValidator{}
+
+func (v *ControllerConfigValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return v.validate(obj)
+}
+
+func (v *ControllerConfigValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	return v.validate(newObj)
+}
+
+func (v *ControllerConfigValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *ControllerConfigValidator) validate(obj runtime.Object) (admission.Warnings, error) {
+	controllerConfig, ok := obj.(*vaultv1alpha1.VaultNamespaceControllerConfig)
+	if !ok {
+		return nil, fmt.Errorf("expected a VaultNamespaceControllerConfig, got %T", obj)
+	}
+	if controllerConfig.Name != vaultv1alpha1.ControllerConfigName {
+		return nil, fmt.Errorf("the VaultNamespaceControllerConfig must be named %s", vaultv1alpha1.ControllerConfigName)
+	}
+	return nil, ValidateControllerConfig(v.Base, &controllerConfig.Spec)
+}
+
+func (v *ControllerConfigValidator) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&vaultv1alpha1.VaultNamespaceControllerConfig{}).
+		WithValidator(v).
+		Complete()
+}
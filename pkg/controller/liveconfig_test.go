@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestNamespaceReconciler_liveConfig tests that overrides replace the configured settings.
+func TestNamespaceReconciler_liveConfig(t *testing.T) {
+	r := &NamespaceReconciler{
+		Config: &config.ControllerConfig{
+			NamespaceFormat:       "%s",
+			ExcludeNamespaces:     []string{"^sandbox-.*"},
+			DeleteVaultNamespaces: true,
+		},
+		Live: &LiveConfig{},
+	}
+
+	assert.True(t, r.shouldSyncNamespace(&metav1.ObjectMeta{Name: "team-a"}))
+	assert.False(t, r.shouldSyncNamespace(&metav1.ObjectMeta{Name: "sandbox-a"}))
+	assert.Equal(t, "team-a", r.formatNamespaceName("team-a"))
+	assert.True(t, r.deleteVaultNamespaces())
+
+	deleteVaultNamespaces := false
+	notified := 0
+	r.Live.subscribe(func() { notified++ })
+	r.Live.Set(&vaultv1alpha1.VaultNamespaceControllerConfigSpec{
+		IncludeNamespaces:     []string{"^sandbox-.*"},
+		ExcludeNamespaces:     []string{},
+		NamespaceFormat:       "k8s-%s",
+		DeleteVaultNamespaces: &deleteVaultNamespaces,
+	})
+	assert.Equal(t, 1, notified)
+	assert.False(t, r.shouldSyncNamespace(&metav1.ObjectMeta{Name: "team-a"}))
+	assert.True(t, r.shouldSyncNamespace(&metav1.ObjectMeta{Name: "sandbox-a"}))
+	assert.Equal(t, "k8s-team-a", r.formatNamespaceName("team-a"))
+	assert.False(t, r.deleteVaultNamespaces())
+	assert.False(t, r.deleteNonEmptyNamespaces())
+
+	r.Live.Set(nil)
+	assert.Equal(t, 2, notified)
+	assert.Equal(t, "team-a", r.formatNamespaceName("team-a"))
+	assert.True(t, r.deleteVaultNamespaces())
+}
+
+// liveConfigBase returns a valid configuration file to apply overrides to.
+func liveConfigBase(t *testing.T) *config.ControllerConfig {
+	t.Setenv(config.ClusterNameEnv, "")
+	base, err := config.LoadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base.Vault = config.VaultConfig{
+		Address: "https://vault:8200",
+		Auth:    config.VaultAuthConfig{Type: "token", Token: "root"},
+	}
+	return base
+}
+
+// TestValidateControllerConfig tests validating overrides against the configuration file.
+func TestValidateControllerConfig(t *testing.T) {
+	base := liveConfigBase(t)
+
+	tests := []struct {
+		name    string
+		spec    vaultv1alpha1.VaultNamespaceControllerConfigSpec
+		wantErr bool
+	}{
+		{name: "empty"},
+		{name: "valid", spec: vaultv1alpha1.VaultNamespaceControllerConfigSpec{
+			IncludeNamespaces: []string{"^team-.*"},
+			NamespaceFormat:   "k8s-%s",
+		}},
+		{name: "invalid include pattern", spec: vaultv1alpha1.VaultNamespaceControllerConfigSpec{
+			IncludeNamespaces: []string{"team-("},
+		}, wantErr: true},
+		{name: "invalid exclude pattern", spec: vaultv1alpha1.VaultNamespaceControllerConfigSpec{
+			ExcludeNamespaces: []string{"["},
+		}, wantErr: true},
+		{name: "cluster placeholder without cluster name", spec: vaultv1alpha1.VaultNamespaceControllerConfigSpec{
+			NamespaceFormat: config.ClusterPlaceholder + "-%s",
+		}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateControllerConfig(base, &tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestControllerConfigReconciler tests applying and reverting the VaultNamespaceControllerConfig.
+func TestControllerConfigReconciler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = vaultv1alpha1.AddToScheme(scheme)
+
+	controllerConfig := &vaultv1alpha1.VaultNamespaceControllerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: vaultv1alpha1.ControllerConfigName, Generation: 1},
+		Spec:       vaultv1alpha1.VaultNamespaceControllerConfigSpec{NamespaceFormat: "k8s-%s"},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(controllerConfig).
+		WithStatusSubresource(controllerConfig).
+		Build()
+
+	live := &LiveConfig{}
+	r := &ControllerConfigReconciler{
+		Client: fakeClient,
+		Log:    testr.New(t),
+		Live:   live,
+		Base:   liveConfigBase(t),
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: vaultv1alpha1.ControllerConfigName}}
+	ctx := context.Background()
+
+	_, err := r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	if assert.NotNil(t, live.Spec()) {
+		assert.Equal(t, "k8s-%s", live.Spec().NamespaceFormat)
+	}
+	assert.NoError(t, fakeClient.Get(ctx, req.NamespacedName, controllerConfig))
+	assert.True(t, meta.IsStatusConditionTrue(controllerConfig.Status.Conditions, vaultv1alpha1.ConditionApplied))
+
+	// An invalid spec keeps the previous settings
+	controllerConfig.Spec.IncludeNamespaces = []string{"team-("}
+	controllerConfig.Generation++
+	assert.NoError(t, fakeClient.Update(ctx, controllerConfig))
+	_, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Nil(t, live.Spec().IncludeNamespaces)
+	assert.NoError(t, fakeClient.Get(ctx, req.NamespacedName, controllerConfig))
+	applied := meta.FindStatusCondition(controllerConfig.Status.Conditions, vaultv1alpha1.ConditionApplied)
+	if assert.NotNil(t, applied) {
+		assert.Equal(t, metav1.ConditionFalse, applied.Status)
+		assert.Equal(t, "Invalid", applied.Reason)
+	}
+
+	assert.NoError(t, fakeClient.Delete(ctx, controllerConfig))
+	_, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Nil(t, live.Spec())
+}
+
+// TestControllerConfigValidator tests the admission webhook.
+func TestControllerConfigValidator(t *testing.T) {
+	v := &ControllerConfigValidator{Base: liveConfigBase(t)}
+	ctx := context.Background()
+
+	valid := &vaultv1alpha1.VaultNamespaceControllerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: vaultv1alpha1.ControllerConfigName},
+		Spec:       vaultv1alpha1.VaultNamespaceControllerConfigSpec{ExcludeNamespaces: []string{"^tmp-.*"}},
+	}
+	_, err := v.ValidateCreate(ctx, valid)
+	assert.NoError(t, err)
+
+	misnamed := valid.DeepCopy()
+	misnamed.Name = "other"
+	_, err = v.ValidateCreate(ctx, misnamed)
+	assert.Error(t, err)
+
+	invalid := valid.DeepCopy()
+	invalid.Spec.ExcludeNamespaces = []string{"tmp-("}
+	_, err = v.ValidateUpdate(ctx, valid, invalid)
+	assert.Error(t, err)
+
+	_, err = v.ValidateDelete(ctx, invalid)
+	assert.NoError(t, err)
+}
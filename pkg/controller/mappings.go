@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MappingRecord is the persisted mapping of a Kubernetes namespace to the Vault
+// namespace created for it.
+type MappingRecord struct {
+	KubernetesNamespace string    `json:"kubernetesNamespace"`
+	Cluster             string    `json:"cluster,omitempty"`
+	VaultNamespace      string    `json:"vaultNamespace"`
+	CreatedAt           time.Time `json:"createdAt"`
+}
+
+// MappingStore persists the mapping of every synchronized namespace in a
+// ConfigMap, one JSON-encoded MappingRecord per key. It is the authoritative
+// record of where each namespace was synchronized to, whatever the current
+// mapping configuration. A nil MappingStore records nothing.
+type MappingStore struct {
+	// Client writes the ConfigMap and Reader reads it, bypassing the cache.
+	Client    client.Client
+	Reader    client.Reader
+	ConfigMap types.NamespacedName
+
+	mu sync.Mutex
+	// records caches the ConfigMap's records by key, loaded on first use.
+	records map[string]MappingRecord
+}
+
+// mappingKey returns the ConfigMap key of a namespace's record. Namespace names
+// cannot contain dots, so the cluster prefix is unambiguous.
+func mappingKey(cluster, namespaceName string) string {
+	if cluster == "" {
+		return namespaceName
+	}
+	return cluster + "." + namespaceName
+}
+
+// Lookup returns the record of a namespace in cluster, if any.
+func (s *MappingStore) Lookup(ctx context.Context, cluster, namespaceName string) (MappingRecord, bool, error) {
+	if s == nil {
+		return MappingRecord{}, false, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(ctx); err != nil {
+		return MappingRecord{}, false, err
+	}
+	record, ok := s.records[mappingKey(cluster, namespaceName)]
+	return record, ok, nil
+}
+
+// Records returns every record, sorted by cluster and namespace.
+func (s *MappingStore) Records(ctx context.Context) ([]MappingRecord, error) {
+	if s == nil {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+	records := make([]MappingRecord, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Cluster != records[j].Cluster {
+			return records[i].Cluster < records[j].Cluster
+		}
+		return records[i].KubernetesNamespace < records[j].KubernetesNamespace
+	})
+	return records, nil
+}
+
+// Record stores the mapping of a namespace in cluster to vaultNamespace. An
+// unchanged mapping keeps its original creation time and is not written again.
+func (s *MappingStore) Record(ctx context.Context, cluster, namespaceName, vaultNamespace string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(ctx); err != nil {
+		return err
+	}
+	key := mappingKey(cluster, namespaceName)
+	if record, ok := s.records[key]; ok && record.VaultNamespace == vaultNamespace {
+		return nil
+	}
+
+	value, err := json.Marshal(MappingRecord{
+		KubernetesNamespace: namespaceName,
+		Cluster:             cluster,
+		VaultNamespace:      vaultNamespace,
+		CreatedAt:           time.Now().UTC().Truncate(time.Second),
+	})
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, func(data map[string]string) { data[key] = string(value) })
+}
+
+// Forget removes the record of a namespace in cluster.
+func (s *MappingStore) Forget(ctx context.Context, cluster, namespaceName string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(ctx); err != nil {
+		return err
+	}
+	key := mappingKey(cluster, namespaceName)
+	if _, ok := s.records[key]; !ok {
+		return nil
+	}
+	return s.update(ctx, func(data map[string]string) { delete(data, key) })
+}
+
+// load reads the ConfigMap into the cache unless it has been read already.
+// The caller must hold mu.
+func (s *MappingStore) load(ctx context.Context) error {
+	if s.records != nil {
+		return nil
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := s.Reader.Get(ctx, s.ConfigMap, configMap); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	s.records = decodeMappingRecords(configMap.Data)
+	return nil
+}
+
+// update applies mutate to the latest ConfigMap data, creating the ConfigMap if
+// needed, and refreshes the cache. Other replicas may write the same ConfigMap,
+// so conflicts are retried against a fresh read. The caller must hold mu.
+func (s *MappingStore) update(ctx context.Context, mutate func(data map[string]string)) error {
+	var data map[string]string
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap := &corev1.ConfigMap{}
+		err := s.Reader.Get(ctx, s.ConfigMap, configMap)
+		if k8serrors.IsNotFound(err) {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: s.ConfigMap.Namespace, Name: s.ConfigMap.Name},
+				Data:       map[string]string{},
+			}
+			mutate(configMap.Data)
+			data = configMap.Data
+			return s.Client.Create(ctx, configMap)
+		}
+		if err != nil {
+			return err
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		mutate(configMap.Data)
+		data = configMap.Data
+		return s.Client.Update(ctx, configMap)
+	})
+	if err != nil {
+		// Reload on next use rather than trusting a cache that may be stale
+		s.records = nil
+		return err
+	}
+	s.records = decodeMappingRecords(data)
+	return nil
+}
+
+// decodeMappingRecords parses ConfigMap data, skipping entries that are not records.
+func decodeMappingRecords(data map[string]string) map[string]MappingRecord {
+	records := make(map[string]MappingRecord, len(data))
+	for key, value := range data {
+		var record MappingRecord
+		if err := json.Unmarshal([]byte(value), &record); err != nil || record.VaultNamespace == "" {
+			continue
+		}
+		records[key] = record
+	}
+	return records
+}
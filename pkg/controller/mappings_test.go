@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+func TestMappingStore(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	key := types.NamespacedName{Namespace: "vault-system", Name: "mappings"}
+	store := &MappingStore{Client: k8sClient, Reader: k8sClient, ConfigMap: key}
+	ctx := context.Background()
+
+	// The ConfigMap is created with the first record
+	assert.NoError(t, store.Record(ctx, "", "app", "admin/app"))
+	assert.NoError(t, store.Record(ctx, "west", "app", "admin/west/app"))
+
+	configMap := &corev1.ConfigMap{}
+	assert.NoError(t, k8sClient.Get(ctx, key, configMap))
+	assert.Contains(t, configMap.Data, "app")
+	assert.Contains(t, configMap.Data, "west.app")
+
+	// Records survive a restart
+	reloaded := &MappingStore{Client: k8sClient, Reader: k8sClient, ConfigMap: key}
+	record, ok, err := reloaded.Lookup(ctx, "west", "app")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "admin/west/app", record.VaultNamespace)
+	assert.False(t, record.CreatedAt.IsZero())
+
+	records, err := reloaded.Records(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "", records[0].Cluster)
+
+	assert.NoError(t, reloaded.Forget(ctx, "", "app"))
+	_, ok, err = reloaded.Lookup(ctx, "", "app")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// A nil store records nothing
+	var none *MappingStore
+	assert.NoError(t, none.Record(ctx, "", "app", "admin/app"))
+	_, ok, err = none.Lookup(ctx, "", "app")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+// TestNamespaceReconciler_PersistedMapping tests that a deleted namespace's Vault
+// namespace is found through its persisted mapping after the format changed.
+func TestNamespaceReconciler_PersistedMapping(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "vault-system", Name: "mappings"},
+		Data: map[string]string{
+			"app": `{"kubernetesNamespace":"app","vaultNamespace":"old-app","createdAt":"2026-01-12T09:30:00Z"}`,
+		},
+	}).Build()
+	store := &MappingStore{
+		Client:    k8sClient,
+		Reader:    k8sClient,
+		ConfigMap: types.NamespacedName{Namespace: "vault-system", Name: "mappings"},
+	}
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "old-app").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "old-app").Return(ownedMetadata("app"), nil)
+	mockClient.On("NamespaceEmpty", mock.Anything, "old-app").Return(true, nil)
+	mockClient.On("DeleteNamespace", mock.Anything, "old-app").Return(nil)
+
+	reconciler := &NamespaceReconciler{
+		Client:      k8sClient,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Mappings:    store,
+		Config: &config.ControllerConfig{
+			NamespaceFormat:       "new-%s",
+			DeleteVaultNamespaces: true,
+		},
+	}
+
+	result, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}})
+	assert.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+	mockClient.AssertExpectations(t)
+
+	_, ok, err := store.Lookup(context.Background(), "", "app")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// namespaceGVK identifies Namespaces for metadata-only reads.
+var namespaceGVK = corev1.SchemeGroupVersion.WithKind("Namespace")
+
+// newNamespaceMetadata returns an empty metadata-only Namespace. The controller
+// only needs names, labels and annotations, so all Namespace reads go through
+// metadata-only objects, which are served from a metadata-only cache instead of
+// caching full Namespace objects.
+func newNamespaceMetadata() *metav1.PartialObjectMetadata {
+	namespace := &metav1.PartialObjectMetadata{}
+	namespace.SetGroupVersionKind(namespaceGVK)
+	return namespace
+}
+
+// newNamespaceMetadataList returns an empty metadata-only Namespace list.
+func newNamespaceMetadataList() *metav1.PartialObjectMetadataList {
+	nsList := &metav1.PartialObjectMetadataList{}
+	nsList.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("NamespaceList"))
+	return nsList
+}
+
+// existsOutsideCache reports whether a namespace missing from the cache still
+// exists. When the cache is limited by NamespaceSelector, a namespace that stops
+// matching the selector disappears from the cache just as a deleted one does,
+// so the API server is asked directly before treating it as deleted.
+func (r *NamespaceReconciler) existsOutsideCache(ctx context.Context, namespaceName string) (bool, error) {
+	if r.Config.NamespaceSelector == "" || r.APIReader == nil {
+		return false, nil
+	}
+	err := r.APIReader.Get(ctx, types.NamespacedName{Name: namespaceName}, newNamespaceMetadata())
+	if err == nil {
+		return true, nil
+	}
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/benemon/vault-namespace-controller/pkg/audit"
+	"github.com/go-logr/logr"
+)
+
+// MetadataMigratedFrom records, on a Vault namespace, the Vault namespace its
+// Kubernetes namespace was previously synchronized to.
+const MetadataMigratedFrom = "migrated-from"
+
+// MigrationStatus is the state of a namespace's move from its previous Vault namespace.
+type MigrationStatus string
+
+const (
+	// MigrationPending means the new Vault namespace does not exist yet.
+	MigrationPending MigrationStatus = "pending"
+	// MigrationReady means the new Vault namespace exists and the previous one
+	// can be deleted.
+	MigrationReady MigrationStatus = "ready"
+	// MigrationNotEmpty means the previous Vault namespace still has secret or
+	// auth mounts, and is kept unless DeleteNonEmptyNamespaces is set.
+	MigrationNotEmpty MigrationStatus = "notEmpty"
+	// MigrationNotOwned means the previous Vault namespace was not created for
+	// this namespace by this controller, and is left alone.
+	MigrationNotOwned MigrationStatus = "notOwned"
+)
+
+// MigrationEntry describes the move of a single Kubernetes namespace.
+type MigrationEntry struct {
+	KubernetesNamespace string          `json:"kubernetesNamespace"`
+	OldVaultNamespace   string          `json:"oldVaultNamespace"`
+	NewVaultNamespace   string          `json:"newVaultNamespace"`
+	Status              MigrationStatus `json:"status"`
+}
+
+// MigrationReport lists the namespaces whose previous Vault namespace still exists.
+type MigrationReport struct {
+	PreviousFormat string `json:"previousFormat"`
+
+	// DeleteOld reports whether previous Vault namespaces that are ready would
+	// actually be deleted.
+	DeleteOld bool `json:"deleteOld"`
+
+	Entries []MigrationEntry `json:"entries"`
+}
+
+// previousVaultNamespacePath returns the Vault namespace path namespace had with
+// the previous NamespaceFormat.
+func (r *NamespaceReconciler) previousVaultNamespacePath(namespace metav1.Object) string {
+	return joinNamespaceRoot(r.namespaceRootFor(namespace),
+		r.formatNamespaceNameWith(r.Config.Migration.PreviousFormat, namespace.GetName()))
+}
+
+// migrationStatus returns the state of the move from oldVaultNamespace to
+// vaultNamespace, or "" when there is nothing left to migrate.
+func (r *NamespaceReconciler) migrationStatus(ctx context.Context, namespaceName, oldVaultNamespace, vaultNamespace string) (MigrationStatus, error) {
+	if strings.Trim(oldVaultNamespace, "/") == strings.Trim(vaultNamespace, "/") {
+		return "", nil
+	}
+
+	exists, err := r.VaultClient.NamespaceExists(ctx, oldVaultNamespace)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrNamespaceCheck, err)
+	}
+	if !exists {
+		return "", nil
+	}
+
+	customMetadata, err := r.VaultClient.GetNamespaceMetadata(ctx, oldVaultNamespace)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrNamespaceCheck, err)
+	}
+	if _, ours := r.ownedBy(customMetadata); !ours || customMetadata[MetadataKubernetesNamespace] != namespaceName {
+		return MigrationNotOwned, nil
+	}
+
+	if exists, err := r.VaultClient.NamespaceExists(ctx, vaultNamespace); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrNamespaceCheck, err)
+	} else if !exists {
+		return MigrationPending, nil
+	}
+
+	if !r.deleteNonEmptyNamespaces() {
+		empty, err := r.VaultClient.NamespaceEmpty(ctx, oldVaultNamespace)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrNamespaceCheck, err)
+		}
+		if !empty {
+			return MigrationNotEmpty, nil
+		}
+	}
+	return MigrationReady, nil
+}
+
+// migrateNamespace moves a synchronized namespace off the Vault namespace it
+// had with the previous NamespaceFormat. Once the new Vault namespace exists,
+// the previous path is recorded in its metadata and, with DeleteOld, the
+// previous Vault namespace is deleted.
+func (r *NamespaceReconciler) migrateNamespace(ctx context.Context, namespace metav1.Object, vaultNamespace string, log logr.Logger) error {
+	if r.Config.Migration.PreviousFormat == "" {
+		return nil
+	}
+
+	namespaceName := namespace.GetName()
+	oldVaultNamespace := r.previousVaultNamespacePath(namespace)
+	status, err := r.migrationStatus(ctx, namespaceName, oldVaultNamespace, vaultNamespace)
+	if err != nil {
+		return err
+	}
+	log = log.WithValues("oldVaultNamespace", oldVaultNamespace, "migrationStatus", status)
+
+	switch status {
+	case "", MigrationPending:
+		return nil
+	case MigrationNotOwned:
+		log.V(1).Info("Previous Vault namespace is not owned by this namespace, skipping migration")
+		return nil
+	}
+
+	if err := r.recordMigration(ctx, namespaceName, oldVaultNamespace, vaultNamespace, log); err != nil {
+		return err
+	}
+
+	if !r.Config.Migration.DeleteOld {
+		log.Info("Previous Vault namespace kept after migration")
+		return nil
+	}
+	if status == MigrationNotEmpty {
+		log.Info("Previous Vault namespace is not empty, skipping deletion")
+		r.recordEvent(namespaceName, corev1.EventTypeWarning, "VaultNamespaceNotEmpty",
+			"Vault namespace %s contains secret or auth mounts and was not deleted", oldVaultNamespace)
+		return nil
+	}
+	if r.skipForDryRun(namespaceName, "delete", oldVaultNamespace, log) {
+		return nil
+	}
+
+	log.Info("Deleting previous Vault namespace")
+	err = r.VaultClient.DeleteNamespace(ctx, oldVaultNamespace)
+	r.audit(ctx, audit.Record{Action: audit.ActionDelete, Namespace: namespaceName, VaultNamespace: oldVaultNamespace}, err)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNamespaceDeletion, err)
+	}
+	r.Limit.add(ctx, -1)
+	r.recordEvent(namespaceName, corev1.EventTypeNormal, "VaultNamespaceMigrated",
+		"Deleted previous Vault namespace %s after migrating to %s", oldVaultNamespace, vaultNamespace)
+	return nil
+}
+
+// recordMigration records oldVaultNamespace in the metadata of vaultNamespace,
+// unless it is already there.
+func (r *NamespaceReconciler) recordMigration(ctx context.Context, namespaceName, oldVaultNamespace, vaultNamespace string, log logr.Logger) error {
+	customMetadata, err := r.VaultClient.GetNamespaceMetadata(ctx, vaultNamespace)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNamespaceCheck, err)
+	}
+	if customMetadata[MetadataMigratedFrom] == oldVaultNamespace {
+		return nil
+	}
+	if r.skipForDryRun(namespaceName, "patch", vaultNamespace, log) {
+		return nil
+	}
+
+	err = r.VaultClient.PatchNamespaceMetadata(ctx, vaultNamespace, map[string]string{
+		MetadataMigratedFrom: oldVaultNamespace,
+	})
+	r.audit(ctx, audit.Record{
+		Action:                 audit.ActionMove,
+		Namespace:              namespaceName,
+		VaultNamespace:         vaultNamespace,
+		PreviousVaultNamespace: oldVaultNamespace,
+	}, err)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNamespaceCreation, err)
+	}
+	log.Info("Recorded Vault namespace migration")
+	r.recordEvent(namespaceName, corev1.EventTypeNormal, "VaultNamespaceMoved",
+		"Vault namespace moved from %s to %s", oldVaultNamespace, vaultNamespace)
+	return nil
+}
+
+// BuildMigrationReport lists the synchronized namespaces whose Vault namespace
+// from the previous NamespaceFormat still exists, without changing anything.
+func (r *NamespaceReconciler) BuildMigrationReport(ctx context.Context) (*MigrationReport, error) {
+	report := &MigrationReport{
+		PreviousFormat: r.Config.Migration.PreviousFormat,
+		DeleteOld:      r.Config.Migration.DeleteOld,
+		Entries:        []MigrationEntry{},
+	}
+	if r.Config.Migration.PreviousFormat == "" {
+		return report, nil
+	}
+
+	nsList := newNamespaceMetadataList()
+	if err := r.Client.List(ctx, nsList); err != nil {
+		return nil, err
+	}
+	for _, ns := range nsList.Items {
+		if !r.Shard.Owns(ns.Name) || !r.shouldSyncNamespace(&ns) {
+			continue
+		}
+		connection, err := r.connectionFor(ctx, &ns)
+		if err != nil {
+			r.Log.V(1).Info("Skipping namespace without a valid VaultConnection",
+				"kubernetesNamespace", ns.Name, "error", err.Error())
+			continue
+		}
+		vaultNamespace, err := r.resolveVaultNamespacePath(ctx, &ns)
+		if err != nil {
+			r.Log.V(1).Info("Skipping namespace without a valid Vault namespace path",
+				"kubernetesNamespace", ns.Name, "error", err.Error())
+			continue
+		}
+
+		oldVaultNamespace := r.previousVaultNamespacePath(&ns)
+		status, err := r.migrationStatus(withConnection(ctx, connection), ns.Name, oldVaultNamespace, vaultNamespace)
+		if err != nil {
+			return nil, err
+		}
+		if status == "" {
+			continue
+		}
+		report.Entries = append(report.Entries, MigrationEntry{
+			KubernetesNamespace: ns.Name,
+			OldVaultNamespace:   oldVaultNamespace,
+			NewVaultNamespace:   vaultNamespace,
+			Status:              status,
+		})
+	}
+
+	sort.Slice(report.Entries, func(i, j int) bool {
+		return report.Entries[i].KubernetesNamespace < report.Entries[j].KubernetesNamespace
+	})
+	return report, nil
+}
+
+// MigrationHandler returns an HTTP handler that computes and serves the
+// migration report as JSON.
+func (r *NamespaceReconciler) MigrationHandler() http.Handler {
+	return r.reportHandler("migration", func(ctx context.Context) (any, error) {
+		return r.BuildMigrationReport(ctx)
+	})
+}
@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+func TestNamespaceReconciler_migrateNamespace(t *testing.T) {
+	namespace := &metav1.ObjectMeta{Name: "app"}
+
+	tests := []struct {
+		name          string
+		deleteOld     bool
+		oldMetadata   map[string]string
+		oldEmpty      bool
+		expectPatch   bool
+		expectDeleted bool
+	}{
+		{
+			name:          "previous namespace is deleted once the new one exists",
+			deleteOld:     true,
+			oldMetadata:   ownedMetadata("app"),
+			oldEmpty:      true,
+			expectPatch:   true,
+			expectDeleted: true,
+		},
+		{
+			name:        "previous namespace is kept without deleteOld",
+			oldMetadata: ownedMetadata("app"),
+			oldEmpty:    true,
+			expectPatch: true,
+		},
+		{
+			name:        "non-empty previous namespace is kept",
+			deleteOld:   true,
+			oldMetadata: ownedMetadata("app"),
+			expectPatch: true,
+		},
+		{
+			name:        "previous namespace of another namespace is left alone",
+			deleteOld:   true,
+			oldMetadata: ownedMetadata("other"),
+			oldEmpty:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(mockVaultClient)
+			mockClient.On("NamespaceExists", mock.Anything, "admin/app").Return(true, nil)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "admin/app").Return(tt.oldMetadata, nil)
+			mockClient.On("NamespaceExists", mock.Anything, "admin/k8s-app").Return(true, nil)
+			mockClient.On("NamespaceEmpty", mock.Anything, "admin/app").Return(tt.oldEmpty, nil)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "admin/k8s-app").Return(ownedMetadata("app"), nil)
+			mockClient.On("PatchNamespaceMetadata", mock.Anything, "admin/k8s-app",
+				map[string]string{MetadataMigratedFrom: "admin/app"}).Return(nil)
+			mockClient.On("DeleteNamespace", mock.Anything, "admin/app").Return(nil)
+
+			r := &NamespaceReconciler{
+				Log:         testr.New(t),
+				VaultClient: mockClient,
+				Config: &config.ControllerConfig{
+					NamespaceFormat: "k8s-%s",
+					Migration:       config.MigrationConfig{PreviousFormat: "%s", DeleteOld: tt.deleteOld},
+					Vault:           config.VaultConfig{NamespaceRoot: "admin"},
+				},
+			}
+
+			assert.NoError(t, r.migrateNamespace(context.Background(), namespace, "admin/k8s-app", r.Log))
+			if tt.expectPatch {
+				mockClient.AssertCalled(t, "PatchNamespaceMetadata", mock.Anything, "admin/k8s-app", mock.Anything)
+			} else {
+				mockClient.AssertNotCalled(t, "PatchNamespaceMetadata", mock.Anything, mock.Anything, mock.Anything)
+			}
+			if tt.expectDeleted {
+				mockClient.AssertCalled(t, "DeleteNamespace", mock.Anything, "admin/app")
+			} else {
+				mockClient.AssertNotCalled(t, "DeleteNamespace", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+// TestMigrationHandler tests the report of namespaces still to be migrated.
+func TestMigrationHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-b"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-c"}},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	// app-a is ready, app-b has no new namespace yet and app-c is done
+	mockClient.On("NamespaceExists", mock.Anything, "admin/app-a").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "admin/app-a").Return(ownedMetadata("app-a"), nil)
+	mockClient.On("NamespaceExists", mock.Anything, "admin/k8s-app-a").Return(true, nil)
+	mockClient.On("NamespaceEmpty", mock.Anything, "admin/app-a").Return(true, nil)
+	mockClient.On("NamespaceExists", mock.Anything, "admin/app-b").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "admin/app-b").Return(ownedMetadata("app-b"), nil)
+	mockClient.On("NamespaceExists", mock.Anything, "admin/k8s-app-b").Return(false, nil)
+	mockClient.On("NamespaceExists", mock.Anything, "admin/app-c").Return(false, nil)
+
+	reconciler := &NamespaceReconciler{
+		Client:      fakeClient,
+		Log:         testr.New(t),
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{
+			NamespaceFormat: "k8s-%s",
+			Migration:       config.MigrationConfig{PreviousFormat: "%s"},
+			Vault:           config.VaultConfig{NamespaceRoot: "admin"},
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	reconciler.MigrationHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/migrate", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var report MigrationReport
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, "%s", report.PreviousFormat)
+	assert.False(t, report.DeleteOld)
+	assert.Equal(t, []MigrationEntry{
+		{KubernetesNamespace: "app-a", OldVaultNamespace: "admin/app-a", NewVaultNamespace: "admin/k8s-app-a", Status: MigrationReady},
+		{KubernetesNamespace: "app-b", OldVaultNamespace: "admin/app-b", NewVaultNamespace: "admin/k8s-app-b", Status: MigrationPending},
+	}, report.Entries)
+
+	// The report never changes Vault
+	mockClient.AssertNotCalled(t, "PatchNamespaceMetadata", mock.Anything, mock.Anything, mock.Anything)
+	mockClient.AssertNotCalled(t, "DeleteNamespace", mock.Anything, mock.Anything)
+}
@@ -6,20 +6,41 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/benemon/vault-namespace-controller/pkg/audit"
 	"github.com/benemon/vault-namespace-controller/pkg/config"
 	"github.com/benemon/vault-namespace-controller/pkg/metrics"
 	"github.com/benemon/vault-namespace-controller/pkg/vault"
 	"github.com/go-logr/logr"
 )
 
+// pausedRequeueInterval is how often a namespace is retried while the controller is paused.
+const pausedRequeueInterval = 30 * time.Second
+
+// defaultReconcileTimeout bounds a reconcile when the config sets no timeout.
+const defaultReconcileTimeout = 30 * time.Second
+
 var (
 	ErrNamespaceCreation = errors.New("failed to create vault namespace")
 	ErrNamespaceDeletion = errors.New("failed to delete vault namespace")
@@ -32,47 +53,204 @@ type NamespaceReconciler struct {
 	Scheme      *runtime.Scheme
 	VaultClient vault.Client
 	Config      *config.ControllerConfig
+	Recorder    record.EventRecorder
+	Pause       *PauseState
+	// Shard limits the replica to a slice of namespaces when sharding is enabled.
+	Shard *Shard
+	// APIReader reads directly from the API server, bypassing the cache.
+	APIReader client.Reader
+	// Mappings persists where each namespace was synchronized to, when configured.
+	Mappings *MappingStore
+	// VaultNamespaces maintains a VaultNamespace resource per namespace, when enabled.
+	VaultNamespaces *VaultNamespaceResources
+	// Classes resolves the VaultNamespaceClass namespaces select, when enabled.
+	Classes *VaultNamespaceClasses
+	// Blueprints provisions the blueprints of namespaces' classes, when enabled.
+	Blueprints *BlueprintReconciler
+	// Live overrides settings from the VaultNamespaceControllerConfig, when enabled.
+	Live *LiveConfig
+	// KubernetesAPI is the cluster's API server, for bootstrapping the kubernetes auth method.
+	KubernetesAPI *KubernetesAPI
+	// Hooks calls external endpoints on Vault namespace lifecycle events, when configured.
+	Hooks *LifecycleHooks
+	// Audit records the Vault namespace changes, when configured.
+	Audit *audit.Log
+	// Notifications posts messages about Vault namespace lifecycle events, when configured.
+	Notifications *Notifications
+	// CloudEvents emits CloudEvents for Vault namespace lifecycle events, when configured.
+	CloudEvents *CloudEvents
+	// Liveness tracks running reconciles for the liveness probe, when set.
+	Liveness *ReconcileLiveness
+	// Limit enforces MaxManagedNamespaces, shared with the remote clusters'
+	// reconcilers. One counting from the reconciler's roots is used when unset.
+	Limit       *NamespaceLimit
 	syncChecker func(string) bool
+
+	// pendingDeletions tracks Vault namespace deletions waiting out the
+	// configured grace period, keyed by Kubernetes namespace name.
+	pendingDeletions map[string]time.Time
+	// failures counts consecutive failed reconciles per Kubernetes namespace
+	// and drives the error backoff.
+	failures map[string]int
+	// recentFailures lists the latest failed reconciles, oldest first, for the
+	// sync report.
+	recentFailures []vaultv1alpha1.SyncFailure
+	// onceFailures collects the failed reconciles while SyncOnce runs.
+	onceFailures map[string]string
+	// lastFullScan is when every namespace was last checked against Vault by a drift scan.
+	lastFullScan time.Time
+	// paths remembers the Vault namespace path of each synchronized namespace.
+	paths map[string]string
+	// classes remembers the VaultNamespaceClass of each synchronized namespace.
+	classes map[string]string
+	// connections remembers the VaultConnection of each synchronized namespace.
+	connections map[string]string
+	// unbootstrapped holds the Vault namespaces created but not bootstrapped
+	// yet, keyed like the applied blueprints.
+	unbootstrapped map[string]bool
+	// unannounced holds the post-create hooks not yet called successfully per
+	// created Vault namespace, keyed like the applied blueprints.
+	unannounced map[string]map[string]bool
+	// appliedPolicies records the rendered policy templates last written into
+	// each Vault namespace by policy name, keyed like the applied blueprints.
+	appliedPolicies map[string]map[string]string
+	// appliedGroups records the identity groups last written into each Vault
+	// namespace, keyed like the applied blueprints.
+	appliedGroups map[string]map[string]string
+	// appliedSentinel records the Sentinel policies last written into each
+	// Vault namespace by type and name, keyed like the applied blueprints.
+	appliedSentinel map[string]map[string]string
+	// retryLimiter delays the retries of failed reconciles as configured by
+	// RateLimiter. The workqueue's own rate limiter forgets a namespace whenever
+	// its reconcile asks for a delayed requeue, so retries are limited here.
+	retryLimiter workqueue.TypedRateLimiter[ctrl.Request]
+	mu           sync.Mutex
+
+	// template, rules, the expressions and the policy templates are compiled
+	// from the configuration on first use.
+	template           *template.Template
+	rules              []mappingRule
+	policyTemplates    []*template.Template
+	groupNames         []*template.Template
+	groupAliasNames    []*template.Template
+	includeExpressions []*config.NamespaceExpression
+	excludeExpressions []*config.NamespaceExpression
+	compileErr         error
+	compileOnce        sync.Once
+
+	// limitOnce sets Limit on first use when it was not shared.
+	limitOnce sync.Once
+
+	// startup tracks progress of the initial bulk sync.
+	startup startupSync
+
+	// clusterNamespace is the Vault namespace holding a remote cluster's namespaces.
+	clusterNamespace      string
+	clusterNamespaceReady atomic.Bool
 }
 
 func (r *NamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	metrics.KubernetesEventsTotal.WithLabelValues("namespace").Inc()
+	defer r.Liveness.Track()()
+	// A namespace counts towards the initial sync once its first reconcile
+	// finishes, whatever the outcome, so one that keeps failing does not hold
+	// the initial sync up forever
+	defer r.startup.done(req.Name, r.Log)
 	startTime := time.Now()
 
-	// Format the Vault namespace path
-	vaultNamespacePath := r.formatVaultNamespacePath(req.Name)
+	// Vault requests carry the correlation ID, so Vault audit log entries
+	// can be matched to the reconcile
+	correlationID := string(controller.ReconcileIDFromContext(ctx))
+	if correlationID == "" {
+		correlationID = string(uuid.NewUUID())
+	}
+	ctx = vault.WithCorrelationID(ctx, correlationID)
 
-	// Create logger with both namespace contexts already added
+	// The Vault namespace context is added once the path is known
 	log := r.Log.WithValues(
 		"kubernetesNamespace", req.Name,
-		"vaultNamespace", vaultNamespacePath,
-		"reconcileID", fmt.Sprintf("%d", startTime.UnixNano()),
+		"correlationID", correlationID,
 	)
 
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	// While paused, make no Vault changes and check back later
+	if r.Pause.Paused() {
+		log.V(1).Info("Reconciliation paused, skipping", "pausedBy", r.Pause.Sources())
+		metrics.ReconcilesSkippedTotal.WithLabelValues(SkipPaused).Inc()
+		return ctrl.Result{RequeueAfter: pausedRequeueInterval}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.reconcileTimeout())
 	defer cancel()
 
-	var namespace corev1.Namespace
-	if err := r.Get(ctx, req.NamespacedName, &namespace); err != nil {
+	namespace := newNamespaceMetadata()
+	if err := r.Get(ctx, req.NamespacedName, namespace); err != nil {
 		if k8serrors.IsNotFound(err) {
-			// Only log at INFO level for actual deletions
-			if r.Config.DeleteVaultNamespaces {
-				exists, _ := r.VaultClient.NamespaceExists(ctx, vaultNamespacePath)
-				if exists {
-					log.Info("Deleting Vault namespace")
+			// A namespace that no longer matches the namespace selector is not deleted
+			if exists, err := r.existsOutsideCache(ctx, req.Name); err != nil {
+				log.Error(err, "Failed to get Kubernetes namespace")
+				metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+				metrics.ErrorsTotal.WithLabelValues("get").Inc()
+				return ctrl.Result{}, err
+			} else if exists {
+				log.V(1).Info("Namespace does not match the namespace selector, skipping",
+					"namespaceSelector", r.Config.NamespaceSelector)
+				metrics.ReconcilesSkippedTotal.WithLabelValues(SkipNamespaceSelector).Inc()
+				return ctrl.Result{}, nil
+			}
+
+			vaultNamespacePath, err := r.deletedNamespacePath(ctx, req.Name)
+			if err != nil {
+				log.Error(err, "Failed to read persisted Vault namespace mapping")
+				metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+				metrics.ErrorsTotal.WithLabelValues("mapping").Inc()
+				return r.retryResult(ctx, req.Name, err, log), nil
+			}
+			if vaultNamespacePath == "" {
+				log.Info("Vault namespace path of deleted namespace is unknown, skipping deletion")
+				return ctrl.Result{}, nil
+			}
+			log = log.WithValues("vaultNamespace", vaultNamespacePath)
+			if connection := r.deletedNamespaceConnection(req.Name); connection != "" {
+				ctx = withConnection(ctx, connection)
+				log = log.WithValues("connection", connection)
+			}
+
+			// Hold off on deleting the Vault namespace until the grace period expires
+			if wait := r.deletionGraceRemaining(req.Name); wait > 0 {
+				log.V(1).Info("Vault namespace deletion scheduled", "remaining", wait.String())
+				if err := r.VaultNamespaces.SetCondition(ctx, r.vaultNamespaceName(req.Name),
+					vaultv1alpha1.ConditionDeletionPending, metav1.ConditionTrue, "GracePeriod",
+					fmt.Sprintf("Vault namespace is deleted in %s", wait.Round(time.Second))); err != nil {
+					log.Error(err, "Failed to update VaultNamespace status")
 				}
+				return ctrl.Result{RequeueAfter: wait}, nil
 			}
 
 			// Handle the deletion
-			if err := r.handleNamespaceDeletion(ctx, vaultNamespacePath, log); err != nil {
+			if _, err := r.handleNamespaceDeletion(ctx, req.Name, vaultNamespacePath, log); err != nil {
 				log.Error(err, "Failed to delete Vault namespace")
 				metrics.ReconciliationTotal.WithLabelValues("error").Inc()
 				metrics.ErrorsTotal.WithLabelValues("delete").Inc()
-				return ctrl.Result{RequeueAfter: 30 * time.Second}, err
+				// Retry on our own schedule; returning the error would discard RequeueAfter
+				return r.syncFailed(ctx, req.Name, err, log), nil
 			}
 
+			if err := r.Mappings.Forget(ctx, r.Config.ClusterName, req.Name); err != nil {
+				log.Error(err, "Failed to remove persisted Vault namespace mapping")
+				metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+				metrics.ErrorsTotal.WithLabelValues("mapping").Inc()
+				return r.retryResult(ctx, req.Name, err, log), nil
+			}
+			if err := r.VaultNamespaces.Delete(ctx, r.vaultNamespaceName(req.Name)); err != nil {
+				log.Error(err, "Failed to delete VaultNamespace")
+				metrics.ErrorsTotal.WithLabelValues("resource").Inc()
+			}
+			r.clearPendingDeletion(req.Name)
+			r.forgetPath(req.Name)
+			r.resetBackoff(req.Name)
 			metrics.ReconciliationTotal.WithLabelValues("success").Inc()
 			metrics.ReconciliationDuration.WithLabelValues("delete").Observe(time.Since(startTime).Seconds())
+			metrics.RecordSuccessfulReconcile("delete", time.Now())
 			return ctrl.Result{}, nil
 		}
 		log.Error(err, "Failed to get Kubernetes namespace")
@@ -81,148 +259,571 @@ func (r *NamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	if !r.shouldSyncNamespace(namespace.Name) {
+	// The namespace exists again, so any scheduled deletion no longer applies
+	if r.clearPendingDeletion(req.Name) {
+		log.Info("Cancelled scheduled Vault namespace deletion, namespace was recreated")
+	}
+
+	if reason := r.skipReason(namespace); reason != "" {
 		// Log exclusions at higher verbosity
+		metrics.ReconcilesSkippedTotal.WithLabelValues(reason).Inc()
 		log.V(1).Info("Namespace excluded from synchronization",
-			"includePatterns", r.Config.IncludeNamespaces,
-			"excludePatterns", r.Config.ExcludeNamespaces)
-		metrics.NamespacesExcluded.Set(1)
+			"reason", reason,
+			"includePatterns", r.includeNamespaces(),
+			"excludePatterns", r.excludeNamespaces(),
+			"includeExpressions", r.Config.IncludeExpressions,
+			"excludeExpressions", r.Config.ExcludeExpressions)
 		return ctrl.Result{}, nil
 	}
 
-	// Before trying to create, check if it exists
-	exists, _ := r.VaultClient.NamespaceExists(ctx, vaultNamespacePath)
-	if !exists {
-		log.Info("Creating Vault namespace")
-	} else {
-		// Only log routine reconciliations at higher verbosity
-		log.V(1).Info("Reconciling existing namespace")
+	connection, err := r.connectionFor(ctx, namespace)
+	if err != nil {
+		log.Error(err, "Failed to determine VaultConnection")
+		metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+		metrics.ErrorsTotal.WithLabelValues("get").Inc()
+		return r.retryResult(ctx, namespace.Name, err, log), nil
+	}
+	if connection != "" {
+		ctx = withConnection(ctx, connection)
+		log = log.WithValues("connection", connection)
+	}
+
+	vaultNamespacePath, err := r.resolveVaultNamespacePath(ctx, namespace)
+	if err != nil && !errors.Is(err, ErrInvalidNamespacePath) {
+		log.Error(err, "Failed to determine Vault namespace path")
+		metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+		metrics.ErrorsTotal.WithLabelValues("get").Inc()
+		return r.retryResult(ctx, namespace.Name, err, log), nil
+	} else if err != nil {
+		// Retrying cannot help; a label or annotation change triggers a new reconcile
+		log.Error(err, "Failed to determine Vault namespace path")
+		metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+		metrics.ErrorsTotal.WithLabelValues("path").Inc()
+		r.recordFailure(namespace.Name, err)
+		r.recordEvent(namespace.Name, corev1.EventTypeWarning, "VaultNamespacePathInvalid",
+			"Cannot determine Vault namespace path: %v", err)
+		return ctrl.Result{}, nil
+	}
+	log = log.WithValues("vaultNamespace", vaultNamespacePath)
+	r.rememberPath(namespace.Name, vaultNamespacePath)
+	if r.Classes != nil {
+		r.rememberClass(namespace.Name, namespace.GetAnnotations()[vaultv1alpha1.ClassAnnotation])
+	}
+	if r.Config.VaultConnections {
+		r.rememberConnection(namespace.Name, connection)
+	}
+
+	if err := r.checkParentSynced(ctx, namespace, vaultNamespacePath); err != nil {
+		log.Info("Waiting for the parent Vault namespace", "reason", err.Error())
+		return r.retryResult(ctx, namespace.Name, err, log), nil
+	}
+
+	if err := r.ensureClusterNamespace(ctx, namespace.Name, log); err != nil {
+		log.Error(err, "Failed to create Vault namespace for cluster")
+		metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+		metrics.ErrorsTotal.WithLabelValues("create").Inc()
+		return r.syncFailed(ctx, namespace.Name, err, log), nil
+	}
+	if err := r.ensureTenantNamespace(ctx, namespace, vaultNamespacePath, log); err != nil {
+		log.Error(err, "Failed to create Vault namespace for Capsule Tenant")
+		metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+		metrics.ErrorsTotal.WithLabelValues("create").Inc()
+		return r.syncFailed(ctx, namespace.Name, err, log), nil
 	}
 
 	// Handle creation/reconciliation
-	if err := r.handleNamespaceCreation(ctx, vaultNamespacePath, log); err != nil {
+	manage, err := r.handleNamespaceCreation(ctx, namespace.Name, vaultNamespacePath, log)
+	if err != nil {
 		log.Error(err, "Failed to create/reconcile Vault namespace")
 		metrics.ReconciliationTotal.WithLabelValues("error").Inc()
 		metrics.ErrorsTotal.WithLabelValues("create").Inc()
-		return ctrl.Result{RequeueAfter: 30 * time.Second}, err
-	}
-
-	// Update metrics at higher verbosity
-	log.V(2).Info("Updating namespace metrics")
-	var nsList corev1.NamespaceList
-	if err := r.Client.List(ctx, &nsList); err == nil {
-		var managed, excluded, pending int
-		for _, ns := range nsList.Items {
-			if r.shouldSyncNamespace(ns.Name) {
-				managed++
-				vaultNS := r.formatVaultNamespacePath(ns.Name)
-				exists, err := r.VaultClient.NamespaceExists(ctx, vaultNS)
-				if err != nil || !exists {
-					pending++
-				}
-			} else {
-				excluded++
-			}
+		return r.syncFailed(ctx, namespace.Name, err, log), nil
+	}
+	// Only Vault namespaces the controller manages are provisioned below; the
+	// steps do not check ownership themselves
+	if !manage {
+		// A Vault namespace left alone by the existing namespace policy is
+		// neither provisioned nor recorded, but checked again later
+		r.resetBackoff(namespace.Name)
+		return ctrl.Result{RequeueAfter: r.reconcileInterval()}, nil
+	}
+	if err := r.syncCustomMetadata(ctx, namespace, vaultNamespacePath, log); err != nil {
+		log.Error(err, "Failed to update Vault namespace custom metadata")
+		metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+		metrics.ErrorsTotal.WithLabelValues("metadata").Inc()
+		return r.syncFailed(ctx, namespace.Name, err, log), nil
+	}
+	if err := r.migrateNamespace(ctx, namespace, vaultNamespacePath, log); err != nil {
+		log.Error(err, "Failed to migrate previous Vault namespace")
+		metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+		metrics.ErrorsTotal.WithLabelValues("migrate").Inc()
+		return r.syncFailed(ctx, namespace.Name, err, log), nil
+	}
+	if err := r.bootstrapVaultNamespace(ctx, namespace, vaultNamespacePath, log); err != nil {
+		log.Error(err, "Failed to bootstrap Vault namespace")
+		metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+		metrics.ErrorsTotal.WithLabelValues("provision").Inc()
+		return r.syncFailed(ctx, namespace.Name, err, log), nil
+	}
+	if err := r.applyPolicyTemplates(ctx, namespace, vaultNamespacePath, log); err != nil {
+		log.Error(err, "Failed to write templated policies")
+		metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+		metrics.ErrorsTotal.WithLabelValues("provision").Inc()
+		return r.syncFailed(ctx, namespace.Name, err, log), nil
+	}
+	if err := r.applySentinelPolicies(ctx, namespace, vaultNamespacePath, log); err != nil {
+		log.Error(err, "Failed to write Sentinel policies")
+		metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+		metrics.ErrorsTotal.WithLabelValues("provision").Inc()
+		return r.syncFailed(ctx, namespace.Name, err, log), nil
+	}
+	if err := r.applyIdentityGroups(ctx, namespace, vaultNamespacePath, log); err != nil {
+		log.Error(err, "Failed to write identity groups")
+		metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+		metrics.ErrorsTotal.WithLabelValues("provision").Inc()
+		return r.syncFailed(ctx, namespace.Name, err, log), nil
+	}
+	if err := r.provisionBlueprint(ctx, namespace, vaultNamespacePath, log); err != nil {
+		log.Error(err, "Failed to provision Vault namespace from blueprint")
+		metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+		metrics.ErrorsTotal.WithLabelValues("provision").Inc()
+		return r.syncFailed(ctx, namespace.Name, err, log), nil
+	}
+	if !r.Config.DryRun {
+		if err := r.Mappings.Record(ctx, r.Config.ClusterName, namespace.Name, vaultNamespacePath); err != nil {
+			log.Error(err, "Failed to persist Vault namespace mapping")
+			metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+			metrics.ErrorsTotal.WithLabelValues("mapping").Inc()
+			return r.syncFailed(ctx, namespace.Name, err, log), nil
+		}
+		if err := r.syncVSOResources(ctx, namespace.Name, vaultNamespacePath, log); err != nil {
+			log.Error(err, "Failed to write Vault Secrets Operator resources")
+			metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+			metrics.ErrorsTotal.WithLabelValues("resource").Inc()
+			return r.syncFailed(ctx, namespace.Name, err, log), nil
+		}
+		if err := r.syncESOResources(ctx, namespace.Name, vaultNamespacePath, log); err != nil {
+			log.Error(err, "Failed to write External Secrets Operator SecretStore")
+			metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+			metrics.ErrorsTotal.WithLabelValues("resource").Inc()
+			return r.syncFailed(ctx, namespace.Name, err, log), nil
+		}
+		if err := r.syncConnectionConfigMap(ctx, namespace.Name, vaultNamespacePath, log); err != nil {
+			log.Error(err, "Failed to write Vault connection ConfigMap")
+			metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+			metrics.ErrorsTotal.WithLabelValues("resource").Inc()
+			return r.syncFailed(ctx, namespace.Name, err, log), nil
+		}
+		if err := r.callPostCreateHooks(ctx, namespace.Name, vaultNamespacePath, log); err != nil {
+			log.Error(err, "Failed to call post-create lifecycle hooks")
+			metrics.ReconciliationTotal.WithLabelValues("error").Inc()
+			metrics.ErrorsTotal.WithLabelValues("hook").Inc()
+			return r.syncFailed(ctx, namespace.Name, err, log), nil
+		}
+		if err := r.VaultNamespaces.Sync(ctx, r.vaultNamespaceName(namespace.Name),
+			r.vaultNamespaceSpec(namespace.Name, vaultNamespacePath)); err != nil {
+			// The Vault namespace itself is in sync, so this is not retried
+			log.Error(err, "Failed to update VaultNamespace")
+			metrics.ErrorsTotal.WithLabelValues("resource").Inc()
 		}
-		metrics.NamespacesManaged.Set(float64(managed))
-		metrics.NamespacesExcluded.Set(float64(excluded))
-		metrics.NamespacesPendingSync.Set(float64(pending))
 	}
+	r.resetBackoff(namespace.Name)
 
 	metrics.ReconciliationTotal.WithLabelValues("success").Inc()
 	metrics.ReconciliationDuration.WithLabelValues("create").Observe(time.Since(startTime).Seconds())
-	return ctrl.Result{RequeueAfter: time.Duration(r.Config.ReconcileInterval) * time.Second}, nil
+	metrics.RecordSuccessfulReconcile("create", time.Now())
+	// In event-driven-only mode the namespace is only revisited on change or by the drift scanner
+	return ctrl.Result{RequeueAfter: r.reconcileInterval()}, nil
+}
+
+// Reasons a reconcile is skipped, as in the reconciles skipped metric.
+const (
+	SkipSystemNamespace   = "system_namespace"
+	SkipExcludePattern    = "exclude_pattern"
+	SkipExcludeExpression = "exclude_expression"
+	SkipNoIncludeMatch    = "no_include_match"
+	SkipInvalidConfig     = "invalid_config"
+	SkipNamespaceSelector = "namespace_selector"
+	SkipPaused            = "paused"
+	// SkipExistingNamespace is a pre-existing Vault namespace left alone by the skip policy.
+	SkipExistingNamespace = "existing_namespace"
+	// SkipGuardrail is a creation or deletion refused by a safety check.
+	SkipGuardrail = "guardrail"
+)
+
+func (r *NamespaceReconciler) shouldSyncNamespace(namespace metav1.Object) bool {
+	return r.skipReason(namespace) == ""
 }
 
-func (r *NamespaceReconciler) shouldSyncNamespace(namespaceName string) bool {
+// skipReason returns why namespace is not synchronized, or "" if it is.
+func (r *NamespaceReconciler) skipReason(namespace metav1.Object) string {
+	namespaceName := namespace.GetName()
 	if r.syncChecker != nil {
-		return r.syncChecker(namespaceName)
+		if r.syncChecker(namespaceName) {
+			return ""
+		}
+		return SkipExcludePattern
+	}
+	if err := r.compileConfig(); err != nil {
+		r.Log.Error(err, "Invalid namespace matching configuration")
+		return SkipInvalidConfig
+	}
+
+	included := func() bool {
+		return matchesAnyPattern(namespaceName, r.patternSyntax(), r.strictMatch(), r.includeNamespaces()) ||
+			matchesAnyExpression(namespace, r.includeExpressions)
 	}
 	systemPatterns := []string{"^kube-.*", "^openshift-.*", "^openshift$", "^default$"}
-	if matchesAnyPattern(namespaceName, systemPatterns) {
-		return matchesAnyPattern(namespaceName, r.Config.IncludeNamespaces)
+	if matchesAnyPattern(namespaceName, config.PatternSyntaxRegex, false, systemPatterns) {
+		if included() {
+			return ""
+		}
+		return SkipSystemNamespace
 	}
-	if matchesAnyPattern(namespaceName, r.Config.ExcludeNamespaces) {
-		return false
+	if matchesAnyPattern(namespaceName, r.patternSyntax(), r.strictMatch(), r.excludeNamespaces()) {
+		return SkipExcludePattern
 	}
-	if len(r.Config.IncludeNamespaces) > 0 {
-		return matchesAnyPattern(namespaceName, r.Config.IncludeNamespaces)
+	if matchesAnyExpression(namespace, r.excludeExpressions) {
+		return SkipExcludeExpression
 	}
-	return true
+	if (len(r.includeNamespaces()) > 0 || len(r.includeExpressions) > 0) && !included() {
+		return SkipNoIncludeMatch
+	}
+	return ""
 }
 
-func matchesAnyPattern(name string, patterns []string) bool {
+// compiledPatterns caches the compiled namespace patterns by how they match
+// and pattern, as the patterns in effect can change at runtime with the live
+// configuration.
+var compiledPatterns sync.Map
+
+func matchesAnyPattern(name, syntax string, anchored bool, patterns []string) bool {
 	for _, pattern := range patterns {
-		if match, _ := regexp.MatchString(pattern, name); match {
+		if re := compilePattern(pattern, syntax, anchored); re != nil && re.MatchString(name) {
 			return true
 		}
 	}
 	return false
 }
 
-// Update the handler methods to accept a logger parameter
-func (r *NamespaceReconciler) handleNamespaceCreation(ctx context.Context, vaultNamespace string, log logr.Logger) error {
+// compilePattern returns the compiled pattern, or nil if it does not compile.
+// Patterns are validated before they take effect, so that cannot happen for
+// the patterns in effect.
+func compilePattern(pattern, syntax string, anchored bool) *regexp.Regexp {
+	key := fmt.Sprintf("%s\x00%t\x00%s", syntax, anchored, pattern)
+	if re, ok := compiledPatterns.Load(key); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := config.CompilePattern(pattern, syntax, anchored)
+	if err != nil {
+		return nil
+	}
+	compiledPatterns.Store(key, re)
+	return re
+}
+
+// handleNamespaceCreation creates the Vault namespace, or applies the existing
+// namespace policy when it is already there. It reports whether the controller
+// manages the Vault namespace, so that it may be provisioned. It is the only
+// place a reconcile checks whether the Vault namespace exists.
+func (r *NamespaceReconciler) handleNamespaceCreation(ctx context.Context, namespaceName, vaultNamespace string, log logr.Logger) (bool, error) {
 
 	exists, err := r.VaultClient.NamespaceExists(ctx, vaultNamespace)
 	if err != nil {
 		log.Error(err, "Failed to check if Vault namespace exists")
-		return fmt.Errorf("%w: %v", ErrNamespaceCheck, err)
+		return false, fmt.Errorf("%w: %w", ErrNamespaceCheck, err)
 	}
 
 	if !exists {
-		// We already logged the creation in the main Reconcile function
-		if err := r.VaultClient.CreateNamespace(ctx, vaultNamespace); err != nil {
+		limited := r.Config.MaxManagedNamespaces > 0
+		if limited {
+			if err := r.checkNamespaceLimit(ctx, namespaceName, vaultNamespace, log); err != nil {
+				return false, err
+			}
+		}
+		if r.skipForDryRun(namespaceName, "create", vaultNamespace, log) {
+			if limited {
+				r.namespaceLimit().created(ctx, false)
+			}
+			return true, nil
+		}
+		log.Info("Creating Vault namespace")
+		err := r.VaultClient.CreateNamespace(ctx, vaultNamespace, r.ownershipMetadata(namespaceName))
+		if limited {
+			r.namespaceLimit().created(ctx, err == nil)
+		}
+		r.audit(ctx, audit.Record{Action: audit.ActionCreate, Namespace: namespaceName, VaultNamespace: vaultNamespace}, err)
+		if err != nil {
 			log.Error(err, "Failed to create Vault namespace")
-			return fmt.Errorf("%w: %v", ErrNamespaceCreation, err)
+			return false, fmt.Errorf("%w: %w", ErrNamespaceCreation, err)
 		}
+		r.notify(ctx, NotificationEvent{Event: config.NotificationEventCreated, Namespace: namespaceName, VaultNamespace: vaultNamespace})
+		r.emitCloudEvent(ctx, config.CloudEventCreated, CloudEventData{Namespace: namespaceName, VaultNamespace: vaultNamespace})
+		// A recreated namespace is provisioned from scratch
+		r.Blueprints.Forget(ctx, vaultNamespace)
+		r.markForBootstrap(ctx, vaultNamespace)
+		r.markForPostCreate(ctx, vaultNamespace)
+		r.forgetApplied(ctx, vaultNamespace)
 		log.V(1).Info("Successfully created Vault namespace")
-	} else {
-		log.V(2).Info("Vault namespace already exists")
+		return true, nil
 	}
 
-	return nil
+	// Only log routine reconciliations at higher verbosity
+	log.V(1).Info("Reconciling existing namespace")
+	manage, err := r.handleExistingNamespace(ctx, namespaceName, vaultNamespace, log)
+	if (err == nil && !manage) || errors.Is(err, ErrNamespaceConflict) {
+		r.forgetProvisioned(ctx, vaultNamespace)
+	}
+	return manage, err
 }
 
-func (r *NamespaceReconciler) handleNamespaceDeletion(ctx context.Context, vaultNamespace string, log logr.Logger) error {
-	if !r.Config.DeleteVaultNamespaces {
+// handleNamespaceDeletion deletes the Vault namespace when deletion is enabled and
+// the namespace is owned by this controller and empty. It reports whether the
+// Vault namespace is gone, that is deleted now or by a previous attempt. It is
+// the only place a reconcile checks whether the Vault namespace exists.
+func (r *NamespaceReconciler) handleNamespaceDeletion(ctx context.Context, namespaceName, vaultNamespace string, log logr.Logger) (bool, error) {
+	deleteEnabled, err := r.deleteVaultNamespace(ctx, namespaceName)
+	if err != nil {
+		log.Error(err, "Failed to read VaultNamespaceClass")
+		return false, err
+	}
+	if !deleteEnabled {
 		log.V(1).Info("Vault namespace deletion is disabled, skipping")
-		return nil
+		return false, nil
 	}
 
 	exists, err := r.VaultClient.NamespaceExists(ctx, vaultNamespace)
 	if err != nil {
 		log.Error(err, "Failed to check if Vault namespace exists")
-		return fmt.Errorf("%w: %v", ErrNamespaceCheck, err)
+		return false, fmt.Errorf("%w: %w", ErrNamespaceCheck, err)
 	}
 
 	if exists {
-		// We already logged the deletion in the main Reconcile function
-		if err := r.VaultClient.DeleteNamespace(ctx, vaultNamespace); err != nil {
+		owned, err := r.isOwned(ctx, vaultNamespace)
+		if err != nil {
+			log.Error(err, "Failed to read Vault namespace metadata")
+			return false, err
+		}
+		if !owned {
+			log.Info("Vault namespace is not owned by this controller, skipping deletion")
+			metrics.DeletionsBlockedTotal.WithLabelValues(DeletionBlockedNotOwned).Inc()
+			metrics.ReconcilesSkippedTotal.WithLabelValues(SkipGuardrail).Inc()
+			r.notifyDeletionBlocked(ctx, namespaceName, vaultNamespace, DeletionBlockedNotOwned)
+			return false, nil
+		}
+	}
+
+	if exists && !r.deleteNonEmptyNamespaces() {
+		empty, err := r.VaultClient.NamespaceEmpty(ctx, vaultNamespace)
+		if err != nil {
+			log.Error(err, "Failed to inspect Vault namespace contents")
+			return false, fmt.Errorf("%w: %w", ErrNamespaceCheck, err)
+		}
+		if !empty {
+			log.Info("Vault namespace is not empty, skipping deletion")
+			metrics.DeletionsBlockedTotal.WithLabelValues(DeletionBlockedNonEmpty).Inc()
+			metrics.ReconcilesSkippedTotal.WithLabelValues(SkipGuardrail).Inc()
+			r.notifyDeletionBlocked(ctx, namespaceName, vaultNamespace, DeletionBlockedNonEmpty)
+			r.recordEvent(namespaceName, corev1.EventTypeWarning, "VaultNamespaceNotEmpty",
+				"Vault namespace %s contains secret or auth mounts and was not deleted", vaultNamespace)
+			return false, nil
+		}
+	}
+
+	if exists {
+		if r.skipForDryRun(namespaceName, "delete", vaultNamespace, log) {
+			return false, nil
+		}
+		if err := r.callPreDeleteHooks(ctx, namespaceName, vaultNamespace, log); err != nil {
+			log.Error(err, "Pre-delete lifecycle hook failed, skipping deletion")
+			metrics.DeletionsBlockedTotal.WithLabelValues(DeletionBlockedHook).Inc()
+			r.notifyDeletionBlocked(ctx, namespaceName, vaultNamespace, DeletionBlockedHook)
+			return false, err
+		}
+		log.Info("Deleting Vault namespace")
+		err := r.VaultClient.DeleteNamespace(ctx, vaultNamespace)
+		r.audit(ctx, audit.Record{Action: audit.ActionDelete, Namespace: namespaceName, VaultNamespace: vaultNamespace}, err)
+		if err != nil {
 			log.Error(err, "Failed to delete Vault namespace")
-			return fmt.Errorf("%w: %v", ErrNamespaceDeletion, err)
+			return false, fmt.Errorf("%w: %w", ErrNamespaceDeletion, err)
 		}
+		r.Limit.add(ctx, -1)
+		r.notify(ctx, NotificationEvent{Event: config.NotificationEventDeleted, Namespace: namespaceName, VaultNamespace: vaultNamespace})
+		r.emitCloudEvent(ctx, config.CloudEventDeleted, CloudEventData{Namespace: namespaceName, VaultNamespace: vaultNamespace})
 		log.V(1).Info("Successfully deleted Vault namespace")
 	} else {
 		log.V(2).Info("Vault namespace does not exist, skipping deletion")
 	}
 
-	return nil
+	// Quotas outlive the namespace, also when it was deleted by a previous attempt
+	if !r.Config.DryRun {
+		if err := r.Blueprints.RemoveQuotas(ctx, vaultNamespace); err != nil {
+			log.Error(err, "Failed to delete quotas of Vault namespace")
+			return true, fmt.Errorf("%w: %w", ErrNamespaceDeletion, err)
+		}
+	}
+
+	return true, nil
+}
+
+// skipForDryRun reports whether a Vault change must be skipped because dry-run
+// mode is enabled, recording the operation that would have been performed.
+func (r *NamespaceReconciler) skipForDryRun(namespaceName, operation, vaultNamespace string, log logr.Logger) bool {
+	if !r.Config.DryRun {
+		return false
+	}
+	log.Info("Dry run: skipping Vault namespace change", "operation", operation)
+	metrics.DryRunOperationsTotal.WithLabelValues(operation).Inc()
+	r.recordEvent(namespaceName, corev1.EventTypeNormal, "DryRun",
+		"Dry run: would %s Vault namespace %s", operation, vaultNamespace)
+	return true
+}
+
+// uncachedReader returns a reader for objects the controller does not watch,
+// such as ConfigMaps and Secrets in the synchronized namespaces, so reading
+// them does not start caching all of them.
+func (r *NamespaceReconciler) uncachedReader() client.Reader {
+	if r.APIReader == nil {
+		return r.Client
+	}
+	return r.APIReader
+}
+
+// reconcileTimeout returns how long a single reconcile may take.
+func (r *NamespaceReconciler) reconcileTimeout() time.Duration {
+	if r.Config.ReconcileTimeout <= 0 {
+		return defaultReconcileTimeout
+	}
+	return r.Config.ReconcileTimeout.Duration()
+}
+
+// recordEvent emits a Kubernetes Event against the named namespace. The namespace
+// does not need to exist any more, which lets deletion outcomes be reported.
+func (r *NamespaceReconciler) recordEvent(namespaceName, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.Recorder == nil {
+		return
+	}
+	ref := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}}
+	r.Recorder.Eventf(ref, eventType, reason, messageFmt, args...)
+}
+
+// deletionGraceRemaining returns how long the deletion of the Vault namespace for
+// namespaceName must still be deferred. The first call for a namespace starts the
+// grace period. A zero result means the deletion may proceed.
+func (r *NamespaceReconciler) deletionGraceRemaining(namespaceName string) time.Duration {
+	if r.Config.DeletionGracePeriod <= 0 || !r.deleteVaultNamespaces() {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pendingDeletions == nil {
+		r.pendingDeletions = make(map[string]time.Time)
+	}
+	deadline, ok := r.pendingDeletions[namespaceName]
+	if !ok {
+		deadline = time.Now().Add(time.Duration(r.Config.DeletionGracePeriod) * time.Second)
+		r.pendingDeletions[namespaceName] = deadline
+		metrics.NamespacesPendingDeletion.Set(float64(len(r.pendingDeletions)))
+	}
+
+	if remaining := time.Until(deadline); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// hasPendingDeletion reports whether a deletion is scheduled for namespaceName.
+func (r *NamespaceReconciler) hasPendingDeletion(namespaceName string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.pendingDeletions[namespaceName]
+	return ok
+}
+
+// clearPendingDeletion removes any scheduled deletion for namespaceName and
+// reports whether one was present.
+func (r *NamespaceReconciler) clearPendingDeletion(namespaceName string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.pendingDeletions[namespaceName]; !ok {
+		return false
+	}
+	delete(r.pendingDeletions, namespaceName)
+	metrics.NamespacesPendingDeletion.Set(float64(len(r.pendingDeletions)))
+	return true
 }
 
 func (r *NamespaceReconciler) formatVaultNamespacePath(namespaceName string) string {
+	return r.withNamespaceRoot(r.formatNamespaceName(namespaceName))
+}
+
+// formatNamespaceName formats a namespace name with NamespaceFormat, without the namespace root.
+func (r *NamespaceReconciler) formatNamespaceName(namespaceName string) string {
+	return r.formatNamespaceNameWith(r.namespaceFormat(), namespaceName)
+}
+
+// formatNamespaceNameWith formats a namespace name with format, without the namespace root.
+func (r *NamespaceReconciler) formatNamespaceNameWith(format, namespaceName string) string {
 	formatted := namespaceName
-	if r.Config.NamespaceFormat != "" {
-		formatted = fmt.Sprintf(r.Config.NamespaceFormat, namespaceName)
+	if format != "" {
+		format = strings.ReplaceAll(format, config.ClusterPlaceholder, r.Config.ClusterName)
+		formatted = fmt.Sprintf(format, namespaceName)
 	}
-	if r.Config.Vault.NamespaceRoot != "" {
-		nsRoot := strings.TrimRight(r.Config.Vault.NamespaceRoot, "/")
+	return r.truncateNames(formatted)
+}
+
+// withNamespaceRoot prefixes a formatted namespace name with the configured namespace root.
+func (r *NamespaceReconciler) withNamespaceRoot(formatted string) string {
+	return joinNamespaceRoot(r.Config.Vault.NamespaceRoot, formatted)
+}
+
+// joinNamespaceRoot prefixes a formatted namespace name with root, if any.
+func joinNamespaceRoot(root, formatted string) string {
+	if root != "" {
+		nsRoot := strings.TrimRight(root, "/")
 		formatted = fmt.Sprintf("%s/%s", nsRoot, strings.TrimLeft(formatted, "/"))
 	}
 	return formatted
 }
 
 func (r *NamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Namespace{}).
-		Complete(r)
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Namespace{}, builder.OnlyMetadata, builder.WithPredicates(r.predicates()...)).
+		WithOptions(r.controllerOptions())
+	if r.Live != nil {
+		b = b.WatchesRawSource(r.liveConfigSource())
+	}
+	return b.Complete(r)
+}
+
+// SetupWithCluster registers the reconciler with mgr to watch the namespaces of a remote cluster.
+func (r *NamespaceReconciler) SetupWithCluster(mgr ctrl.Manager, name string, remote cluster.Cluster) error {
+	b := ctrl.NewControllerManagedBy(mgr).
+		Named("namespace-" + name).
+		WatchesRawSource(source.Kind[client.Object](remote.GetCache(), newNamespaceMetadata(),
+			&handler.EnqueueRequestForObject{}, r.predicates()...)).
+		WithOptions(r.controllerOptions())
+	if r.Live != nil {
+		b = b.WatchesRawSource(r.liveConfigSource())
+	}
+	return b.Complete(r)
+}
+
+// predicates filters the namespace events that trigger a reconcile.
+func (r *NamespaceReconciler) predicates() []predicate.Predicate {
+	return []predicate.Predicate{
+		namespaceChangedPredicate(),
+		predicate.NewPredicateFuncs(func(obj client.Object) bool { return r.Shard.Owns(obj.GetName()) }),
+	}
+}
+
+// controllerOptions bounds concurrency and retries of the namespace controller.
+func (r *NamespaceReconciler) controllerOptions() controller.Options {
+	return controller.Options{
+		MaxConcurrentReconciles: r.Config.SyncWorkers,
+		RateLimiter:             newRateLimiter(r.Config.RateLimiter),
+	}
 }
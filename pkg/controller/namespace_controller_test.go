@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"testing"
@@ -13,6 +14,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -30,8 +32,14 @@ func (m *mockVaultClient) NamespaceExists(ctx context.Context, path string) (boo
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *mockVaultClient) CreateNamespace(ctx context.Context, path string) error {
-	args := m.Called(ctx, path)
+func (m *mockVaultClient) ListNamespaces(ctx context.Context, parent string) ([]string, error) {
+	args := m.Called(ctx, parent)
+	names, _ := args.Get(0).([]string)
+	return names, args.Error(1)
+}
+
+func (m *mockVaultClient) CreateNamespace(ctx context.Context, path string, customMetadata map[string]string) error {
+	args := m.Called(ctx, path, customMetadata)
 	return args.Error(0)
 }
 
@@ -40,18 +48,118 @@ func (m *mockVaultClient) DeleteNamespace(ctx context.Context, path string) erro
 	return args.Error(0)
 }
 
+func (m *mockVaultClient) NamespaceEmpty(ctx context.Context, path string) (bool, error) {
+	args := m.Called(ctx, path)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockVaultClient) GetNamespaceMetadata(ctx context.Context, path string) (map[string]string, error) {
+	args := m.Called(ctx, path)
+	customMetadata, _ := args.Get(0).(map[string]string)
+	return customMetadata, args.Error(1)
+}
+
+func (m *mockVaultClient) PatchNamespaceMetadata(ctx context.Context, path string, customMetadata map[string]string) error {
+	args := m.Called(ctx, path, customMetadata)
+	return args.Error(0)
+}
+
+func (m *mockVaultClient) RemoveNamespaceMetadata(ctx context.Context, path string, keys []string) error {
+	args := m.Called(ctx, path, keys)
+	return args.Error(0)
+}
+
+func (m *mockVaultClient) PutPolicy(ctx context.Context, namespacePath, name, policy string) error {
+	args := m.Called(ctx, namespacePath, name, policy)
+	return args.Error(0)
+}
+
+func (m *mockVaultClient) PutSentinelPolicy(ctx context.Context, namespacePath, policyType, name, policy, enforcementLevel string, paths []string) error {
+	args := m.Called(ctx, namespacePath, policyType, name, policy, enforcementLevel, paths)
+	return args.Error(0)
+}
+
+func (m *mockVaultClient) EnsureAuditDevice(ctx context.Context, namespacePath, devicePath, deviceType, description string, options map[string]string) error {
+	args := m.Called(ctx, namespacePath, devicePath, deviceType, description, options)
+	return args.Error(0)
+}
+
+func (m *mockVaultClient) EnsureSecretsEngine(ctx context.Context, namespacePath, mountPath, mountType, description string) error {
+	args := m.Called(ctx, namespacePath, mountPath, mountType, description)
+	return args.Error(0)
+}
+
+func (m *mockVaultClient) EnsureAuthMethod(ctx context.Context, namespacePath, mountPath, methodType, description string) error {
+	args := m.Called(ctx, namespacePath, mountPath, methodType, description)
+	return args.Error(0)
+}
+
+func (m *mockVaultClient) WriteAuthRole(ctx context.Context, namespacePath, mountPath, role string, data map[string]string) error {
+	args := m.Called(ctx, namespacePath, mountPath, role, data)
+	return args.Error(0)
+}
+
+func (m *mockVaultClient) WriteAuthConfig(ctx context.Context, namespacePath, mountPath string, data map[string]string) error {
+	args := m.Called(ctx, namespacePath, mountPath, data)
+	return args.Error(0)
+}
+
+func (m *mockVaultClient) EnsureGroup(ctx context.Context, namespacePath, name, groupType string, policies, memberGroupIDs []string) (string, error) {
+	args := m.Called(ctx, namespacePath, name, groupType, policies, memberGroupIDs)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockVaultClient) EnsureGroupAlias(ctx context.Context, namespacePath, groupID, mountPath, name string) error {
+	args := m.Called(ctx, namespacePath, groupID, mountPath, name)
+	return args.Error(0)
+}
+
+func (m *mockVaultClient) WriteQuota(ctx context.Context, quotaType, name string, data map[string]string) error {
+	args := m.Called(ctx, quotaType, name, data)
+	return args.Error(0)
+}
+
+func (m *mockVaultClient) DeleteQuota(ctx context.Context, quotaType, name string) error {
+	args := m.Called(ctx, quotaType, name)
+	return args.Error(0)
+}
+
+func (m *mockVaultClient) Request(ctx context.Context, namespacePath, method, path string, body map[string]interface{}) error {
+	args := m.Called(ctx, namespacePath, method, path, body)
+	return args.Error(0)
+}
+
+func (m *mockVaultClient) CreateToken(ctx context.Context, namespacePath string, policies []string, ttl, wrapTTL string) (string, error) {
+	args := m.Called(ctx, namespacePath, policies, ttl, wrapTTL)
+	return args.String(0), args.Error(1)
+}
+
+// ownedMetadata returns the ownership metadata stamped by a controller with an empty cluster name.
+func ownedMetadata(namespaceName string) map[string]string {
+	return map[string]string{
+		MetadataManagedBy:           "vault-namespace-controller",
+		MetadataKubernetesCluster:   "",
+		MetadataKubernetesNamespace: namespaceName,
+	}
+}
+
 func TestNamespaceReconciler_shouldSyncNamespace(t *testing.T) {
 	tests := []struct {
 		name           string
 		namespaceName  string
+		labels         map[string]string
 		includePattern []string
 		excludePattern []string
+		includeExpr    []string
+		excludeExpr    []string
 		expected       bool
+		reason         string
 	}{
 		{
 			name:          "default system namespace should not be synced",
 			namespaceName: "kube-system",
 			expected:      false,
+			reason:        SkipSystemNamespace,
 		},
 		{
 			name:           "system namespace explicitly included should be synced",
@@ -64,12 +172,14 @@ func TestNamespaceReconciler_shouldSyncNamespace(t *testing.T) {
 			namespaceName:  "test-ns",
 			excludePattern: []string{"test-.*"},
 			expected:       false,
+			reason:         SkipExcludePattern,
 		},
 		{
 			name:           "namespace not matching include pattern should not be synced",
 			namespaceName:  "test-ns",
 			includePattern: []string{"prod-.*"},
 			expected:       false,
+			reason:         SkipNoIncludeMatch,
 		},
 		{
 			name:           "namespace matching include pattern should be synced",
@@ -77,6 +187,36 @@ func TestNamespaceReconciler_shouldSyncNamespace(t *testing.T) {
 			includePattern: []string{"prod-.*"},
 			expected:       true,
 		},
+		{
+			name:          "namespace matching include expression should be synced",
+			namespaceName: "payments",
+			labels:        map[string]string{"env": "prod"},
+			includeExpr:   []string{"labels.env == 'prod' && !name.startsWith('tmp-')"},
+			expected:      true,
+		},
+		{
+			name:          "namespace not matching include expression should not be synced",
+			namespaceName: "tmp-payments",
+			labels:        map[string]string{"env": "prod"},
+			includeExpr:   []string{"labels.env == 'prod' && !name.startsWith('tmp-')"},
+			expected:      false,
+			reason:        SkipNoIncludeMatch,
+		},
+		{
+			name:          "include expression on a missing label does not match",
+			namespaceName: "payments",
+			includeExpr:   []string{"labels.env == 'prod'"},
+			expected:      false,
+			reason:        SkipNoIncludeMatch,
+		},
+		{
+			name:          "namespace matching exclude expression should not be synced",
+			namespaceName: "payments",
+			labels:        map[string]string{"env": "sandbox"},
+			excludeExpr:   []string{"labels.env == 'sandbox'"},
+			expected:      false,
+			reason:        SkipExcludeExpression,
+		},
 		{
 			name:          "regular namespace should be synced by default",
 			namespaceName: "app-namespace",
@@ -89,14 +229,17 @@ func TestNamespaceReconciler_shouldSyncNamespace(t *testing.T) {
 			// Create a minimal controller for testing shouldSyncNamespace
 			r := &NamespaceReconciler{
 				Config: &config.ControllerConfig{
-					IncludeNamespaces: tt.includePattern,
-					ExcludeNamespaces: tt.excludePattern,
+					IncludeNamespaces:  tt.includePattern,
+					ExcludeNamespaces:  tt.excludePattern,
+					IncludeExpressions: tt.includeExpr,
+					ExcludeExpressions: tt.excludeExpr,
 				},
 				Log: testr.New(t),
 			}
 
-			result := r.shouldSyncNamespace(tt.namespaceName)
-			assert.Equal(t, tt.expected, result)
+			namespace := &metav1.ObjectMeta{Name: tt.namespaceName, Labels: tt.labels}
+			assert.Equal(t, tt.expected, r.shouldSyncNamespace(namespace))
+			assert.Equal(t, tt.reason, r.skipReason(namespace))
 		})
 	}
 }
@@ -107,6 +250,7 @@ func TestNamespaceReconciler_formatVaultNamespacePath(t *testing.T) {
 		namespaceName string
 		format        string
 		namespaceRoot string
+		clusterName   string
 		expected      string
 	}{
 		{
@@ -144,6 +288,14 @@ func TestNamespaceReconciler_formatVaultNamespacePath(t *testing.T) {
 			namespaceRoot: "/admin",
 			expected:      "/admin/k8s-test-ns",
 		},
+		{
+			name:          "cluster name in format",
+			namespaceName: "test-ns",
+			format:        "%{cluster}-%s",
+			namespaceRoot: "/admin",
+			clusterName:   "east",
+			expected:      "/admin/east-test-ns",
+		},
 	}
 
 	for _, tt := range tests {
@@ -151,6 +303,7 @@ func TestNamespaceReconciler_formatVaultNamespacePath(t *testing.T) {
 			r := &NamespaceReconciler{
 				Config: &config.ControllerConfig{
 					NamespaceFormat: tt.format,
+					ClusterName:     tt.clusterName,
 					Vault: config.VaultConfig{
 						NamespaceRoot: tt.namespaceRoot,
 					},
@@ -268,7 +421,7 @@ func TestNamespaceReconciler_Reconcile(t *testing.T) {
 			setupMocks:        true,
 			mockError:         errors.New("vault error"),
 			expectedResult:    ctrl.Result{RequeueAfter: 30 * time.Second},
-			expectedError:     ErrNamespaceCreation,
+			expectedError:     nil, // retried on the error backoff
 		},
 		{
 			name:              "Should handle Vault deletion error",
@@ -281,7 +434,7 @@ func TestNamespaceReconciler_Reconcile(t *testing.T) {
 			setupMocks:        true,
 			mockError:         errors.New("vault error"),
 			expectedResult:    ctrl.Result{RequeueAfter: 30 * time.Second},
-			expectedError:     ErrNamespaceDeletion,
+			expectedError:     nil, // retried on the error backoff
 		},
 	}
 
@@ -312,22 +465,31 @@ func TestNamespaceReconciler_Reconcile(t *testing.T) {
 				if tt.mockError != nil && tt.expectDeletion {
 					// For deletion with error
 					mockClient.On("NamespaceExists", mock.Anything, vaultNamespaceName).Return(tt.existingNamespace, nil)
+					mockClient.On("GetNamespaceMetadata", mock.Anything, vaultNamespaceName).Return(ownedMetadata("deleted-ns"), nil)
+					mockClient.On("NamespaceEmpty", mock.Anything, vaultNamespaceName).Return(true, nil)
 					mockClient.On("DeleteNamespace", mock.Anything, vaultNamespaceName).Return(tt.mockError)
 				} else if tt.mockError != nil && tt.expectCreation {
 					// For creation with error
 					mockClient.On("NamespaceExists", mock.Anything, vaultNamespaceName).Return(tt.existingNamespace, nil)
-					mockClient.On("CreateNamespace", mock.Anything, vaultNamespaceName).Return(tt.mockError)
+					mockClient.On("CreateNamespace", mock.Anything, vaultNamespaceName, mock.Anything).Return(tt.mockError)
 				} else {
 					// Normal flow without errors
 					mockClient.On("NamespaceExists", mock.Anything, vaultNamespaceName).Return(tt.existingNamespace, nil)
 
 					// Set up CreateNamespace expectation if needed
 					if tt.expectCreation && !tt.existingNamespace {
-						mockClient.On("CreateNamespace", mock.Anything, vaultNamespaceName).Return(nil)
+						mockClient.On("CreateNamespace", mock.Anything, vaultNamespaceName, mock.Anything).Return(nil)
+					}
+
+					// An existing namespace has its ownership checked
+					if tt.namespace != nil && tt.existingNamespace {
+						mockClient.On("GetNamespaceMetadata", mock.Anything, vaultNamespaceName).Return(ownedMetadata(tt.namespace.Name), nil)
 					}
 
 					// Set up DeleteNamespace expectation if needed
 					if tt.expectDeletion && tt.existingNamespace {
+						mockClient.On("GetNamespaceMetadata", mock.Anything, vaultNamespaceName).Return(ownedMetadata("deleted-ns"), nil)
+						mockClient.On("NamespaceEmpty", mock.Anything, vaultNamespaceName).Return(true, nil)
 						mockClient.On("DeleteNamespace", mock.Anything, vaultNamespaceName).Return(nil)
 					}
 				}
@@ -342,6 +504,8 @@ func TestNamespaceReconciler_Reconcile(t *testing.T) {
 				Config: &config.ControllerConfig{
 					NamespaceFormat:       "k8s-%s",
 					DeleteVaultNamespaces: tt.deleteEnabled,
+					ErrorBackoffBase:      30,
+					ErrorBackoffMax:       300,
 				},
 				// Use the syncChecker function field to control the shouldSyncNamespace behavior
 				syncChecker: func(string) bool { return tt.shouldSync },
@@ -374,8 +538,11 @@ func TestNamespaceReconciler_Reconcile(t *testing.T) {
 
 			assert.Equal(t, tt.expectedResult, result)
 
-			// Assert that the expected methods were called
+			// Assert that the expected methods were called, checking existence only once
 			mockClient.AssertExpectations(t)
+			if tt.setupMocks {
+				mockClient.AssertNumberOfCalls(t, "NamespaceExists", 1)
+			}
 		})
 	}
 }
@@ -385,6 +552,8 @@ func TestMatchesAnyPattern(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
+		syntax   string
+		anchored bool
 		patterns []string
 		expected bool
 	}{
@@ -418,14 +587,59 @@ func TestMatchesAnyPattern(t *testing.T) {
 			patterns: []string{"^kube-system$"},
 			expected: true,
 		},
+		{
+			name:     "invalid pattern never matches",
+			input:    "test-namespace",
+			patterns: []string{"(test"},
+			expected: false,
+		},
+		{
+			name:     "regex matches anywhere",
+			input:    "not-prod-test",
+			patterns: []string{"prod"},
+			expected: true,
+		},
+		{
+			name:     "anchored regex matches whole name",
+			input:    "not-prod-test",
+			anchored: true,
+			patterns: []string{"prod"},
+			expected: false,
+		},
+		{
+			name:     "anchored regex alternation",
+			input:    "staging",
+			anchored: true,
+			patterns: []string{"prod|staging"},
+			expected: true,
+		},
+		{
+			name:     "glob matches whole name",
+			input:    "not-prod-test",
+			syntax:   config.PatternSyntaxGlob,
+			patterns: []string{"prod"},
+			expected: false,
+		},
+		{
+			name:     "glob wildcard",
+			input:    "team-payments",
+			syntax:   config.PatternSyntaxGlob,
+			patterns: []string{"team-*"},
+			expected: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := matchesAnyPattern(tt.input, tt.patterns)
+			result := matchesAnyPattern(tt.input, cmp.Or(tt.syntax, config.PatternSyntaxRegex), tt.anchored, tt.patterns)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
+
+	// Compiled patterns are cached by how they match
+	assert.Same(t, compilePattern("test-.*", config.PatternSyntaxRegex, false), compilePattern("test-.*", config.PatternSyntaxRegex, false))
+	assert.NotSame(t, compilePattern("test-*", config.PatternSyntaxRegex, false), compilePattern("test-*", config.PatternSyntaxGlob, false))
+	assert.NotSame(t, compilePattern("test-.*", config.PatternSyntaxRegex, false), compilePattern("test-.*", config.PatternSyntaxRegex, true))
 }
 
 // TestHandleNamespaceCreation tests the handleNamespaceCreation method.
@@ -436,18 +650,21 @@ func TestHandleNamespaceCreation(t *testing.T) {
 		namespaceExists    bool
 		namespaceExistsErr error
 		createNamespaceErr error
+		expectedManage     bool
 		expectedError      error
 	}{
 		{
 			name:            "create new namespace successfully",
 			namespaceName:   "test-namespace",
 			namespaceExists: false,
+			expectedManage:  true,
 			expectedError:   nil,
 		},
 		{
 			name:            "namespace already exists",
 			namespaceName:   "existing-namespace",
 			namespaceExists: true,
+			expectedManage:  true,
 			expectedError:   nil,
 		},
 		{
@@ -476,9 +693,13 @@ func TestHandleNamespaceCreation(t *testing.T) {
 				Return(tt.namespaceExists, tt.namespaceExistsErr)
 
 			if !tt.namespaceExists && tt.namespaceExistsErr == nil {
-				mockClient.On("CreateNamespace", mock.Anything, vaultNamespacePath).
+				mockClient.On("CreateNamespace", mock.Anything, vaultNamespacePath, ownedMetadata(tt.namespaceName)).
 					Return(tt.createNamespaceErr)
 			}
+			if tt.namespaceExists {
+				mockClient.On("GetNamespaceMetadata", mock.Anything, vaultNamespacePath).
+					Return(ownedMetadata(tt.namespaceName), nil)
+			}
 
 			// Create reconciler with mock
 			reconciler := &NamespaceReconciler{
@@ -490,7 +711,7 @@ func TestHandleNamespaceCreation(t *testing.T) {
 			}
 
 			// Call the method
-			err := reconciler.handleNamespaceCreation(context.Background(), reconciler.formatVaultNamespacePath(tt.namespaceName), reconciler.Log)
+			manage, err := reconciler.handleNamespaceCreation(context.Background(), tt.namespaceName, reconciler.formatVaultNamespacePath(tt.namespaceName), reconciler.Log)
 
 			// Check the result
 			if tt.expectedError != nil {
@@ -500,6 +721,7 @@ func TestHandleNamespaceCreation(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 			}
+			assert.Equal(t, tt.expectedManage, manage)
 
 			// Verify mock calls
 			mockClient.AssertExpectations(t)
@@ -515,7 +737,11 @@ func TestHandleNamespaceDeletion(t *testing.T) {
 		deleteEnabled      bool
 		namespaceExists    bool
 		namespaceExistsErr error
+		namespaceNotEmpty  bool
+		forceNonEmpty      bool
+		expectDelete       bool
 		deleteNamespaceErr error
+		expectGone         bool
 		expectedError      error
 	}{
 		{
@@ -529,13 +755,36 @@ func TestHandleNamespaceDeletion(t *testing.T) {
 			namespaceName:   "existing-namespace",
 			deleteEnabled:   true,
 			namespaceExists: true,
+			expectDelete:    true,
+			expectGone:      true,
 			expectedError:   nil,
 		},
+		{
+			name:              "non-empty namespace is not deleted",
+			namespaceName:     "populated-namespace",
+			deleteEnabled:     true,
+			namespaceExists:   true,
+			namespaceNotEmpty: true,
+			expectDelete:      false,
+			expectedError:     nil,
+		},
+		{
+			name:              "non-empty namespace is deleted when forced",
+			namespaceName:     "forced-namespace",
+			deleteEnabled:     true,
+			namespaceExists:   true,
+			namespaceNotEmpty: true,
+			forceNonEmpty:     true,
+			expectDelete:      true,
+			expectGone:        true,
+			expectedError:     nil,
+		},
 		{
 			name:            "namespace doesn't exist",
 			namespaceName:   "non-existing-namespace",
 			deleteEnabled:   true,
 			namespaceExists: false,
+			expectGone:      true,
 			expectedError:   nil,
 		},
 		{
@@ -550,6 +799,7 @@ func TestHandleNamespaceDeletion(t *testing.T) {
 			namespaceName:      "delete-error-namespace",
 			deleteEnabled:      true,
 			namespaceExists:    true,
+			expectDelete:       true,
 			deleteNamespaceErr: errors.New("failed to delete"),
 			expectedError:      ErrNamespaceDeletion,
 		},
@@ -567,25 +817,39 @@ func TestHandleNamespaceDeletion(t *testing.T) {
 					Return(tt.namespaceExists, tt.namespaceExistsErr)
 
 				if tt.namespaceExists && tt.namespaceExistsErr == nil {
+					mockClient.On("GetNamespaceMetadata", mock.Anything, vaultNamespacePath).
+						Return(ownedMetadata(tt.namespaceName), nil)
+				}
+
+				if tt.namespaceExists && tt.namespaceExistsErr == nil && !tt.forceNonEmpty {
+					mockClient.On("NamespaceEmpty", mock.Anything, vaultNamespacePath).
+						Return(!tt.namespaceNotEmpty, nil)
+				}
+
+				if tt.expectDelete {
 					mockClient.On("DeleteNamespace", mock.Anything, vaultNamespacePath).
 						Return(tt.deleteNamespaceErr)
 				}
 			}
 
 			// Create reconciler with mock
+			recorder := record.NewFakeRecorder(10)
 			reconciler := &NamespaceReconciler{
 				Log:         testr.New(t),
 				VaultClient: mockClient,
+				Recorder:    recorder,
 				Config: &config.ControllerConfig{
-					NamespaceFormat:       "k8s-%s",
-					DeleteVaultNamespaces: tt.deleteEnabled,
+					NamespaceFormat:          "k8s-%s",
+					DeleteVaultNamespaces:    tt.deleteEnabled,
+					DeleteNonEmptyNamespaces: tt.forceNonEmpty,
 				},
 			}
 
 			// Call the method
-			err := reconciler.handleNamespaceDeletion(context.Background(), reconciler.formatVaultNamespacePath(tt.namespaceName), reconciler.Log)
+			gone, err := reconciler.handleNamespaceDeletion(context.Background(), tt.namespaceName, reconciler.formatVaultNamespacePath(tt.namespaceName), reconciler.Log)
 
 			// Check the result
+			assert.Equal(t, tt.expectGone, gone)
 			if tt.expectedError != nil {
 				assert.Error(t, err)
 				assert.True(t, errors.Is(err, tt.expectedError),
@@ -594,8 +858,183 @@ func TestHandleNamespaceDeletion(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
+			// A warning event is emitted for skipped non-empty namespaces
+			if tt.namespaceNotEmpty && !tt.forceNonEmpty {
+				assert.Len(t, recorder.Events, 1)
+				assert.Contains(t, <-recorder.Events, "VaultNamespaceNotEmpty")
+			} else {
+				assert.Empty(t, recorder.Events)
+			}
+
 			// Verify mock calls
 			mockClient.AssertExpectations(t)
 		})
 	}
 }
+
+// TestNamespaceReconciler_DeletionGracePeriod tests that Vault namespace deletion
+// is deferred during the grace period and cancelled if the namespace returns.
+func TestNamespaceReconciler_DeletionGracePeriod(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "grace-ns"}}
+
+	t.Run("deletion is deferred until the grace period expires", func(t *testing.T) {
+		mockClient := new(mockVaultClient)
+		reconciler := &NamespaceReconciler{
+			Client:      fake.NewClientBuilder().WithScheme(scheme).Build(),
+			Log:         testr.New(t),
+			Scheme:      scheme,
+			VaultClient: mockClient,
+			Config: &config.ControllerConfig{
+				NamespaceFormat:       "k8s-%s",
+				DeleteVaultNamespaces: true,
+				DeletionGracePeriod:   3600,
+			},
+		}
+
+		// First reconcile schedules the deletion without touching Vault
+		result, err := reconciler.Reconcile(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Greater(t, result.RequeueAfter, 59*time.Minute)
+		mockClient.AssertNotCalled(t, "DeleteNamespace", mock.Anything, mock.Anything)
+
+		// Once the deadline has passed the deletion proceeds
+		reconciler.pendingDeletions["grace-ns"] = time.Now().Add(-time.Second)
+		mockClient.On("NamespaceExists", mock.Anything, "k8s-grace-ns").Return(true, nil)
+		mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-grace-ns").Return(ownedMetadata("grace-ns"), nil)
+		mockClient.On("NamespaceEmpty", mock.Anything, "k8s-grace-ns").Return(true, nil)
+		mockClient.On("DeleteNamespace", mock.Anything, "k8s-grace-ns").Return(nil)
+
+		result, err = reconciler.Reconcile(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, ctrl.Result{}, result)
+		assert.NotContains(t, reconciler.pendingDeletions, "grace-ns")
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("recreating the namespace cancels the deletion", func(t *testing.T) {
+		mockClient := new(mockVaultClient)
+		mockClient.On("NamespaceExists", mock.Anything, "k8s-grace-ns").Return(true, nil)
+		mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-grace-ns").Return(ownedMetadata("grace-ns"), nil)
+
+		reconciler := &NamespaceReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "grace-ns"},
+			}).Build(),
+			Log:         testr.New(t),
+			Scheme:      scheme,
+			VaultClient: mockClient,
+			Config: &config.ControllerConfig{
+				NamespaceFormat:       "k8s-%s",
+				DeleteVaultNamespaces: true,
+				DeletionGracePeriod:   3600,
+			},
+			syncChecker:      func(string) bool { return true },
+			pendingDeletions: map[string]time.Time{"grace-ns": time.Now().Add(time.Hour)},
+		}
+
+		_, err := reconciler.Reconcile(context.Background(), req)
+		assert.NoError(t, err)
+		assert.NotContains(t, reconciler.pendingDeletions, "grace-ns")
+		mockClient.AssertNotCalled(t, "DeleteNamespace", mock.Anything, mock.Anything)
+	})
+}
+
+// TestNamespaceReconciler_DryRun tests that dry-run mode records changes without making them.
+func TestNamespaceReconciler_DryRun(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	tests := []struct {
+		name      string
+		namespace *corev1.Namespace
+		exists    bool
+		event     string
+	}{
+		{
+			name:      "creation is skipped",
+			namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dry-ns"}},
+			exists:    false,
+			event:     "would create Vault namespace k8s-dry-ns",
+		},
+		{
+			name:   "deletion is skipped",
+			exists: true,
+			event:  "would delete Vault namespace k8s-dry-ns",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientBuilder := fake.NewClientBuilder().WithScheme(scheme)
+			if tt.namespace != nil {
+				clientBuilder = clientBuilder.WithObjects(tt.namespace)
+			}
+
+			mockClient := new(mockVaultClient)
+			mockClient.On("NamespaceExists", mock.Anything, "k8s-dry-ns").Return(tt.exists, nil)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-dry-ns").Return(ownedMetadata("dry-ns"), nil)
+			mockClient.On("NamespaceEmpty", mock.Anything, "k8s-dry-ns").Return(true, nil)
+
+			recorder := record.NewFakeRecorder(10)
+			reconciler := &NamespaceReconciler{
+				Client:      clientBuilder.Build(),
+				Log:         testr.New(t),
+				Scheme:      scheme,
+				VaultClient: mockClient,
+				Recorder:    recorder,
+				Config: &config.ControllerConfig{
+					NamespaceFormat:       "k8s-%s",
+					DeleteVaultNamespaces: true,
+					DryRun:                true,
+				},
+				syncChecker: func(string) bool { return true },
+			}
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "dry-ns"}}
+			_, err := reconciler.Reconcile(context.Background(), req)
+
+			assert.NoError(t, err)
+			assert.Contains(t, <-recorder.Events, tt.event)
+			mockClient.AssertNotCalled(t, "CreateNamespace", mock.Anything, mock.Anything, mock.Anything)
+			mockClient.AssertNotCalled(t, "DeleteNamespace", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestNamespaceReconciler_NamespaceSelector tests that a namespace leaving the
+// selected set is not mistaken for a deleted one.
+func TestNamespaceReconciler_NamespaceSelector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	// The cache no longer holds the namespace, but the API server still does
+	apiReader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "relabelled-ns"}},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	reconciler := &NamespaceReconciler{
+		Client:      fake.NewClientBuilder().WithScheme(scheme).Build(),
+		APIReader:   apiReader,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{
+			NamespaceFormat:       "k8s-%s",
+			DeleteVaultNamespaces: true,
+			NamespaceSelector:     "team=payments",
+		},
+	}
+
+	result, err := reconciler.Reconcile(context.Background(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Name: "relabelled-ns"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+	mockClient.AssertNotCalled(t, "NamespaceExists", mock.Anything, mock.Anything)
+	mockClient.AssertNotCalled(t, "DeleteNamespace", mock.Anything, mock.Anything)
+}
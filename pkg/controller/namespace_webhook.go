@@ -0,0 +1,245 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+)
+
+// Annotations the namespace webhook stamps on new namespaces. They record the
+// mapping computed at creation and are not read back by the controller.
+const (
+	// VaultPathAnnotation is the Vault namespace path of the namespace.
+	VaultPathAnnotation = "vault.benemon.io/vault-path"
+	// ResolvedClassAnnotation is the VaultNamespaceClass the path was computed with.
+	ResolvedClassAnnotation = "vault.benemon.io/resolved-class"
+)
+
+// AllowDataLossAnnotation set to "true" on a namespace lets it be deleted
+// although the deletion guard would deny it.
+const AllowDataLossAnnotation = "vault.benemon.io/allow-data-loss"
+
+// Paths the namespace webhooks are served on.
+const (
+	NamespaceWebhookPath           = "/mutate-v1-namespace"
+	NamespaceValidationWebhookPath = "/validate-v1-namespace"
+	NamespaceDeletionWebhookPath   = "/validate-v1-namespace-deletion"
+)
+
+// admissionSyncs reports whether a namespace under admission will be
+// synchronized, and otherwise why not.
+func (r *NamespaceReconciler) admissionSyncs(namespace *corev1.Namespace, selector labels.Selector) (string, bool) {
+	if selector != nil && !selector.Matches(labels.Set(namespace.Labels)) {
+		return "namespace does not match the namespace selector", false
+	}
+	if !r.shouldSyncNamespace(namespace) {
+		return "namespace is not synchronized to Vault", false
+	}
+	return "", true
+}
+
+// NamespaceAnnotator is a mutating admission webhook that annotates new
+// namespaces with the Vault namespace path they will be synchronized to.
+// Namespaces whose path cannot be computed are admitted unchanged with a
+// warning, so the controller never blocks namespace creation.
+type NamespaceAnnotator struct {
+	Reconciler *NamespaceReconciler
+	Decoder    admission.Decoder
+	// NamespaceSelector limits the annotated namespaces to those the controller
+	// watches. Nil annotates every namespace.
+	NamespaceSelector labels.Selector
+	Log               logr.Logger
+}
+
+var _ admission.Handler = &NamespaceAnnotator{}
+
+// Handle annotates the namespace in req.
+func (a *NamespaceAnnotator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	namespace := &corev1.Namespace{}
+	if err := a.Decoder.Decode(req, namespace); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	r := a.Reconciler
+	if reason, ok := r.admissionSyncs(namespace, a.NamespaceSelector); !ok {
+		return admission.Allowed(reason)
+	}
+
+	log := a.Log.WithValues("kubernetesNamespace", namespace.Name)
+	vaultNamespace, err := r.resolveVaultNamespacePath(ctx, namespace)
+	if err != nil {
+		log.V(1).Info("Cannot determine Vault namespace path", "error", err.Error())
+		return admission.Allowed("").WithWarnings(
+			fmt.Sprintf("vault-namespace-controller cannot determine the Vault namespace: %v", err))
+	}
+
+	annotated := namespace.DeepCopy()
+	if annotated.Annotations == nil {
+		annotated.Annotations = make(map[string]string)
+	}
+	annotated.Annotations[VaultPathAnnotation] = vaultNamespace
+	if className := namespace.Annotations[vaultv1alpha1.ClassAnnotation]; className != "" && r.Classes != nil {
+		annotated.Annotations[ResolvedClassAnnotation] = className
+	} else {
+		delete(annotated.Annotations, ResolvedClassAnnotation)
+	}
+
+	raw, err := json.Marshal(annotated)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	log.V(1).Info("Annotated namespace with its Vault namespace path", "vaultNamespace", vaultNamespace)
+	return admission.PatchResponseFromRaw(req.Object.Raw, raw)
+}
+
+// NamespaceValidator is a validating admission webhook that rejects new
+// namespaces whose Vault namespace path Vault would not accept. Namespaces
+// whose path cannot be computed for other reasons, such as a class that does
+// not exist yet, are admitted with a warning.
+type NamespaceValidator struct {
+	Reconciler *NamespaceReconciler
+	Decoder    admission.Decoder
+	// NamespaceSelector limits the validated namespaces to those the controller
+	// watches. Nil validates every namespace.
+	NamespaceSelector labels.Selector
+	Log               logr.Logger
+}
+
+var _ admission.Handler = &NamespaceValidator{}
+
+// Handle validates the namespace in req.
+func (v *NamespaceValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	namespace := &corev1.Namespace{}
+	if err := v.Decoder.Decode(req, namespace); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	r := v.Reconciler
+	if reason, ok := r.admissionSyncs(namespace, v.NamespaceSelector); !ok {
+		return admission.Allowed(reason)
+	}
+
+	_, err := r.resolveVaultNamespacePath(ctx, namespace)
+	switch {
+	case err == nil:
+		return admission.Allowed("")
+	case errors.Is(err, ErrInvalidNamespacePath):
+		v.Log.Info("Rejected namespace with an invalid Vault namespace path",
+			"kubernetesNamespace", namespace.Name, "error", err.Error())
+		return admission.Denied(fmt.Sprintf("namespace cannot be synchronized to Vault: %v", err))
+	default:
+		return admission.Allowed("").WithWarnings(
+			fmt.Sprintf("vault-namespace-controller cannot determine the Vault namespace: %v", err))
+	}
+}
+
+// NamespaceDeletionGuard is a validating admission webhook that denies deleting
+// a namespace when the controller would then delete its Vault namespace while
+// it still holds secret or auth mounts. Namespaces annotated with
+// AllowDataLossAnnotation are deleted regardless. Failures to inspect Vault
+// admit the deletion with a warning.
+type NamespaceDeletionGuard struct {
+	Reconciler *NamespaceReconciler
+	Decoder    admission.Decoder
+	// NamespaceSelector limits the guarded namespaces to those the controller
+	// watches. Nil guards every namespace.
+	NamespaceSelector labels.Selector
+	Log               logr.Logger
+}
+
+var _ admission.Handler = &NamespaceDeletionGuard{}
+
+// Handle validates the deletion of the namespace in req.
+func (g *NamespaceDeletionGuard) Handle(ctx context.Context, req admission.Request) admission.Response {
+	namespace := &corev1.Namespace{}
+	if err := g.Decoder.DecodeRaw(req.OldObject, namespace); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if namespace.Annotations[AllowDataLossAnnotation] == "true" {
+		return admission.Allowed("deletion allowed by " + AllowDataLossAnnotation)
+	}
+	r := g.Reconciler
+	if reason, ok := r.admissionSyncs(namespace, g.NamespaceSelector); !ok {
+		return admission.Allowed(reason)
+	}
+
+	log := g.Log.WithValues("kubernetesNamespace", namespace.Name)
+	vaultNamespace, err := r.guardedVaultNamespace(ctx, namespace)
+	if err != nil {
+		log.V(1).Info("Cannot inspect Vault namespace", "error", err.Error())
+		return admission.Allowed("").WithWarnings(
+			fmt.Sprintf("vault-namespace-controller cannot inspect the Vault namespace: %v", err))
+	}
+	if vaultNamespace == "" {
+		return admission.Allowed("")
+	}
+
+	log.Info("Denied deletion of namespace with a non-empty Vault namespace", "vaultNamespace", vaultNamespace)
+	return admission.Denied(fmt.Sprintf(
+		"deleting the namespace deletes Vault namespace %s, which contains secret or auth mounts; "+
+			"annotate the namespace with %s=true to delete it anyway", vaultNamespace, AllowDataLossAnnotation))
+}
+
+// guardedVaultNamespace returns the Vault namespace that deleting the
+// Kubernetes namespace would delete although it is not empty, or "" when the
+// deletion loses no Vault data.
+func (r *NamespaceReconciler) guardedVaultNamespace(ctx context.Context, namespace *corev1.Namespace) (string, error) {
+	deleteEnabled, err := r.deleteVaultNamespace(ctx, namespace.Name)
+	if err != nil {
+		return "", err
+	}
+	// Without DeleteNonEmptyNamespaces the controller keeps non-empty Vault namespaces itself
+	if !deleteEnabled || !r.deleteNonEmptyNamespaces() {
+		return "", nil
+	}
+
+	vaultNamespace, err := r.deletedNamespacePath(ctx, namespace.Name)
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	_, synchronized := r.paths[namespace.Name]
+	r.mu.Unlock()
+	connection := r.deletedNamespaceConnection(namespace.Name)
+	// Replicas that have not synchronized the namespace since they started, such
+	// as those not leading, map it from the namespace like its reconcile would
+	if vaultNamespace == "" {
+		if vaultNamespace, err = r.resolveVaultNamespacePath(ctx, namespace); err != nil {
+			return "", err
+		}
+		vaultNamespace = strings.Trim(vaultNamespace, "/")
+	}
+	if !synchronized {
+		if connection, err = r.connectionFor(ctx, namespace); err != nil {
+			return "", err
+		}
+	}
+	if connection != "" {
+		ctx = withConnection(ctx, connection)
+	}
+
+	exists, err := r.VaultClient.NamespaceExists(ctx, vaultNamespace)
+	if err != nil || !exists {
+		return "", err
+	}
+	owned, err := r.isOwned(ctx, vaultNamespace)
+	if err != nil || !owned {
+		return "", err
+	}
+	empty, err := r.VaultClient.NamespaceEmpty(ctx, vaultNamespace)
+	if err != nil || empty {
+		return "", err
+	}
+	return vaultNamespace, nil
+}
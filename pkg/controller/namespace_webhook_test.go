@@ -0,0 +1,318 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestNamespaceAnnotator_Handle tests annotating new namespaces with their Vault path.
+func TestNamespaceAnnotator_Handle(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = vaultv1alpha1.AddToScheme(scheme)
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&vaultv1alpha1.VaultNamespaceClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "regulated"},
+			Spec:       vaultv1alpha1.VaultNamespaceClassSpec{Parent: "admin/regulated"},
+		},
+	).Build()
+
+	annotator := &NamespaceAnnotator{
+		Reconciler: &NamespaceReconciler{
+			Client: k8sClient,
+			Log:    testr.New(t),
+			Config: &config.ControllerConfig{
+				NamespaceFormat:   "k8s-%s",
+				ExcludeNamespaces: []string{"^tmp-.*"},
+				Vault:             config.VaultConfig{NamespaceRoot: "admin"},
+			},
+			Classes: &VaultNamespaceClasses{Reader: k8sClient},
+		},
+		Decoder:           admission.NewDecoder(scheme),
+		NamespaceSelector: labels.SelectorFromSet(labels.Set{"vault": "enabled"}),
+		Log:               testr.New(t),
+	}
+
+	tests := []struct {
+		name        string
+		namespace   string
+		labels      map[string]string
+		class       string
+		annotations map[string]string
+		warning     bool
+	}{
+		{
+			name:        "path without a class",
+			namespace:   "payments",
+			labels:      map[string]string{"vault": "enabled"},
+			annotations: map[string]string{VaultPathAnnotation: "admin/k8s-payments"},
+		},
+		{
+			name:      "path and class",
+			namespace: "payments",
+			labels:    map[string]string{"vault": "enabled"},
+			class:     "regulated",
+			annotations: map[string]string{
+				vaultv1alpha1.ClassAnnotation: "regulated",
+				ResolvedClassAnnotation:       "regulated",
+				VaultPathAnnotation:           "admin/regulated/k8s-payments",
+			},
+		},
+		{
+			name:        "missing class warns",
+			namespace:   "payments",
+			labels:      map[string]string{"vault": "enabled"},
+			class:       "missing",
+			annotations: map[string]string{vaultv1alpha1.ClassAnnotation: "missing"},
+			warning:     true,
+		},
+		{
+			name:      "excluded namespace",
+			namespace: "tmp-build",
+			labels:    map[string]string{"vault": "enabled"},
+		},
+		{
+			name:      "namespace outside the selector",
+			namespace: "payments",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace := &corev1.Namespace{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+				ObjectMeta: metav1.ObjectMeta{Name: tt.namespace, Labels: tt.labels},
+			}
+			if tt.class != "" {
+				namespace.Annotations = map[string]string{vaultv1alpha1.ClassAnnotation: tt.class}
+			}
+			raw, err := json.Marshal(namespace)
+			assert.NoError(t, err)
+
+			resp := annotator.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+			assert.True(t, resp.Allowed)
+			assert.Equal(t, tt.warning, len(resp.Warnings) > 0)
+
+			// Apply the patch to the annotations the namespace was created with
+			annotations := map[string]string{}
+			for key, value := range namespace.Annotations {
+				annotations[key] = value
+			}
+			for _, patch := range resp.Patches {
+				switch {
+				case patch.Path == "/metadata/annotations":
+					for key, value := range patch.Value.(map[string]any) {
+						annotations[key] = value.(string)
+					}
+				case len(patch.Path) > len("/metadata/annotations/"):
+					key := patch.Path[len("/metadata/annotations/"):]
+					annotations[strings.NewReplacer("~1", "/", "~0", "~").Replace(key)] = patch.Value.(string)
+				}
+			}
+			if tt.annotations == nil {
+				tt.annotations = map[string]string{}
+			}
+			assert.Equal(t, tt.annotations, annotations)
+		})
+	}
+}
+
+// TestNamespaceValidator_Handle tests rejecting namespaces that map to invalid Vault paths.
+func TestNamespaceValidator_Handle(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = vaultv1alpha1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	validator := &NamespaceValidator{
+		Reconciler: &NamespaceReconciler{
+			Client: k8sClient,
+			Log:    testr.New(t),
+			Config: &config.ControllerConfig{
+				NamespaceTemplate:      `{{ index .Labels "team" }}/{{ .Name }}`,
+				MaxNamespaceNameLength: 64,
+				StrictNamespaceNames:   true,
+				ExcludeNamespaces:      []string{"^tmp-.*"},
+			},
+			Classes: &VaultNamespaceClasses{Reader: k8sClient},
+		},
+		Decoder: admission.NewDecoder(scheme),
+		Log:     testr.New(t),
+	}
+
+	tests := []struct {
+		name    string
+		ns      string
+		team    string
+		class   string
+		allowed bool
+		warning bool
+	}{
+		{name: "valid path", ns: "payments", team: "finance", allowed: true},
+		{name: "reserved name", ns: "auth", team: "finance", allowed: false},
+		{name: "strict names", ns: "payments", team: "finance.eu", allowed: false},
+		{name: "excluded namespace", ns: "tmp-auth", team: "finance", allowed: true},
+		{name: "empty path segment", ns: "payments", allowed: false},
+		{name: "missing class warns", ns: "payments", team: "finance", class: "missing", allowed: true, warning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace := &corev1.Namespace{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+				ObjectMeta: metav1.ObjectMeta{Name: tt.ns},
+			}
+			if tt.team != "" {
+				namespace.Labels = map[string]string{"team": tt.team}
+			}
+			if tt.class != "" {
+				namespace.Annotations = map[string]string{vaultv1alpha1.ClassAnnotation: tt.class}
+			}
+			raw, err := json.Marshal(namespace)
+			assert.NoError(t, err)
+
+			resp := validator.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+			assert.Equal(t, tt.allowed, resp.Allowed)
+			assert.Equal(t, tt.warning, len(resp.Warnings) > 0)
+		})
+	}
+}
+
+// TestNamespaceDeletionGuard_Handle tests denying deletions that would delete non-empty Vault namespaces.
+func TestNamespaceDeletionGuard_Handle(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "k8s-payments").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-payments").Return(ownedMetadata("payments"), nil)
+	mockClient.On("NamespaceEmpty", mock.Anything, "k8s-payments").Return(false, nil)
+	mockClient.On("NamespaceExists", mock.Anything, "k8s-empty").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-empty").Return(ownedMetadata("empty"), nil)
+	mockClient.On("NamespaceEmpty", mock.Anything, "k8s-empty").Return(true, nil)
+	mockClient.On("NamespaceExists", mock.Anything, "k8s-shared").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-shared").Return(map[string]string{}, nil)
+	mockClient.On("NamespaceExists", mock.Anything, "k8s-new").Return(false, nil)
+	mockClient.On("NamespaceExists", mock.Anything, "k8s-down").Return(false, errors.New("vault unavailable"))
+
+	tests := []struct {
+		name           string
+		ns             string
+		annotations    map[string]string
+		deleteNonEmpty bool
+		allowed        bool
+		warning        bool
+	}{
+		{name: "non-empty Vault namespace", ns: "payments", deleteNonEmpty: true, allowed: false},
+		{name: "override annotation", ns: "payments", deleteNonEmpty: true,
+			annotations: map[string]string{AllowDataLossAnnotation: "true"}, allowed: true},
+		{name: "non-empty Vault namespaces are kept", ns: "payments", allowed: true},
+		{name: "empty Vault namespace", ns: "empty", deleteNonEmpty: true, allowed: true},
+		{name: "unowned Vault namespace", ns: "shared", deleteNonEmpty: true, allowed: true},
+		{name: "missing Vault namespace", ns: "new", deleteNonEmpty: true, allowed: true},
+		{name: "Vault unavailable warns", ns: "down", deleteNonEmpty: true, allowed: true, warning: true},
+		{name: "excluded namespace", ns: "kube-system", deleteNonEmpty: true, allowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := &NamespaceDeletionGuard{
+				Reconciler: &NamespaceReconciler{
+					Log:         testr.New(t),
+					VaultClient: mockClient,
+					Config: &config.ControllerConfig{
+						NamespaceFormat:          "k8s-%s",
+						DeleteVaultNamespaces:    true,
+						DeleteNonEmptyNamespaces: tt.deleteNonEmpty,
+						ExcludeNamespaces:        []string{"^kube-.*"},
+					},
+				},
+				Decoder: admission.NewDecoder(scheme),
+				Log:     testr.New(t),
+			}
+			namespace := &corev1.Namespace{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+				ObjectMeta: metav1.ObjectMeta{Name: tt.ns, Annotations: tt.annotations},
+			}
+			raw, err := json.Marshal(namespace)
+			assert.NoError(t, err)
+
+			resp := guard.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Delete,
+				OldObject: runtime.RawExtension{Raw: raw},
+			}})
+			assert.Equal(t, tt.allowed, resp.Allowed)
+			assert.Equal(t, tt.warning, len(resp.Warnings) > 0)
+		})
+	}
+}
+
+// TestNamespaceDeletionGuard_Unsynchronized tests a replica that has not
+// reconciled the namespace, such as one not leading, maps it from the namespace
+// itself to guard its deletion.
+func TestNamespaceDeletionGuard_Unsynchronized(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	inConnection := mock.MatchedBy(func(ctx context.Context) bool { return connectionFrom(ctx) == "eu" })
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", inConnection, "finance/payments").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", inConnection, "finance/payments").Return(ownedMetadata("payments"), nil)
+	mockClient.On("NamespaceEmpty", inConnection, "finance/payments").Return(false, nil)
+
+	guard := &NamespaceDeletionGuard{
+		Reconciler: &NamespaceReconciler{
+			Log:         testr.New(t),
+			VaultClient: mockClient,
+			Config: &config.ControllerConfig{
+				NamespaceTemplate:        `{{ index .Labels "team" }}/{{ .Name }}`,
+				DeleteVaultNamespaces:    true,
+				DeleteNonEmptyNamespaces: true,
+				VaultConnections:         true,
+			},
+		},
+		Decoder: admission.NewDecoder(scheme),
+		Log:     testr.New(t),
+	}
+	namespace := &corev1.Namespace{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: map[string]string{
+			"team":                        "finance",
+			vaultv1alpha1.ConnectionLabel: "eu",
+		}},
+	}
+	raw, err := json.Marshal(namespace)
+	assert.NoError(t, err)
+
+	resp := guard.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Operation: admissionv1.Delete,
+		OldObject: runtime.RawExtension{Raw: raw},
+	}})
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, "finance/payments")
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,254 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+	"github.com/go-logr/logr"
+)
+
+// notificationQueueSize is how many notifications wait for delivery before
+// further ones are dropped.
+const notificationQueueSize = 100
+
+// defaultNotificationMessages are sent for events when a notifier has no message template.
+var defaultNotificationMessages = map[string]*template.Template{
+	config.NotificationEventCreated: defaultNotificationMessage(
+		`Vault namespace {{.VaultNamespace}} was created for Kubernetes namespace {{.Namespace}}`),
+	config.NotificationEventDeleted: defaultNotificationMessage(
+		`Vault namespace {{.VaultNamespace}} of Kubernetes namespace {{.Namespace}} was deleted`),
+	config.NotificationEventDeletionBlocked: defaultNotificationMessage(
+		`Vault namespace {{.VaultNamespace}} of Kubernetes namespace {{.Namespace}} was not deleted: {{.Reason}}`),
+	config.NotificationEventFailing: defaultNotificationMessage(
+		`Kubernetes namespace {{.Namespace}} failed to sync {{.Failures}} times in a row: {{.Error}}`),
+}
+
+// defaultNotificationMessage parses a default message, prefixed with the
+// cluster when one is configured.
+func defaultNotificationMessage(text string) *template.Template {
+	return template.Must(config.ParseNotificationMessage(`{{with .Cluster}}[{{.}}] {{end}}` + text))
+}
+
+// Reasons a Vault namespace deletion is blocked, as in the deletions blocked metric.
+const (
+	DeletionBlockedNotOwned = "not_owned"
+	DeletionBlockedNonEmpty = "non_empty"
+	DeletionBlockedHook     = "hook"
+)
+
+// NotificationEvent describes a Vault namespace lifecycle event. It is the
+// data message templates are executed against.
+type NotificationEvent struct {
+	Event          string `json:"event"`
+	Namespace      string `json:"namespace"`
+	VaultNamespace string `json:"vaultNamespace,omitempty"`
+	Cluster        string `json:"cluster,omitempty"`
+	Connection     string `json:"connection,omitempty"`
+	// Reason is why a deletion was blocked: not_owned, non_empty or hook.
+	Reason string `json:"reason,omitempty"`
+	// Error is the latest error of a failing namespace.
+	Error string `json:"error,omitempty"`
+	// Failures counts the consecutive failed reconciles of a failing namespace.
+	Failures  int       `json:"failures,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NotificationPayload is the JSON body POSTed to webhook notifiers.
+type NotificationPayload struct {
+	NotificationEvent
+	Message string `json:"message"`
+}
+
+// Notifications posts messages about Vault namespace lifecycle events to the
+// configured notifiers. Delivery is best effort: notifications are sent once,
+// in the background, and dropped when they fail or too many are waiting.
+type Notifications struct {
+	// FailureThreshold is how many consecutive failed reconciles send the failing event.
+	FailureThreshold int
+	HTTPClient       *http.Client
+	Log              logr.Logger
+
+	notifiers []notifier
+	queue     chan notification
+}
+
+// notifier is a configured notifier with its parsed message template.
+type notifier struct {
+	config.Notifier
+	message *template.Template
+}
+
+// notification is a rendered notification waiting for delivery.
+type notification struct {
+	notifier *notifier
+	event    string
+	body     []byte
+}
+
+// NewNotifications returns the configured notifications, or nil when no
+// notifiers are configured.
+func NewNotifications(cfg config.NotificationsConfig, log logr.Logger) (*Notifications, error) {
+	if len(cfg.Notifiers) == 0 {
+		return nil, nil
+	}
+	n := &Notifications{
+		FailureThreshold: cfg.Threshold(),
+		HTTPClient:       &http.Client{},
+		Log:              log,
+		queue:            make(chan notification, notificationQueueSize),
+	}
+	for _, nc := range cfg.Notifiers {
+		if nc.Message == "" {
+			n.notifiers = append(n.notifiers, notifier{Notifier: nc})
+			continue
+		}
+		message, err := config.ParseNotificationMessage(nc.Message)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message of notifier %s: %w", nc.Name, err)
+		}
+		n.notifiers = append(n.notifiers, notifier{Notifier: nc, message: message})
+	}
+	return n, nil
+}
+
+// Notify queues event for the notifiers subscribed to it.
+func (n *Notifications) Notify(event NotificationEvent) {
+	if n == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	for i := range n.notifiers {
+		notifier := &n.notifiers[i]
+		if !notifier.Subscribes(event.Event) {
+			continue
+		}
+		body, err := notifier.render(event)
+		if err != nil {
+			n.Log.Error(err, "Failed to render notification", "notifier", notifier.Name, "event", event.Event)
+			metrics.NotificationsTotal.WithLabelValues(notifier.Name, event.Event, "error").Inc()
+			continue
+		}
+		select {
+		case n.queue <- notification{notifier: notifier, event: event.Event, body: body}:
+		default:
+			n.Log.Info("Too many notifications waiting, dropping notification", "notifier", notifier.Name, "event", event.Event)
+			metrics.NotificationsTotal.WithLabelValues(notifier.Name, event.Event, "dropped").Inc()
+		}
+	}
+}
+
+// Start delivers queued notifications until ctx is cancelled.
+func (n *Notifications) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-n.queue:
+			if err := n.post(ctx, notification); err != nil {
+				n.Log.Error(err, "Failed to send notification", "notifier", notification.notifier.Name, "event", notification.event)
+				metrics.NotificationsTotal.WithLabelValues(notification.notifier.Name, notification.event, "error").Inc()
+				continue
+			}
+			metrics.NotificationsTotal.WithLabelValues(notification.notifier.Name, notification.event, "success").Inc()
+		}
+	}
+}
+
+// NeedLeaderElection reports that every replica sends the notifications of
+// the namespaces it reconciles.
+func (n *Notifications) NeedLeaderElection() bool {
+	return false
+}
+
+func (n *Notifications) post(ctx context.Context, notification notification) error {
+	ctx, cancel := context.WithTimeout(ctx, notification.notifier.Timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notification.notifier.URL, bytes.NewReader(notification.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// render returns the payload notifying event in the notifier's format.
+func (n *notifier) render(event NotificationEvent) ([]byte, error) {
+	message := n.message
+	if message == nil {
+		message = defaultNotificationMessages[event.Event]
+	}
+	var text strings.Builder
+	if err := message.Execute(&text, event); err != nil {
+		return nil, err
+	}
+	if n.Format == config.NotificationFormatSlack {
+		return json.Marshal(map[string]string{"text": text.String()})
+	}
+	return json.Marshal(NotificationPayload{NotificationEvent: event, Message: text.String()})
+}
+
+// notify sends event, adding the cluster and connection of ctx.
+func (r *NamespaceReconciler) notify(ctx context.Context, event NotificationEvent) {
+	if r.Notifications == nil {
+		return
+	}
+	event.Cluster = r.Config.ClusterName
+	event.Connection = connectionFrom(ctx)
+	r.Notifications.Notify(event)
+}
+
+// notifyFailing sends the failing event once the Kubernetes namespace name
+// has failed as many consecutive times as the failure threshold.
+func (r *NamespaceReconciler) notifyFailing(ctx context.Context, name string, err error) {
+	if r.Notifications == nil {
+		return
+	}
+	r.mu.Lock()
+	failures := r.failures[name]
+	vaultNamespace := r.paths[name]
+	r.mu.Unlock()
+
+	if failures != r.Notifications.FailureThreshold {
+		return
+	}
+	r.notify(ctx, NotificationEvent{
+		Event:          config.NotificationEventFailing,
+		Namespace:      name,
+		VaultNamespace: vaultNamespace,
+		Error:          err.Error(),
+		Failures:       failures,
+	})
+}
+
+// notifyDeletionBlocked sends the deletion-blocked event for the reason the
+// Vault namespace was not deleted.
+func (r *NamespaceReconciler) notifyDeletionBlocked(ctx context.Context, namespaceName, vaultNamespace, reason string) {
+	r.notify(ctx, NotificationEvent{
+		Event:          config.NotificationEventDeletionBlocked,
+		Namespace:      namespaceName,
+		VaultNamespace: vaultNamespace,
+		Reason:         reason,
+	})
+}
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// queuedEvents drains the notifications waiting for delivery and returns
+// their decoded webhook payloads.
+func queuedEvents(t *testing.T, n *Notifications) []NotificationPayload {
+	var payloads []NotificationPayload
+	for len(n.queue) > 0 {
+		var payload NotificationPayload
+		assert.NoError(t, json.Unmarshal((<-n.queue).body, &payload))
+		payloads = append(payloads, payload)
+	}
+	return payloads
+}
+
+// TestNotifications_Send tests the payload formats, message templates and
+// event subscriptions of notifiers.
+func TestNotifications_Send(t *testing.T) {
+	var mu sync.Mutex
+	bodies := make(map[string][]byte)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		mu.Lock()
+		defer mu.Unlock()
+		bodies[r.URL.Path] = body
+	}))
+	defer server.Close()
+
+	notifications, err := NewNotifications(config.NotificationsConfig{Notifiers: []config.Notifier{
+		{
+			Name:    "onboarding",
+			URL:     server.URL + "/slack",
+			Format:  config.NotificationFormatSlack,
+			Events:  []string{config.NotificationEventCreated},
+			Message: "{{ .Namespace | replace \"-\" \" \" }} is ready in {{ .VaultNamespace }}",
+		},
+		{Name: "platform", URL: server.URL + "/webhook"},
+		{Name: "deletions", URL: server.URL + "/deletions", Events: []string{config.NotificationEventDeleted}},
+	}}, testr.New(t))
+	assert.NoError(t, err)
+	notifications.HTTPClient = server.Client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = notifications.Start(ctx) }()
+
+	notifications.Notify(NotificationEvent{
+		Event:          config.NotificationEventCreated,
+		Namespace:      "team-a",
+		VaultNamespace: "tenants/team-a",
+		Cluster:        "east",
+	})
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(bodies) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.JSONEq(t, `{"text": "team a is ready in tenants/team-a"}`, string(bodies["/slack"]))
+	var payload NotificationPayload
+	assert.NoError(t, json.Unmarshal(bodies["/webhook"], &payload))
+	assert.Equal(t, config.NotificationEventCreated, payload.Event)
+	assert.Equal(t, "east", payload.Cluster)
+	assert.False(t, payload.Timestamp.IsZero())
+	assert.Equal(t, "[east] Vault namespace tenants/team-a was created for Kubernetes namespace team-a", payload.Message)
+	assert.NotContains(t, bodies, "/deletions")
+}
+
+// TestNotifications_NilSafe tests a reconciler without notifications ignores events.
+func TestNotifications_NilSafe(t *testing.T) {
+	var notifications *Notifications
+	notifications.Notify(NotificationEvent{Event: config.NotificationEventCreated})
+
+	reconciler := &NamespaceReconciler{Config: &config.ControllerConfig{}}
+	reconciler.notify(context.Background(), NotificationEvent{Event: config.NotificationEventCreated})
+	reconciler.notifyFailing(context.Background(), "app", errors.New("boom"))
+}
+
+// TestNotifications_Failing tests the failing event is sent once a namespace
+// reaches the failure threshold, and again only after it recovered.
+func TestNotifications_Failing(t *testing.T) {
+	notifications, err := NewNotifications(config.NotificationsConfig{
+		FailureThreshold: 3,
+		Notifiers:        []config.Notifier{{Name: "platform", URL: "https://alerts.example.com"}},
+	}, testr.New(t))
+	assert.NoError(t, err)
+	reconciler := &NamespaceReconciler{
+		Config:        &config.ControllerConfig{ClusterName: "east"},
+		Recorder:      record.NewFakeRecorder(10),
+		Notifications: notifications,
+	}
+	reconciler.rememberPath("app", "tenants/app")
+
+	ctx := context.Background()
+	failure := errors.New("connection refused")
+	for range 5 {
+		reconciler.retryResult(ctx, "app", failure, testr.New(t))
+	}
+	payloads := queuedEvents(t, notifications)
+	if assert.Len(t, payloads, 1) {
+		assert.Equal(t, config.NotificationEventFailing, payloads[0].Event)
+		assert.Equal(t, "tenants/app", payloads[0].VaultNamespace)
+		assert.Equal(t, 3, payloads[0].Failures)
+		assert.Equal(t, "connection refused", payloads[0].Error)
+		assert.Equal(t, "[east] Kubernetes namespace app failed to sync 3 times in a row: connection refused", payloads[0].Message)
+	}
+
+	reconciler.resetBackoff("app")
+	for range 3 {
+		reconciler.retryResult(ctx, "app", failure, testr.New(t))
+	}
+	assert.Len(t, queuedEvents(t, notifications), 1)
+}
+
+// TestNotifications_NamespaceDeletion tests deletions and blocked deletions are notified.
+func TestNotifications_NamespaceDeletion(t *testing.T) {
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, mock.Anything).Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-team-a").Return(ownedMetadata("team-a"), nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-team-b").Return(ownedMetadata("team-b"), nil)
+	mockClient.On("NamespaceEmpty", mock.Anything, "k8s-team-a").Return(true, nil)
+	mockClient.On("NamespaceEmpty", mock.Anything, "k8s-team-b").Return(false, nil)
+	mockClient.On("DeleteNamespace", mock.Anything, "k8s-team-a").Return(nil)
+
+	notifications, err := NewNotifications(config.NotificationsConfig{
+		Notifiers: []config.Notifier{{Name: "platform", URL: "https://alerts.example.com"}},
+	}, testr.New(t))
+	assert.NoError(t, err)
+	reconciler := &NamespaceReconciler{
+		Log:           testr.New(t),
+		VaultClient:   mockClient,
+		Recorder:      record.NewFakeRecorder(10),
+		Config:        &config.ControllerConfig{NamespaceFormat: "k8s-%s", DeleteVaultNamespaces: true},
+		Notifications: notifications,
+	}
+
+	ctx := context.Background()
+	_, err = reconciler.handleNamespaceDeletion(ctx, "team-a", "k8s-team-a", reconciler.Log)
+	assert.NoError(t, err)
+	_, err = reconciler.handleNamespaceDeletion(ctx, "team-b", "k8s-team-b", reconciler.Log)
+	assert.NoError(t, err)
+
+	payloads := queuedEvents(t, notifications)
+	if assert.Len(t, payloads, 2) {
+		assert.Equal(t, config.NotificationEventDeleted, payloads[0].Event)
+		assert.Equal(t, "k8s-team-a", payloads[0].VaultNamespace)
+		assert.Equal(t, config.NotificationEventDeletionBlocked, payloads[1].Event)
+		assert.Equal(t, DeletionBlockedNonEmpty, payloads[1].Reason)
+		assert.Equal(t, "Vault namespace k8s-team-b of Kubernetes namespace team-b was not deleted: non_empty", payloads[1].Message)
+	}
+	mockClient.AssertExpectations(t)
+}
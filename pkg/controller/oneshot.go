@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/go-logr/logr"
+)
+
+// SyncOnce reconciles every namespace of this shard once, SyncWorkers at a
+// time, and returns the names of those that failed with their errors.
+// Requeues are not followed, so a failed namespace is not retried.
+func (r *NamespaceReconciler) SyncOnce(ctx context.Context) (map[string]string, error) {
+	if r.Pause.Paused() {
+		return nil, errors.New("reconciliation is paused")
+	}
+
+	nsList := newNamespaceMetadataList()
+	if err := r.List(ctx, nsList); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.onceFailures = make(map[string]string)
+	r.mu.Unlock()
+
+	names := make(chan string)
+	var wg sync.WaitGroup
+	for range max(r.Config.SyncWorkers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range names {
+				if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: name}}); err != nil {
+					r.mu.Lock()
+					r.onceFailures[name] = err.Error()
+					r.mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, ns := range nsList.Items {
+		if r.Shard.Owns(ns.Name) {
+			names <- ns.Name
+		}
+	}
+	close(names)
+	wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	failures := r.onceFailures
+	r.onceFailures = nil
+	return failures, ctx.Err()
+}
+
+// OneShotSync reconciles the namespaces of every cluster once and then stops
+// the manager, for running the controller as a Kubernetes Job.
+type OneShotSync struct {
+	Reconcilers []*NamespaceReconciler
+	// Stop stops the manager once the sync is done.
+	Stop context.CancelFunc
+	Log  logr.Logger
+
+	mu        sync.Mutex
+	completed bool
+	failed    int
+}
+
+// Start runs the sync and stops the manager.
+func (s *OneShotSync) Start(ctx context.Context) error {
+	defer s.Stop()
+
+	failed := 0
+	for _, r := range s.Reconcilers {
+		log := s.Log.WithValues("cluster", r.Config.ClusterName)
+		log.Info("Starting one-shot sync")
+		failures, err := r.SyncOnce(ctx)
+		if err != nil {
+			log.Error(err, "One-shot sync did not complete")
+			return nil
+		}
+
+		names := make([]string, 0, len(failures))
+		for name := range failures {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			log.Info("Namespace failed to sync", "kubernetesNamespace", name, "error", failures[name])
+		}
+		log.Info("One-shot sync finished", "failed", len(failures))
+		failed += len(failures)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.completed = true
+	s.failed = failed
+	return nil
+}
+
+// NeedLeaderElection reports that the sync runs without leader election,
+// which one-shot mode disables.
+func (s *OneShotSync) NeedLeaderElection() bool {
+	return false
+}
+
+// Result returns how many namespaces failed to sync, and whether every
+// cluster was synced.
+func (s *OneShotSync) Result() (failed int, completed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.failed, s.completed
+}
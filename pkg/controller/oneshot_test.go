@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestOneShotSync tests every namespace is reconciled once, the failures are
+// counted and the manager is stopped.
+func TestOneShotSync(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-b"}},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "k8s-app-a").Return(false, nil)
+	mockClient.On("CreateNamespace", mock.Anything, "k8s-app-a", mock.Anything).Return(nil)
+	mockClient.On("NamespaceExists", mock.Anything, "k8s-app-b").Return(false, nil)
+	mockClient.On("CreateNamespace", mock.Anything, "k8s-app-b", mock.Anything).Return(errors.New("connection refused"))
+
+	stopped := false
+	oneShot := &OneShotSync{
+		Reconcilers: []*NamespaceReconciler{{
+			Client:      fakeClient,
+			Log:         testr.New(t),
+			VaultClient: mockClient,
+			Recorder:    record.NewFakeRecorder(10),
+			Config: &config.ControllerConfig{
+				NamespaceFormat: "k8s-%s",
+				SyncWorkers:     2,
+			},
+			syncChecker: func(string) bool { return true },
+		}},
+		Stop: func() { stopped = true },
+		Log:  testr.New(t),
+	}
+
+	assert.NoError(t, oneShot.Start(context.Background()))
+	failed, completed := oneShot.Result()
+	assert.Equal(t, 1, failed)
+	assert.True(t, completed)
+	assert.True(t, stopped)
+	mockClient.AssertNumberOfCalls(t, "CreateNamespace", 2)
+}
+
+// TestOneShotSync_InvalidPath tests a namespace without a valid Vault namespace
+// path is counted as a failure, although it is not retried.
+func TestOneShotSync_InvalidPath(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "audit"}},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "app").Return(false, nil)
+	mockClient.On("CreateNamespace", mock.Anything, "app", mock.Anything).Return(nil)
+
+	oneShot := &OneShotSync{
+		Reconcilers: []*NamespaceReconciler{{
+			Client:      fakeClient,
+			Log:         testr.New(t),
+			VaultClient: mockClient,
+			Recorder:    record.NewFakeRecorder(10),
+			Config: &config.ControllerConfig{
+				NamespaceFormat: "%s",
+			},
+			syncChecker: func(string) bool { return true },
+		}},
+		Stop: func() {},
+		Log:  testr.New(t),
+	}
+
+	assert.NoError(t, oneShot.Start(context.Background()))
+	failed, completed := oneShot.Result()
+	assert.Equal(t, 1, failed)
+	assert.True(t, completed)
+	mockClient.AssertNotCalled(t, "NamespaceExists", mock.Anything, "audit")
+}
+
+// TestOneShotSync_Paused tests a paused controller does not complete the sync.
+func TestOneShotSync_Paused(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	pause := &PauseState{}
+	pause.Set(PauseSourceConfig, true)
+	mockClient := new(mockVaultClient)
+	oneShot := &OneShotSync{
+		Reconcilers: []*NamespaceReconciler{{
+			Client:      fake.NewClientBuilder().WithScheme(scheme).Build(),
+			Log:         testr.New(t),
+			VaultClient: mockClient,
+			Config:      &config.ControllerConfig{},
+			Pause:       pause,
+		}},
+		Stop: func() {},
+		Log:  testr.New(t),
+	}
+
+	assert.NoError(t, oneShot.Start(context.Background()))
+	_, completed := oneShot.Result()
+	assert.False(t, completed)
+	mockClient.AssertNotCalled(t, "NamespaceExists", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+	"github.com/go-logr/logr"
+)
+
+// MetadataOrphanedAt records, on a Vault namespace owned by this controller,
+// when the orphan scanner first found its Kubernetes namespace gone, so that
+// OrphanMinAge is measured across restarts and leader changes.
+const MetadataOrphanedAt = "orphaned-at"
+
+// OrphanScanner periodically looks for Vault namespaces owned by this controller
+// whose Kubernetes namespace no longer exists, for example because it was deleted
+// while the controller was down, and applies the configured orphan policy.
+type OrphanScanner struct {
+	Reconciler *NamespaceReconciler
+	Log        logr.Logger
+
+	// firstSeen records when each orphaned Vault namespace was first observed,
+	// for those whose custom metadata could not record it.
+	firstSeen map[string]time.Time
+}
+
+// Start runs a scan every OrphanScanInterval until ctx is cancelled.
+func (s *OrphanScanner) Start(ctx context.Context) error {
+	interval := time.Duration(s.Reconciler.Config.OrphanScanInterval) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.Scan(ctx); err != nil {
+				s.Log.Error(err, "Orphan scan failed")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection reports that only the leader scans for orphans.
+func (s *OrphanScanner) NeedLeaderElection() bool {
+	return true
+}
+
+// Scan finds orphaned Vault namespaces, reports them, and deletes those older
+// than OrphanMinAge when the orphan policy is delete.
+func (s *OrphanScanner) Scan(ctx context.Context) error {
+	r := s.Reconciler
+	now := time.Now()
+	orphans, err := r.findOrphans(ctx)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]time.Time)
+	for _, entry := range orphans {
+		log := s.Log.WithValues(
+			"kubernetesNamespace", entry.KubernetesNamespace,
+			"vaultNamespace", entry.VaultNamespace,
+		)
+
+		firstSeen := s.orphanedSince(ctx, entry.VaultNamespace, now, log)
+		seen[entry.VaultNamespace] = firstSeen
+		age := now.Sub(firstSeen)
+
+		minAge := time.Duration(r.Config.OrphanMinAge) * time.Second
+		if r.Config.OrphanPolicy != config.OrphanPolicyDelete || age < minAge || r.Pause.Paused() {
+			log.Info("Found orphaned Vault namespace", "age", age.Round(time.Second).String())
+			continue
+		}
+
+		log.Info("Deleting orphaned Vault namespace", "age", age.Round(time.Second).String())
+		deleted, err := r.handleNamespaceDeletion(ctx, entry.KubernetesNamespace, entry.VaultNamespace, log)
+		if err != nil {
+			log.Error(err, "Failed to delete orphaned Vault namespace")
+		}
+		// An orphan kept by a guardrail or dry run stays reported with its age
+		if deleted {
+			delete(seen, entry.VaultNamespace)
+		}
+	}
+
+	s.firstSeen = seen
+	metrics.OrphanedVaultNamespaces.Set(float64(len(seen)))
+	metrics.OrphanScanDuration.Observe(time.Since(now).Seconds())
+	metrics.LastOrphanScan.SetToCurrentTime()
+	return nil
+}
+
+// orphanedSince returns when vaultNamespace was first found orphaned, as
+// recorded in its custom metadata, and records now when it was not. The time
+// is kept in memory only when it cannot be recorded, such as in dry-run mode.
+func (s *OrphanScanner) orphanedSince(ctx context.Context, vaultNamespace string, now time.Time, log logr.Logger) time.Time {
+	r := s.Reconciler
+	customMetadata, err := r.VaultClient.GetNamespaceMetadata(ctx, vaultNamespace)
+	if err != nil {
+		log.Error(err, "Failed to read when the Vault namespace was orphaned")
+	} else if since, err := time.Parse(time.RFC3339, customMetadata[MetadataOrphanedAt]); err == nil {
+		return since
+	}
+
+	since, ok := s.firstSeen[vaultNamespace]
+	if !ok {
+		since = now
+	}
+	if err == nil && !r.Config.DryRun {
+		orphanedAt := map[string]string{MetadataOrphanedAt: since.UTC().Format(time.RFC3339)}
+		if err := r.VaultClient.PatchNamespaceMetadata(ctx, vaultNamespace, orphanedAt); err != nil {
+			log.Error(err, "Failed to record when the Vault namespace was orphaned")
+		}
+	}
+	return since
+}
+
+// findOrphans returns the Vault namespaces owned by this controller whose
+// Kubernetes namespace no longer exists and is not waiting out its deletion
+// grace period.
+func (r *NamespaceReconciler) findOrphans(ctx context.Context) ([]PlanEntry, error) {
+	plan, err := r.BuildPlan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []PlanEntry
+	for _, entry := range plan.ToDelete {
+		// Only namespaces that are gone are orphans, not ones that are merely excluded
+		if exists, err := r.namespaceExists(ctx, entry.KubernetesNamespace); err != nil {
+			return nil, err
+		} else if exists {
+			continue
+		}
+
+		// Deletions waiting out their grace period are handled by the reconciler
+		if r.hasPendingDeletion(entry.KubernetesNamespace) {
+			continue
+		}
+		orphans = append(orphans, entry)
+	}
+	return orphans, nil
+}
+
+// OrphanedNamespace is a Vault namespace that no Kubernetes namespace maps to.
+type OrphanedNamespace struct {
+	VaultNamespace string `json:"vaultNamespace"`
+	// KubernetesNamespace is the namespace an owned Vault namespace was created for.
+	KubernetesNamespace string `json:"kubernetesNamespace,omitempty"`
+	// Owned reports whether this controller created the Vault namespace.
+	Owned bool `json:"owned"`
+	// CreatedAt is when the Vault namespace was created, known from the
+	// persisted mappings only.
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	// Age is the time since CreatedAt.
+	Age            string            `json:"age,omitempty"`
+	CustomMetadata map[string]string `json:"customMetadata"`
+}
+
+// ListOrphanedNamespaces returns, sorted by path, the Vault namespaces next to
+// the synchronized ones whose Kubernetes namespace is gone or that no
+// Kubernetes namespace maps to, with their custom metadata, for reviewing them
+// before cleaning up.
+func (r *NamespaceReconciler) ListOrphanedNamespaces(ctx context.Context) ([]OrphanedNamespace, error) {
+	diff, err := r.BuildDiff(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := r.Mappings.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	createdAt := make(map[string]time.Time)
+	for _, record := range records {
+		if record.Cluster == r.Config.ClusterName && !record.CreatedAt.IsZero() {
+			createdAt[record.VaultNamespace] = record.CreatedAt
+		}
+	}
+
+	now := time.Now()
+	orphans := make([]OrphanedNamespace, 0, len(diff.Orphans)+len(diff.Unmanaged))
+	for _, entries := range [][]PlanEntry{diff.Orphans, diff.Unmanaged} {
+		for _, entry := range entries {
+			customMetadata, err := r.VaultClient.GetNamespaceMetadata(ctx, entry.VaultNamespace)
+			if err != nil {
+				return nil, err
+			}
+			orphan := OrphanedNamespace{
+				VaultNamespace:      entry.VaultNamespace,
+				KubernetesNamespace: entry.KubernetesNamespace,
+				Owned:               entry.KubernetesNamespace != "",
+				CustomMetadata:      customMetadata,
+			}
+			if created, ok := createdAt[entry.VaultNamespace]; ok {
+				orphan.CreatedAt = &created
+				orphan.Age = now.Sub(created).Round(time.Second).String()
+			}
+			orphans = append(orphans, orphan)
+		}
+	}
+
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].VaultNamespace < orphans[j].VaultNamespace })
+	return orphans, nil
+}
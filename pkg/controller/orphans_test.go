@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+)
+
+// TestOrphanScanner_Scan tests the orphan policies, with the age of orphans
+// recorded in their custom metadata, and that orphans a guardrail or dry run
+// keeps stay reported.
+func TestOrphanScanner_Scan(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	tests := []struct {
+		name         string
+		policy       string
+		orphanedAt   time.Duration
+		firstSeen    time.Duration
+		nonEmpty     bool
+		dryRun       bool
+		expectDelete bool
+	}{
+		{
+			name:         "report policy never deletes",
+			policy:       config.OrphanPolicyReport,
+			orphanedAt:   48 * time.Hour,
+			expectDelete: false,
+		},
+		{
+			name:         "delete policy records new orphans",
+			policy:       config.OrphanPolicyDelete,
+			expectDelete: false,
+		},
+		{
+			name:         "delete policy waits for the minimum age",
+			policy:       config.OrphanPolicyDelete,
+			orphanedAt:   time.Hour,
+			expectDelete: false,
+		},
+		{
+			name:         "delete policy removes old orphans",
+			policy:       config.OrphanPolicyDelete,
+			orphanedAt:   48 * time.Hour,
+			expectDelete: true,
+		},
+		{
+			name:         "age seen before the mark is recorded",
+			policy:       config.OrphanPolicyDelete,
+			firstSeen:    48 * time.Hour,
+			expectDelete: true,
+		},
+		{
+			name:         "non-empty orphans are kept",
+			policy:       config.OrphanPolicyDelete,
+			orphanedAt:   48 * time.Hour,
+			nonEmpty:     true,
+			expectDelete: false,
+		},
+		{
+			name:         "dry run keeps orphans",
+			policy:       config.OrphanPolicyDelete,
+			orphanedAt:   48 * time.Hour,
+			dryRun:       true,
+			expectDelete: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// "excluded" still exists in Kubernetes, so it is not an orphan
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "excluded"}},
+			).Build()
+
+			mockClient := new(mockVaultClient)
+			mockClient.On("ListNamespaces", mock.Anything, "").
+				Return([]string{"k8s-gone", "k8s-excluded"}, nil)
+			gone := ownedMetadata("gone")
+			if tt.orphanedAt > 0 {
+				gone[MetadataOrphanedAt] = time.Now().Add(-tt.orphanedAt).UTC().Format(time.RFC3339)
+			} else {
+				mockClient.On("PatchNamespaceMetadata", mock.Anything, "k8s-gone", mock.MatchedBy(func(m map[string]string) bool {
+					_, err := time.Parse(time.RFC3339, m[MetadataOrphanedAt])
+					return len(m) == 1 && err == nil
+				})).Return(nil).Once()
+			}
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-gone").Return(gone, nil)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-excluded").Return(ownedMetadata("excluded"), nil)
+			if tt.expectDelete || tt.nonEmpty || tt.dryRun {
+				mockClient.On("NamespaceExists", mock.Anything, "k8s-gone").Return(true, nil)
+				mockClient.On("NamespaceEmpty", mock.Anything, "k8s-gone").Return(!tt.nonEmpty, nil)
+			}
+			if tt.expectDelete {
+				mockClient.On("DeleteNamespace", mock.Anything, "k8s-gone").Return(nil)
+			}
+
+			firstSeen := map[string]time.Time{}
+			if tt.firstSeen > 0 {
+				firstSeen["k8s-gone"] = time.Now().Add(-tt.firstSeen)
+			}
+			scanner := &OrphanScanner{
+				Reconciler: &NamespaceReconciler{
+					Client:      fakeClient,
+					Log:         testr.New(t),
+					VaultClient: mockClient,
+					Config: &config.ControllerConfig{
+						NamespaceFormat:       "k8s-%s",
+						DeleteVaultNamespaces: true,
+						OrphanPolicy:          tt.policy,
+						OrphanMinAge:          86400,
+						DryRun:                tt.dryRun,
+					},
+					syncChecker: func(string) bool { return false },
+				},
+				Log:       testr.New(t),
+				firstSeen: firstSeen,
+			}
+
+			metrics.LastOrphanScan.Set(0)
+			assert.NoError(t, scanner.Scan(context.Background()))
+			assert.Greater(t, testutil.ToFloat64(metrics.LastOrphanScan), 0.0)
+			if tt.expectDelete {
+				assert.NotContains(t, scanner.firstSeen, "k8s-gone")
+				assert.Equal(t, 0.0, testutil.ToFloat64(metrics.OrphanedVaultNamespaces))
+			} else {
+				assert.Contains(t, scanner.firstSeen, "k8s-gone")
+				assert.Equal(t, 1.0, testutil.ToFloat64(metrics.OrphanedVaultNamespaces))
+				mockClient.AssertNotCalled(t, "DeleteNamespace", mock.Anything, mock.Anything)
+			}
+			assert.NotContains(t, scanner.firstSeen, "k8s-excluded")
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+// TestListOrphanedNamespaces tests owned and unmanaged Vault namespaces without
+// a Kubernetes namespace are listed, with the age of those with a mapping.
+func TestListOrphanedNamespaces(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+	).Build()
+	mappings := &MappingStore{Client: fakeClient, Reader: fakeClient,
+		ConfigMap: types.NamespacedName{Namespace: "vault-system", Name: "mappings"}}
+	assert.NoError(t, mappings.Record(context.Background(), "", "gone", "k8s-gone"))
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("ListNamespaces", mock.Anything, "").Return([]string{"k8s-app", "k8s-gone", "team-x"}, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-gone").Return(ownedMetadata("gone"), nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "team-x").Return(map[string]string{"owner": "platform"}, nil)
+
+	reconciler := &NamespaceReconciler{
+		Client:      fakeClient,
+		Log:         testr.New(t),
+		VaultClient: mockClient,
+		Mappings:    mappings,
+		Config:      &config.ControllerConfig{NamespaceFormat: "k8s-%s"},
+	}
+
+	orphans, err := reconciler.ListOrphanedNamespaces(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, orphans, 2) {
+		assert.Equal(t, "k8s-gone", orphans[0].VaultNamespace)
+		assert.Equal(t, "gone", orphans[0].KubernetesNamespace)
+		assert.True(t, orphans[0].Owned)
+		assert.NotNil(t, orphans[0].CreatedAt)
+		assert.NotEmpty(t, orphans[0].Age)
+		assert.Equal(t, ownedMetadata("gone"), orphans[0].CustomMetadata)
+
+		assert.Equal(t, OrphanedNamespace{VaultNamespace: "team-x", CustomMetadata: map[string]string{"owner": "platform"}}, orphans[1])
+	}
+}
@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/benemon/vault-namespace-controller/pkg/audit"
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+	"github.com/go-logr/logr"
+)
+
+// Custom metadata keys recording which controller instance owns a Vault namespace.
+const (
+	MetadataManagedBy           = "managed-by"
+	MetadataKubernetesCluster   = "kubernetes-cluster"
+	MetadataKubernetesNamespace = "kubernetes-namespace"
+
+	managedByValue = "vault-namespace-controller"
+)
+
+var (
+	ErrNamespaceConflict = errors.New("vault namespace is not owned by this controller")
+)
+
+// ownershipMetadata returns the custom metadata stamped on Vault namespaces
+// managed for namespaceName.
+func (r *NamespaceReconciler) ownershipMetadata(namespaceName string) map[string]string {
+	return map[string]string{
+		MetadataManagedBy:           managedByValue,
+		MetadataKubernetesCluster:   r.Config.ClusterName,
+		MetadataKubernetesNamespace: namespaceName,
+	}
+}
+
+// ownedBy reports whether customMetadata marks a namespace as managed by any
+// controller, and if so whether it is this one.
+func (r *NamespaceReconciler) ownedBy(customMetadata map[string]string) (managed, ours bool) {
+	if customMetadata[MetadataManagedBy] != managedByValue {
+		return false, false
+	}
+	return true, customMetadata[MetadataKubernetesCluster] == r.Config.ClusterName
+}
+
+// isOwned reports whether the Vault namespace carries this controller's ownership metadata.
+func (r *NamespaceReconciler) isOwned(ctx context.Context, vaultNamespace string) (bool, error) {
+	customMetadata, err := r.VaultClient.GetNamespaceMetadata(ctx, vaultNamespace)
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrNamespaceCheck, err)
+	}
+	_, ours := r.ownedBy(customMetadata)
+	return ours, nil
+}
+
+// handleExistingNamespace applies the configured policy to a Vault namespace that
+// already exists. It reports whether the controller should go on managing it.
+func (r *NamespaceReconciler) handleExistingNamespace(ctx context.Context, namespaceName, vaultNamespace string, log logr.Logger) (bool, error) {
+	customMetadata, err := r.VaultClient.GetNamespaceMetadata(ctx, vaultNamespace)
+	if err != nil {
+		log.Error(err, "Failed to read Vault namespace metadata")
+		return false, fmt.Errorf("%w: %w", ErrNamespaceCheck, err)
+	}
+
+	managed, ours := r.ownedBy(customMetadata)
+	if ours {
+		// The Kubernetes namespace is back, so the Vault namespace is no longer an orphan
+		if _, orphaned := customMetadata[MetadataOrphanedAt]; orphaned && !r.Config.DryRun {
+			if err := r.VaultClient.RemoveNamespaceMetadata(ctx, vaultNamespace, []string{MetadataOrphanedAt}); err != nil {
+				log.Error(err, "Failed to clear orphaned mark")
+				return false, fmt.Errorf("%w: %w", ErrNamespaceCreation, err)
+			}
+		}
+		return true, nil
+	}
+
+	policy := r.Config.ExistingNamespacePolicy
+	if managed {
+		// Never take over a namespace claimed by another cluster, whatever the policy
+		policy = config.ExistingNamespaceError
+	}
+
+	switch policy {
+	case config.ExistingNamespaceSkip:
+		log.V(1).Info("Vault namespace is not owned by this controller, skipping",
+			"owner", customMetadata[MetadataKubernetesCluster])
+		metrics.ReconcilesSkippedTotal.WithLabelValues(SkipExistingNamespace).Inc()
+		return false, nil
+	case config.ExistingNamespaceError:
+		r.recordEvent(namespaceName, corev1.EventTypeWarning, "VaultNamespaceConflict",
+			"Vault namespace %s already exists and is not owned by this controller", vaultNamespace)
+		return false, fmt.Errorf("%w: %s", ErrNamespaceConflict, vaultNamespace)
+	default:
+		if r.skipForDryRun(namespaceName, "adopt", vaultNamespace, log) {
+			return true, nil
+		}
+		err := r.VaultClient.PatchNamespaceMetadata(ctx, vaultNamespace, r.ownershipMetadata(namespaceName))
+		r.audit(ctx, audit.Record{Action: audit.ActionAdopt, Namespace: namespaceName, VaultNamespace: vaultNamespace}, err)
+		if err != nil {
+			log.Error(err, "Failed to adopt Vault namespace")
+			return false, fmt.Errorf("%w: %w", ErrNamespaceCreation, err)
+		}
+		r.Limit.add(ctx, 1)
+		log.Info("Adopted existing Vault namespace")
+		r.recordEvent(namespaceName, corev1.EventTypeNormal, "VaultNamespaceAdopted",
+			"Adopted existing Vault namespace %s", vaultNamespace)
+		return true, nil
+	}
+}
+
+// syncCustomMetadata mirrors the configured labels and annotations of
+// namespace into the custom metadata of its Vault namespace, removing keys
+// whose label or annotation was removed.
+func (r *NamespaceReconciler) syncCustomMetadata(ctx context.Context, namespace metav1.Object, vaultNamespace string, log logr.Logger) error {
+	mirrored := r.Config.CustomMetadata
+	if !mirrored.Enabled() || r.Config.DryRun {
+		return nil
+	}
+	customMetadata, err := r.VaultClient.GetNamespaceMetadata(ctx, vaultNamespace)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNamespaceCheck, err)
+	}
+
+	changed := make(map[string]string)
+	var removed []string
+	mirror := func(keys []string, values map[string]string) {
+		for _, key := range keys {
+			current, exists := customMetadata[key]
+			value, ok := values[key]
+			switch {
+			case !ok && exists:
+				removed = append(removed, key)
+			case !ok:
+			case len(value) > config.MaxMetadataValueLength:
+				log.Info("Value is too long for Vault custom metadata, not mirrored", "key", key)
+			case !exists || current != value:
+				changed[key] = value
+			}
+		}
+	}
+	mirror(mirrored.Labels, namespace.GetLabels())
+	mirror(mirrored.Annotations, namespace.GetAnnotations())
+
+	if len(changed) > 0 {
+		log.Info("Updating Vault namespace custom metadata", "keys", slices.Sorted(maps.Keys(changed)))
+		if err := r.VaultClient.PatchNamespaceMetadata(ctx, vaultNamespace, changed); err != nil {
+			return err
+		}
+	}
+	if len(removed) > 0 {
+		log.Info("Removing Vault namespace custom metadata", "keys", removed)
+		if err := r.VaultClient.RemoveNamespaceMetadata(ctx, vaultNamespace, removed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// containerMetadata returns the ownership metadata of a Vault namespace the
+// controller creates to hold other Vault namespaces, rather than for a single
+// Kubernetes namespace.
+func (r *NamespaceReconciler) containerMetadata() map[string]string {
+	return map[string]string{
+		MetadataManagedBy:         managedByValue,
+		MetadataKubernetesCluster: r.Config.ClusterName,
+	}
+}
+
+// ensureContainerNamespace creates a Vault namespace holding other Vault
+// namespaces if it does not exist yet. Events are recorded against namespaceName.
+func (r *NamespaceReconciler) ensureContainerNamespace(ctx context.Context, namespaceName, vaultNamespace string, customMetadata map[string]string, log logr.Logger) error {
+	exists, err := r.VaultClient.NamespaceExists(ctx, vaultNamespace)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNamespaceCheck, err)
+	}
+	if exists {
+		return nil
+	}
+	if r.skipForDryRun(namespaceName, "create", vaultNamespace, log) {
+		return nil
+	}
+
+	log.Info("Creating parent Vault namespace", "parentNamespace", vaultNamespace)
+	err = r.VaultClient.CreateNamespace(ctx, vaultNamespace, customMetadata)
+	r.audit(ctx, audit.Record{Action: audit.ActionCreate, Namespace: namespaceName, VaultNamespace: vaultNamespace}, err)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNamespaceCreation, err)
+	}
+	return nil
+}
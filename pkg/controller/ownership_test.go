@@ -0,0 +1,264 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestHandleExistingNamespace tests the existing namespace policies.
+func TestHandleExistingNamespace(t *testing.T) {
+	otherCluster := map[string]string{
+		MetadataManagedBy:         "vault-namespace-controller",
+		MetadataKubernetesCluster: "other",
+	}
+
+	tests := []struct {
+		name           string
+		policy         string
+		customMetadata map[string]string
+		expectAdopt    bool
+		expectUnorphan bool
+		expectedManage bool
+		expectedError  error
+		expectedEvent  string
+	}{
+		{
+			name:           "namespace owned by this controller",
+			policy:         config.ExistingNamespaceError,
+			customMetadata: map[string]string{MetadataManagedBy: "vault-namespace-controller", MetadataKubernetesCluster: "east"},
+			expectedManage: true,
+		},
+		{
+			name:   "namespace owned by this controller and found orphaned before",
+			policy: config.ExistingNamespaceError,
+			customMetadata: map[string]string{MetadataManagedBy: "vault-namespace-controller", MetadataKubernetesCluster: "east",
+				MetadataOrphanedAt: "2026-01-02T15:04:05Z"},
+			expectUnorphan: true,
+			expectedManage: true,
+		},
+		{
+			name:           "adopt unowned namespace",
+			policy:         config.ExistingNamespaceAdopt,
+			customMetadata: map[string]string{},
+			expectAdopt:    true,
+			expectedManage: true,
+			expectedEvent:  "VaultNamespaceAdopted",
+		},
+		{
+			name:           "skip unowned namespace",
+			policy:         config.ExistingNamespaceSkip,
+			customMetadata: map[string]string{},
+			expectedManage: false,
+		},
+		{
+			name:           "error on unowned namespace",
+			policy:         config.ExistingNamespaceError,
+			customMetadata: map[string]string{},
+			expectedError:  ErrNamespaceConflict,
+			expectedEvent:  "VaultNamespaceConflict",
+		},
+		{
+			name:           "never adopt a namespace owned by another cluster",
+			policy:         config.ExistingNamespaceAdopt,
+			customMetadata: otherCluster,
+			expectedError:  ErrNamespaceConflict,
+			expectedEvent:  "VaultNamespaceConflict",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(mockVaultClient)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-app").Return(tt.customMetadata, nil)
+
+			recorder := record.NewFakeRecorder(10)
+			reconciler := &NamespaceReconciler{
+				Log:         testr.New(t),
+				VaultClient: mockClient,
+				Recorder:    recorder,
+				Config: &config.ControllerConfig{
+					ClusterName:             "east",
+					ExistingNamespacePolicy: tt.policy,
+				},
+			}
+
+			if tt.expectAdopt {
+				mockClient.On("PatchNamespaceMetadata", mock.Anything, "k8s-app", reconciler.ownershipMetadata("app")).Return(nil)
+			}
+			if tt.expectUnorphan {
+				mockClient.On("RemoveNamespaceMetadata", mock.Anything, "k8s-app", []string{MetadataOrphanedAt}).Return(nil)
+			}
+
+			manage, err := reconciler.handleExistingNamespace(context.Background(), "app", "k8s-app", reconciler.Log)
+
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError),
+					"Expected error of type %v, got %v", tt.expectedError, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedManage, manage)
+
+			if tt.expectedEvent != "" {
+				assert.Contains(t, <-recorder.Events, tt.expectedEvent)
+			} else {
+				assert.Empty(t, recorder.Events)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+// TestReconcile_SkipExistingNamespace tests that a Vault namespace left alone
+// by the skip policy is not provisioned.
+func TestReconcile_SkipExistingNamespace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+	).Build()
+
+	server := newHookServer(t)
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "app").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "app").Return(map[string]string{"owner": "platform"}, nil)
+
+	reconciler := &NamespaceReconciler{
+		Client:      k8sClient,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{
+			ExistingNamespacePolicy: config.ExistingNamespaceSkip,
+			CustomMetadata:          config.CustomMetadataConfig{Labels: []string{"team"}},
+			PolicyTemplates:         []config.PolicyTemplate{{Name: "tenant-admin", Policy: `path "secret/*" { capabilities = ["sudo"] }`}},
+			SentinelPolicies:        []config.SentinelPolicy{{Name: "business-hours", Type: "egp", Policy: "main = rule { true }", Paths: []string{"*"}}},
+			IdentityGroups:          []config.IdentityGroup{{Name: "{{ .Name }}-admins", Policies: []string{"tenant-admin"}}},
+		},
+		Hooks: testHooks(server, config.LifecycleHook{
+			Name: "cmdb", URL: server.URL, Events: []string{config.HookEventPostCreate}, Attempts: 1,
+		}),
+		syncChecker: func(string) bool { return true },
+	}
+	ctx := context.Background()
+	// A post-create hook still pending from an earlier Vault namespace at the path
+	reconciler.markForPostCreate(ctx, "app")
+
+	result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}})
+	assert.NoError(t, err)
+	assert.Equal(t, reconciler.reconcileInterval(), result.RequeueAfter)
+	assert.Equal(t, 0, server.calls())
+	mockClient.AssertNotCalled(t, "PatchNamespaceMetadata", mock.Anything, mock.Anything, mock.Anything)
+	mockClient.AssertNotCalled(t, "PutPolicy", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockClient.AssertNotCalled(t, "PutSentinelPolicy", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything)
+	mockClient.AssertNotCalled(t, "EnsureGroup", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything)
+	mockClient.AssertExpectations(t)
+}
+
+// TestReconcile_ReprovisionAfterSkip tests that a Vault namespace recreated
+// outside the controller and skipped is provisioned in full once adopted,
+// rather than as if the earlier Vault namespace at its path were still there.
+func TestReconcile_ReprovisionAfterSkip(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+	).Build()
+
+	policy := `path "secret/*" { capabilities = ["sudo"] }`
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "app").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "app").Return(ownedMetadata("app"), nil).Once()
+	mockClient.On("PutPolicy", mock.Anything, "app", "tenant-admin", policy).Return(nil)
+
+	reconciler := &NamespaceReconciler{
+		Client:      k8sClient,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{
+			ExistingNamespacePolicy: config.ExistingNamespaceSkip,
+			PolicyTemplates:         []config.PolicyTemplate{{Name: "tenant-admin", Policy: policy}},
+		},
+		syncChecker: func(string) bool { return true },
+	}
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	mockClient.AssertNumberOfCalls(t, "PutPolicy", 1)
+
+	// Recreated by hand, so skipped
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "app").Return(map[string]string{}, nil).Once()
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	mockClient.AssertNumberOfCalls(t, "PutPolicy", 1)
+
+	// Adopted, and provisioned again
+	reconciler.Config.ExistingNamespacePolicy = config.ExistingNamespaceAdopt
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "app").Return(map[string]string{}, nil).Once()
+	mockClient.On("PatchNamespaceMetadata", mock.Anything, "app", ownedMetadata("app")).Return(nil).Once()
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	mockClient.AssertNumberOfCalls(t, "PutPolicy", 2)
+	mockClient.AssertExpectations(t)
+}
+
+// TestSyncCustomMetadata tests mirroring labels and annotations into the
+// custom metadata of managed Vault namespaces.
+func TestSyncCustomMetadata(t *testing.T) {
+	owned := map[string]string{
+		MetadataManagedBy:         "vault-namespace-controller",
+		MetadataKubernetesCluster: "east",
+		"team":                    "payments",
+		"tenant":                  "acme",
+		"cost-center":             "cc-1",
+	}
+	namespace := &metav1.ObjectMeta{
+		Name:        "app",
+		Labels:      map[string]string{"team": "checkout", "cost-center": "cc-1", "env": "prod"},
+		Annotations: map[string]string{"example.com/owner": "jane@example.com"},
+	}
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "app").Return(owned, nil)
+	mockClient.On("PatchNamespaceMetadata", mock.Anything, "app", map[string]string{
+		"team":              "checkout",
+		"example.com/owner": "jane@example.com",
+	}).Return(nil).Once()
+	mockClient.On("RemoveNamespaceMetadata", mock.Anything, "app", []string{"tenant"}).Return(nil).Once()
+
+	reconciler := &NamespaceReconciler{
+		Log:         testr.New(t),
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{
+			ClusterName: "east",
+			CustomMetadata: config.CustomMetadataConfig{
+				Labels:      []string{"team", "tenant", "cost-center"},
+				Annotations: []string{"example.com/owner"},
+			},
+		},
+	}
+
+	err := reconciler.syncCustomMetadata(context.Background(), namespace, "app", reconciler.Log)
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
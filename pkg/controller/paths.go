@@ -0,0 +1,340 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// ErrInvalidNamespacePath is returned when a namespace cannot be mapped to a
+// usable Vault namespace path.
+var ErrInvalidNamespacePath = errors.New("invalid vault namespace path")
+
+// reservedNamespaceNames are path segments Vault does not accept as namespace names.
+var reservedNamespaceNames = map[string]bool{
+	".": true, "..": true, "root": true, "sys": true, "audit": true,
+	"auth": true, "cubbyhole": true, "identity": true,
+}
+
+// strictNamespaceName matches the namespace names allowed with StrictNamespaceNames.
+var strictNamespaceName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// mappingRule is a compiled config.MappingRule.
+type mappingRule struct {
+	match      *regexp.Regexp
+	selector   labels.Selector
+	expression *config.NamespaceExpression
+	template   *template.Template
+	parent     string
+}
+
+// matches reports whether the rule applies to namespace.
+func (m *mappingRule) matches(namespace metav1.Object) bool {
+	if m.match != nil && !m.match.MatchString(namespace.GetName()) {
+		return false
+	}
+	if m.selector != nil && !m.selector.Matches(labels.Set(namespace.GetLabels())) {
+		return false
+	}
+	if m.expression != nil && !matchesExpression(namespace, m.expression) {
+		return false
+	}
+	return true
+}
+
+// vaultNamespacePath maps a Kubernetes namespace to its Vault namespace path,
+// using its static mapping, then its VaultNamespaceClass, then the first
+// matching mapping rule, then NamespaceTemplate when configured and
+// NamespaceFormat otherwise.
+func (r *NamespaceReconciler) vaultNamespacePath(ctx context.Context, namespace metav1.Object) (string, error) {
+	if vaultNamespace, ok := r.staticPath(namespace.GetName()); ok {
+		return vaultNamespace, nil
+	}
+	class, err := r.Classes.Get(ctx, namespace)
+	if err != nil {
+		return "", err
+	}
+	if class != nil {
+		return r.classPath(namespace, class)
+	}
+	if err := r.compileConfig(); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidNamespacePath, err)
+	}
+
+	for _, rule := range r.rules {
+		if !rule.matches(namespace) {
+			continue
+		}
+		formatted, err := r.executePathTemplate(rule.template, namespace)
+		if err != nil {
+			return "", err
+		}
+		root := rule.parent
+		if root == "" {
+			root = r.namespaceRootFor(namespace)
+		}
+		return joinNamespaceRoot(root, r.truncateNames(formatted)), nil
+	}
+
+	if r.template == nil {
+		return joinNamespaceRoot(r.namespaceRootFor(namespace), r.formatNamespaceName(namespace.GetName())), nil
+	}
+	formatted, err := r.executePathTemplate(r.template, namespace)
+	if err != nil {
+		return "", err
+	}
+	return joinNamespaceRoot(r.namespaceRootFor(namespace), r.truncateNames(formatted)), nil
+}
+
+// checkVaultNamespacePath returns an error wrapping ErrInvalidNamespacePath
+// when a segment of vaultNamespace is a name Vault reserves, contains
+// whitespace, is longer than MaxNamespaceNameLength, or, with
+// StrictNamespaceNames, has characters other than letters, digits, '-' and '_'.
+func (r *NamespaceReconciler) checkVaultNamespacePath(vaultNamespace string) error {
+	trimmed := strings.Trim(vaultNamespace, "/")
+	if trimmed == "" {
+		return fmt.Errorf("%w: path is empty", ErrInvalidNamespacePath)
+	}
+	for _, segment := range strings.Split(trimmed, "/") {
+		switch {
+		case segment == "":
+			return fmt.Errorf("%w: %q has an empty path segment", ErrInvalidNamespacePath, vaultNamespace)
+		case reservedNamespaceNames[segment]:
+			return fmt.Errorf("%w: %q is a reserved Vault namespace name", ErrInvalidNamespacePath, segment)
+		case strings.ContainsAny(segment, " \t\r\n"):
+			return fmt.Errorf("%w: %q contains whitespace", ErrInvalidNamespacePath, segment)
+		case r.Config.MaxNamespaceNameLength > 0 && len(segment) > r.Config.MaxNamespaceNameLength:
+			return fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidNamespacePath, segment, r.Config.MaxNamespaceNameLength)
+		case r.Config.StrictNamespaceNames && !strictNamespaceName.MatchString(segment):
+			return fmt.Errorf("%w: %q may only contain letters, digits, '-' and '_'", ErrInvalidNamespacePath, segment)
+		}
+	}
+	return nil
+}
+
+// staticPath returns the Vault namespace path StaticMappings assign to a
+// namespace, used exactly as configured.
+func (r *NamespaceReconciler) staticPath(namespaceName string) (string, bool) {
+	vaultNamespace, ok := r.Config.StaticMappings[namespaceName]
+	return vaultNamespace, ok
+}
+
+// namespaceRootFor returns the Vault namespace a namespace's path is created
+// under: the root its ParentRoots label routes it to, else the default root.
+func (r *NamespaceReconciler) namespaceRootFor(namespace metav1.Object) string {
+	routing := r.Config.ParentRoots
+	if routing.Label == "" {
+		return r.Config.Vault.NamespaceRoot
+	}
+	if root, ok := routing.Roots[namespace.GetLabels()[routing.Label]]; ok {
+		return root
+	}
+	if routing.Default != "" {
+		return routing.Default
+	}
+	return r.Config.Vault.NamespaceRoot
+}
+
+// namespaceRoots returns every root NamespaceFormat paths may be created under.
+func (r *NamespaceReconciler) namespaceRoots() []string {
+	roots := []string{r.Config.Vault.NamespaceRoot}
+	if r.Config.ParentRoots.Label == "" {
+		return roots
+	}
+	if r.Config.ParentRoots.Default != "" {
+		roots[0] = r.Config.ParentRoots.Default
+	}
+	for _, root := range r.Config.ParentRoots.Roots {
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+// templateData returns the data templates are executed against for namespace.
+func (r *NamespaceReconciler) templateData(namespace metav1.Object) config.NamespaceTemplateData {
+	return config.NamespaceTemplateData{
+		Name:        namespace.GetName(),
+		Labels:      namespace.GetLabels(),
+		Annotations: namespace.GetAnnotations(),
+		ClusterName: r.Config.ClusterName,
+	}
+}
+
+// executePathTemplate renders a path template for namespace and checks the result.
+func (r *NamespaceReconciler) executePathTemplate(tmpl *template.Template, namespace metav1.Object) (string, error) {
+	var b strings.Builder
+	err := tmpl.Execute(&b, r.templateData(namespace))
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidNamespacePath, err)
+	}
+
+	formatted := strings.TrimSpace(b.String())
+	for _, segment := range strings.Split(formatted, "/") {
+		if segment == "" {
+			return "", fmt.Errorf("%w: %q has an empty path segment", ErrInvalidNamespacePath, formatted)
+		}
+	}
+	return formatted, nil
+}
+
+// compileConfig compiles NamespaceTemplate, MappingRules, the include and
+// exclude expressions, PolicyTemplates and IdentityGroups on first use.
+func (r *NamespaceReconciler) compileConfig() error {
+	r.compileOnce.Do(func() {
+		if r.Config.NamespaceTemplate != "" {
+			r.template, r.compileErr = config.ParseNamespaceTemplate(r.Config.NamespaceTemplate)
+			if r.compileErr != nil {
+				return
+			}
+		}
+		for i, rule := range r.Config.MappingRules {
+			compiled := mappingRule{parent: rule.Parent}
+			if rule.Match != "" {
+				if compiled.match, r.compileErr = regexp.Compile(rule.Match); r.compileErr != nil {
+					r.compileErr = fmt.Errorf("mappingRules[%d]: %w", i, r.compileErr)
+					return
+				}
+			}
+			if rule.Selector != "" {
+				if compiled.selector, r.compileErr = labels.Parse(rule.Selector); r.compileErr != nil {
+					r.compileErr = fmt.Errorf("mappingRules[%d]: %w", i, r.compileErr)
+					return
+				}
+			}
+			if rule.Expression != "" {
+				if compiled.expression, r.compileErr = config.CompileNamespaceExpression(rule.Expression); r.compileErr != nil {
+					r.compileErr = fmt.Errorf("mappingRules[%d]: %w", i, r.compileErr)
+					return
+				}
+			}
+			if compiled.template, r.compileErr = config.ParseNamespaceTemplate(rule.VaultPath); r.compileErr != nil {
+				r.compileErr = fmt.Errorf("mappingRules[%d]: %w", i, r.compileErr)
+				return
+			}
+			r.rules = append(r.rules, compiled)
+		}
+		if r.includeExpressions, r.compileErr = compileExpressions(r.Config.IncludeExpressions); r.compileErr != nil {
+			return
+		}
+		if r.excludeExpressions, r.compileErr = compileExpressions(r.Config.ExcludeExpressions); r.compileErr != nil {
+			return
+		}
+		for i, policy := range r.Config.PolicyTemplates {
+			compiled, err := config.ParseNamespaceTemplate(policy.Policy)
+			if err != nil {
+				r.compileErr = fmt.Errorf("policyTemplates[%d]: %w", i, err)
+				return
+			}
+			r.policyTemplates = append(r.policyTemplates, compiled)
+		}
+		for i, group := range r.Config.IdentityGroups {
+			name, err := config.ParseNamespaceTemplate(group.Name)
+			if err != nil {
+				r.compileErr = fmt.Errorf("identityGroups[%d]: %w", i, err)
+				return
+			}
+			var aliasName *template.Template
+			if group.ExternalAlias != nil {
+				if aliasName, err = config.ParseNamespaceTemplate(group.ExternalAlias.Name); err != nil {
+					r.compileErr = fmt.Errorf("identityGroups[%d]: %w", i, err)
+					return
+				}
+			}
+			r.groupNames = append(r.groupNames, name)
+			r.groupAliasNames = append(r.groupAliasNames, aliasName)
+		}
+	})
+	return r.compileErr
+}
+
+// pathsUseMetadata reports whether Vault namespace paths may depend on labels
+// or annotations, rather than only on the namespace name.
+func (r *NamespaceReconciler) pathsUseMetadata() bool {
+	return r.Config.NamespaceTemplate != "" || len(r.Config.MappingRules) > 0 ||
+		r.Config.ParentRoots.Label != "" || r.Config.MirrorHierarchy || r.Config.CapsuleTenants ||
+		r.Config.NamespaceClasses
+}
+
+// hashSuffixLength is the number of hex digits of the hash appended to truncated names.
+const hashSuffixLength = 8
+
+// truncateNames shortens every segment of a formatted path longer than
+// MaxNamespaceNameLength, keeping its start and appending a hash of the whole
+// segment, so distinct long names stay distinct and always map the same way.
+func (r *NamespaceReconciler) truncateNames(formatted string) string {
+	maxLength := r.Config.MaxNamespaceNameLength
+	if maxLength <= 0 {
+		return formatted
+	}
+
+	segments := strings.Split(formatted, "/")
+	for i, segment := range segments {
+		if len(segment) <= maxLength {
+			continue
+		}
+		sum := sha1.Sum([]byte(segment))
+		prefix := strings.TrimRight(segment[:maxLength-hashSuffixLength-1], "-_.")
+		segments[i] = prefix + "-" + hex.EncodeToString(sum[:])[:hashSuffixLength]
+	}
+	return strings.Join(segments, "/")
+}
+
+// rememberPath records the Vault namespace path of a synchronized namespace.
+// The path may depend on labels and annotations, which are gone once the
+// namespace is deleted, so deletions use the remembered path.
+func (r *NamespaceReconciler) rememberPath(namespaceName, vaultNamespace string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.paths == nil {
+		r.paths = make(map[string]string)
+	}
+	r.paths[namespaceName] = vaultNamespace
+}
+
+// deletedNamespacePath returns the Vault namespace path of a deleted namespace,
+// or "" when it cannot be known. Paths remembered in memory come first, then
+// persisted mappings, which survive restarts and configuration changes.
+func (r *NamespaceReconciler) deletedNamespacePath(ctx context.Context, namespaceName string) (string, error) {
+	r.mu.Lock()
+	vaultNamespace, ok := r.paths[namespaceName]
+	r.mu.Unlock()
+
+	if ok {
+		return vaultNamespace, nil
+	}
+	record, ok, err := r.Mappings.Lookup(ctx, r.Config.ClusterName, namespaceName)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return record.VaultNamespace, nil
+	}
+	if vaultNamespace, ok := r.staticPath(namespaceName); ok {
+		return vaultNamespace, nil
+	}
+	if r.pathsUseMetadata() {
+		return "", nil
+	}
+	return r.formatVaultNamespacePath(namespaceName), nil
+}
+
+// forgetPath drops the remembered Vault namespace path, class and connection of
+// a deleted namespace.
+func (r *NamespaceReconciler) forgetPath(namespaceName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.paths, namespaceName)
+	delete(r.classes, namespaceName)
+	delete(r.connections, namespaceName)
+}
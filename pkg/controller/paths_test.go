@@ -0,0 +1,352 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+func TestNamespaceReconciler_vaultNamespacePath(t *testing.T) {
+	tests := []struct {
+		name          string
+		template      string
+		format        string
+		static        map[string]string
+		rules         []config.MappingRule
+		parentRoots   config.ParentRootsConfig
+		namespaceRoot string
+		labels        map[string]string
+		annotations   map[string]string
+		expected      string
+		expectedErr   bool
+	}{
+		{
+			name:     "format is used without a template",
+			format:   "k8s-%s",
+			expected: "k8s-test-ns",
+		},
+		{
+			name:     "template with name",
+			template: "apps/{{ .Name }}",
+			expected: "apps/test-ns",
+		},
+		{
+			name:          "template with label and root",
+			template:      "{{ .Labels.team }}/{{ .Name }}",
+			namespaceRoot: "/admin",
+			labels:        map[string]string{"team": "payments"},
+			expected:      "/admin/payments/test-ns",
+		},
+		{
+			name:        "template with annotation and cluster name",
+			template:    `{{ .ClusterName }}/{{ index .Annotations "example.com/tenant" }}`,
+			annotations: map[string]string{"example.com/tenant": "acme"},
+			expected:    "east/acme",
+		},
+		{
+			name:          "static mapping takes precedence over rules and root",
+			template:      "apps/{{ .Name }}",
+			static:        map[string]string{"test-ns": "legacy/TestNS"},
+			rules:         []config.MappingRule{{VaultPath: "other/{{ .Name }}"}},
+			namespaceRoot: "/admin",
+			expected:      "legacy/TestNS",
+		},
+		{
+			name:          "namespace without a static mapping",
+			static:        map[string]string{"other-ns": "legacy/OtherNS"},
+			format:        "k8s-%s",
+			namespaceRoot: "/admin",
+			expected:      "/admin/k8s-test-ns",
+		},
+		{
+			name:   "first matching rule applies",
+			format: "k8s-%s",
+			rules: []config.MappingRule{
+				{Selector: "env=prod", VaultPath: "prod/{{ .Name }}", Parent: "/tenants"},
+				{Match: "^test-", VaultPath: "dev/{{ .Name }}"},
+				{VaultPath: "other/{{ .Name }}"},
+			},
+			namespaceRoot: "/admin",
+			labels:        map[string]string{"env": "prod"},
+			expected:      "/tenants/prod/test-ns",
+		},
+		{
+			name:   "rule without parent uses the namespace root",
+			format: "k8s-%s",
+			rules: []config.MappingRule{
+				{Selector: "env=prod", VaultPath: "prod/{{ .Name }}", Parent: "/tenants"},
+				{Match: "^test-", VaultPath: "dev/{{ .Name }}"},
+			},
+			namespaceRoot: "/admin",
+			labels:        map[string]string{"env": "dev"},
+			expected:      "/admin/dev/test-ns",
+		},
+		{
+			name:   "rule with expression",
+			format: "k8s-%s",
+			rules: []config.MappingRule{
+				{Expression: "labels.env == 'prod' && !name.startsWith('tmp-')", VaultPath: "prod/{{ .Name }}"},
+				{VaultPath: "other/{{ .Name }}"},
+			},
+			labels:   map[string]string{"env": "prod"},
+			expected: "prod/test-ns",
+		},
+		{
+			name:   "no matching rule falls back to the format",
+			format: "k8s-%s",
+			rules: []config.MappingRule{
+				{Match: "^prod-", VaultPath: "prod/{{ .Name }}"},
+			},
+			expected: "k8s-test-ns",
+		},
+		{
+			name:   "label routes to a parent root",
+			format: "k8s-%s",
+			parentRoots: config.ParentRootsConfig{
+				Label:   "tenant-tier",
+				Roots:   map[string]string{"gold": "/tenants/gold"},
+				Default: "/tenants/standard",
+			},
+			namespaceRoot: "/admin",
+			labels:        map[string]string{"tenant-tier": "gold"},
+			expected:      "/tenants/gold/k8s-test-ns",
+		},
+		{
+			name:     "unlisted label value uses the default root",
+			template: "{{ .Name }}",
+			parentRoots: config.ParentRootsConfig{
+				Label:   "tenant-tier",
+				Roots:   map[string]string{"gold": "/tenants/gold"},
+				Default: "/tenants/standard",
+			},
+			namespaceRoot: "/admin",
+			labels:        map[string]string{"tenant-tier": "bronze"},
+			expected:      "/tenants/standard/test-ns",
+		},
+		{
+			name:   "rule parent takes precedence over label routing",
+			format: "k8s-%s",
+			rules:  []config.MappingRule{{VaultPath: "{{ .Name }}", Parent: "/special"}},
+			parentRoots: config.ParentRootsConfig{
+				Label:   "tenant-tier",
+				Roots:   map[string]string{"gold": "/tenants/gold"},
+				Default: "/tenants/standard",
+			},
+			labels:   map[string]string{"tenant-tier": "gold"},
+			expected: "/special/test-ns",
+		},
+		{
+			name:        "missing label",
+			template:    "{{ .Labels.team }}/{{ .Name }}",
+			labels:      map[string]string{"env": "prod"},
+			expectedErr: true,
+		},
+		{
+			name:        "empty path segment",
+			template:    "{{ .Labels.team }}/{{ .Name }}",
+			labels:      map[string]string{"team": ""},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &NamespaceReconciler{
+				Config: &config.ControllerConfig{
+					NamespaceFormat:   tt.format,
+					NamespaceTemplate: tt.template,
+					StaticMappings:    tt.static,
+					MappingRules:      tt.rules,
+					ParentRoots:       tt.parentRoots,
+					ClusterName:       "east",
+					Vault: config.VaultConfig{
+						NamespaceRoot: tt.namespaceRoot,
+					},
+				},
+				Log: testr.New(t),
+			}
+
+			namespace := &metav1.ObjectMeta{Name: "test-ns", Labels: tt.labels, Annotations: tt.annotations}
+			result, err := r.vaultNamespacePath(context.Background(), namespace)
+
+			if tt.expectedErr {
+				assert.True(t, errors.Is(err, ErrInvalidNamespacePath))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// TestNamespaceReconciler_NamespaceTemplate tests reconciling namespaces whose
+// Vault paths are derived from their labels.
+func TestNamespaceReconciler_NamespaceTemplate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	cfg := &config.ControllerConfig{
+		NamespaceTemplate:     "{{ .Labels.team }}/{{ .Name }}",
+		DeleteVaultNamespaces: true,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "team-ns"}}
+
+	t.Run("deletion uses the path the namespace was synchronized to", func(t *testing.T) {
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-ns", Labels: map[string]string{"team": "payments"}},
+		}).Build()
+
+		mockClient := new(mockVaultClient)
+		mockClient.On("NamespaceExists", mock.Anything, "payments/team-ns").Return(false, nil).Once()
+		mockClient.On("CreateNamespace", mock.Anything, "payments/team-ns", ownedMetadata("team-ns")).Return(nil)
+
+		reconciler := &NamespaceReconciler{
+			Client:      k8sClient,
+			Log:         testr.New(t),
+			Scheme:      scheme,
+			VaultClient: mockClient,
+			Config:      cfg,
+			syncChecker: func(string) bool { return true },
+		}
+
+		_, err := reconciler.Reconcile(context.Background(), req)
+		assert.NoError(t, err)
+
+		// The labels are gone with the namespace, but the path was remembered
+		assert.NoError(t, k8sClient.Delete(context.Background(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-ns"}}))
+		mockClient.On("NamespaceExists", mock.Anything, "payments/team-ns").Return(true, nil)
+		mockClient.On("GetNamespaceMetadata", mock.Anything, "payments/team-ns").Return(ownedMetadata("team-ns"), nil)
+		mockClient.On("NamespaceEmpty", mock.Anything, "payments/team-ns").Return(true, nil)
+		mockClient.On("DeleteNamespace", mock.Anything, "payments/team-ns").Return(nil)
+
+		result, err := reconciler.Reconcile(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, ctrl.Result{}, result)
+		assert.NotContains(t, reconciler.paths, "team-ns")
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("deletion of an unknown path is skipped", func(t *testing.T) {
+		mockClient := new(mockVaultClient)
+		reconciler := &NamespaceReconciler{
+			Client:      fake.NewClientBuilder().WithScheme(scheme).Build(),
+			Log:         testr.New(t),
+			Scheme:      scheme,
+			VaultClient: mockClient,
+			Config:      cfg,
+		}
+
+		result, err := reconciler.Reconcile(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, ctrl.Result{}, result)
+		mockClient.AssertNotCalled(t, "NamespaceExists", mock.Anything, mock.Anything)
+	})
+
+	t.Run("missing label is reported without retrying", func(t *testing.T) {
+		mockClient := new(mockVaultClient)
+		recorder := record.NewFakeRecorder(10)
+		reconciler := &NamespaceReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-ns"},
+			}).Build(),
+			Log:         testr.New(t),
+			Scheme:      scheme,
+			VaultClient: mockClient,
+			Recorder:    recorder,
+			Config:      cfg,
+			syncChecker: func(string) bool { return true },
+		}
+
+		result, err := reconciler.Reconcile(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, ctrl.Result{}, result)
+		assert.Contains(t, <-recorder.Events, "VaultNamespacePathInvalid")
+		mockClient.AssertNotCalled(t, "CreateNamespace", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestNamespaceReconciler_deletedNamespacePath(t *testing.T) {
+	r := &NamespaceReconciler{
+		Config: &config.ControllerConfig{
+			NamespaceTemplate: "{{ .Labels.team }}/{{ .Name }}",
+			StaticMappings:    map[string]string{"legacy": "/admin/LegacyTeam"},
+		},
+	}
+
+	// Static mappings do not depend on the deleted namespace's labels
+	vaultNamespace, err := r.deletedNamespacePath(context.Background(), "legacy")
+	assert.NoError(t, err)
+	assert.Equal(t, "/admin/LegacyTeam", vaultNamespace)
+	vaultNamespace, err = r.deletedNamespacePath(context.Background(), "team-ns")
+	assert.NoError(t, err)
+	assert.Equal(t, "", vaultNamespace)
+}
+
+func TestNamespaceReconciler_truncateNames(t *testing.T) {
+	r := &NamespaceReconciler{
+		Config: &config.ControllerConfig{MaxNamespaceNameLength: 20},
+	}
+
+	long := "team-payments-checkout-service"
+	truncated := r.truncateNames("apps/" + long)
+
+	// Short segments are kept, long ones truncated to the limit with a hash suffix
+	assert.Equal(t, "apps/team-paymen-23434ed3", truncated)
+	assert.LessOrEqual(t, len(truncated)-len("apps/"), 20)
+
+	// Truncation is deterministic and keeps names sharing a prefix distinct
+	assert.Equal(t, truncated, r.truncateNames("apps/"+long))
+	assert.NotEqual(t, truncated, r.truncateNames("apps/team-payments-checkout-worker"))
+
+	assert.Equal(t, "apps/short", r.truncateNames("apps/short"))
+}
+
+// TestNamespaceReconciler_checkVaultNamespacePath tests rejecting paths Vault would not accept.
+func TestNamespaceReconciler_checkVaultNamespacePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		strict  bool
+		wantErr bool
+	}{
+		{name: "valid path", path: "/admin/team-a/app_1"},
+		{name: "reserved name", path: "admin/sys", wantErr: true},
+		{name: "dot segment", path: "admin/../root", wantErr: true},
+		{name: "whitespace", path: "admin/my app", wantErr: true},
+		{name: "too long", path: "admin/" + strings.Repeat("a", 65), wantErr: true},
+		{name: "empty", path: "/", wantErr: true},
+		{name: "dots allowed without strict names", path: "admin/team.a"},
+		{name: "dots rejected with strict names", path: "admin/team.a", strict: true, wantErr: true},
+		{name: "leading dash rejected with strict names", path: "admin/-team", strict: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &NamespaceReconciler{Config: &config.ControllerConfig{
+				MaxNamespaceNameLength: 64,
+				StrictNamespaceNames:   tt.strict,
+			}}
+			err := r.checkVaultNamespacePath(tt.path)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidNamespacePath)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
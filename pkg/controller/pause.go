@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+	"github.com/go-logr/logr"
+)
+
+// PausedAnnotation pauses all Vault changes when set to "true" on the controller's ConfigMap.
+const PausedAnnotation = "vault.benemon.io/paused"
+
+// Sources that can pause the controller.
+const (
+	PauseSourceConfig     = "config"
+	PauseSourceAnnotation = "annotation"
+	PauseSourceAdmin      = "admin"
+)
+
+// PauseState tracks whether Vault changes are paused. Each source pauses
+// independently, and the controller stays paused while any source is set.
+type PauseState struct {
+	mu      sync.RWMutex
+	sources map[string]bool
+}
+
+// Set records whether source is pausing the controller.
+func (p *PauseState) Set(source string, paused bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sources == nil {
+		p.sources = make(map[string]bool)
+	}
+	if paused {
+		p.sources[source] = true
+	} else {
+		delete(p.sources, source)
+	}
+
+	if len(p.sources) > 0 {
+		metrics.Paused.Set(1)
+	} else {
+		metrics.Paused.Set(0)
+	}
+}
+
+// Paused reports whether any source is pausing the controller.
+func (p *PauseState) Paused() bool {
+	if p == nil {
+		return false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.sources) > 0
+}
+
+// Sources returns the sources currently pausing the controller.
+func (p *PauseState) Sources() []string {
+	if p == nil {
+		return nil
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	sources := make([]string, 0, len(p.sources))
+	for source := range p.sources {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// isSet reports whether source is pausing the controller.
+func (p *PauseState) isSet(source string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.sources[source]
+}
+
+// PauseReconciler watches the controller's own ConfigMap and pauses the
+// controller while it carries the paused annotation.
+type PauseReconciler struct {
+	client.Client
+	Log       logr.Logger
+	Pause     *PauseState
+	ConfigMap types.NamespacedName
+}
+
+func (r *PauseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var configMap corev1.ConfigMap
+	if err := r.Get(ctx, req.NamespacedName, &configMap); err != nil {
+		if k8serrors.IsNotFound(err) {
+			r.Pause.Set(PauseSourceAnnotation, false)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	paused := configMap.Annotations[PausedAnnotation] == "true"
+	if paused != r.Pause.isSet(PauseSourceAnnotation) {
+		r.Log.Info("Pause annotation changed", "paused", paused)
+	}
+	r.Pause.Set(PauseSourceAnnotation, paused)
+	return ctrl.Result{}, nil
+}
+
+func (r *PauseReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	isControllerConfigMap := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetNamespace() == r.ConfigMap.Namespace && obj.GetName() == r.ConfigMap.Name
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("pause").
+		For(&corev1.ConfigMap{}, builder.WithPredicates(isControllerConfigMap)).
+		Complete(r)
+}
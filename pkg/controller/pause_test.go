@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+)
+
+// TestPauseState tests combining pause sources.
+func TestPauseState(t *testing.T) {
+	var nilState *PauseState
+	assert.False(t, nilState.Paused())
+
+	pause := &PauseState{}
+	assert.False(t, pause.Paused())
+
+	pause.Set(PauseSourceConfig, true)
+	pause.Set(PauseSourceAnnotation, true)
+	assert.True(t, pause.Paused())
+	assert.Equal(t, []string{PauseSourceAnnotation, PauseSourceConfig}, pause.Sources())
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.Paused))
+
+	pause.Set(PauseSourceConfig, false)
+	assert.True(t, pause.Paused())
+
+	pause.Set(PauseSourceAnnotation, false)
+	assert.False(t, pause.Paused())
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.Paused))
+}
+
+// TestPauseReconciler tests pausing from the ConfigMap annotation.
+func TestPauseReconciler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	key := types.NamespacedName{Namespace: "vault-system", Name: "vault-namespace-controller"}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   key.Namespace,
+			Name:        key.Name,
+			Annotations: map[string]string{PausedAnnotation: "true"},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(configMap).Build()
+
+	pause := &PauseState{}
+	reconciler := &PauseReconciler{
+		Client:    fakeClient,
+		Log:       testr.New(t),
+		Pause:     pause,
+		ConfigMap: key,
+	}
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	assert.True(t, pause.Paused())
+
+	// Removing the annotation resumes the controller
+	configMap.Annotations = nil
+	assert.NoError(t, fakeClient.Update(context.Background(), configMap))
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	assert.False(t, pause.Paused())
+}
+
+// TestNamespaceReconciler_Paused tests that a paused controller makes no Vault calls.
+func TestNamespaceReconciler_Paused(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	pause := &PauseState{}
+	pause.Set(PauseSourceConfig, true)
+	defer pause.Set(PauseSourceConfig, false)
+
+	mockClient := new(mockVaultClient)
+	reconciler := &NamespaceReconciler{
+		Client:      fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Pause:       pause,
+		Config: &config.ControllerConfig{
+			NamespaceFormat:       "k8s-%s",
+			DeleteVaultNamespaces: true,
+		},
+	}
+
+	result, err := reconciler.Reconcile(context.Background(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Name: "deleted-ns"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ctrl.Result{RequeueAfter: pausedRequeueInterval}, result)
+	mockClient.AssertNotCalled(t, "NamespaceExists", mock.Anything, mock.Anything)
+	mockClient.AssertNotCalled(t, "DeleteNamespace", mock.Anything, mock.Anything)
+}
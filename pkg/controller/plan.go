@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PlanEntry describes a single Kubernetes namespace to Vault namespace mapping.
+type PlanEntry struct {
+	KubernetesNamespace string `json:"kubernetesNamespace,omitempty"`
+	VaultNamespace      string `json:"vaultNamespace"`
+}
+
+// Plan is a diff of Kubernetes namespaces against the Vault namespaces they map to.
+type Plan struct {
+	// DeletionEnabled reports whether ToDelete entries would actually be deleted.
+	DeletionEnabled bool `json:"deletionEnabled"`
+
+	// ToCreate lists synchronized Kubernetes namespaces with no Vault namespace.
+	ToCreate []PlanEntry `json:"toCreate"`
+
+	// ToDelete lists Vault namespaces owned by this controller whose Kubernetes
+	// namespace no longer exists or is no longer synchronized.
+	ToDelete []PlanEntry `json:"toDelete"`
+
+	// InSync lists synchronized Kubernetes namespaces whose Vault namespace exists.
+	InSync []PlanEntry `json:"inSync"`
+
+	// Unmanaged lists Vault namespaces that no Kubernetes namespace maps to and
+	// that are not owned by this controller.
+	Unmanaged []PlanEntry `json:"unmanaged"`
+}
+
+// splitVaultPath splits a Vault namespace path into its parent and final element.
+func splitVaultPath(vaultNamespace string) (parent, child string) {
+	dir, base := path.Split(strings.Trim(vaultNamespace, "/"))
+	return strings.TrimSuffix(dir, "/"), base
+}
+
+// BuildPlan compares the Kubernetes namespaces in the cluster with the Vault
+// namespaces below the parents they map to.
+func (r *NamespaceReconciler) BuildPlan(ctx context.Context) (*Plan, error) {
+	nsList := newNamespaceMetadataList()
+	if err := r.Client.List(ctx, nsList); err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{
+		DeletionEnabled: r.deleteVaultNamespaces(),
+		ToCreate:        []PlanEntry{},
+		ToDelete:        []PlanEntry{},
+		InSync:          []PlanEntry{},
+		Unmanaged:       []PlanEntry{},
+	}
+
+	// Map every synchronized namespace to its Vault path, and collect the parents to scan
+	mapped := make(map[string]string)
+	parents := make(map[string]bool)
+	if r.Config.NamespaceTemplate == "" {
+		// With NamespaceFormat namespaces share a parent per root, scanned even when empty
+		for _, root := range r.namespaceRoots() {
+			placeholderParent, _ := splitVaultPath(joinNamespaceRoot(root, r.formatNamespaceName("placeholder")))
+			parents[placeholderParent] = true
+		}
+	}
+	for _, ns := range nsList.Items {
+		if !r.Shard.Owns(ns.Name) || !r.shouldSyncNamespace(&ns) {
+			continue
+		}
+		// Only the controller's own Vault is scanned
+		connection, err := r.connectionFor(ctx, &ns)
+		if err != nil {
+			r.Log.V(1).Info("Skipping namespace without a valid VaultConnection",
+				"kubernetesNamespace", ns.Name, "error", err.Error())
+			continue
+		} else if connection != "" {
+			r.Log.V(1).Info("Skipping namespace routed to a VaultConnection",
+				"kubernetesNamespace", ns.Name, "connection", connection)
+			continue
+		}
+		vaultNamespacePath, err := r.resolveVaultNamespacePath(ctx, &ns)
+		if err != nil {
+			r.Log.V(1).Info("Skipping namespace without a valid Vault namespace path",
+				"kubernetesNamespace", ns.Name, "error", err.Error())
+			continue
+		}
+		vaultNamespace := strings.Trim(vaultNamespacePath, "/")
+		mapped[vaultNamespace] = ns.Name
+		parent, _ := splitVaultPath(vaultNamespace)
+		parents[parent] = true
+	}
+
+	// Persisted mappings reach Vault namespaces created under earlier configurations
+	records, err := r.Mappings.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.Cluster != r.Config.ClusterName {
+			continue
+		}
+		parent, _ := splitVaultPath(record.VaultNamespace)
+		parents[parent] = true
+	}
+
+	existing := make(map[string]bool)
+	for parent := range parents {
+		children, err := r.VaultClient.ListNamespaces(ctx, parent)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			vaultNamespace := child
+			if parent != "" {
+				vaultNamespace = parent + "/" + child
+			}
+			existing[vaultNamespace] = true
+			if _, ok := mapped[vaultNamespace]; ok {
+				continue
+			}
+
+			customMetadata, err := r.VaultClient.GetNamespaceMetadata(ctx, vaultNamespace)
+			if err != nil {
+				return nil, err
+			}
+			managed, ours := r.ownedBy(customMetadata)
+			if managed && customMetadata[MetadataKubernetesNamespace] == "" {
+				// Namespaces such as Capsule Tenants' hold namespaces rather than mirroring one
+				continue
+			}
+			if ours {
+				// Namespaces belonging to other shards are their replicas' business
+				if !r.Shard.Owns(customMetadata[MetadataKubernetesNamespace]) {
+					continue
+				}
+				plan.ToDelete = append(plan.ToDelete, PlanEntry{
+					KubernetesNamespace: customMetadata[MetadataKubernetesNamespace],
+					VaultNamespace:      vaultNamespace,
+				})
+			} else {
+				plan.Unmanaged = append(plan.Unmanaged, PlanEntry{VaultNamespace: vaultNamespace})
+			}
+		}
+	}
+
+	for vaultNamespace, namespaceName := range mapped {
+		entry := PlanEntry{KubernetesNamespace: namespaceName, VaultNamespace: vaultNamespace}
+		if existing[vaultNamespace] {
+			plan.InSync = append(plan.InSync, entry)
+		} else {
+			plan.ToCreate = append(plan.ToCreate, entry)
+		}
+	}
+
+	for _, entries := range [][]PlanEntry{plan.ToCreate, plan.ToDelete, plan.InSync, plan.Unmanaged} {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].VaultNamespace < entries[j].VaultNamespace })
+	}
+	return plan, nil
+}
+
+// PlanHandler returns an HTTP handler that computes and serves the plan as JSON.
+func (r *NamespaceReconciler) PlanHandler() http.Handler {
+	return r.reportHandler("plan", func(ctx context.Context) (any, error) {
+		return r.BuildPlan(ctx)
+	})
+}
+
+// reportHandler returns an HTTP handler that computes a report on demand and
+// serves it as JSON.
+func (r *NamespaceReconciler) reportHandler(name string, build func(context.Context) (any, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(req.Context(), 60*time.Second)
+		defer cancel()
+
+		report, err := build(ctx)
+		if err != nil {
+			r.Log.Error(err, "Failed to build report", "report", name)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
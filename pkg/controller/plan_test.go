@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestPlanHandler tests the plan diff of Kubernetes and Vault namespaces.
+func TestPlanHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-b"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("ListNamespaces", mock.Anything, "admin").
+		Return([]string{"k8s-app-a", "k8s-gone", "team-x"}, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "admin/k8s-gone").
+		Return(ownedMetadata("gone"), nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "admin/team-x").
+		Return(map[string]string{}, nil)
+
+	reconciler := &NamespaceReconciler{
+		Client:      fakeClient,
+		Log:         testr.New(t),
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{
+			NamespaceFormat:       "k8s-%s",
+			DeleteVaultNamespaces: true,
+			Vault:                 config.VaultConfig{NamespaceRoot: "/admin"},
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	reconciler.PlanHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/plan", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var plan Plan
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &plan))
+	assert.True(t, plan.DeletionEnabled)
+	assert.Equal(t, []PlanEntry{{KubernetesNamespace: "app-b", VaultNamespace: "admin/k8s-app-b"}}, plan.ToCreate)
+	assert.Equal(t, []PlanEntry{{KubernetesNamespace: "app-a", VaultNamespace: "admin/k8s-app-a"}}, plan.InSync)
+	assert.Equal(t, []PlanEntry{{KubernetesNamespace: "gone", VaultNamespace: "admin/k8s-gone"}}, plan.ToDelete)
+	assert.Equal(t, []PlanEntry{{VaultNamespace: "admin/team-x"}}, plan.Unmanaged)
+	mockClient.AssertExpectations(t)
+
+	rec = httptest.NewRecorder()
+	reconciler.PlanHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/plan", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
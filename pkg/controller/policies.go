@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/go-logr/logr"
+)
+
+// applyPolicyTemplates writes the configured policy templates, rendered for
+// namespace, into vaultNamespace. Policies whose rendered HCL was already
+// written are skipped, so only changed labels or annotations cause writes.
+func (r *NamespaceReconciler) applyPolicyTemplates(ctx context.Context, namespace metav1.Object, vaultNamespace string, log logr.Logger) error {
+	if len(r.Config.PolicyTemplates) == 0 {
+		return nil
+	}
+	if err := r.compileConfig(); err != nil {
+		return err
+	}
+
+	key := appliedKey(ctx, vaultNamespace)
+	for i, policy := range r.Config.PolicyTemplates {
+		rendered, err := r.renderTemplate(r.policyTemplates[i], namespace)
+		if err != nil {
+			return fmt.Errorf("policy template %q: %w", policy.Name, err)
+		}
+
+		r.mu.Lock()
+		written := r.appliedPolicies[key][policy.Name] == rendered
+		r.mu.Unlock()
+		if written {
+			continue
+		}
+		if r.skipForDryRun(namespace.GetName(), "provision", vaultNamespace, log) {
+			return nil
+		}
+		log.V(1).Info("Writing templated policy", "policy", policy.Name)
+		if err := r.VaultClient.PutPolicy(ctx, vaultNamespace, policy.Name, rendered); err != nil {
+			return err
+		}
+
+		r.mu.Lock()
+		if r.appliedPolicies == nil {
+			r.appliedPolicies = make(map[string]map[string]string)
+		}
+		if r.appliedPolicies[key] == nil {
+			r.appliedPolicies[key] = make(map[string]string)
+		}
+		r.appliedPolicies[key][policy.Name] = rendered
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+// forgetApplied drops the record of the policies and identity groups written
+// into vaultNamespace, so they are written again, such as after it was recreated.
+func (r *NamespaceReconciler) forgetApplied(ctx context.Context, vaultNamespace string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.appliedPolicies, appliedKey(ctx, vaultNamespace))
+	delete(r.appliedSentinel, appliedKey(ctx, vaultNamespace))
+	delete(r.appliedGroups, appliedKey(ctx, vaultNamespace))
+}
+
+// forgetProvisioned drops everything recorded about provisioning
+// vaultNamespace, including the bootstrap and post-create hooks still pending
+// for it. It is called when the controller does not manage the Vault namespace
+// at the path, so that a namespace adopted there later is provisioned in full
+// and not bootstrapped as if the controller had created it.
+func (r *NamespaceReconciler) forgetProvisioned(ctx context.Context, vaultNamespace string) {
+	r.Blueprints.Forget(ctx, vaultNamespace)
+	r.forgetApplied(ctx, vaultNamespace)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.unbootstrapped, appliedKey(ctx, vaultNamespace))
+	delete(r.unannounced, appliedKey(ctx, vaultNamespace))
+}
+
+// renderTemplate executes a policy or identity group template for namespace.
+func (r *NamespaceReconciler) renderTemplate(tmpl *template.Template, namespace metav1.Object) (string, error) {
+	var b strings.Builder
+	err := tmpl.Execute(&b, r.templateData(namespace))
+	return b.String(), err
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestNamespaceReconciler_PolicyTemplates tests that policy templates are
+// rendered for each namespace and rewritten only when their content changes.
+func TestNamespaceReconciler_PolicyTemplates(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "app",
+		Labels: map[string]string{"team": "payments"},
+	}}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(namespace).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "app").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "app").Return(ownedMetadata("app"), nil)
+	mockClient.On("PutPolicy", mock.Anything, "app", "tenant-admin", `path "secret/*" { capabilities = ["sudo"] }`).Return(nil).Once()
+	mockClient.On("PutPolicy", mock.Anything, "app", "tenant-read", `path "secret/data/payments/app/*" { capabilities = ["read"] }`).Return(nil).Once()
+
+	reconciler := &NamespaceReconciler{
+		Client:      k8sClient,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{PolicyTemplates: []config.PolicyTemplate{
+			{Name: "tenant-admin", Policy: `path "secret/*" { capabilities = ["sudo"] }`},
+			{Name: "tenant-read", Policy: `path "secret/data/{{ .Labels.team }}/{{ .Name }}/*" { capabilities = ["read"] }`},
+		}},
+		syncChecker: func(string) bool { return true },
+	}
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	mockClient.AssertNumberOfCalls(t, "PutPolicy", 2)
+
+	// A label change rewrites the policies that use it
+	namespace.Labels["team"] = "billing"
+	assert.NoError(t, k8sClient.Update(ctx, namespace))
+	mockClient.On("PutPolicy", mock.Anything, "app", "tenant-read", `path "secret/data/billing/app/*" { capabilities = ["read"] }`).Return(nil).Once()
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	mockClient.AssertNumberOfCalls(t, "PutPolicy", 3)
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"maps"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// namespaceChangedPredicate drops namespace update events that leave the name,
+// labels, annotations and deletion state unchanged, such as status-only updates,
+// so they never reach the workqueue.
+func namespaceChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return namespaceChanged(e.ObjectOld, e.ObjectNew)
+		},
+	}
+}
+
+// namespaceChanged reports whether anything the controller reads differs between oldObj and newObj.
+func namespaceChanged(oldObj, newObj client.Object) bool {
+	if oldObj == nil || newObj == nil {
+		return true
+	}
+	return oldObj.GetName() != newObj.GetName() ||
+		!maps.Equal(oldObj.GetLabels(), newObj.GetLabels()) ||
+		!maps.Equal(oldObj.GetAnnotations(), newObj.GetAnnotations()) ||
+		(oldObj.GetDeletionTimestamp() == nil) != (newObj.GetDeletionTimestamp() == nil)
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// TestNamespaceChangedPredicate tests filtering of no-op namespace updates.
+func TestNamespaceChangedPredicate(t *testing.T) {
+	base := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "app",
+			Labels:      map[string]string{"team": "a"},
+			Annotations: map[string]string{"owner": "alice"},
+		},
+	}
+
+	statusOnly := base.DeepCopy()
+	statusOnly.ResourceVersion = "2"
+	statusOnly.Status.Phase = corev1.NamespaceActive
+
+	relabelled := base.DeepCopy()
+	relabelled.Labels["team"] = "b"
+
+	annotated := base.DeepCopy()
+	annotated.Annotations["owner"] = "bob"
+
+	terminating := base.DeepCopy()
+	now := metav1.Now()
+	terminating.DeletionTimestamp = &now
+
+	tests := []struct {
+		name     string
+		newObj   *corev1.Namespace
+		expected bool
+	}{
+		{name: "status-only update is dropped", newObj: statusOnly, expected: false},
+		{name: "label change passes", newObj: relabelled, expected: true},
+		{name: "annotation change passes", newObj: annotated, expected: true},
+		{name: "deletion passes", newObj: terminating, expected: true},
+	}
+
+	p := namespaceChangedPredicate()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, p.Update(event.UpdateEvent{ObjectOld: base, ObjectNew: tt.newObj}))
+		})
+	}
+
+	assert.True(t, p.Create(event.CreateEvent{Object: base}))
+	assert.True(t, p.Delete(event.DeleteEvent{Object: base}))
+}
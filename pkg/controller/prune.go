@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/benemon/vault-namespace-controller/pkg/audit"
+)
+
+// Results of pruning an orphaned Vault namespace.
+const (
+	PruneWouldDelete = "would-delete"
+	PruneDeleted     = "deleted"
+	PruneNotEmpty    = "not-empty"
+	PruneNotOwned    = "not-owned"
+	PruneFailed      = "failed"
+)
+
+// PruneFilter selects the orphaned Vault namespaces to prune. Only namespaces
+// owned by this controller are ever selected.
+type PruneFilter struct {
+	// MinAge, when set, excludes namespaces created more recently and those of
+	// unknown age.
+	MinAge time.Duration
+	// Pattern, when set, must match the Vault namespace path.
+	Pattern *regexp.Regexp
+}
+
+// Matches reports whether orphan is selected at now.
+func (f PruneFilter) Matches(orphan OrphanedNamespace, now time.Time) bool {
+	if !orphan.Owned {
+		return false
+	}
+	if f.MinAge > 0 && (orphan.CreatedAt == nil || now.Sub(*orphan.CreatedAt) < f.MinAge) {
+		return false
+	}
+	return f.Pattern == nil || f.Pattern.MatchString(orphan.VaultNamespace)
+}
+
+// Prune is the result of pruning an orphaned Vault namespace.
+type Prune struct {
+	VaultNamespace      string `json:"vaultNamespace"`
+	KubernetesNamespace string `json:"kubernetesNamespace"`
+	Result              string `json:"result"`
+	Error               string `json:"error,omitempty"`
+}
+
+// PruneNamespace deletes an orphaned Vault namespace, along with its quotas and
+// persisted mapping. Ownership is checked again first, and Vault namespaces
+// with secret or auth mounts are kept unless nonEmpty is set. Deletion is not
+// subject to deleteVaultNamespaces or the orphan policy, which govern the
+// controller's own deletions.
+func (r *NamespaceReconciler) PruneNamespace(ctx context.Context, orphan OrphanedNamespace, nonEmpty bool) Prune {
+	prune := Prune{VaultNamespace: orphan.VaultNamespace, KubernetesNamespace: orphan.KubernetesNamespace}
+	fail := func(err error) Prune {
+		prune.Result, prune.Error = PruneFailed, err.Error()
+		return prune
+	}
+
+	owned, err := r.isOwned(ctx, orphan.VaultNamespace)
+	if err != nil {
+		return fail(err)
+	}
+	if !owned {
+		prune.Result = PruneNotOwned
+		return prune
+	}
+	if !nonEmpty {
+		empty, err := r.VaultClient.NamespaceEmpty(ctx, orphan.VaultNamespace)
+		if err != nil {
+			return fail(err)
+		}
+		if !empty {
+			prune.Result = PruneNotEmpty
+			return prune
+		}
+	}
+
+	err = r.VaultClient.DeleteNamespace(ctx, orphan.VaultNamespace)
+	r.audit(ctx, audit.Record{Action: audit.ActionDelete, Namespace: orphan.KubernetesNamespace, VaultNamespace: orphan.VaultNamespace}, err)
+	if err != nil {
+		return fail(err)
+	}
+	r.Limit.add(ctx, -1)
+	if err := r.Blueprints.RemoveQuotas(ctx, orphan.VaultNamespace); err != nil {
+		return fail(err)
+	}
+	if err := r.Mappings.Forget(ctx, r.Config.ClusterName, orphan.KubernetesNamespace); err != nil {
+		return fail(err)
+	}
+	prune.Result = PruneDeleted
+	return prune
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestPruneFilter tests orphans are selected by ownership, age and pattern.
+func TestPruneFilter(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-72 * time.Hour)
+	recent := now.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		filter   PruneFilter
+		orphan   OrphanedNamespace
+		expected bool
+	}{
+		{"owned without filters", PruneFilter{}, OrphanedNamespace{VaultNamespace: "k8s-gone", Owned: true}, true},
+		{"unmanaged", PruneFilter{}, OrphanedNamespace{VaultNamespace: "team-x"}, false},
+		{"old enough", PruneFilter{MinAge: 24 * time.Hour}, OrphanedNamespace{VaultNamespace: "k8s-gone", Owned: true, CreatedAt: &old}, true},
+		{"too recent", PruneFilter{MinAge: 24 * time.Hour}, OrphanedNamespace{VaultNamespace: "k8s-gone", Owned: true, CreatedAt: &recent}, false},
+		{"unknown age", PruneFilter{MinAge: 24 * time.Hour}, OrphanedNamespace{VaultNamespace: "k8s-gone", Owned: true}, false},
+		{"matching pattern", PruneFilter{Pattern: regexp.MustCompile("^k8s-ci-")}, OrphanedNamespace{VaultNamespace: "k8s-ci-123", Owned: true}, true},
+		{"other pattern", PruneFilter{Pattern: regexp.MustCompile("^k8s-ci-")}, OrphanedNamespace{VaultNamespace: "k8s-gone", Owned: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.filter.Matches(tt.orphan, now))
+		})
+	}
+}
+
+// TestPruneNamespace tests ownership and contents are checked again before
+// an orphan is deleted.
+func TestPruneNamespace(t *testing.T) {
+	tests := []struct {
+		name         string
+		metadata     map[string]string
+		empty        bool
+		nonEmpty     bool
+		deleteErr    error
+		expectDelete bool
+		expected     Prune
+	}{
+		{
+			name:         "deletes an empty orphan",
+			metadata:     ownedMetadata("gone"),
+			empty:        true,
+			expectDelete: true,
+			expected:     Prune{VaultNamespace: "k8s-gone", KubernetesNamespace: "gone", Result: PruneDeleted},
+		},
+		{
+			name:     "keeps a non-empty orphan",
+			metadata: ownedMetadata("gone"),
+			expected: Prune{VaultNamespace: "k8s-gone", KubernetesNamespace: "gone", Result: PruneNotEmpty},
+		},
+		{
+			name:         "deletes a non-empty orphan when allowed",
+			metadata:     ownedMetadata("gone"),
+			nonEmpty:     true,
+			expectDelete: true,
+			expected:     Prune{VaultNamespace: "k8s-gone", KubernetesNamespace: "gone", Result: PruneDeleted},
+		},
+		{
+			name:     "keeps a namespace no longer owned",
+			metadata: map[string]string{},
+			expected: Prune{VaultNamespace: "k8s-gone", KubernetesNamespace: "gone", Result: PruneNotOwned},
+		},
+		{
+			name:         "reports a failed deletion",
+			metadata:     ownedMetadata("gone"),
+			empty:        true,
+			deleteErr:    errors.New("permission denied"),
+			expectDelete: true,
+			expected:     Prune{VaultNamespace: "k8s-gone", KubernetesNamespace: "gone", Result: PruneFailed, Error: "permission denied"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(mockVaultClient)
+			mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-gone").Return(tt.metadata, nil)
+			mockClient.On("NamespaceEmpty", mock.Anything, "k8s-gone").Return(tt.empty, nil).Maybe()
+			if tt.expectDelete {
+				mockClient.On("DeleteNamespace", mock.Anything, "k8s-gone").Return(tt.deleteErr)
+			}
+
+			reconciler := &NamespaceReconciler{
+				Log:         testr.New(t),
+				VaultClient: mockClient,
+				Config:      &config.ControllerConfig{},
+			}
+
+			prune := reconciler.PruneNamespace(context.Background(),
+				OrphanedNamespace{VaultNamespace: "k8s-gone", KubernetesNamespace: "gone", Owned: true}, tt.nonEmpty)
+			assert.Equal(t, tt.expected, prune)
+			mockClient.AssertExpectations(t)
+			if !tt.expectDelete {
+				mockClient.AssertNotCalled(t, "DeleteNamespace", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
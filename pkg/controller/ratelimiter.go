@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// newRateLimiter builds the workqueue rate limiter from cfg. Like the
+// controller-runtime default, it combines a per-item exponential backoff with
+// an overall token bucket, and settings left at zero keep their defaults.
+func newRateLimiter(cfg config.RateLimiterConfig) workqueue.TypedRateLimiter[reconcile.Request] {
+	baseDelay := time.Duration(cfg.BaseDelayMilliseconds) * time.Millisecond
+	if baseDelay <= 0 {
+		baseDelay = 5 * time.Millisecond
+	}
+	maxDelay := time.Duration(cfg.MaxDelaySeconds) * time.Second
+	if maxDelay <= 0 {
+		maxDelay = 1000 * time.Second
+	}
+	qps := cfg.QPS
+	if qps <= 0 {
+		qps = 10
+	}
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = 100
+	}
+
+	return workqueue.NewTypedMaxOfRateLimiter(
+		workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](baseDelay, maxDelay),
+		&workqueue.TypedBucketRateLimiter[reconcile.Request]{Limiter: rate.NewLimiter(rate.Limit(qps), burst)},
+	)
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+)
+
+// TestNewRateLimiter tests the per-item backoff of the configured rate limiter.
+func TestNewRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(config.RateLimiterConfig{
+		BaseDelayMilliseconds: 100,
+		MaxDelaySeconds:       1,
+	})
+	item := reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}}
+
+	assert.Equal(t, 100*time.Millisecond, limiter.When(item))
+	assert.Equal(t, 200*time.Millisecond, limiter.When(item))
+	for i := 0; i < 10; i++ {
+		limiter.When(item)
+	}
+	assert.Equal(t, time.Second, limiter.When(item))
+
+	limiter.Forget(item)
+	assert.Equal(t, 100*time.Millisecond, limiter.When(item))
+}
+
+// TestNamespaceReconciler_RetryRateLimiter tests the configured rate limiter
+// delays the retries of Vault failures when it is slower than the backoff.
+func TestNamespaceReconciler_RetryRateLimiter(t *testing.T) {
+	reconciler := &NamespaceReconciler{
+		Config: &config.ControllerConfig{
+			ErrorBackoffBase: 1,
+			ErrorBackoffMax:  60,
+			RateLimiter: config.RateLimiterConfig{
+				BaseDelayMilliseconds: 10000,
+				MaxDelaySeconds:       30,
+				QPS:                   0.1,
+				Burst:                 2,
+			},
+		},
+	}
+	err := fmt.Errorf("%w: %w", ErrNamespaceCreation, vault.ErrVaultUnavailable)
+	retry := func(name string) time.Duration {
+		return reconciler.retryResult(context.Background(), name, err, testr.New(t)).RequeueAfter
+	}
+
+	// The per-namespace delay doubles up to its maximum, and resets on success
+	assert.Equal(t, 10*time.Second, retry("app"))
+	assert.Equal(t, 20*time.Second, retry("app"))
+	reconciler.resetBackoff("app")
+	assert.Equal(t, 10*time.Second, retry("app"))
+
+	// Retries beyond the burst wait for the overall rate
+	assert.Greater(t, retry("other"), 15*time.Second)
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/go-logr/logr"
+)
+
+// ConfigReloader reloads the configuration file when it changes or the
+// controller receives SIGHUP, and applies the settings that can change at
+// runtime through Live.
+type ConfigReloader struct {
+	Path string
+	// Current is the configuration in effect, initially the one loaded at startup.
+	Current *config.ControllerConfig
+	// Overrides are the command line flags, which keep precedence over the file.
+	Overrides config.FlagOverrides
+	Live      *LiveConfig
+	Log       logr.Logger
+}
+
+// Start watches the configuration file until ctx is cancelled. The directory
+// is watched rather than the file, so files replaced by renaming, as mounted
+// ConfigMaps are, keep being picked up.
+func (c *ConfigReloader) Start(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err := watcher.Add(filepath.Dir(c.Path)); err != nil {
+		return err
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hangup:
+			c.Log.Info("Received SIGHUP, reloading configuration file")
+			c.Reload()
+		case event := <-watcher.Events:
+			if c.affects(event) {
+				c.Reload()
+			}
+		case err := <-watcher.Errors:
+			c.Log.Error(err, "Failed to watch configuration file")
+		}
+	}
+}
+
+// NeedLeaderElection reports that every replica reloads its configuration.
+func (c *ConfigReloader) NeedLeaderElection() bool {
+	return false
+}
+
+// affects reports whether event may have changed the configuration file.
+func (c *ConfigReloader) affects(event fsnotify.Event) bool {
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+		return false
+	}
+	// Mounted ConfigMaps are updated by swapping the ..data symlink
+	name := filepath.Base(event.Name)
+	return name == filepath.Base(c.Path) || name == "..data"
+}
+
+// Reload loads and validates the configuration file and applies the settings
+// that can be reloaded. Changes to other settings are refused until restart;
+// an invalid file is refused entirely.
+func (c *ConfigReloader) Reload() {
+	loaded, err := config.LoadConfig(c.Path)
+	if err == nil {
+		err = c.Overrides.Apply(loaded)
+	}
+	if err != nil {
+		c.Log.Error(err, "Invalid configuration file, keeping the current configuration")
+		return
+	}
+
+	if refused := unreloadableChanges(c.Current, loaded); len(refused) > 0 {
+		c.Log.Error(nil, "Configuration changes require a restart and are not applied",
+			"settings", refused)
+	}
+	next := *c.Current
+	next.IncludeNamespaces = loaded.IncludeNamespaces
+	next.ExcludeNamespaces = loaded.ExcludeNamespaces
+	next.PatternSyntax = loaded.PatternSyntax
+	next.StrictMatch = loaded.StrictMatch
+	next.NamespaceFormat = loaded.NamespaceFormat
+	next.DeleteVaultNamespaces = loaded.DeleteVaultNamespaces
+	next.DeleteNonEmptyNamespaces = loaded.DeleteNonEmptyNamespaces
+	next.ReconcileInterval = loaded.ReconcileInterval
+	if reflect.DeepEqual(&next, c.Current) {
+		c.Log.V(1).Info("Configuration file reloaded, nothing to apply")
+		return
+	}
+
+	c.Log.Info("Applying reloaded configuration file",
+		"includeNamespaces", next.IncludeNamespaces,
+		"excludeNamespaces", next.ExcludeNamespaces,
+		"patternSyntax", next.PatternSyntax,
+		"strictMatch", next.StrictMatch,
+		"namespaceFormat", next.NamespaceFormat,
+		"deleteVaultNamespaces", next.DeleteVaultNamespaces,
+		"deleteNonEmptyNamespaces", next.DeleteNonEmptyNamespaces,
+		"reconcileInterval", next.ReconcileInterval)
+	c.Current = &next
+	c.Live.SetFile(&next)
+}
+
+// unreloadableChanges returns the YAML names of the settings that differ
+// between current and loaded but cannot be changed at runtime.
+func unreloadableChanges(current, loaded *config.ControllerConfig) []string {
+	reloadable := map[string]bool{
+		"IncludeNamespaces":        true,
+		"ExcludeNamespaces":        true,
+		"PatternSyntax":            true,
+		"StrictMatch":              true,
+		"NamespaceFormat":          true,
+		"DeleteVaultNamespaces":    true,
+		"DeleteNonEmptyNamespaces": true,
+		"ReconcileInterval":        true,
+	}
+	var changed []string
+	cv, lv := reflect.ValueOf(current).Elem(), reflect.ValueOf(loaded).Elem()
+	for i := 0; i < cv.NumField(); i++ {
+		field := cv.Type().Field(i)
+		if reloadable[field.Name] || reflect.DeepEqual(cv.Field(i).Interface(), lv.Field(i).Interface()) {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		changed = append(changed, name)
+	}
+	return changed
+}
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+const reloadTestConfig = `
+vault:
+  address: https://vault.example.com:8200
+  auth:
+    type: token
+    token: test-token
+includeNamespaces: ["app-.*"]
+reconcileInterval: 300
+`
+
+// TestConfigReloader_Reload tests that only the reloadable settings of a
+// valid configuration file are applied.
+func TestConfigReloader_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(reloadTestConfig), 0o600))
+	cfg, err := config.LoadConfig(path)
+	assert.NoError(t, err)
+
+	live := &LiveConfig{}
+	reloader := &ConfigReloader{Path: path, Current: cfg, Live: live, Log: testr.New(t)}
+	reconciler := &NamespaceReconciler{Config: cfg, Live: live}
+
+	assert.NoError(t, os.WriteFile(path, []byte(`
+vault:
+  address: https://vault.example.com:8200
+  auth:
+    type: kubernetes
+    role: controller
+includeNamespaces: ["team-.*"]
+deleteVaultNamespaces: false
+reconcileInterval: 0
+`), 0o600))
+	reloader.Reload()
+	assert.Equal(t, []string{"team-.*"}, reconciler.includeNamespaces())
+	assert.False(t, reconciler.deleteVaultNamespaces())
+	assert.Zero(t, reconciler.reconcileInterval())
+	// The auth method cannot change at runtime
+	assert.Equal(t, "token", live.File().Vault.Auth.Type)
+	assert.Equal(t, "token", cfg.Vault.Auth.Type)
+
+	// An invalid file is refused entirely
+	assert.NoError(t, os.WriteFile(path, []byte(`
+vault:
+  address: https://vault.example.com:8200
+  auth:
+    type: token
+    token: test-token
+includeNamespaces: ["(unclosed"]
+`), 0o600))
+	reloader.Reload()
+	assert.Equal(t, []string{"team-.*"}, reconciler.includeNamespaces())
+
+	// The VaultNamespaceControllerConfig still takes precedence
+	live.Set(&vaultv1alpha1.VaultNamespaceControllerConfigSpec{IncludeNamespaces: []string{"live-.*"}})
+	assert.Equal(t, []string{"live-.*"}, reconciler.includeNamespaces())
+}
+
+// TestConfigReloader_Watch tests that changes to the file are picked up.
+func TestConfigReloader_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(reloadTestConfig), 0o600))
+	cfg, err := config.LoadConfig(path)
+	assert.NoError(t, err)
+
+	live := &LiveConfig{}
+	reloader := &ConfigReloader{Path: path, Current: cfg, Live: live, Log: testr.New(t)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- reloader.Start(ctx) }()
+
+	assert.Eventually(t, func() bool {
+		// Rewrite until the watcher is set up and sees a change
+		_ = os.WriteFile(path, []byte(reloadTestConfig+"namespaceFormat: k8s-%s\n"), 0o600)
+		file := live.File()
+		return file != nil && file.NamespaceFormat == "k8s-%s"
+	}, 5*time.Second, 50*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+// TestConfigReloader_Overrides tests that command line flags keep precedence
+// over the reloaded file.
+func TestConfigReloader_Overrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(reloadTestConfig), 0o600))
+	cfg, err := config.LoadConfig(path)
+	assert.NoError(t, err)
+	overrides := config.FlagOverrides{IncludeNamespaces: []string{"flag-.*"}}
+	assert.NoError(t, overrides.Apply(cfg))
+
+	live := &LiveConfig{}
+	reloader := &ConfigReloader{Path: path, Current: cfg, Overrides: overrides, Live: live, Log: testr.New(t)}
+	reconciler := &NamespaceReconciler{Config: cfg, Live: live}
+
+	assert.NoError(t, os.WriteFile(path, []byte(reloadTestConfig+"namespaceFormat: k8s-%s\n"), 0o600))
+	reloader.Reload()
+	assert.Equal(t, "k8s-%s", reconciler.namespaceFormat())
+	assert.Equal(t, []string{"flag-.*"}, reconciler.includeNamespaces())
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+
+	"github.com/go-logr/logr"
+)
+
+// NewRemoteReconciler returns a reconciler synchronizing the namespaces of a
+// remote cluster into the same Vault as local. Their Vault namespaces are
+// created below a Vault namespace named after the cluster, and record the
+// cluster name in their ownership metadata.
+func NewRemoteReconciler(local *NamespaceReconciler, name string, remote cluster.Cluster) *NamespaceReconciler {
+	cfg := *local.Config
+	cfg.ClusterName = name
+	cfg.Vault.NamespaceRoot = joinNamespaceRoot(local.Config.Vault.NamespaceRoot, name)
+	cfg.RemoteClusters = nil
+	// Static mappings name namespaces of the local cluster
+	cfg.StaticMappings = nil
+
+	return &NamespaceReconciler{
+		Client:           remote.GetClient(),
+		Log:              local.Log.WithValues("cluster", name),
+		Scheme:           remote.GetScheme(),
+		VaultClient:      local.VaultClient,
+		Config:           &cfg,
+		Recorder:         remote.GetEventRecorderFor("vault-namespace-controller"),
+		Pause:            local.Pause,
+		Shard:            local.Shard,
+		APIReader:        remote.GetAPIReader(),
+		Mappings:         local.Mappings,
+		VaultNamespaces:  local.VaultNamespaces,
+		Classes:          local.Classes,
+		Blueprints:       local.Blueprints,
+		Live:             local.Live,
+		Hooks:            local.Hooks,
+		Liveness:         local.Liveness,
+		Audit:            local.Audit,
+		Notifications:    local.Notifications,
+		CloudEvents:      local.CloudEvents,
+		Limit:            local.namespaceLimit(),
+		clusterNamespace: cfg.Vault.NamespaceRoot,
+	}
+}
+
+// ensureClusterNamespace creates the Vault namespace a remote cluster's
+// namespaces are created below, checking Vault only until it has succeeded once.
+func (r *NamespaceReconciler) ensureClusterNamespace(ctx context.Context, namespaceName string, log logr.Logger) error {
+	// Namespaces routed to VaultConnections are checked every time, in their own Vault
+	defaultConnection := connectionFrom(ctx) == ""
+	if r.clusterNamespace == "" || (defaultConnection && r.clusterNamespaceReady.Load()) {
+		return nil
+	}
+	if err := r.ensureContainerNamespace(ctx, namespaceName, r.clusterNamespace, r.containerMetadata(), log); err != nil {
+		return err
+	}
+	if defaultConnection && !r.Config.DryRun {
+		r.clusterNamespaceReady.Store(true)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// fakeCluster is a cluster.Cluster serving a fake client.
+type fakeCluster struct {
+	cluster.Cluster
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func (c *fakeCluster) GetClient() client.Client    { return c.client }
+func (c *fakeCluster) GetAPIReader() client.Reader { return c.client }
+func (c *fakeCluster) GetScheme() *runtime.Scheme  { return c.scheme }
+func (c *fakeCluster) GetEventRecorderFor(string) record.EventRecorder {
+	return record.NewFakeRecorder(10)
+}
+
+// TestNewRemoteReconciler tests that a remote cluster's namespaces are created
+// below a Vault namespace named after the cluster, created once.
+func TestNewRemoteReconciler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	remote := &fakeCluster{
+		client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "orders"}},
+		).Build(),
+		scheme: scheme,
+	}
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "/admin/west").Return(false, nil).Once()
+	mockClient.On("CreateNamespace", mock.Anything, "/admin/west", map[string]string{
+		MetadataManagedBy:         "vault-namespace-controller",
+		MetadataKubernetesCluster: "west",
+	}).Return(nil).Once()
+	for _, name := range []string{"payments", "orders"} {
+		mockClient.On("NamespaceExists", mock.Anything, "/admin/west/"+name).Return(false, nil)
+		mockClient.On("CreateNamespace", mock.Anything, "/admin/west/"+name, map[string]string{
+			MetadataManagedBy:           "vault-namespace-controller",
+			MetadataKubernetesCluster:   "west",
+			MetadataKubernetesNamespace: name,
+		}).Return(nil)
+	}
+
+	local := &NamespaceReconciler{
+		Log:         testr.New(t),
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{
+			ClusterName:     "east",
+			NamespaceFormat: "%s",
+			Vault:           config.VaultConfig{NamespaceRoot: "/admin"},
+			RemoteClusters:  []config.RemoteClusterConfig{{Name: "west"}},
+		},
+	}
+	reconciler := NewRemoteReconciler(local, "west", remote)
+	reconciler.syncChecker = func(string) bool { return true }
+
+	// The local configuration is left untouched
+	assert.Equal(t, "east", local.Config.ClusterName)
+	assert.Equal(t, "/admin", local.Config.Vault.NamespaceRoot)
+	assert.Empty(t, reconciler.Config.RemoteClusters)
+
+	for _, name := range []string{"payments", "orders"} {
+		_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+		assert.NoError(t, err)
+	}
+	mockClient.AssertExpectations(t)
+}
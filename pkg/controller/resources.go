@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+)
+
+// VaultNamespaceResources maintains a cluster-scoped VaultNamespace resource per
+// synchronized namespace, mirroring its Vault namespace and state. A nil
+// VaultNamespaceResources maintains nothing.
+type VaultNamespaceResources struct {
+	// Client reads and writes VaultNamespaces in the local cluster, also for the
+	// namespaces of remote clusters.
+	Client client.Client
+}
+
+// vaultNamespaceName returns the name of the VaultNamespace resource of a
+// namespace. Remote clusters' resources are prefixed with the cluster name.
+func (r *NamespaceReconciler) vaultNamespaceName(namespaceName string) string {
+	if r.clusterNamespace == "" {
+		return namespaceName
+	}
+	return mappingKey(r.Config.ClusterName, namespaceName)
+}
+
+// Sync creates or updates the VaultNamespace of a namespace synchronized to
+// spec.Path, and marks it created and free of errors.
+func (v *VaultNamespaceResources) Sync(ctx context.Context, name string, spec vaultv1alpha1.VaultNamespaceSpec) error {
+	if v == nil {
+		return nil
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		resource := &vaultv1alpha1.VaultNamespace{}
+		err := v.Client.Get(ctx, types.NamespacedName{Name: name}, resource)
+		if k8serrors.IsNotFound(err) {
+			resource = &vaultv1alpha1.VaultNamespace{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec:       spec,
+			}
+			if err := v.Client.Create(ctx, resource); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		} else if !equalSpec(resource.Spec, spec) {
+			resource.Spec = spec
+			if err := v.Client.Update(ctx, resource); err != nil {
+				return err
+			}
+		}
+
+		now := metav1.Now()
+		changed := setCondition(resource, vaultv1alpha1.ConditionCreated, metav1.ConditionTrue, "Synchronized",
+			"Vault namespace exists")
+		changed = setCondition(resource, vaultv1alpha1.ConditionSynced, metav1.ConditionTrue, "Synchronized",
+			"Vault namespace is in sync") || changed
+		changed = setCondition(resource, vaultv1alpha1.ConditionError, metav1.ConditionFalse, "Synchronized", "") || changed
+		changed = setCondition(resource, vaultv1alpha1.ConditionDeletionPending, metav1.ConditionFalse, "NamespaceExists", "") || changed
+		if !changed && resource.Status.LastSyncTime != nil {
+			return nil
+		}
+		resource.Status.LastSyncTime = &now
+		return v.Client.Status().Update(ctx, resource)
+	})
+}
+
+// SetCondition sets a condition on the VaultNamespace called name. Namespaces
+// without a VaultNamespace yet are skipped.
+func (v *VaultNamespaceResources) SetCondition(ctx context.Context, name, conditionType string, status metav1.ConditionStatus, reason, message string) error {
+	if v == nil {
+		return nil
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		resource := &vaultv1alpha1.VaultNamespace{}
+		if err := v.Client.Get(ctx, types.NamespacedName{Name: name}, resource); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if !setCondition(resource, conditionType, status, reason, message) {
+			return nil
+		}
+		return v.Client.Status().Update(ctx, resource)
+	})
+}
+
+// Delete removes the VaultNamespace called name, if any.
+func (v *VaultNamespaceResources) Delete(ctx context.Context, name string) error {
+	if v == nil {
+		return nil
+	}
+	resource := &vaultv1alpha1.VaultNamespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	return client.IgnoreNotFound(v.Client.Delete(ctx, resource))
+}
+
+// setCondition sets a condition on resource and reports whether it changed.
+func setCondition(resource *vaultv1alpha1.VaultNamespace, conditionType string, status metav1.ConditionStatus, reason, message string) bool {
+	return meta.SetStatusCondition(&resource.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: resource.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// equalSpec reports whether two VaultNamespace specs are the same.
+func equalSpec(a, b vaultv1alpha1.VaultNamespaceSpec) bool {
+	if a.KubernetesNamespace != b.KubernetesNamespace || a.Cluster != b.Cluster ||
+		a.Path != b.Path || a.Parent != b.Parent || len(a.Metadata) != len(b.Metadata) {
+		return false
+	}
+	for key, value := range a.Metadata {
+		if b.Metadata[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// syncFailed records a failed synchronization in the Error and Synced
+// conditions of the namespace's VaultNamespace, and returns the result retrying it.
+func (r *NamespaceReconciler) syncFailed(ctx context.Context, namespaceName string, err error, log logr.Logger) ctrl.Result {
+	for _, condition := range []struct {
+		conditionType string
+		status        metav1.ConditionStatus
+	}{
+		{vaultv1alpha1.ConditionError, metav1.ConditionTrue},
+		{vaultv1alpha1.ConditionSynced, metav1.ConditionFalse},
+	} {
+		if statusErr := r.VaultNamespaces.SetCondition(ctx, r.vaultNamespaceName(namespaceName),
+			condition.conditionType, condition.status, "SyncFailed", err.Error()); statusErr != nil {
+			log.Error(statusErr, "Failed to update VaultNamespace status")
+		}
+	}
+	return r.retryResult(ctx, namespaceName, err, log)
+}
+
+// vaultNamespaceSpec returns the VaultNamespace spec of a namespace synchronized
+// to vaultNamespace.
+func (r *NamespaceReconciler) vaultNamespaceSpec(namespaceName, vaultNamespace string) vaultv1alpha1.VaultNamespaceSpec {
+	parent, _ := splitVaultPath(vaultNamespace)
+	return vaultv1alpha1.VaultNamespaceSpec{
+		KubernetesNamespace: namespaceName,
+		Cluster:             r.Config.ClusterName,
+		Path:                vaultNamespace,
+		Parent:              parent,
+		Metadata:            r.ownershipMetadata(namespaceName),
+	}
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestNamespaceReconciler_VaultNamespaceResources tests the lifecycle of the
+// VaultNamespace resource of a namespace.
+func TestNamespaceReconciler_VaultNamespaceResources(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = vaultv1alpha1.AddToScheme(scheme)
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithStatusSubresource(&vaultv1alpha1.VaultNamespace{}).
+		WithObjects(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}}).
+		Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "admin/app").Return(false, nil).Once()
+	mockClient.On("CreateNamespace", mock.Anything, "admin/app", ownedMetadata("app")).Return(nil).Once()
+
+	reconciler := &NamespaceReconciler{
+		Client:          k8sClient,
+		Log:             testr.New(t),
+		Scheme:          scheme,
+		VaultClient:     mockClient,
+		VaultNamespaces: &VaultNamespaceResources{Client: k8sClient},
+		Config: &config.ControllerConfig{
+			DeleteVaultNamespaces: true,
+			DeletionGracePeriod:   60,
+			Vault:                 config.VaultConfig{NamespaceRoot: "admin"},
+		},
+		syncChecker: func(string) bool { return true },
+	}
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+
+	resource := &vaultv1alpha1.VaultNamespace{}
+	assert.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Name: "app"}, resource))
+	assert.Equal(t, "admin/app", resource.Spec.Path)
+	assert.Equal(t, "admin", resource.Spec.Parent)
+	assert.Equal(t, ownedMetadata("app"), resource.Spec.Metadata)
+	assert.True(t, meta.IsStatusConditionTrue(resource.Status.Conditions, vaultv1alpha1.ConditionCreated))
+	assert.True(t, meta.IsStatusConditionTrue(resource.Status.Conditions, vaultv1alpha1.ConditionSynced))
+	assert.NotNil(t, resource.Status.LastSyncTime)
+
+	// A failed sync is reported in the Error condition
+	mockClient.On("NamespaceExists", mock.Anything, "admin/app").Return(false, errors.New("connection refused")).Once()
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Name: "app"}, resource))
+	condition := meta.FindStatusCondition(resource.Status.Conditions, vaultv1alpha1.ConditionError)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Contains(t, condition.Message, "connection refused")
+	synced := meta.FindStatusCondition(resource.Status.Conditions, vaultv1alpha1.ConditionSynced)
+	assert.Equal(t, metav1.ConditionFalse, synced.Status)
+	assert.Equal(t, condition.Message, synced.Message)
+
+	// The deletion grace period is reported, and the resource goes with the Vault namespace
+	assert.NoError(t, k8sClient.Delete(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}}))
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Name: "app"}, resource))
+	assert.True(t, meta.IsStatusConditionTrue(resource.Status.Conditions, vaultv1alpha1.ConditionDeletionPending))
+
+	reconciler.mu.Lock()
+	reconciler.pendingDeletions["app"] = time.Now().Add(-time.Second)
+	reconciler.mu.Unlock()
+	mockClient.On("NamespaceExists", mock.Anything, "admin/app").Return(false, nil).Once()
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	err = k8sClient.Get(ctx, types.NamespacedName{Name: "app"}, resource)
+	assert.True(t, k8serrors.IsNotFound(err))
+}
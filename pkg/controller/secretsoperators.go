@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/go-logr/logr"
+)
+
+// API groups and versions of the Vault Secrets Operator and External Secrets
+// Operator resources.
+var (
+	vsoGroupVersion = schema.GroupVersion{Group: "secrets.hashicorp.com", Version: "v1beta1"}
+	esoGroupVersion = schema.GroupVersion{Group: "external-secrets.io", Version: "v1beta1"}
+)
+
+// operatorLogin returns the Vault address, kubernetes auth method and role a
+// secrets operator logs in with.
+func (r *NamespaceReconciler) operatorLogin(operator config.SecretsOperatorConfig) (address, mount, role string) {
+	address, mount, role = operator.Address, strings.Trim(operator.Mount, "/"), operator.Role
+	if address == "" {
+		address = r.Config.Vault.Address
+	}
+	if mount == "" {
+		mount = r.Config.Bootstrap.KubernetesAuth.MountPath()
+	}
+	if role == "" {
+		role = r.Config.Bootstrap.KubernetesAuth.RoleName()
+	}
+	return address, mount, role
+}
+
+// syncVSOResources creates or updates the VaultConnection and VaultAuth of the
+// Vault Secrets Operator in namespaceName, pointed at vaultNamespace, so
+// workloads can consume its secrets through VSO without further setup.
+func (r *NamespaceReconciler) syncVSOResources(ctx context.Context, namespaceName, vaultNamespace string, log logr.Logger) error {
+	vso := r.Config.VSO
+	if !vso.Enabled {
+		return nil
+	}
+	address, mount, role := r.operatorLogin(vso)
+
+	connection := map[string]interface{}{"address": address}
+	if vso.CACertSecret != "" {
+		connection["caCertSecretRef"] = vso.CACertSecret
+	}
+	if err := r.applyResource(ctx, vsoGroupVersion.WithKind("VaultConnection"), namespaceName, vso.ResourceName(), connection, log); err != nil {
+		return err
+	}
+	return r.applyResource(ctx, vsoGroupVersion.WithKind("VaultAuth"), namespaceName, vso.ResourceName(), map[string]interface{}{
+		"vaultConnectionRef": vso.ResourceName(),
+		"namespace":          strings.Trim(vaultNamespace, "/"),
+		"method":             "kubernetes",
+		"mount":              mount,
+		"kubernetes": map[string]interface{}{
+			"role":           role,
+			"serviceAccount": vso.ServiceAccountName(),
+		},
+	}, log)
+}
+
+// syncESOResources creates or updates the SecretStore of the External Secrets
+// Operator in namespaceName, reading the kv-v2 secrets engine of vaultNamespace.
+func (r *NamespaceReconciler) syncESOResources(ctx context.Context, namespaceName, vaultNamespace string, log logr.Logger) error {
+	eso := r.Config.ESO
+	if !eso.Enabled {
+		return nil
+	}
+	address, mount, role := r.operatorLogin(eso.SecretsOperatorConfig)
+	kvMount := strings.Trim(eso.KVMount, "/")
+	if kvMount == "" {
+		kvMount = strings.Trim(r.Config.Bootstrap.KVMount, "/")
+	}
+	if kvMount == "" {
+		kvMount = "secret"
+	}
+
+	provider := map[string]interface{}{
+		"server":    address,
+		"path":      kvMount,
+		"version":   "v2",
+		"namespace": strings.Trim(vaultNamespace, "/"),
+		"auth": map[string]interface{}{
+			"kubernetes": map[string]interface{}{
+				"mountPath":         mount,
+				"role":              role,
+				"serviceAccountRef": map[string]interface{}{"name": eso.ServiceAccountName()},
+			},
+		},
+	}
+	if eso.CACertSecret != "" {
+		provider["caProvider"] = map[string]interface{}{"type": "Secret", "name": eso.CACertSecret, "key": "ca.crt"}
+	}
+	return r.applyResource(ctx, esoGroupVersion.WithKind("SecretStore"), namespaceName, eso.ResourceName(),
+		map[string]interface{}{"provider": map[string]interface{}{"vault": provider}}, log)
+}
+
+// applyResource creates the resource of gvk called name in namespaceName with
+// spec, or sets the fields of spec on it when they differ. Fields its operator
+// defaults, or that were added to the resource, are left alone.
+func (r *NamespaceReconciler) applyResource(ctx context.Context, gvk schema.GroupVersionKind, namespaceName, name string, spec map[string]interface{}, log logr.Logger) error {
+	resource := &unstructured.Unstructured{}
+	resource.SetGroupVersionKind(gvk)
+	key := types.NamespacedName{Namespace: namespaceName, Name: name}
+	err := r.Client.Get(ctx, key, resource)
+	if k8serrors.IsNotFound(err) {
+		resource.SetNamespace(key.Namespace)
+		resource.SetName(key.Name)
+		resource.SetLabels(map[string]string{"app.kubernetes.io/managed-by": managedByValue})
+		resource.Object["spec"] = spec
+		log.Info("Creating secrets operator resource", "kind", gvk.Kind, "name", key.Name)
+		return r.Client.Create(ctx, resource)
+	} else if err != nil {
+		return err
+	}
+
+	existing, _ := resource.Object["spec"].(map[string]interface{})
+	if existing == nil {
+		existing = make(map[string]interface{})
+	}
+	if !mergeSpec(existing, spec) {
+		return nil
+	}
+	resource.Object["spec"] = existing
+	log.Info("Updating secrets operator resource", "kind", gvk.Kind, "name", key.Name)
+	return r.Client.Update(ctx, resource)
+}
+
+// mergeSpec sets the fields of want on spec, descending into nested objects,
+// and reports whether any changed.
+func mergeSpec(spec, want map[string]interface{}) bool {
+	changed := false
+	for key, value := range want {
+		if nested, ok := value.(map[string]interface{}); ok {
+			existing, ok := spec[key].(map[string]interface{})
+			if !ok {
+				existing = make(map[string]interface{})
+				spec[key] = existing
+				changed = true
+			}
+			changed = mergeSpec(existing, nested) || changed
+			continue
+		}
+		if !equality.Semantic.DeepEqual(spec[key], value) {
+			spec[key] = value
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestNamespaceReconciler_VSOResources tests that a synchronized namespace gets
+// a VaultConnection and VaultAuth pointed at its Vault namespace.
+func TestNamespaceReconciler_VSOResources(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "admin/app").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "admin/app").Return(ownedMetadata("app"), nil)
+
+	reconciler := &NamespaceReconciler{
+		Client:      k8sClient,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{
+			Vault:           config.VaultConfig{Address: "https://vault.example.com:8200"},
+			NamespaceFormat: "admin/%s",
+			Bootstrap: config.BootstrapConfig{KubernetesAuth: config.KubernetesAuthBootstrapConfig{
+				Mount: "k8s",
+				Role:  "app",
+			}},
+			VSO: config.SecretsOperatorConfig{Enabled: true, CACertSecret: "vault-ca"},
+		},
+		syncChecker: func(string) bool { return true },
+	}
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+
+	connection := operatorResource(t, k8sClient, vsoGroupVersion.WithKind("VaultConnection"))
+	assert.Equal(t, map[string]interface{}{
+		"address":         "https://vault.example.com:8200",
+		"caCertSecretRef": "vault-ca",
+	}, connection.Object["spec"])
+	auth := operatorResource(t, k8sClient, vsoGroupVersion.WithKind("VaultAuth"))
+	assert.Equal(t, map[string]interface{}{
+		"vaultConnectionRef": "vault",
+		"namespace":          "admin/app",
+		"method":             "kubernetes",
+		"mount":              "k8s",
+		"kubernetes":         map[string]interface{}{"role": "app", "serviceAccount": "default"},
+	}, auth.Object["spec"])
+
+	// Fields set by VSO or by hand are kept, and ours are restored
+	spec := auth.Object["spec"].(map[string]interface{})
+	spec["kubernetes"].(map[string]interface{})["tokenExpirationSeconds"] = int64(600)
+	spec["mount"] = "kubernetes"
+	assert.NoError(t, k8sClient.Update(ctx, auth))
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	auth = operatorResource(t, k8sClient, vsoGroupVersion.WithKind("VaultAuth"))
+	kubernetes := auth.Object["spec"].(map[string]interface{})["kubernetes"].(map[string]interface{})
+	assert.Equal(t, "k8s", auth.Object["spec"].(map[string]interface{})["mount"])
+	assert.EqualValues(t, 600, kubernetes["tokenExpirationSeconds"])
+}
+
+// TestNamespaceReconciler_ESOSecretStore tests that a synchronized namespace
+// gets a SecretStore reading its Vault namespace.
+func TestNamespaceReconciler_ESOSecretStore(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+	).Build()
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "app").Return(true, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "app").Return(ownedMetadata("app"), nil)
+
+	reconciler := &NamespaceReconciler{
+		Client:      k8sClient,
+		Log:         testr.New(t),
+		Scheme:      scheme,
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{
+			Vault:     config.VaultConfig{Address: "https://vault.example.com:8200"},
+			Bootstrap: config.BootstrapConfig{KVMount: "kv"},
+			ESO: config.ESOConfig{SecretsOperatorConfig: config.SecretsOperatorConfig{
+				Enabled:        true,
+				CACertSecret:   "vault-ca",
+				ServiceAccount: "eso",
+			}},
+		},
+		syncChecker: func(string) bool { return true },
+	}
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "app"}})
+	assert.NoError(t, err)
+
+	store := operatorResource(t, k8sClient, esoGroupVersion.WithKind("SecretStore"))
+	assert.Equal(t, map[string]interface{}{
+		"server":     "https://vault.example.com:8200",
+		"path":       "kv",
+		"version":    "v2",
+		"namespace":  "app",
+		"caProvider": map[string]interface{}{"type": "Secret", "name": "vault-ca", "key": "ca.crt"},
+		"auth": map[string]interface{}{"kubernetes": map[string]interface{}{
+			"mountPath":         "kubernetes",
+			"role":              "default",
+			"serviceAccountRef": map[string]interface{}{"name": "eso"},
+		}},
+	}, store.Object["spec"].(map[string]interface{})["provider"].(map[string]interface{})["vault"])
+}
+
+// operatorResource returns the resource of gvk called vault in the namespace "app".
+func operatorResource(t *testing.T, k8sClient client.Client, gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	resource := &unstructured.Unstructured{}
+	resource.SetGroupVersionKind(gvk)
+	assert.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Namespace: "app", Name: "vault"}, resource))
+	return resource
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/go-logr/logr"
+)
+
+// applySentinelPolicies writes the configured Sentinel policies into
+// vaultNamespace. Policies already written unchanged are skipped.
+func (r *NamespaceReconciler) applySentinelPolicies(ctx context.Context, namespace metav1.Object, vaultNamespace string, log logr.Logger) error {
+	key := appliedKey(ctx, vaultNamespace)
+	for _, policy := range r.Config.SentinelPolicies {
+		name := policy.Type + "/" + policy.Name
+		applied := fmt.Sprintf("%s|%s|%s", policy.Level(), strings.Join(policy.Paths, ","), policy.Policy)
+
+		r.mu.Lock()
+		written := r.appliedSentinel[key][name] == applied
+		r.mu.Unlock()
+		if written {
+			continue
+		}
+		if r.skipForDryRun(namespace.GetName(), "provision", vaultNamespace, log) {
+			return nil
+		}
+		log.V(1).Info("Writing Sentinel policy", "type", policy.Type, "policy", policy.Name)
+		if err := r.VaultClient.PutSentinelPolicy(ctx, vaultNamespace, policy.Type, policy.Name,
+			policy.Policy, policy.Level(), policy.Paths); err != nil {
+			return err
+		}
+
+		r.mu.Lock()
+		if r.appliedSentinel == nil {
+			r.appliedSentinel = make(map[string]map[string]string)
+		}
+		if r.appliedSentinel[key] == nil {
+			r.appliedSentinel[key] = make(map[string]string)
+		}
+		r.appliedSentinel[key][name] = applied
+		r.mu.Unlock()
+	}
+	return nil
+}
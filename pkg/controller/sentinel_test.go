@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+func TestNamespaceReconciler_ApplySentinelPolicies(t *testing.T) {
+	mockClient := new(mockVaultClient)
+	mockClient.On("PutSentinelPolicy", mock.Anything, "app", "egp", "business-hours",
+		"main = rule { true }", "soft-mandatory", []string{"*"}).Return(nil).Once()
+	mockClient.On("PutSentinelPolicy", mock.Anything, "app", "rgp", "business-hours",
+		"main = rule { false }", "hard-mandatory", []string(nil)).Return(nil).Once()
+
+	reconciler := &NamespaceReconciler{
+		VaultClient: mockClient,
+		Config: &config.ControllerConfig{SentinelPolicies: []config.SentinelPolicy{
+			{Name: "business-hours", Type: "egp", Paths: []string{"*"}, EnforcementLevel: "soft-mandatory", Policy: "main = rule { true }"},
+			{Name: "business-hours", Type: "rgp", Policy: "main = rule { false }"},
+		}},
+	}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}}
+	ctx := context.Background()
+
+	assert.NoError(t, reconciler.applySentinelPolicies(ctx, namespace, "app", testr.New(t)))
+	mockClient.AssertExpectations(t)
+
+	// Unchanged policies are not written again
+	assert.NoError(t, reconciler.applySentinelPolicies(ctx, namespace, "app", testr.New(t)))
+	mockClient.AssertNumberOfCalls(t, "PutSentinelPolicy", 2)
+
+	// A recreated Vault namespace gets them again
+	mockClient.On("PutSentinelPolicy", mock.Anything, "app", mock.Anything, "business-hours",
+		mock.Anything, mock.Anything, mock.Anything).Return(nil).Twice()
+	reconciler.forgetApplied(ctx, "app")
+	assert.NoError(t, reconciler.applySentinelPolicies(ctx, namespace, "app", testr.New(t)))
+	mockClient.AssertNumberOfCalls(t, "PutSentinelPolicy", 4)
+}
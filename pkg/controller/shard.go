@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Environment variables configuring active-active sharding.
+const (
+	// ShardTotalEnv is the number of shards. Sharding is enabled when it is set.
+	ShardTotalEnv = "SHARD_TOTAL"
+	// ShardIndexEnv is this replica's shard, from 0 to SHARD_TOTAL-1.
+	ShardIndexEnv = "SHARD_INDEX"
+	// PodNameEnv is used to derive the shard index from a StatefulSet pod
+	// ordinal when SHARD_INDEX is not set.
+	PodNameEnv = "POD_NAME"
+)
+
+// ErrInvalidShard is returned when the sharding environment is inconsistent.
+var ErrInvalidShard = errors.New("invalid shard configuration")
+
+// Shard is the slice of namespaces a replica is responsible for when several
+// replicas run active-active. Each namespace belongs to exactly one shard,
+// chosen by hashing its name.
+type Shard struct {
+	Index int
+	Total int
+}
+
+// ShardFromEnv reads the shard from the environment. It returns nil when
+// sharding is not configured.
+func ShardFromEnv() (*Shard, error) {
+	totalValue := os.Getenv(ShardTotalEnv)
+	if totalValue == "" {
+		return nil, nil
+	}
+	total, err := strconv.Atoi(totalValue)
+	if err != nil || total < 1 {
+		return nil, fmt.Errorf("%w: %s must be a positive integer, got %q", ErrInvalidShard, ShardTotalEnv, totalValue)
+	}
+
+	indexValue := os.Getenv(ShardIndexEnv)
+	if indexValue == "" {
+		// StatefulSet pods are named <name>-<ordinal>
+		podName := os.Getenv(PodNameEnv)
+		indexValue = podName[strings.LastIndex(podName, "-")+1:]
+	}
+	index, err := strconv.Atoi(indexValue)
+	if err != nil || index < 0 || index >= total {
+		return nil, fmt.Errorf("%w: shard index must be between 0 and %d, got %q", ErrInvalidShard, total-1, indexValue)
+	}
+
+	return &Shard{Index: index, Total: total}, nil
+}
+
+// Owns reports whether the Kubernetes namespace belongs to this shard. A nil
+// Shard owns every namespace.
+func (s *Shard) Owns(namespaceName string) bool {
+	if s == nil || s.Total <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(namespaceName))
+	return int(h.Sum32()%uint32(s.Total)) == s.Index
+}
+
+// String returns the shard as index/total.
+func (s *Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Total)
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestShardFromEnv tests reading the shard from the environment.
+func TestShardFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		total       string
+		index       string
+		podName     string
+		expected    *Shard
+		expectedErr error
+	}{
+		{
+			name:     "sharding disabled",
+			expected: nil,
+		},
+		{
+			name:     "explicit index",
+			total:    "3",
+			index:    "2",
+			expected: &Shard{Index: 2, Total: 3},
+		},
+		{
+			name:     "index from StatefulSet pod name",
+			total:    "3",
+			podName:  "vault-namespace-controller-1",
+			expected: &Shard{Index: 1, Total: 3},
+		},
+		{
+			name:        "index out of range",
+			total:       "3",
+			index:       "3",
+			expectedErr: ErrInvalidShard,
+		},
+		{
+			name:        "invalid total",
+			total:       "none",
+			expectedErr: ErrInvalidShard,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ShardTotalEnv, tt.total)
+			t.Setenv(ShardIndexEnv, tt.index)
+			t.Setenv(PodNameEnv, tt.podName)
+
+			shard, err := ShardFromEnv()
+			if tt.expectedErr != nil {
+				assert.True(t, errors.Is(err, tt.expectedErr))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, shard)
+		})
+	}
+}
+
+// TestShard_Owns tests that every namespace belongs to exactly one shard.
+func TestShard_Owns(t *testing.T) {
+	var noShard *Shard
+	assert.True(t, noShard.Owns("any"))
+
+	shards := []*Shard{{Index: 0, Total: 3}, {Index: 1, Total: 3}, {Index: 2, Total: 3}}
+	counts := make([]int, len(shards))
+	for i := 0; i < 300; i++ {
+		name := fmt.Sprintf("ns-%d", i)
+		owners := 0
+		for j, shard := range shards {
+			if shard.Owns(name) {
+				owners++
+				counts[j]++
+			}
+		}
+		assert.Equal(t, 1, owners, name)
+	}
+	for _, count := range counts {
+		assert.Greater(t, count, 50)
+	}
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+	"github.com/go-logr/logr"
+)
+
+// startupSync tracks how many of the namespaces present at startup have been
+// reconciled at least once, successfully or not, so operators can see when the
+// initial bulk sync has finished.
+type startupSync struct {
+	mu       sync.Mutex
+	started  time.Time
+	total    int
+	pending  map[string]struct{}
+	early    map[string]struct{}
+	reported int
+	complete bool
+	finished time.Time
+}
+
+// expect records the namespaces the initial sync has to cover. Namespaces
+// reconciled before the list was taken are already counted as done.
+func (s *startupSync) expect(names []string, log logr.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.started = time.Now()
+	s.total = len(names)
+	s.pending = make(map[string]struct{}, len(names))
+	for _, name := range names {
+		if _, ok := s.early[name]; !ok {
+			s.pending[name] = struct{}{}
+		}
+	}
+	s.early = nil
+
+	log.Info("Starting initial sync", "namespaces", s.total)
+	s.progress(log)
+}
+
+// done marks name as reconciled, whatever the outcome.
+func (s *startupSync) done(name string, log logr.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.complete {
+		return
+	}
+	if s.pending == nil {
+		if s.early == nil {
+			s.early = make(map[string]struct{})
+		}
+		s.early[name] = struct{}{}
+		return
+	}
+	if _, ok := s.pending[name]; !ok {
+		return
+	}
+	delete(s.pending, name)
+	s.progress(log)
+}
+
+// progress logs every tenth of the initial sync and records completion.
+// The caller must hold s.mu.
+func (s *startupSync) progress(log logr.Logger) {
+	synced := s.total - len(s.pending)
+	if len(s.pending) == 0 {
+		s.complete = true
+		s.finished = time.Now()
+		duration := s.finished.Sub(s.started)
+		metrics.InitialSyncComplete.Set(1)
+		metrics.InitialSyncDuration.Set(duration.Seconds())
+		log.Info("Initial sync complete", "namespaces", s.total, "duration", duration.Round(time.Millisecond).String())
+		return
+	}
+
+	step := s.total / 10
+	if step == 0 {
+		step = 1
+	}
+	if synced/step > s.reported {
+		s.reported = synced / step
+		log.Info("Initial sync progress", "synced", synced, "total", s.total)
+	}
+}
+
+// completedAt returns when the initial sync finished, or the zero time.
+func (s *startupSync) completedAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.finished
+}
+
+// StartupSync lists the namespaces present when the controller starts leading
+// and reports progress as the reconciler works through them.
+type StartupSync struct {
+	Reconciler *NamespaceReconciler
+	Log        logr.Logger
+}
+
+// Start records the namespaces the initial sync has to cover.
+func (s *StartupSync) Start(ctx context.Context) error {
+	r := s.Reconciler
+
+	nsList := newNamespaceMetadataList()
+	if err := r.List(ctx, nsList); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(nsList.Items))
+	for _, ns := range nsList.Items {
+		if r.Shard.Owns(ns.Name) && r.shouldSyncNamespace(&ns) {
+			names = append(names, ns.Name)
+		}
+	}
+	r.startup.expect(names, s.Log)
+	return nil
+}
+
+// NeedLeaderElection reports that the initial sync is tracked by the leader,
+// which is the only replica reconciling.
+func (s *StartupSync) NeedLeaderElection() bool {
+	return true
+}
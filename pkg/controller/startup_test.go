@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+)
+
+// TestStartupSync tests tracking of the initial bulk sync.
+func TestStartupSync(t *testing.T) {
+	log := testr.New(t)
+	metrics.InitialSyncComplete.Set(0)
+
+	var s startupSync
+	// Reconciles can finish before the startup list is taken
+	s.done("ns-a", log)
+	s.expect([]string{"ns-a", "ns-b", "ns-c"}, log)
+	assert.False(t, s.complete)
+
+	s.done("ns-b", log)
+	s.done("unknown", log)
+	assert.False(t, s.complete)
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.InitialSyncComplete))
+
+	s.done("ns-c", log)
+	assert.True(t, s.complete)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.InitialSyncComplete))
+}
+
+// TestStartupSync_Failing tests a namespace whose reconciles keep failing still
+// completes the initial sync once it has been reconciled.
+func TestStartupSync_Failing(t *testing.T) {
+	metrics.InitialSyncComplete.Set(0)
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	mockClient := new(mockVaultClient)
+	mockClient.On("NamespaceExists", mock.Anything, "k8s-app").Return(false, errors.New("connection refused"))
+	reconciler := &NamespaceReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+		).Build(),
+		Log:         testr.New(t),
+		VaultClient: mockClient,
+		Recorder:    record.NewFakeRecorder(10),
+		Config:      &config.ControllerConfig{NamespaceFormat: "k8s-%s"},
+	}
+	assert.NoError(t, (&StartupSync{Reconciler: reconciler, Log: testr.New(t)}).Start(context.Background()))
+	assert.True(t, reconciler.startup.completedAt().IsZero())
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "app"}}
+	result, err := reconciler.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Positive(t, result.RequeueAfter)
+	assert.False(t, reconciler.startup.completedAt().IsZero())
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.InitialSyncComplete))
+}
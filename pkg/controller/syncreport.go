@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+)
+
+// maxRecentFailures bounds the failures listed in a sync report.
+const maxRecentFailures = 10
+
+// recordFailure adds a failed reconcile of the Kubernetes namespace name to the
+// recent failures, replacing its previous failure.
+func (r *NamespaceReconciler) recordFailure(name string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.recentFailures = append(removeFailure(r.recentFailures, name), vaultv1alpha1.SyncFailure{
+		KubernetesNamespace: name,
+		Message:             err.Error(),
+		Time:                metav1.Now(),
+	})
+	if r.onceFailures != nil {
+		r.onceFailures[name] = err.Error()
+	}
+	if len(r.recentFailures) > maxRecentFailures {
+		r.recentFailures = r.recentFailures[len(r.recentFailures)-maxRecentFailures:]
+	}
+}
+
+// removeFailure returns failures without the failure of the Kubernetes namespace name.
+func removeFailure(failures []vaultv1alpha1.SyncFailure, name string) []vaultv1alpha1.SyncFailure {
+	for i, failure := range failures {
+		if failure.KubernetesNamespace == name {
+			return append(failures[:i:i], failures[i+1:]...)
+		}
+	}
+	return failures
+}
+
+// recordFullScan records that every namespace was checked against Vault at t.
+func (r *NamespaceReconciler) recordFullScan(t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastFullScan = t
+}
+
+// syncState returns a copy of the recent failures and when every namespace was
+// last checked against Vault, by the initial sync or a drift scan.
+func (r *NamespaceReconciler) syncState() ([]vaultv1alpha1.SyncFailure, time.Time) {
+	lastFullScan := r.startup.completedAt()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lastFullScan.After(lastFullScan) {
+		lastFullScan = r.lastFullScan
+	}
+	return append([]vaultv1alpha1.SyncFailure(nil), r.recentFailures...), lastFullScan
+}
+
+// SyncReportName returns the name of the VaultNamespaceSyncReport of a cluster,
+// suffixed with the shard when sharding is enabled.
+func SyncReportName(clusterName string, shard *Shard) string {
+	name := clusterName
+	if name == "" {
+		name = "default"
+	}
+	if shard != nil && shard.Total > 1 {
+		name = fmt.Sprintf("%s-shard-%d", name, shard.Index)
+	}
+	return name
+}
+
+// SyncReporter periodically publishes a VaultNamespaceSyncReport summarizing
+// the synchronization of the reconciler's namespaces.
+type SyncReporter struct {
+	Reconciler *NamespaceReconciler
+	// Client writes the report in the local cluster, also for remote clusters.
+	Client client.Client
+	// Name is the name of the report.
+	Name string
+	Log  logr.Logger
+}
+
+// Start publishes the report immediately and then every SyncReportInterval until ctx is cancelled.
+func (s *SyncReporter) Start(ctx context.Context) error {
+	interval := time.Duration(s.Reconciler.Config.SyncReportInterval) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Publish(ctx); err != nil {
+			s.Log.Error(err, "Failed to publish sync report")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection reports that only the leader, which does the reconciling, publishes the report.
+func (s *SyncReporter) NeedLeaderElection() bool {
+	return true
+}
+
+// Publish builds the report and creates or updates the VaultNamespaceSyncReport.
+func (s *SyncReporter) Publish(ctx context.Context) error {
+	status, err := s.Reconciler.buildSyncReport(ctx)
+	if err != nil {
+		return err
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		report := &vaultv1alpha1.VaultNamespaceSyncReport{}
+		err := s.Client.Get(ctx, types.NamespacedName{Name: s.Name}, report)
+		if k8serrors.IsNotFound(err) {
+			report = &vaultv1alpha1.VaultNamespaceSyncReport{ObjectMeta: metav1.ObjectMeta{Name: s.Name}}
+			if err := s.Client.Create(ctx, report); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+
+		conditions := report.Status.Conditions
+		report.Status = *status.DeepCopy()
+		report.Status.Conditions = conditions
+		healthy := meta.FindStatusCondition(status.Conditions, vaultv1alpha1.ConditionHealthy)
+		meta.SetStatusCondition(&report.Status.Conditions, *healthy)
+
+		s.Log.V(2).Info("Publishing sync report", "report", s.Name,
+			"managed", status.Managed, "pending", status.Pending, "orphaned", status.Orphaned)
+		return s.Client.Status().Update(ctx, report)
+	})
+}
+
+// buildSyncReport counts the reconciler's namespaces and orphaned Vault
+// namespaces, and lists its recent failures.
+func (r *NamespaceReconciler) buildSyncReport(ctx context.Context) (*vaultv1alpha1.VaultNamespaceSyncReportStatus, error) {
+	counts, err := r.countNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orphans, err := r.findOrphans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	failures, lastFullScan := r.syncState()
+
+	now := metav1.Now()
+	status := &vaultv1alpha1.VaultNamespaceSyncReportStatus{
+		Cluster:        r.Config.ClusterName,
+		Managed:        int32(counts.managed),
+		Excluded:       int32(counts.excluded),
+		Synced:         int32(counts.synced),
+		Pending:        int32(counts.pending),
+		Orphaned:       int32(len(orphans)),
+		RecentFailures: failures,
+		LastReportTime: &now,
+	}
+	if r.Shard != nil && r.Shard.Total > 1 {
+		status.Shard = r.Shard.String()
+	}
+	if !lastFullScan.IsZero() {
+		status.LastFullScanTime = &metav1.Time{Time: lastFullScan}
+	}
+
+	healthy := metav1.Condition{
+		Type:    vaultv1alpha1.ConditionHealthy,
+		Status:  metav1.ConditionTrue,
+		Reason:  "InSync",
+		Message: "All managed namespaces have their Vault namespace",
+	}
+	switch {
+	case len(failures) > 0:
+		healthy.Status = metav1.ConditionFalse
+		healthy.Reason = "SyncFailed"
+		healthy.Message = fmt.Sprintf("%d namespaces failed to synchronize", len(failures))
+	case counts.pending > 0:
+		healthy.Status = metav1.ConditionFalse
+		healthy.Reason = "Pending"
+		healthy.Message = fmt.Sprintf("%d namespaces are waiting for their Vault namespace", counts.pending)
+	}
+	meta.SetStatusCondition(&status.Conditions, healthy)
+	return status, nil
+}
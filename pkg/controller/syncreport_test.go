@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	vaultv1alpha1 "github.com/benemon/vault-namespace-controller/api/v1alpha1"
+	"github.com/benemon/vault-namespace-controller/pkg/config"
+)
+
+// TestNamespaceReconciler_recordFailure tests the bounded list of recent failures.
+func TestNamespaceReconciler_recordFailure(t *testing.T) {
+	r := &NamespaceReconciler{}
+
+	for i := 0; i < maxRecentFailures+2; i++ {
+		r.recordFailure(fmt.Sprintf("app-%d", i), errors.New("vault unavailable"))
+	}
+	r.recordFailure("app-5", errors.New("permission denied"))
+
+	failures, _ := r.syncState()
+	assert.Len(t, failures, maxRecentFailures)
+	assert.Equal(t, "app-2", failures[0].KubernetesNamespace)
+	assert.Equal(t, "app-5", failures[len(failures)-1].KubernetesNamespace)
+	assert.Equal(t, "permission denied", failures[len(failures)-1].Message)
+
+	r.resetBackoff("app-5")
+	failures, _ = r.syncState()
+	assert.Len(t, failures, maxRecentFailures-1)
+	for _, failure := range failures {
+		assert.NotEqual(t, "app-5", failure.KubernetesNamespace)
+	}
+}
+
+// TestSyncReportName tests naming reports by cluster and shard.
+func TestSyncReportName(t *testing.T) {
+	assert.Equal(t, "default", SyncReportName("", nil))
+	assert.Equal(t, "west", SyncReportName("west", &Shard{Index: 0, Total: 1}))
+	assert.Equal(t, "west-shard-2", SyncReportName("west", &Shard{Index: 2, Total: 3}))
+}
+
+// TestSyncReporter_Publish tests publishing and updating the report.
+func TestSyncReporter_Publish(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = vaultv1alpha1.AddToScheme(scheme)
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-b"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+	).WithStatusSubresource(&vaultv1alpha1.VaultNamespaceSyncReport{}).Build()
+
+	// app-b is pending and the Vault namespace of the deleted "gone" is orphaned
+	mockClient := new(mockVaultClient)
+	mockClient.On("ListNamespaces", mock.Anything, "").Return([]string{"k8s-app-a", "k8s-gone"}, nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-app-a").Return(ownedMetadata("app-a"), nil)
+	mockClient.On("GetNamespaceMetadata", mock.Anything, "k8s-gone").Return(ownedMetadata("gone"), nil)
+
+	r := &NamespaceReconciler{
+		Client:      fakeClient,
+		Log:         testr.New(t),
+		VaultClient: mockClient,
+		Config:      &config.ControllerConfig{NamespaceFormat: "k8s-%s"},
+		syncChecker: func(name string) bool { return name != "kube-system" },
+	}
+	reporter := &SyncReporter{Reconciler: r, Client: fakeClient, Name: "default", Log: testr.New(t)}
+	ctx := context.Background()
+
+	scanned := time.Now().Add(-time.Minute).Truncate(time.Second)
+	r.recordFullScan(scanned)
+	r.recordFailure("app-b", errors.New("vault unavailable"))
+
+	assert.NoError(t, reporter.Publish(ctx))
+	report := &vaultv1alpha1.VaultNamespaceSyncReport{}
+	assert.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: "default"}, report))
+	assert.Equal(t, int32(2), report.Status.Managed)
+	assert.Equal(t, int32(1), report.Status.Excluded)
+	assert.Equal(t, int32(1), report.Status.Synced)
+	assert.Equal(t, int32(1), report.Status.Pending)
+	assert.Equal(t, int32(1), report.Status.Orphaned)
+	if assert.Len(t, report.Status.RecentFailures, 1) {
+		assert.Equal(t, "app-b", report.Status.RecentFailures[0].KubernetesNamespace)
+	}
+	if assert.NotNil(t, report.Status.LastFullScanTime) {
+		assert.True(t, scanned.Equal(report.Status.LastFullScanTime.Time))
+	}
+	healthy := meta.FindStatusCondition(report.Status.Conditions, vaultv1alpha1.ConditionHealthy)
+	if assert.NotNil(t, healthy) {
+		assert.Equal(t, metav1.ConditionFalse, healthy.Status)
+		assert.Equal(t, "SyncFailed", healthy.Reason)
+	}
+
+	// Once the failure clears, only the pending namespace keeps it unhealthy
+	r.resetBackoff("app-b")
+	assert.NoError(t, reporter.Publish(ctx))
+	assert.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: "default"}, report))
+	assert.Empty(t, report.Status.RecentFailures)
+	assert.Equal(t, "Pending", meta.FindStatusCondition(report.Status.Conditions, vaultv1alpha1.ConditionHealthy).Reason)
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+	"github.com/go-logr/logr"
+)
+
+// tokenTTLReportInterval is how often the token TTL is looked up without a
+// threshold, only to export it.
+const tokenTTLReportInterval = time.Minute
+
+// TokenTTLMonitor watches the remaining TTL of the controller's Vault token
+// and exports it. Once it falls below Threshold the token is renewed, and
+// while renewing fails to lift it above Threshold the controller reports not
+// ready, before every Vault operation starts failing on an expired token. A
+// zero Threshold only exports the TTL.
+type TokenTTLMonitor struct {
+	Client    vault.TokenRenewer
+	Threshold time.Duration
+	Log       logr.Logger
+
+	mu       sync.Mutex
+	expiring error
+}
+
+// Start checks the token immediately and then every minute, or twice per
+// Threshold when that is shorter, until ctx is cancelled.
+func (m *TokenTTLMonitor) Start(ctx context.Context) error {
+	interval := tokenTTLReportInterval
+	if m.Threshold > 0 {
+		interval = min(m.Threshold/2, interval)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection reports that every replica watches its own token.
+func (m *TokenTTLMonitor) NeedLeaderElection() bool {
+	return false
+}
+
+// Check looks up the token TTL and renews the token when it is below
+// Threshold. A token without a TTL never expires.
+func (m *TokenTTLMonitor) Check(ctx context.Context) {
+	ttl, err := m.Client.GetTokenTTL()
+	if err != nil {
+		// Vault being unreachable is reported by the vault readiness check
+		m.Log.Error(err, "Failed to look up Vault token TTL")
+		return
+	}
+	metrics.VaultTokenTTL.Set(float64(ttl))
+	if m.Threshold == 0 {
+		return
+	}
+	if ttl == 0 || time.Duration(ttl)*time.Second >= m.Threshold {
+		m.setExpiring(nil)
+		return
+	}
+
+	m.Log.Info("Vault token TTL is below the threshold, renewing it",
+		"ttl", ttl, "threshold", m.Threshold.String())
+	if err := m.Client.RenewToken(ctx); err != nil {
+		m.setExpiring(fmt.Errorf("vault token expires in %ds and renewing it failed: %w", ttl, err))
+		return
+	}
+	if ttl, err = m.Client.GetTokenTTL(); err != nil {
+		m.Log.Error(err, "Failed to look up Vault token TTL")
+		return
+	}
+	metrics.VaultTokenTTL.Set(float64(ttl))
+	if time.Duration(ttl)*time.Second < m.Threshold {
+		// Renewals are capped by the token's max TTL
+		m.setExpiring(fmt.Errorf("vault token expires in %ds, renewing it did not extend it beyond %s", ttl, m.Threshold))
+		return
+	}
+	m.setExpiring(nil)
+}
+
+// Ready implements healthz.Checker, failing while the token is expiring.
+func (m *TokenTTLMonitor) Ready(_ *http.Request) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.expiring
+}
+
+func (m *TokenTTLMonitor) setExpiring(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		m.Log.Error(err, "Vault token is about to expire")
+		metrics.VaultTokenExpiring.Set(1)
+	} else {
+		if m.expiring != nil {
+			m.Log.Info("Vault token TTL is above the threshold again")
+		}
+		metrics.VaultTokenExpiring.Set(0)
+	}
+	m.expiring = err
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benemon/vault-namespace-controller/pkg/metrics"
+	"github.com/benemon/vault-namespace-controller/pkg/vault"
+)
+
+// tokenRenewer reports the TTLs in ttls in turn, and renews with renewErr.
+type tokenRenewer struct {
+	ttls     []int64
+	renewErr error
+	renewals int
+}
+
+func (t *tokenRenewer) GetTokenTTL() (int64, error) {
+	ttl := t.ttls[0]
+	if len(t.ttls) > 1 {
+		t.ttls = t.ttls[1:]
+	}
+	return ttl, nil
+}
+
+func (t *tokenRenewer) RenewToken(_ context.Context) error {
+	t.renewals++
+	return t.renewErr
+}
+
+// TestTokenTTLMonitor tests renewing the Vault token below the TTL threshold
+// and reporting not ready while that fails.
+func TestTokenTTLMonitor(t *testing.T) {
+	tests := []struct {
+		name             string
+		ttls             []int64
+		renewErr         error
+		expectedRenewals int
+		expectedErr      string
+	}{
+		{
+			name: "TTL above threshold",
+			ttls: []int64{3600},
+		},
+		{
+			name: "token without TTL",
+			ttls: []int64{0},
+		},
+		{
+			name:             "renewed",
+			ttls:             []int64{60, 3600},
+			expectedRenewals: 1,
+		},
+		{
+			name:             "renewal fails",
+			ttls:             []int64{60},
+			renewErr:         errors.New("permission denied"),
+			expectedRenewals: 1,
+			expectedErr:      "vault token expires in 60s and renewing it failed: permission denied",
+		},
+		{
+			name:             "renewal capped by max TTL",
+			ttls:             []int64{60, 120},
+			expectedRenewals: 1,
+			expectedErr:      "vault token expires in 120s, renewing it did not extend it beyond 5m0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tokenRenewer{ttls: tt.ttls, renewErr: tt.renewErr}
+			monitor := &TokenTTLMonitor{Client: client, Threshold: 5 * time.Minute, Log: testr.New(t)}
+
+			monitor.Check(context.Background())
+
+			assert.Equal(t, tt.expectedRenewals, client.renewals)
+			err := monitor.Ready(httptest.NewRequest("GET", "/readyz", nil))
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, float64(0), testutil.ToFloat64(metrics.VaultTokenExpiring))
+			} else {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Equal(t, float64(1), testutil.ToFloat64(metrics.VaultTokenExpiring))
+			}
+			assert.Equal(t, float64(tt.ttls[len(tt.ttls)-1]), testutil.ToFloat64(metrics.VaultTokenTTL))
+		})
+	}
+}
+
+// TestTokenTTLMonitor_NoThreshold tests the TTL is exported without renewing
+// the token or reporting it as expiring when no threshold is set.
+func TestTokenTTLMonitor_NoThreshold(t *testing.T) {
+	client := &tokenRenewer{ttls: []int64{30}}
+	monitor := &TokenTTLMonitor{Client: client, Log: testr.New(t)}
+
+	monitor.Check(context.Background())
+
+	assert.Equal(t, 0, client.renewals)
+	assert.NoError(t, monitor.Ready(httptest.NewRequest("GET", "/readyz", nil)))
+	assert.Equal(t, float64(30), testutil.ToFloat64(metrics.VaultTokenTTL))
+}
+
+// TestTokenTTLMonitor_VaultConnections tests the token of the controller's own
+// Vault is renewed when namespaces are routed to others.
+func TestTokenTTLMonitor_VaultConnections(t *testing.T) {
+	renewer := &tokenRenewer{ttls: []int64{30, 3600}}
+	var client vault.Client = &VaultConnections{Default: struct {
+		*mockVaultClient
+		*tokenRenewer
+	}{new(mockVaultClient), renewer}}
+
+	tokenClient, ok := client.(vault.TokenRenewer)
+	assert.True(t, ok)
+	monitor := &TokenTTLMonitor{Client: tokenClient, Threshold: time.Minute, Log: testr.New(t)}
+	monitor.Check(context.Background())
+
+	assert.Equal(t, 1, renewer.renewals)
+	assert.NoError(t, monitor.Ready(httptest.NewRequest("GET", "/readyz", nil)))
+}
@@ -2,8 +2,12 @@
 package metrics
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/benemon/vault-namespace-controller/pkg/version"
 )
 
 // Define metrics variables
@@ -17,11 +21,20 @@ var (
 		[]string{"result"},
 	)
 
-	ReconciliationDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "vault_ns_controller_reconciliation_duration_seconds",
-			Help:    "Time taken to complete reconciliations",
-			Buckets: prometheus.DefBuckets,
+	ReconciliationDuration = newReconciliationDuration(prometheus.DefBuckets)
+
+	// Freshness of successful reconciles, for alerting on a stuck controller
+	LastSuccessfulReconcile = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vault_ns_controller_last_successful_reconcile_timestamp_seconds",
+			Help: "Unix time of the last successful reconcile",
+		},
+	)
+
+	LastSuccessfulOperation = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "vault_ns_controller_last_successful_operation_timestamp_seconds",
+			Help: "Unix time of the last successful reconcile by operation (create, delete)",
 		},
 		[]string{"operation"},
 	)
@@ -35,14 +48,7 @@ var (
 		[]string{"operation", "result"},
 	)
 
-	VaultOperationDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "vault_ns_controller_vault_operation_duration_seconds",
-			Help:    "Time taken for Vault API operations",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"operation"},
-	)
+	VaultOperationDuration = newVaultOperationDuration(prometheus.DefBuckets)
 
 	// Namespace tracking metrics
 	NamespacesManaged = prometheus.NewGauge(
@@ -59,6 +65,21 @@ var (
 		},
 	)
 
+	// Vault namespaces below the namespace roots, for capacity planning
+	VaultNamespaces = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vault_ns_controller_vault_namespaces",
+			Help: "Number of Vault namespaces below the namespace roots",
+		},
+	)
+
+	VaultNamespacesOwned = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vault_ns_controller_vault_namespaces_owned",
+			Help: "Number of Vault namespaces below the namespace roots carrying this controller's ownership metadata",
+		},
+	)
+
 	// Connection status
 	VaultConnectionUp = prometheus.NewGauge(
 		prometheus.GaugeOpts{
@@ -75,6 +96,13 @@ var (
 		},
 	)
 
+	VaultTokenExpiring = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vault_ns_controller_vault_token_expiring",
+			Help: "Whether the Vault token TTL is below the threshold and renewing it failed (0 or 1)",
+		},
+	)
+
 	// Error metrics by type
 	ErrorsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -107,6 +135,136 @@ var (
 		},
 	)
 
+	// Deletions waiting out the configured grace period
+	NamespacesPendingDeletion = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vault_ns_controller_namespaces_pending_deletion",
+			Help: "Number of Vault namespaces scheduled for deletion after the grace period",
+		},
+	)
+
+	// Deletions refused by safety checks
+	DeletionsBlockedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vault_ns_controller_deletions_blocked_total",
+			Help: "Total number of Vault namespace deletions skipped by safety checks",
+		},
+		[]string{"reason"},
+	)
+
+	ReconcilesSkippedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vault_ns_controller_reconciles_skipped_total",
+			Help: "Total number of reconciles that left a namespace alone, by reason",
+		},
+		[]string{"reason"},
+	)
+
+	CreationsBlockedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vault_ns_controller_creations_blocked_total",
+			Help: "Total number of Vault namespace creations refused by safety checks",
+		},
+		[]string{"reason"},
+	)
+
+	// Operations skipped because dry-run mode is enabled
+	DryRunOperationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vault_ns_controller_dry_run_operations_total",
+			Help: "Total number of Vault changes that would have been made in dry-run mode",
+		},
+		[]string{"operation"},
+	)
+
+	// Drift between Kubernetes and Vault found by the drift scanner
+	DriftDetectedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vault_ns_controller_drift_detected_total",
+			Help: "Total number of drifted Vault namespaces found by the drift scanner",
+		},
+		[]string{"type"},
+	)
+
+	// Orphaned Vault namespaces found by the orphan scanner
+	OrphanedVaultNamespaces = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vault_ns_controller_orphaned_vault_namespaces",
+			Help: "Number of orphaned Vault namespaces left after the last orphan scan",
+		},
+	)
+
+	LastOrphanScan = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vault_ns_controller_last_orphan_scan_timestamp_seconds",
+			Help: "Unix time the last successful orphan scan completed",
+		},
+	)
+
+	OrphanScanDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "vault_ns_controller_orphan_scan_duration_seconds",
+			Help:    "Time taken to complete successful orphan scans",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 12),
+		},
+	)
+
+	// Lifecycle hooks
+	HookCallsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vault_ns_controller_hook_calls_total",
+			Help: "Total number of lifecycle hook calls",
+		},
+		[]string{"hook", "event", "result"},
+	)
+
+	AuditRecordsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vault_ns_controller_audit_records_total",
+			Help: "Total number of audit records written by sink and result (success, error, retry)",
+		},
+		[]string{"sink", "result"},
+	)
+
+	NotificationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vault_ns_controller_notifications_total",
+			Help: "Total number of notifications by notifier, event and result (success, error, dropped)",
+		},
+		[]string{"notifier", "event", "result"},
+	)
+
+	CloudEventsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vault_ns_controller_cloudevents_total",
+			Help: "Total number of CloudEvents emitted by type and result (success, error, dropped)",
+		},
+		[]string{"type", "result"},
+	)
+
+	// Initial sync progress
+	InitialSyncComplete = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vault_ns_controller_initial_sync_complete",
+			Help: "Whether all namespaces present at startup have been reconciled at least once, successfully or not (1 = complete)",
+		},
+	)
+
+	InitialSyncDuration = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vault_ns_controller_initial_sync_duration_seconds",
+			Help: "Time taken to reconcile all namespaces present at startup",
+		},
+	)
+
+	// Paused state
+	Paused = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vault_ns_controller_paused",
+			Help: "Whether Vault changes are paused (0 or 1)",
+		},
+	)
+
 	// Vault authentication metrics
 	VaultAuthOperationsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -124,13 +282,31 @@ var (
 		[]string{"auth_method"},
 	)
 
-	VaultAuthDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "vault_ns_controller_vault_auth_duration_seconds",
-			Help:    "Time taken for Vault authentication operations",
-			Buckets: prometheus.DefBuckets,
+	VaultAuthDuration = newVaultAuthDuration(prometheus.DefBuckets)
+
+	// NamespaceInfo is 1 per managed namespace, when enabled
+	NamespaceInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "vault_ns_controller_namespace_info",
+			Help: "Vault namespace path and sync status of each managed Kubernetes namespace, always 1",
 		},
-		[]string{"auth_method"},
+		[]string{"k8s_namespace", "vault_path", "status"},
+	)
+
+	NamespaceInfoDropped = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vault_ns_controller_namespace_info_dropped",
+			Help: "Number of managed namespaces left out of the namespace info metric by its series limit",
+		},
+	)
+
+	// BuildInfo is 1, labelled with the build of the running controller
+	BuildInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "vault_ns_controller_build_info",
+			Help: "Build of the running controller, always 1",
+		},
+		[]string{"version", "commit", "date", "go_version"},
 	)
 
 	// Kubernetes event processing
@@ -144,23 +320,117 @@ var (
 )
 
 func init() {
+	// Re-export the controller-runtime workqueue metrics under the controller's prefix
+	metrics.Registry = aliasWorkqueueMetrics(metrics.Registry)
+
 	// Register metrics with the controller-runtime manager
 	metrics.Registry.MustRegister(
 		ReconciliationTotal,
 		ReconciliationDuration,
+		LastSuccessfulReconcile,
+		LastSuccessfulOperation,
 		VaultOperationsTotal,
 		VaultOperationDuration,
 		NamespacesManaged,
 		NamespacesExcluded,
+		VaultNamespaces,
+		VaultNamespacesOwned,
+		NamespaceInfo,
+		NamespaceInfoDropped,
 		VaultConnectionUp,
 		VaultTokenTTL,
+		VaultTokenExpiring,
 		ErrorsTotal,
 		IsLeader,
 		LeaderElectionTransitions,
 		NamespacesPendingSync,
+		NamespacesPendingDeletion,
+		DeletionsBlockedTotal,
+		CreationsBlockedTotal,
+		ReconcilesSkippedTotal,
+		DryRunOperationsTotal,
+		Paused,
+		DriftDetectedTotal,
+		OrphanedVaultNamespaces,
+		LastOrphanScan,
+		OrphanScanDuration,
+		HookCallsTotal,
+		AuditRecordsTotal,
+		NotificationsTotal,
+		CloudEventsTotal,
+		InitialSyncComplete,
+		InitialSyncDuration,
 		VaultAuthOperationsTotal,
 		VaultAuthErrorsTotal,
 		VaultAuthDuration,
 		KubernetesEventsTotal,
+		BuildInfo,
+	)
+}
+
+// SetBuildInfo labels the build info metric with the build of the running controller.
+func SetBuildInfo(info version.Info) {
+	BuildInfo.Reset()
+	BuildInfo.WithLabelValues(info.Version, info.Commit, info.Date, info.GoVersion).Set(1)
+}
+
+// RecordSuccessfulReconcile records that a reconcile of operation succeeded at t.
+func RecordSuccessfulReconcile(operation string, t time.Time) {
+	timestamp := float64(t.UnixNano()) / 1e9
+	LastSuccessfulReconcile.Set(timestamp)
+	LastSuccessfulOperation.WithLabelValues(operation).Set(timestamp)
+}
+
+func newReconciliationDuration(buckets []float64) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "vault_ns_controller_reconciliation_duration_seconds",
+			Help:    "Time taken to complete reconciliations",
+			Buckets: buckets,
+		},
+		[]string{"operation"},
 	)
 }
+
+func newVaultOperationDuration(buckets []float64) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "vault_ns_controller_vault_operation_duration_seconds",
+			Help:    "Time taken for Vault API operations",
+			Buckets: buckets,
+		},
+		[]string{"operation"},
+	)
+}
+
+func newVaultAuthDuration(buckets []float64) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "vault_ns_controller_vault_auth_duration_seconds",
+			Help:    "Time taken for Vault authentication operations",
+			Buckets: buckets,
+		},
+		[]string{"auth_method"},
+	)
+}
+
+// SetDurationBuckets replaces the duration histograms with ones using the
+// given buckets, in seconds. Nil buckets keep the current histogram. The Vault
+// buckets apply to both Vault operations and authentication. It must be called
+// before anything is observed, as the replaced histograms are discarded.
+func SetDurationBuckets(reconcile, vault []float64) {
+	if reconcile != nil {
+		ReconciliationDuration = replaceHistogram(ReconciliationDuration, newReconciliationDuration(reconcile))
+	}
+	if vault != nil {
+		VaultOperationDuration = replaceHistogram(VaultOperationDuration, newVaultOperationDuration(vault))
+		VaultAuthDuration = replaceHistogram(VaultAuthDuration, newVaultAuthDuration(vault))
+	}
+}
+
+// replaceHistogram registers histogram in place of old.
+func replaceHistogram(old, histogram *prometheus.HistogramVec) *prometheus.HistogramVec {
+	metrics.Registry.Unregister(old)
+	metrics.Registry.MustRegister(histogram)
+	return histogram
+}
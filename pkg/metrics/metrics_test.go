@@ -2,10 +2,14 @@ package metrics
 
 import (
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/benemon/vault-namespace-controller/pkg/version"
 )
 
 func TestMetricsRegistration(t *testing.T) {
@@ -19,6 +23,7 @@ func TestMetricsRegistration(t *testing.T) {
 		NamespacesExcluded,
 		VaultConnectionUp,
 		VaultTokenTTL,
+		VaultTokenExpiring,
 		ErrorsTotal,
 		IsLeader,
 		LeaderElectionTransitions,
@@ -57,3 +62,42 @@ func TestMetricsIncrement(t *testing.T) {
 	ReconciliationDuration.WithLabelValues("create").Observe(0.1)
 	// We can't directly test the histogram values here, but we can ensure it doesn't panic
 }
+
+func TestSetDurationBuckets(t *testing.T) {
+	reconcile := ReconciliationDuration
+	SetDurationBuckets(nil, []float64{0.2, 0.4, 0.8})
+	t.Cleanup(func() { SetDurationBuckets(prometheus.DefBuckets, prometheus.DefBuckets) })
+	assert.Same(t, reconcile, ReconciliationDuration, "nil buckets should keep the histogram")
+
+	VaultOperationDuration.WithLabelValues("create").Observe(0.3)
+	families, err := metrics.Registry.Gather()
+	assert.NoError(t, err)
+	var buckets []float64
+	for _, family := range families {
+		if family.GetName() == "vault_ns_controller_vault_operation_duration_seconds" {
+			for _, bucket := range family.GetMetric()[0].GetHistogram().GetBucket() {
+				buckets = append(buckets, bucket.GetUpperBound())
+			}
+		}
+	}
+	assert.Equal(t, []float64{0.2, 0.4, 0.8}, buckets)
+}
+
+func TestRecordSuccessfulReconcile(t *testing.T) {
+	LastSuccessfulOperation.Reset()
+
+	RecordSuccessfulReconcile("create", time.Unix(1700000000, 500000000))
+	RecordSuccessfulReconcile("delete", time.Unix(1700000060, 0))
+
+	assert.Equal(t, 1700000060.0, testutil.ToFloat64(LastSuccessfulReconcile))
+	assert.Equal(t, 1700000000.5, testutil.ToFloat64(LastSuccessfulOperation.WithLabelValues("create")))
+	assert.Equal(t, 1700000060.0, testutil.ToFloat64(LastSuccessfulOperation.WithLabelValues("delete")))
+}
+
+func TestSetBuildInfo(t *testing.T) {
+	SetBuildInfo(version.Info{Version: "v1.1.0", Commit: "9aa83ca", Date: "2026-09-30T08:00:00Z", GoVersion: "go1.24.1"})
+	SetBuildInfo(version.Info{Version: "v1.2.0", Commit: "3f2b8c1", Date: "2026-10-01T12:00:00Z", GoVersion: "go1.24.2"})
+
+	assert.Equal(t, 1, testutil.CollectAndCount(BuildInfo))
+	assert.Equal(t, 1.0, testutil.ToFloat64(BuildInfo.WithLabelValues("v1.2.0", "3f2b8c1", "2026-10-01T12:00:00Z", "go1.24.2")))
+}
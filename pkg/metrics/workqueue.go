@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"sort"
+
+	dto "github.com/prometheus/client_model/go"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// workqueueAliases maps the controller-runtime workqueue metrics to the names
+// they are re-exported under, so dashboards only need the controller's prefix.
+var workqueueAliases = map[string]string{
+	"workqueue_depth":                             "vault_ns_controller_workqueue_depth",
+	"workqueue_adds_total":                        "vault_ns_controller_workqueue_adds_total",
+	"workqueue_retries_total":                     "vault_ns_controller_workqueue_retries_total",
+	"workqueue_queue_duration_seconds":            "vault_ns_controller_workqueue_queue_duration_seconds",
+	"workqueue_work_duration_seconds":             "vault_ns_controller_workqueue_work_duration_seconds",
+	"workqueue_unfinished_work_seconds":           "vault_ns_controller_workqueue_unfinished_work_seconds",
+	"workqueue_longest_running_processor_seconds": "vault_ns_controller_workqueue_longest_running_processor_seconds",
+}
+
+// workqueueAliasRegistry gathers the metrics of the wrapped registry along
+// with copies of the workqueue metrics under their aliases. The workqueue
+// metrics are registered by controller-runtime, so they cannot be registered
+// again under other names.
+type workqueueAliasRegistry struct {
+	metrics.RegistererGatherer
+}
+
+// aliasWorkqueueMetrics returns registry re-exporting the workqueue metrics.
+func aliasWorkqueueMetrics(registry metrics.RegistererGatherer) metrics.RegistererGatherer {
+	return &workqueueAliasRegistry{RegistererGatherer: registry}
+}
+
+// Gather returns the gathered metrics and their aliases, sorted by name.
+func (r *workqueueAliasRegistry) Gather() ([]*dto.MetricFamily, error) {
+	families, err := r.RegistererGatherer.Gather()
+	for _, family := range families {
+		alias, ok := workqueueAliases[family.GetName()]
+		if !ok {
+			continue
+		}
+		families = append(families, &dto.MetricFamily{
+			Name:   &alias,
+			Help:   family.Help,
+			Type:   family.Type,
+			Unit:   family.Unit,
+			Metric: family.Metric,
+		})
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].GetName() < families[j].GetName() })
+	return families, err
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/util/workqueue"
+	// Registers the workqueue metrics, as the controller does
+	_ "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func TestWorkqueueAliases(t *testing.T) {
+	queue := workqueue.NewTypedRateLimitingQueueWithConfig(workqueue.DefaultTypedControllerRateLimiter[string](),
+		workqueue.TypedRateLimitingQueueConfig[string]{Name: "namespace-alias-test"})
+	defer queue.ShutDown()
+	queue.Add("app-a")
+	queue.Add("app-b")
+	queue.AddRateLimited("app-c")
+
+	families, err := metrics.Registry.Gather()
+	assert.NoError(t, err)
+	values := make(map[string]float64)
+	var names []string
+	for _, family := range families {
+		names = append(names, family.GetName())
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "name" && label.GetValue() == "namespace-alias-test" {
+					values[family.GetName()] = metric.GetGauge().GetValue() + metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+
+	assert.Equal(t, 2.0, values["workqueue_depth"])
+	assert.Equal(t, 2.0, values["vault_ns_controller_workqueue_depth"])
+	assert.Equal(t, 1.0, values["vault_ns_controller_workqueue_retries_total"])
+	for _, name := range names {
+		if alias, ok := workqueueAliases[name]; ok {
+			assert.Contains(t, names, alias)
+		}
+	}
+	assert.IsNonDecreasing(t, names, "families should be sorted by name")
+}
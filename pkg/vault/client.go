@@ -28,10 +28,34 @@ var (
 // Client provides methods for interacting with Vault Enterprise namespaces.
 type Client interface {
 	NamespaceExists(ctx context.Context, path string) (bool, error)
-	CreateNamespace(ctx context.Context, path string) error
+	ListNamespaces(ctx context.Context, parent string) ([]string, error)
+	CreateNamespace(ctx context.Context, path string, customMetadata map[string]string) error
 	DeleteNamespace(ctx context.Context, path string) error
+	NamespaceEmpty(ctx context.Context, path string) (bool, error)
+	GetNamespaceMetadata(ctx context.Context, path string) (map[string]string, error)
+	PatchNamespaceMetadata(ctx context.Context, path string, customMetadata map[string]string) error
+	RemoveNamespaceMetadata(ctx context.Context, path string, keys []string) error
+	PutPolicy(ctx context.Context, namespacePath, name, policy string) error
+	PutSentinelPolicy(ctx context.Context, namespacePath, policyType, name, policy, enforcementLevel string, paths []string) error
+	EnsureAuditDevice(ctx context.Context, namespacePath, devicePath, deviceType, description string, options map[string]string) error
+	EnsureSecretsEngine(ctx context.Context, namespacePath, mountPath, mountType, description string) error
+	EnsureAuthMethod(ctx context.Context, namespacePath, mountPath, methodType, description string) error
+	WriteAuthRole(ctx context.Context, namespacePath, mountPath, role string, data map[string]string) error
+	WriteAuthConfig(ctx context.Context, namespacePath, mountPath string, data map[string]string) error
+	EnsureGroup(ctx context.Context, namespacePath, name, groupType string, policies, memberGroupIDs []string) (string, error)
+	EnsureGroupAlias(ctx context.Context, namespacePath, groupID, mountPath, name string) error
+	WriteQuota(ctx context.Context, quotaType, name string, data map[string]string) error
+	DeleteQuota(ctx context.Context, quotaType, name string) error
+	Request(ctx context.Context, namespacePath, method, path string, body map[string]interface{}) error
+	CreateToken(ctx context.Context, namespacePath string, policies []string, ttl, wrapTTL string) (string, error)
 }
 
+// Mounts that Vault creates in every namespace and which do not count as content.
+var (
+	defaultSecretMounts = map[string]bool{"cubbyhole/": true, "identity/": true, "sys/": true}
+	defaultAuthMounts   = map[string]bool{"token/": true}
+)
+
 type vaultClient struct {
 	client *api.Client
 	config *config.VaultConfig
@@ -48,15 +72,56 @@ func splitNamespacePath(namespacePath string) (parent, child string) {
 }
 
 func NewClient(config config.VaultConfig) (Client, error) {
+	client, err := newAPIClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authenticate(client, config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVaultAuth, err)
+	}
+
+	return &vaultClient{
+		client: client,
+		config: &config,
+	}, nil
+}
+
+// CheckHealth checks that the Vault server of config is reachable, initialized
+// and unsealed, without authenticating.
+func CheckHealth(ctx context.Context, config config.VaultConfig) error {
+	client, err := newAPIClient(config)
+	if err != nil {
+		return err
+	}
+	health, err := client.Sys().HealthWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reach vault at %s: %w", config.Address, err)
+	}
+	if !health.Initialized {
+		return fmt.Errorf("vault at %s is not initialized", config.Address)
+	}
+	if health.Sealed {
+		return fmt.Errorf("vault at %s is sealed", config.Address)
+	}
+	return nil
+}
+
+// newAPIClient returns an unauthenticated client for config.
+func newAPIClient(config config.VaultConfig) (*api.Client, error) {
 	clientConfig := api.DefaultConfig()
 	clientConfig.Address = config.Address
+	if config.Timeout > 0 {
+		clientConfig.Timeout = config.Timeout.Duration()
+	}
 
-	if config.CACert != "" || config.ClientCert != "" || config.ClientKey != "" || config.Insecure {
+	if config.CACert != "" || config.CACertPEM != "" || config.ClientCert != "" || config.ClientKey != "" || config.Insecure {
 		tlsConfig := &api.TLSConfig{
-			CACert:     config.CACert,
-			ClientCert: config.ClientCert,
-			ClientKey:  config.ClientKey,
-			Insecure:   config.Insecure,
+			CACert:      config.CACert,
+			CACertBytes: []byte(config.CACertPEM),
+			ClientCert:  config.ClientCert,
+			ClientKey:   config.ClientKey,
+			Insecure:    config.Insecure,
 		}
 		if err := clientConfig.ConfigureTLS(tlsConfig); err != nil {
 			return nil, fmt.Errorf("%w: %v", ErrVaultTLSConfig, err)
@@ -75,14 +140,48 @@ func NewClient(config config.VaultConfig) (Client, error) {
 		}
 	}
 
-	if err := authenticate(client, config); err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrVaultAuth, err)
+	return client, nil
+}
+
+// Reauthenticator is implemented by clients that can log in again, e.g.
+// after their credentials were rotated.
+type Reauthenticator interface {
+	Reauthenticate(auth config.VaultAuthConfig) error
+}
+
+// Reauthenticate logs in with auth and switches the client to the new token.
+// The login uses a copy of the client, so requests in flight are unaffected
+// and a failed login keeps the current token.
+func (c *vaultClient) Reauthenticate(auth config.VaultAuthConfig) error {
+	login, err := c.client.Clone()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrVaultAuth, err)
+	}
+	if namespace := c.client.Namespace(); namespace != "" {
+		login.SetNamespace(namespace)
 	}
+	vaultConfig := *c.config
+	vaultConfig.Auth = auth
+	if err := authenticate(login, vaultConfig); err != nil {
+		return fmt.Errorf("%w: %v", ErrVaultAuth, err)
+	}
+	c.client.SetToken(login.Token())
+	return nil
+}
 
-	return &vaultClient{
-		client: client,
-		config: &config,
-	}, nil
+// TokenChecker is implemented by clients that can check their token is
+// still accepted by Vault.
+type TokenChecker interface {
+	CheckToken(ctx context.Context) error
+}
+
+// CheckToken looks up the client's own token, which fails when Vault is
+// unreachable or the token has expired or been revoked.
+func (c *vaultClient) CheckToken(ctx context.Context) error {
+	if _, err := c.client.Auth().Token().LookupSelfWithContext(ctx); err != nil {
+		return fmt.Errorf("%w: %v", ErrVaultAuth, err)
+	}
+	return nil
 }
 
 func authenticate(client *api.Client, config config.VaultConfig) error {
@@ -201,15 +300,9 @@ func (c *vaultClient) NamespaceExists(ctx context.Context, namespacePath string)
 	metrics.VaultOperationsTotal.WithLabelValues("check", "attempt").Inc()
 
 	parent, child := splitNamespacePath(namespacePath)
-	currentNamespace := c.client.Namespace()
-	if parent != "" {
-		c.client.SetNamespace(parent)
-	} else {
-		c.client.SetNamespace("")
-	}
-	defer c.client.SetNamespace(currentNamespace)
+	client := c.withNamespace(ctx, parent)
 
-	secret, err := c.client.Logical().ListWithContext(ctx, "sys/namespaces")
+	secret, err := client.Logical().ListWithContext(ctx, "sys/namespaces")
 	duration := time.Since(start).Seconds()
 	metrics.VaultOperationDuration.WithLabelValues("check").Observe(duration)
 
@@ -218,7 +311,7 @@ func (c *vaultClient) NamespaceExists(ctx context.Context, namespacePath string)
 		if strings.Contains(err.Error(), "404") {
 			return false, nil
 		}
-		return false, fmt.Errorf("failed to list namespaces in %q: %w", parent, err)
+		return false, operationError(err, "failed to list namespaces in %q", parent)
 	}
 
 	if secret == nil || secret.Data == nil {
@@ -246,25 +339,114 @@ func (c *vaultClient) NamespaceExists(ctx context.Context, namespacePath string)
 	return false, nil
 }
 
-func (c *vaultClient) CreateNamespace(ctx context.Context, namespacePath string) error {
+// ListNamespaces returns the names of the namespaces directly below parent.
+// A parent that does not exist has no children.
+func (c *vaultClient) ListNamespaces(ctx context.Context, parent string) ([]string, error) {
+	secret, err := c.listNamespaces(ctx, parent)
+	if err != nil || secret == nil {
+		return nil, err
+	}
+
+	keys, ok := secret.Data["keys"].([]interface{})
+	if !ok {
+		return nil, errors.New("unexpected response format when listing namespaces: 'keys' is not a list")
+	}
+
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if keyStr, ok := key.(string); ok {
+			names = append(names, strings.TrimSuffix(keyStr, "/"))
+		}
+	}
+	return names, nil
+}
+
+// NamespaceMetadataLister is implemented by clients that can list the custom
+// metadata of namespaces along with their names.
+type NamespaceMetadataLister interface {
+	ListNamespaceMetadata(ctx context.Context, parent string) (map[string]map[string]string, error)
+}
+
+// ListNamespaceMetadata returns the custom metadata of the namespaces directly
+// below parent by name, read from the same list call as ListNamespaces. A
+// parent that does not exist has no children.
+func (c *vaultClient) ListNamespaceMetadata(ctx context.Context, parent string) (map[string]map[string]string, error) {
+	secret, err := c.listNamespaces(ctx, parent)
+	if err != nil || secret == nil {
+		return nil, err
+	}
+
+	keyInfo, ok := secret.Data["key_info"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("unexpected response format when listing namespaces: 'key_info' is not a map")
+	}
+
+	namespaces := make(map[string]map[string]string, len(keyInfo))
+	for key, info := range keyInfo {
+		customMetadata := make(map[string]string)
+		if info, ok := info.(map[string]interface{}); ok {
+			if raw, ok := info["custom_metadata"].(map[string]interface{}); ok {
+				for metadataKey, value := range raw {
+					if str, ok := value.(string); ok {
+						customMetadata[metadataKey] = str
+					}
+				}
+			}
+		}
+		namespaces[strings.TrimSuffix(key, "/")] = customMetadata
+	}
+	return namespaces, nil
+}
+
+// listNamespaces lists the namespaces directly below parent, returning nil
+// when parent does not exist or has no children.
+func (c *vaultClient) listNamespaces(ctx context.Context, parent string) (*api.Secret, error) {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("list", "attempt").Inc()
+
+	client := c.withNamespace(ctx, strings.Trim(parent, "/"))
+
+	secret, err := client.Logical().ListWithContext(ctx, "sys/namespaces")
+	duration := time.Since(start).Seconds()
+	metrics.VaultOperationDuration.WithLabelValues("list").Observe(duration)
+
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("list", "error").Inc()
+		if strings.Contains(err.Error(), "404") {
+			return nil, nil
+		}
+		return nil, operationError(err, "failed to list namespaces in %q", parent)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("list", "success").Inc()
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+	return secret, nil
+}
+
+func (c *vaultClient) CreateNamespace(ctx context.Context, namespacePath string, customMetadata map[string]string) error {
 	start := time.Now()
 	metrics.VaultOperationsTotal.WithLabelValues("create", "attempt").Inc()
 
 	parent, child := splitNamespacePath(namespacePath)
-	headers := map[string][]string{
-		"X-Vault-Namespace": {parent},
-	}
+	client := c.withNamespace(ctx, parent)
 
-	req := c.client.NewRequest("POST", fmt.Sprintf("/v1/sys/namespaces/%s", child))
-	req.Headers = headers
+	req := client.NewRequest("POST", fmt.Sprintf("/v1/sys/namespaces/%s", child))
+	if len(customMetadata) > 0 {
+		if err := req.SetJSONBody(map[string]interface{}{"custom_metadata": customMetadata}); err != nil {
+			metrics.VaultOperationsTotal.WithLabelValues("create", "error").Inc()
+			return fmt.Errorf("%w: failed to encode metadata for namespace %q: %v", ErrVaultNamespaceOperation, namespacePath, err)
+		}
+	}
 
-	resp, err := c.client.RawRequestWithContext(ctx, req)
+	resp, err := client.RawRequestWithContext(ctx, req)
 	duration := time.Since(start).Seconds()
 	metrics.VaultOperationDuration.WithLabelValues("create").Observe(duration)
 
 	if err != nil {
 		metrics.VaultOperationsTotal.WithLabelValues("create", "error").Inc()
-		return fmt.Errorf("%w: failed to create namespace %q: %v", ErrVaultNamespaceOperation, namespacePath, err)
+		return operationError(err, "failed to create namespace %q", namespacePath)
 	}
 	defer resp.Body.Close()
 
@@ -283,20 +465,17 @@ func (c *vaultClient) DeleteNamespace(ctx context.Context, namespacePath string)
 	metrics.VaultOperationsTotal.WithLabelValues("delete", "attempt").Inc()
 
 	parent, child := splitNamespacePath(namespacePath)
-	headers := map[string][]string{
-		"X-Vault-Namespace": {parent},
-	}
+	client := c.withNamespace(ctx, parent)
 
-	req := c.client.NewRequest("DELETE", fmt.Sprintf("/v1/sys/namespaces/%s", child))
-	req.Headers = headers
+	req := client.NewRequest("DELETE", fmt.Sprintf("/v1/sys/namespaces/%s", child))
 
-	resp, err := c.client.RawRequestWithContext(ctx, req)
+	resp, err := client.RawRequestWithContext(ctx, req)
 	duration := time.Since(start).Seconds()
 	metrics.VaultOperationDuration.WithLabelValues("delete").Observe(duration)
 
 	if err != nil {
 		metrics.VaultOperationsTotal.WithLabelValues("delete", "error").Inc()
-		return fmt.Errorf("%w: failed to delete namespace %q: %v", ErrVaultNamespaceOperation, namespacePath, err)
+		return operationError(err, "failed to delete namespace %q", namespacePath)
 	}
 	defer resp.Body.Close()
 
@@ -310,6 +489,450 @@ func (c *vaultClient) DeleteNamespace(ctx context.Context, namespacePath string)
 	return nil
 }
 
+// GetNamespaceMetadata returns the custom metadata stored on the namespace at namespacePath.
+func (c *vaultClient) GetNamespaceMetadata(ctx context.Context, namespacePath string) (map[string]string, error) {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("read", "attempt").Inc()
+
+	parent, child := splitNamespacePath(namespacePath)
+	client := c.withNamespace(ctx, parent)
+
+	req := client.NewRequest("GET", fmt.Sprintf("/v1/sys/namespaces/%s", child))
+
+	resp, err := client.RawRequestWithContext(ctx, req)
+	duration := time.Since(start).Seconds()
+	metrics.VaultOperationDuration.WithLabelValues("read").Observe(duration)
+
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("read", "error").Inc()
+		if resp != nil && resp.StatusCode == 404 {
+			return nil, fmt.Errorf("%w: %q", ErrVaultNamespaceNotFound, namespacePath)
+		}
+		return nil, operationError(err, "failed to read namespace %q", namespacePath)
+	}
+
+	secret, err := api.ParseSecret(resp.Body)
+	if err != nil || secret == nil || secret.Data == nil {
+		metrics.VaultOperationsTotal.WithLabelValues("read", "error").Inc()
+		return nil, fmt.Errorf("%w: unexpected response when reading namespace %q", ErrVaultNamespaceOperation, namespacePath)
+	}
+
+	customMetadata := make(map[string]string)
+	if raw, ok := secret.Data["custom_metadata"].(map[string]interface{}); ok {
+		for key, value := range raw {
+			if str, ok := value.(string); ok {
+				customMetadata[key] = str
+			}
+		}
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("read", "success").Inc()
+	return customMetadata, nil
+}
+
+// PatchNamespaceMetadata merges customMetadata into the metadata stored on the
+// namespace at namespacePath, leaving keys that are not supplied unchanged.
+func (c *vaultClient) PatchNamespaceMetadata(ctx context.Context, namespacePath string, customMetadata map[string]string) error {
+	patch := make(map[string]interface{}, len(customMetadata))
+	for key, value := range customMetadata {
+		patch[key] = value
+	}
+	return c.patchNamespaceMetadata(ctx, namespacePath, patch)
+}
+
+// RemoveNamespaceMetadata removes keys from the metadata stored on the
+// namespace at namespacePath, leaving the other keys unchanged.
+func (c *vaultClient) RemoveNamespaceMetadata(ctx context.Context, namespacePath string, keys []string) error {
+	patch := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		// A null value removes the key in a JSON merge patch
+		patch[key] = nil
+	}
+	return c.patchNamespaceMetadata(ctx, namespacePath, patch)
+}
+
+func (c *vaultClient) patchNamespaceMetadata(ctx context.Context, namespacePath string, customMetadata map[string]interface{}) error {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("patch", "attempt").Inc()
+
+	parent, child := splitNamespacePath(namespacePath)
+	client := c.withNamespace(ctx, parent)
+
+	req := client.NewRequest("PATCH", fmt.Sprintf("/v1/sys/namespaces/%s", child))
+	req.Headers.Set("Content-Type", "application/merge-patch+json")
+	if err := req.SetJSONBody(map[string]interface{}{"custom_metadata": customMetadata}); err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("patch", "error").Inc()
+		return fmt.Errorf("%w: failed to encode metadata for namespace %q: %v", ErrVaultNamespaceOperation, namespacePath, err)
+	}
+
+	resp, err := client.RawRequestWithContext(ctx, req)
+	duration := time.Since(start).Seconds()
+	metrics.VaultOperationDuration.WithLabelValues("patch").Observe(duration)
+
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("patch", "error").Inc()
+		return operationError(err, "failed to update metadata on namespace %q", namespacePath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 && resp.StatusCode != 204 {
+		metrics.VaultOperationsTotal.WithLabelValues("patch", "error").Inc()
+		return fmt.Errorf("%w: unexpected status code when updating metadata on namespace %q: %d",
+			ErrVaultNamespaceOperation, namespacePath, resp.StatusCode)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("patch", "success").Inc()
+	return nil
+}
+
+// PutPolicy creates or replaces the ACL policy name in the namespace at namespacePath.
+func (c *vaultClient) PutPolicy(ctx context.Context, namespacePath, name, policy string) error {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "attempt").Inc()
+
+	client := c.withNamespace(ctx, strings.Trim(namespacePath, "/"))
+	err := client.Sys().PutPolicyWithContext(ctx, name, policy)
+	metrics.VaultOperationDuration.WithLabelValues("provision").Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("provision", "error").Inc()
+		return operationError(err, "failed to write policy %q in %q", name, namespacePath)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "success").Inc()
+	return nil
+}
+
+// PutSentinelPolicy writes the Sentinel policy name of policyType ("egp" or
+// "rgp") in the namespace at namespacePath. paths are only sent for an EGP.
+func (c *vaultClient) PutSentinelPolicy(ctx context.Context, namespacePath, policyType, name, policy, enforcementLevel string, paths []string) error {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "attempt").Inc()
+
+	body := map[string]interface{}{
+		"policy":            policy,
+		"enforcement_level": enforcementLevel,
+	}
+	if len(paths) > 0 {
+		body["paths"] = paths
+	}
+	client := c.withNamespace(ctx, strings.Trim(namespacePath, "/"))
+	_, err := client.Logical().WriteWithContext(ctx, fmt.Sprintf("sys/policies/%s/%s", policyType, name), body)
+	metrics.VaultOperationDuration.WithLabelValues("provision").Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("provision", "error").Inc()
+		return operationError(err, "failed to write %s %q in %q", policyType, name, namespacePath)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "success").Inc()
+	return nil
+}
+
+// EnsureAuditDevice enables an audit device of deviceType at devicePath in the
+// namespace at namespacePath, unless a device is enabled there already.
+func (c *vaultClient) EnsureAuditDevice(ctx context.Context, namespacePath, devicePath, deviceType, description string, options map[string]string) error {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "attempt").Inc()
+
+	client := c.withNamespace(ctx, strings.Trim(namespacePath, "/"))
+	devices, err := client.Sys().ListAuditWithContext(ctx)
+	if err == nil {
+		if _, exists := devices[strings.Trim(devicePath, "/")+"/"]; !exists {
+			err = client.Sys().EnableAuditWithOptionsWithContext(ctx, devicePath, &api.EnableAuditOptions{
+				Type:        deviceType,
+				Description: description,
+				Options:     options,
+			})
+		}
+	}
+	metrics.VaultOperationDuration.WithLabelValues("provision").Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("provision", "error").Inc()
+		return operationError(err, "failed to enable audit device %q in %q", devicePath, namespacePath)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "success").Inc()
+	return nil
+}
+
+// EnsureSecretsEngine enables a secrets engine of mountType at mountPath in the
+// namespace at namespacePath, unless a secrets engine is mounted there already.
+func (c *vaultClient) EnsureSecretsEngine(ctx context.Context, namespacePath, mountPath, mountType, description string) error {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "attempt").Inc()
+
+	client := c.withNamespace(ctx, strings.Trim(namespacePath, "/"))
+	mounts, err := client.Sys().ListMountsWithContext(ctx)
+	if err == nil {
+		if _, ok := mounts[strings.Trim(mountPath, "/")+"/"]; !ok {
+			err = client.Sys().MountWithContext(ctx, mountPath, &api.MountInput{
+				Type:        mountType,
+				Description: description,
+			})
+		}
+	}
+	metrics.VaultOperationDuration.WithLabelValues("provision").Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("provision", "error").Inc()
+		return operationError(err, "failed to enable secrets engine %q in %q", mountPath, namespacePath)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "success").Inc()
+	return nil
+}
+
+// EnsureAuthMethod enables an auth method of methodType at mountPath in the
+// namespace at namespacePath, unless an auth method is mounted there already.
+func (c *vaultClient) EnsureAuthMethod(ctx context.Context, namespacePath, mountPath, methodType, description string) error {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "attempt").Inc()
+
+	client := c.withNamespace(ctx, strings.Trim(namespacePath, "/"))
+	authMounts, err := client.Sys().ListAuthWithContext(ctx)
+	if err == nil {
+		if _, ok := authMounts[strings.Trim(mountPath, "/")+"/"]; !ok {
+			err = client.Sys().EnableAuthWithOptionsWithContext(ctx, mountPath, &api.EnableAuthOptions{
+				Type:        methodType,
+				Description: description,
+			})
+		}
+	}
+	metrics.VaultOperationDuration.WithLabelValues("provision").Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("provision", "error").Inc()
+		return operationError(err, "failed to enable auth method %q in %q", mountPath, namespacePath)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "success").Inc()
+	return nil
+}
+
+// WriteAuthRole creates or updates role of the auth method at mountPath in the
+// namespace at namespacePath.
+func (c *vaultClient) WriteAuthRole(ctx context.Context, namespacePath, mountPath, role string, data map[string]string) error {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "attempt").Inc()
+
+	body := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		body[key] = value
+	}
+	client := c.withNamespace(ctx, strings.Trim(namespacePath, "/"))
+	_, err := client.Logical().WriteWithContext(ctx,
+		fmt.Sprintf("auth/%s/role/%s", strings.Trim(mountPath, "/"), role), body)
+	metrics.VaultOperationDuration.WithLabelValues("provision").Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("provision", "error").Inc()
+		return operationError(err, "failed to write role %q of auth method %q in %q", role, mountPath, namespacePath)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "success").Inc()
+	return nil
+}
+
+// WriteAuthConfig writes the configuration of the auth method at mountPath in
+// the namespace at namespacePath.
+func (c *vaultClient) WriteAuthConfig(ctx context.Context, namespacePath, mountPath string, data map[string]string) error {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "attempt").Inc()
+
+	body := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		body[key] = value
+	}
+	client := c.withNamespace(ctx, strings.Trim(namespacePath, "/"))
+	_, err := client.Logical().WriteWithContext(ctx,
+		fmt.Sprintf("auth/%s/config", strings.Trim(mountPath, "/")), body)
+	metrics.VaultOperationDuration.WithLabelValues("provision").Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("provision", "error").Inc()
+		return operationError(err, "failed to configure auth method %q in %q", mountPath, namespacePath)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "success").Inc()
+	return nil
+}
+
+// EnsureGroup creates or updates the identity group name of groupType
+// ("internal" or "external") in the namespace at namespacePath, and returns its
+// ID. Nil policies leave those of an existing group unchanged. Member groups
+// only apply to internal groups.
+func (c *vaultClient) EnsureGroup(ctx context.Context, namespacePath, name, groupType string, policies, memberGroupIDs []string) (string, error) {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "attempt").Inc()
+
+	body := map[string]interface{}{"type": groupType}
+	if policies != nil {
+		body["policies"] = policies
+	}
+	if groupType == "internal" {
+		body["member_group_ids"] = append([]string{}, memberGroupIDs...)
+	}
+	client := c.withNamespace(ctx, strings.Trim(namespacePath, "/"))
+	groupPath := "identity/group/name/" + name
+	var id string
+	_, err := client.Logical().WriteWithContext(ctx, groupPath, body)
+	if err == nil {
+		// Updates return no data, so the ID is read back
+		var group *api.Secret
+		group, err = client.Logical().ReadWithContext(ctx, groupPath)
+		if err == nil && group != nil && group.Data != nil {
+			id, _ = group.Data["id"].(string)
+		}
+		if err == nil && id == "" {
+			err = fmt.Errorf("group %q has no ID", name)
+		}
+	}
+	metrics.VaultOperationDuration.WithLabelValues("provision").Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("provision", "error").Inc()
+		return "", operationError(err, "failed to write identity group %q in %q", name, namespacePath)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "success").Inc()
+	return id, nil
+}
+
+// EnsureGroupAlias gives the external group groupID in the namespace at
+// namespacePath the alias name for the auth method at mountPath, unless it has
+// that alias already.
+func (c *vaultClient) EnsureGroupAlias(ctx context.Context, namespacePath, groupID, mountPath, name string) error {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "attempt").Inc()
+
+	client := c.withNamespace(ctx, strings.Trim(namespacePath, "/"))
+	err := func() error {
+		authMounts, err := client.Sys().ListAuthWithContext(ctx)
+		if err != nil {
+			return err
+		}
+		mount, ok := authMounts[strings.Trim(mountPath, "/")+"/"]
+		if !ok {
+			return fmt.Errorf("auth method %q not found", mountPath)
+		}
+		group, err := client.Logical().ReadWithContext(ctx, "identity/group/id/"+groupID)
+		if err != nil {
+			return err
+		}
+
+		aliasPath := "identity/group-alias"
+		if group != nil && group.Data != nil {
+			if alias, ok := group.Data["alias"].(map[string]interface{}); ok {
+				if alias["name"] == name && alias["mount_accessor"] == mount.Accessor {
+					return nil
+				}
+				if aliasID, _ := alias["id"].(string); aliasID != "" {
+					aliasPath = "identity/group-alias/id/" + aliasID
+				}
+			}
+		}
+		_, err = client.Logical().WriteWithContext(ctx, aliasPath, map[string]interface{}{
+			"name":           name,
+			"mount_accessor": mount.Accessor,
+			"canonical_id":   groupID,
+		})
+		return err
+	}()
+	metrics.VaultOperationDuration.WithLabelValues("provision").Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("provision", "error").Inc()
+		return operationError(err, "failed to write alias %q of identity group %q in %q", name, groupID, namespacePath)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "success").Inc()
+	return nil
+}
+
+// WriteQuota writes the quota name of quotaType ("rate-limit" or
+// "lease-count") in the namespace the client authenticates in.
+func (c *vaultClient) WriteQuota(ctx context.Context, quotaType, name string, data map[string]string) error {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "attempt").Inc()
+
+	body := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		body[key] = value
+	}
+	_, err := c.withNamespace(ctx, c.client.Namespace()).Logical().WriteWithContext(ctx, fmt.Sprintf("sys/quotas/%s/%s", quotaType, name), body)
+	metrics.VaultOperationDuration.WithLabelValues("provision").Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("provision", "error").Inc()
+		return operationError(err, "failed to write %s quota %q", quotaType, name)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "success").Inc()
+	return nil
+}
+
+// DeleteQuota deletes the quota name of quotaType, if it exists.
+func (c *vaultClient) DeleteQuota(ctx context.Context, quotaType, name string) error {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "attempt").Inc()
+
+	_, err := c.withNamespace(ctx, c.client.Namespace()).Logical().DeleteWithContext(ctx, fmt.Sprintf("sys/quotas/%s/%s", quotaType, name))
+	metrics.VaultOperationDuration.WithLabelValues("provision").Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("provision", "error").Inc()
+		return operationError(err, "failed to delete %s quota %q", quotaType, name)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "success").Inc()
+	return nil
+}
+
+// NamespaceEmpty reports whether the namespace at namespacePath contains nothing
+// beyond the secret and auth mounts Vault creates by default.
+func (c *vaultClient) NamespaceEmpty(ctx context.Context, namespacePath string) (bool, error) {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("inspect", "attempt").Inc()
+
+	client := c.withNamespace(ctx, strings.Trim(namespacePath, "/"))
+
+	mounts, err := client.Sys().ListMountsWithContext(ctx)
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("inspect", "error").Inc()
+		return false, operationError(err, "failed to list secret mounts in %q", namespacePath)
+	}
+	authMounts, err := client.Sys().ListAuthWithContext(ctx)
+	duration := time.Since(start).Seconds()
+	metrics.VaultOperationDuration.WithLabelValues("inspect").Observe(duration)
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("inspect", "error").Inc()
+		return false, operationError(err, "failed to list auth mounts in %q", namespacePath)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("inspect", "success").Inc()
+	for mountPath := range mounts {
+		if !defaultSecretMounts[mountPath] {
+			return false, nil
+		}
+	}
+	for mountPath := range authMounts {
+		if !defaultAuthMounts[mountPath] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// TokenRenewer is implemented by clients that can report and extend the
+// remaining TTL of their token.
+type TokenRenewer interface {
+	GetTokenTTL() (int64, error)
+	RenewToken(ctx context.Context) error
+}
+
+// RenewToken renews the client's token by its increment, within the limits
+// of its max TTL.
+func (c *vaultClient) RenewToken(ctx context.Context) error {
+	if _, err := c.client.Auth().Token().RenewSelfWithContext(ctx, 0); err != nil {
+		return fmt.Errorf("%w: failed to renew token: %v", ErrVaultAuth, err)
+	}
+	return nil
+}
+
 func (c *vaultClient) GetTokenTTL() (int64, error) {
 	if c.config.Auth.Type != "token" && c.client.Token() == "" {
 		return 0, nil
@@ -341,3 +964,69 @@ func (c *vaultClient) GetTokenTTL() (int64, error) {
 	}
 	return ttl, nil
 }
+
+// Request sends an arbitrary request for path to the namespace at
+// namespacePath, for resources the controller has no dedicated support for.
+// A nil body sends no body.
+func (c *vaultClient) Request(ctx context.Context, namespacePath, method, path string, body map[string]interface{}) error {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "attempt").Inc()
+
+	client := c.withNamespace(ctx, strings.Trim(namespacePath, "/"))
+	req := client.NewRequest(method, "/v1/"+strings.TrimLeft(path, "/"))
+	if body != nil {
+		if err := req.SetJSONBody(body); err != nil {
+			metrics.VaultOperationsTotal.WithLabelValues("provision", "error").Inc()
+			return fmt.Errorf("failed to encode body of %s %s: %w", method, path, err)
+		}
+	}
+	resp, err := client.RawRequestWithContext(ctx, req)
+	metrics.VaultOperationDuration.WithLabelValues("provision").Observe(time.Since(start).Seconds())
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("provision", "error").Inc()
+		return operationError(err, "failed to %s %q in %q", method, path, namespacePath)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "success").Inc()
+	return nil
+}
+
+// CreateToken creates a child token of the client's token with policies and
+// ttl in the namespace at namespacePath. With wrapTTL, the token is returned
+// wrapped in a response-wrapping token of that TTL.
+func (c *vaultClient) CreateToken(ctx context.Context, namespacePath string, policies []string, ttl, wrapTTL string) (string, error) {
+	start := time.Now()
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "attempt").Inc()
+
+	client := c.withNamespace(ctx, strings.Trim(namespacePath, "/"))
+	if wrapTTL != "" {
+		client.SetWrappingLookupFunc(func(string, string) string { return wrapTTL })
+	}
+	secret, err := client.Auth().Token().CreateWithContext(ctx, &api.TokenCreateRequest{
+		Policies:    policies,
+		TTL:         ttl,
+		DisplayName: "vault-namespace-controller-bootstrap",
+	})
+	metrics.VaultOperationDuration.WithLabelValues("provision").Observe(time.Since(start).Seconds())
+	if err == nil {
+		switch {
+		case wrapTTL != "" && (secret == nil || secret.WrapInfo == nil):
+			err = errors.New("response was not wrapped")
+		case wrapTTL == "" && (secret == nil || secret.Auth == nil):
+			err = errors.New("response has no token")
+		}
+	}
+	if err != nil {
+		metrics.VaultOperationsTotal.WithLabelValues("provision", "error").Inc()
+		return "", operationError(err, "failed to create token in %q", namespacePath)
+	}
+
+	metrics.VaultOperationsTotal.WithLabelValues("provision", "success").Inc()
+	if wrapTTL != "" {
+		return secret.WrapInfo.Token, nil
+	}
+	return secret.Auth.ClientToken, nil
+}
@@ -2,11 +2,17 @@ package vault
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+
+	"github.com/benemon/vault-namespace-controller/pkg/config"
 )
 
 func TestSplitNamespacePath(t *testing.T) {
@@ -73,8 +79,14 @@ func (m *MockVaultClient) NamespaceExists(ctx context.Context, path string) (boo
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockVaultClient) CreateNamespace(ctx context.Context, path string) error {
-	args := m.Called(ctx, path)
+func (m *MockVaultClient) ListNamespaces(ctx context.Context, parent string) ([]string, error) {
+	args := m.Called(ctx, parent)
+	names, _ := args.Get(0).([]string)
+	return names, args.Error(1)
+}
+
+func (m *MockVaultClient) CreateNamespace(ctx context.Context, path string, customMetadata map[string]string) error {
+	args := m.Called(ctx, path, customMetadata)
 	return args.Error(0)
 }
 
@@ -83,6 +95,114 @@ func (m *MockVaultClient) DeleteNamespace(ctx context.Context, path string) erro
 	return args.Error(0)
 }
 
+func (m *MockVaultClient) NamespaceEmpty(ctx context.Context, path string) (bool, error) {
+	args := m.Called(ctx, path)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockVaultClient) GetNamespaceMetadata(ctx context.Context, path string) (map[string]string, error) {
+	args := m.Called(ctx, path)
+	customMetadata, _ := args.Get(0).(map[string]string)
+	return customMetadata, args.Error(1)
+}
+
+func (m *MockVaultClient) PatchNamespaceMetadata(ctx context.Context, path string, customMetadata map[string]string) error {
+	args := m.Called(ctx, path, customMetadata)
+	return args.Error(0)
+}
+
+func (m *MockVaultClient) RemoveNamespaceMetadata(ctx context.Context, path string, keys []string) error {
+	args := m.Called(ctx, path, keys)
+	return args.Error(0)
+}
+
+func (m *MockVaultClient) PutPolicy(ctx context.Context, namespacePath, name, policy string) error {
+	args := m.Called(ctx, namespacePath, name, policy)
+	return args.Error(0)
+}
+
+func (m *MockVaultClient) PutSentinelPolicy(ctx context.Context, namespacePath, policyType, name, policy, enforcementLevel string, paths []string) error {
+	args := m.Called(ctx, namespacePath, policyType, name, policy, enforcementLevel, paths)
+	return args.Error(0)
+}
+
+func (m *MockVaultClient) EnsureAuditDevice(ctx context.Context, namespacePath, devicePath, deviceType, description string, options map[string]string) error {
+	args := m.Called(ctx, namespacePath, devicePath, deviceType, description, options)
+	return args.Error(0)
+}
+
+func (m *MockVaultClient) EnsureSecretsEngine(ctx context.Context, namespacePath, mountPath, mountType, description string) error {
+	args := m.Called(ctx, namespacePath, mountPath, mountType, description)
+	return args.Error(0)
+}
+
+func (m *MockVaultClient) EnsureAuthMethod(ctx context.Context, namespacePath, mountPath, methodType, description string) error {
+	args := m.Called(ctx, namespacePath, mountPath, methodType, description)
+	return args.Error(0)
+}
+
+func (m *MockVaultClient) WriteAuthRole(ctx context.Context, namespacePath, mountPath, role string, data map[string]string) error {
+	args := m.Called(ctx, namespacePath, mountPath, role, data)
+	return args.Error(0)
+}
+
+func (m *MockVaultClient) WriteAuthConfig(ctx context.Context, namespacePath, mountPath string, data map[string]string) error {
+	args := m.Called(ctx, namespacePath, mountPath, data)
+	return args.Error(0)
+}
+
+func (m *MockVaultClient) EnsureGroup(ctx context.Context, namespacePath, name, groupType string, policies, memberGroupIDs []string) (string, error) {
+	args := m.Called(ctx, namespacePath, name, groupType, policies, memberGroupIDs)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockVaultClient) EnsureGroupAlias(ctx context.Context, namespacePath, groupID, mountPath, name string) error {
+	args := m.Called(ctx, namespacePath, groupID, mountPath, name)
+	return args.Error(0)
+}
+
+func (m *MockVaultClient) WriteQuota(ctx context.Context, quotaType, name string, data map[string]string) error {
+	args := m.Called(ctx, quotaType, name, data)
+	return args.Error(0)
+}
+
+func (m *MockVaultClient) DeleteQuota(ctx context.Context, quotaType, name string) error {
+	args := m.Called(ctx, quotaType, name)
+	return args.Error(0)
+}
+
+func (m *MockVaultClient) Request(ctx context.Context, namespacePath, method, path string, body map[string]interface{}) error {
+	args := m.Called(ctx, namespacePath, method, path, body)
+	return args.Error(0)
+}
+
+func (m *MockVaultClient) CreateToken(ctx context.Context, namespacePath string, policies []string, ttl, wrapTTL string) (string, error) {
+	args := m.Called(ctx, namespacePath, policies, ttl, wrapTTL)
+	return args.String(0), args.Error(1)
+}
+
+// newTestClient returns a vaultClient talking to a test server backed by handler.
+func newTestClient(t *testing.T, handler http.Handler) *vaultClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	apiClient, err := api.NewClient(&api.Config{Address: server.URL})
+	assert.NoError(t, err)
+	apiClient.SetToken("test-token")
+
+	return &vaultClient{
+		client: apiClient,
+		config: &config.VaultConfig{Address: server.URL},
+	}
+}
+
+// writeJSON writes body as a JSON response.
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
 // TestNamespaceExistsLogic tests the logic for checking namespace existence.
 func TestNamespaceExistsLogic(t *testing.T) {
 	tests := []struct {
@@ -215,19 +335,19 @@ func TestVaultClient_CreateNamespace(t *testing.T) {
 	mockClient := new(MockVaultClient)
 
 	// Setup expectations for success cases
-	mockClient.On("CreateNamespace", mock.Anything, "test-namespace").Return(nil)
-	mockClient.On("CreateNamespace", mock.Anything, "parent/child").Return(nil)
+	mockClient.On("CreateNamespace", mock.Anything, "test-namespace", mock.Anything).Return(nil)
+	mockClient.On("CreateNamespace", mock.Anything, "parent/child", mock.Anything).Return(nil)
 
 	// Setup expectations for error cases
 	operationErr := errors.New("operation failed")
-	mockClient.On("CreateNamespace", mock.Anything, "error-namespace").Return(operationErr)
+	mockClient.On("CreateNamespace", mock.Anything, "error-namespace", mock.Anything).Return(operationErr)
 
 	// Call the method for success cases
-	err1 := mockClient.CreateNamespace(context.Background(), "test-namespace")
-	err2 := mockClient.CreateNamespace(context.Background(), "parent/child")
+	err1 := mockClient.CreateNamespace(context.Background(), "test-namespace", nil)
+	err2 := mockClient.CreateNamespace(context.Background(), "parent/child", nil)
 
 	// Call the method for error case
-	err3 := mockClient.CreateNamespace(context.Background(), "error-namespace")
+	err3 := mockClient.CreateNamespace(context.Background(), "error-namespace", nil)
 
 	// Verify expectations
 	assert.NoError(t, err1)
@@ -300,3 +420,431 @@ func TestVaultClient_NamespaceExists(t *testing.T) {
 
 	mockClient.AssertExpectations(t)
 }
+
+// TestVaultClient_NamespaceEmpty tests detection of content in a Vault namespace.
+func TestVaultClient_NamespaceEmpty(t *testing.T) {
+	defaultMounts := map[string]interface{}{
+		"cubbyhole/": map[string]interface{}{"type": "cubbyhole"},
+		"identity/":  map[string]interface{}{"type": "identity"},
+		"sys/":       map[string]interface{}{"type": "system"},
+	}
+	defaultAuth := map[string]interface{}{
+		"token/": map[string]interface{}{"type": "token"},
+	}
+
+	tests := []struct {
+		name          string
+		mounts        map[string]interface{}
+		auth          map[string]interface{}
+		expectedEmpty bool
+	}{
+		{
+			name:          "only default mounts",
+			mounts:        defaultMounts,
+			auth:          defaultAuth,
+			expectedEmpty: true,
+		},
+		{
+			name: "additional secret mount",
+			mounts: map[string]interface{}{
+				"cubbyhole/": map[string]interface{}{"type": "cubbyhole"},
+				"secret/":    map[string]interface{}{"type": "kv"},
+			},
+			auth:          defaultAuth,
+			expectedEmpty: false,
+		},
+		{
+			name:   "additional auth mount",
+			mounts: defaultMounts,
+			auth: map[string]interface{}{
+				"token/":      map[string]interface{}{"type": "token"},
+				"kubernetes/": map[string]interface{}{"type": "kubernetes"},
+			},
+			expectedEmpty: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var namespaces []string
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v1/sys/mounts", func(w http.ResponseWriter, r *http.Request) {
+				namespaces = append(namespaces, r.Header.Get("X-Vault-Namespace"))
+				writeJSON(w, map[string]interface{}{"data": tt.mounts})
+			})
+			mux.HandleFunc("/v1/sys/auth", func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, map[string]interface{}{"data": tt.auth})
+			})
+
+			c := newTestClient(t, mux)
+			empty, err := c.NamespaceEmpty(context.Background(), "/admin/team-a")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedEmpty, empty)
+			assert.Equal(t, []string{"admin/team-a"}, namespaces)
+		})
+	}
+}
+
+// TestVaultClient_NamespaceMetadata tests reading and patching namespace custom metadata.
+func TestVaultClient_NamespaceMetadata(t *testing.T) {
+	var patched map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/sys/namespaces/team-a", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "admin", r.Header.Get("X-Vault-Namespace"))
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, map[string]interface{}{"data": map[string]interface{}{
+				"path":            "admin/team-a/",
+				"custom_metadata": map[string]interface{}{"managed-by": "vault-namespace-controller"},
+			}})
+		case http.MethodPatch:
+			assert.Equal(t, "application/merge-patch+json", r.Header.Get("Content-Type"))
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&patched))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	mux.HandleFunc("/v1/sys/namespaces/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	c := newTestClient(t, mux)
+
+	customMetadata, err := c.GetNamespaceMetadata(context.Background(), "admin/team-a")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"managed-by": "vault-namespace-controller"}, customMetadata)
+
+	err = c.PatchNamespaceMetadata(context.Background(), "admin/team-a", map[string]string{"kubernetes-cluster": "east"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"kubernetes-cluster": "east"}, patched["custom_metadata"])
+
+	err = c.RemoveNamespaceMetadata(context.Background(), "admin/team-a", []string{"team"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"team": nil}, patched["custom_metadata"])
+
+	_, err = c.GetNamespaceMetadata(context.Background(), "admin/missing")
+	assert.True(t, errors.Is(err, ErrVaultNamespaceNotFound))
+}
+
+// TestVaultClient_ListNamespaces tests listing the children of a namespace.
+func TestVaultClient_ListNamespaces(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/sys/namespaces", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Namespace") != "admin" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{
+			"keys": []string{"team-a/", "team-b/"},
+		}})
+	})
+
+	c := newTestClient(t, mux)
+
+	names, err := c.ListNamespaces(context.Background(), "/admin")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"team-a", "team-b"}, names)
+
+	names, err = c.ListNamespaces(context.Background(), "missing")
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+// TestVaultClient_ListNamespaceMetadata tests listing the custom metadata of
+// the children of a namespace.
+func TestVaultClient_ListNamespaceMetadata(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/sys/namespaces", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Namespace") != "admin" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{
+			"keys": []string{"team-a/", "shared/"},
+			"key_info": map[string]interface{}{
+				"team-a/": map[string]interface{}{
+					"path":            "admin/team-a/",
+					"custom_metadata": map[string]string{"managed-by": "vault-namespace-controller"},
+				},
+				"shared/": map[string]interface{}{"path": "admin/shared/", "custom_metadata": nil},
+			},
+		}})
+	})
+
+	c := newTestClient(t, mux)
+
+	namespaces, err := c.ListNamespaceMetadata(context.Background(), "admin")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"team-a": {"managed-by": "vault-namespace-controller"},
+		"shared": {},
+	}, namespaces)
+
+	namespaces, err = c.ListNamespaceMetadata(context.Background(), "missing")
+	assert.NoError(t, err)
+	assert.Empty(t, namespaces)
+}
+
+// TestVaultClient_Provisioning tests writing policies, mounts and auth roles
+// inside a namespace.
+func TestVaultClient_Provisioning(t *testing.T) {
+	var requests []string
+	mux := http.NewServeMux()
+	record := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "admin/team-a", r.Header.Get("X-Vault-Namespace"))
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+	mux.HandleFunc("/v1/sys/policies/acl/app-read", record)
+	mux.HandleFunc("/v1/sys/mounts", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{
+			"secret/": map[string]interface{}{"type": "kv"},
+		}})
+	})
+	mux.HandleFunc("/v1/sys/mounts/", record)
+	mux.HandleFunc("/v1/sys/auth", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{}})
+	})
+	mux.HandleFunc("/v1/sys/auth/", record)
+	mux.HandleFunc("/v1/auth/kubernetes/role/app", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "app", body["bound_service_account_names"])
+		record(w, r)
+	})
+	mux.HandleFunc("/v1/auth/kubernetes/config", record)
+
+	c := newTestClient(t, mux)
+	ctx := context.Background()
+
+	assert.NoError(t, c.PutPolicy(ctx, "/admin/team-a", "app-read", `path "secret/*" {}`))
+	// Already mounted, so not enabled again
+	assert.NoError(t, c.EnsureSecretsEngine(ctx, "/admin/team-a", "secret", "kv-v2", ""))
+	assert.NoError(t, c.EnsureSecretsEngine(ctx, "/admin/team-a", "pki", "pki", ""))
+	assert.NoError(t, c.EnsureAuthMethod(ctx, "/admin/team-a", "kubernetes", "kubernetes", ""))
+	assert.NoError(t, c.WriteAuthRole(ctx, "/admin/team-a", "kubernetes", "app",
+		map[string]string{"bound_service_account_names": "app"}))
+	assert.NoError(t, c.WriteAuthConfig(ctx, "/admin/team-a", "kubernetes",
+		map[string]string{"kubernetes_host": "https://kubernetes.default.svc"}))
+
+	assert.Equal(t, []string{
+		"PUT /v1/sys/policies/acl/app-read",
+		"POST /v1/sys/mounts/pki",
+		"POST /v1/sys/auth/kubernetes",
+		"PUT /v1/auth/kubernetes/role/app",
+		"PUT /v1/auth/kubernetes/config",
+	}, requests)
+}
+
+// TestVaultClient_IdentityGroups tests writing identity groups and the alias of
+// an external group.
+func TestVaultClient_IdentityGroups(t *testing.T) {
+	var requests []string
+	alias := map[string]interface{}{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/identity/group/name/app-admins", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "admin/app", r.Header.Get("X-Vault-Namespace"))
+		if r.Method == http.MethodGet {
+			writeJSON(w, map[string]interface{}{"data": map[string]interface{}{"id": "group-1"}})
+			return
+		}
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "internal", body["type"])
+		assert.Equal(t, []interface{}{"ext-1"}, body["member_group_ids"])
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/v1/sys/auth", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{
+			"oidc/": map[string]interface{}{"type": "oidc", "accessor": "auth_oidc_1"},
+		}})
+	})
+	mux.HandleFunc("/v1/identity/group/id/ext-1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{"id": "ext-1", "alias": alias}})
+	})
+	mux.HandleFunc("/v1/identity/group-alias", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "auth_oidc_1", body["mount_accessor"])
+		assert.Equal(t, "ext-1", body["canonical_id"])
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		alias = map[string]interface{}{"id": "alias-1", "name": body["name"], "mount_accessor": body["mount_accessor"]}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	c := newTestClient(t, mux)
+	ctx := context.Background()
+
+	id, err := c.EnsureGroup(ctx, "/admin/app", "app-admins", "internal", []string{"tenant-admin"}, []string{"ext-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "group-1", id)
+
+	assert.NoError(t, c.EnsureGroupAlias(ctx, "", "ext-1", "oidc", "app-admins"))
+	// The alias exists now, so it is not written again
+	assert.NoError(t, c.EnsureGroupAlias(ctx, "", "ext-1", "oidc", "app-admins"))
+	assert.Error(t, c.EnsureGroupAlias(ctx, "", "ext-1", "ldap", "app-admins"))
+
+	assert.Equal(t, []string{
+		"PUT /v1/identity/group/name/app-admins",
+		"PUT /v1/identity/group-alias",
+	}, requests)
+}
+
+// TestVaultClient_Quotas tests writing and deleting quotas.
+func TestVaultClient_Quotas(t *testing.T) {
+	var requests []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/sys/quotas/rate-limit/admin_app", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			var body map[string]interface{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "admin/app/", body["path"])
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	c := newTestClient(t, mux)
+	ctx := context.Background()
+	assert.NoError(t, c.WriteQuota(ctx, "rate-limit", "admin_app", map[string]string{"path": "admin/app/", "rate": "100"}))
+	assert.NoError(t, c.DeleteQuota(ctx, "rate-limit", "admin_app"))
+
+	assert.Equal(t, []string{
+		"PUT /v1/sys/quotas/rate-limit/admin_app",
+		"DELETE /v1/sys/quotas/rate-limit/admin_app",
+	}, requests)
+}
+
+// TestVaultClient_Request tests sending an arbitrary request in a namespace.
+func TestVaultClient_Request(t *testing.T) {
+	var requests []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/pki/root/generate/internal", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "app.example.com", body["common_name"])
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-Vault-Namespace"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/v1/pki/roles/legacy", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+	})
+
+	c := newTestClient(t, mux)
+	ctx := context.Background()
+	assert.NoError(t, c.Request(ctx, "/admin/app", "POST", "pki/root/generate/internal", map[string]interface{}{"common_name": "app.example.com"}))
+	assert.Equal(t, []string{"POST /v1/pki/root/generate/internal admin/app"}, requests)
+
+	err := c.Request(ctx, "admin/app", "DELETE", "pki/roles/legacy", nil)
+	assert.ErrorContains(t, err, `failed to DELETE "pki/roles/legacy" in "admin/app"`)
+}
+
+// TestVaultClient_PutSentinelPolicy tests that paths are only sent for an EGP.
+func TestVaultClient_PutSentinelPolicy(t *testing.T) {
+	bodies := make(map[string]map[string]interface{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/sys/policies/", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies[r.URL.Path] = body
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	c := newTestClient(t, mux)
+	ctx := context.Background()
+	assert.NoError(t, c.PutSentinelPolicy(ctx, "app", "egp", "business-hours", "main = rule { true }", "soft-mandatory", []string{"*"}))
+	assert.NoError(t, c.PutSentinelPolicy(ctx, "app", "rgp", "business-hours", "main = rule { true }", "hard-mandatory", nil))
+
+	assert.Equal(t, map[string]interface{}{
+		"policy":            "main = rule { true }",
+		"enforcement_level": "soft-mandatory",
+		"paths":             []interface{}{"*"},
+	}, bodies["/v1/sys/policies/egp/business-hours"])
+	assert.NotContains(t, bodies["/v1/sys/policies/rgp/business-hours"], "paths")
+}
+
+// TestVaultClient_EnsureAuditDevice tests that an audit device is only enabled
+// when none is enabled at its path.
+func TestVaultClient_EnsureAuditDevice(t *testing.T) {
+	var enabled []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/sys/audit", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"file/": map[string]interface{}{"type": "file", "path": "file/"},
+		})
+	})
+	mux.HandleFunc("/v1/sys/audit/", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "socket", body["type"])
+		enabled = append(enabled, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	c := newTestClient(t, mux)
+	ctx := context.Background()
+	assert.NoError(t, c.EnsureAuditDevice(ctx, "app", "file", "file", "", map[string]string{"file_path": "/tmp/a.log"}))
+	assert.NoError(t, c.EnsureAuditDevice(ctx, "app", "socket", "socket", "", map[string]string{"address": "127.0.0.1:9090"}))
+	assert.Equal(t, []string{"/v1/sys/audit/socket"}, enabled)
+}
+
+// TestVaultClient_CreateToken tests creating plain and wrapped tokens.
+func TestVaultClient_CreateToken(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/token/create", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, []interface{}{"bootstrap"}, body["policies"])
+		assert.Equal(t, "1h", body["ttl"])
+		assert.Equal(t, "app", r.Header.Get("X-Vault-Namespace"))
+		if wrapTTL := r.Header.Get("X-Vault-Wrap-TTL"); wrapTTL != "" {
+			assert.Equal(t, "10m", wrapTTL)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"wrap_info": map[string]interface{}{"token": "hvs.wrapped", "ttl": 600},
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": "hvs.token"},
+		})
+	})
+
+	c := newTestClient(t, mux)
+	ctx := context.Background()
+	token, err := c.CreateToken(ctx, "app", []string{"bootstrap"}, "1h", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "hvs.token", token)
+
+	token, err = c.CreateToken(ctx, "app", []string{"bootstrap"}, "1h", "10m")
+	assert.NoError(t, err)
+	assert.Equal(t, "hvs.wrapped", token)
+}
+
+// TestVaultClient_Reauthenticate tests logging in again with rotated credentials.
+func TestVaultClient_Reauthenticate(t *testing.T) {
+	secretID := "rotated-secret-id"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/approle/login", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["secret_id"] != secretID {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": "hvs.rotated"},
+		})
+	})
+
+	c := newTestClient(t, mux)
+	c.config.Auth = config.VaultAuthConfig{Type: "approle", RoleID: "role-id", SecretID: "old-secret-id"}
+
+	auth := config.VaultAuthConfig{Type: "approle", RoleID: "role-id", SecretID: "stale-secret-id"}
+	assert.ErrorIs(t, c.Reauthenticate(auth), ErrVaultAuth)
+	assert.Equal(t, "test-token", c.client.Token())
+
+	auth.SecretID = secretID
+	assert.NoError(t, c.Reauthenticate(auth))
+	assert.Equal(t, "hvs.rotated", c.client.Token())
+}
@@ -0,0 +1,35 @@
+package vault
+
+import (
+	"context"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// CorrelationIDHeader is the request header carrying the correlation ID of
+// the reconcile a Vault request is made for. Vault records it in its audit
+// log once the header is enabled with sys/config/auditing/request-headers.
+const CorrelationIDHeader = "X-Correlation-Id"
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a context whose Vault requests carry id.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns the correlation ID of ctx, or "" when it has none.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// withNamespace returns a copy of the client for the Vault namespace, which
+// sends the correlation ID of ctx with its requests.
+func (c *vaultClient) withNamespace(ctx context.Context, namespace string) *api.Client {
+	client := c.client.WithNamespace(namespace)
+	if id := CorrelationID(ctx); id != "" {
+		client.AddHeader(CorrelationIDHeader, id)
+	}
+	return client
+}
@@ -0,0 +1,29 @@
+package vault
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCorrelationID tests Vault requests carry the correlation ID of their context.
+func TestCorrelationID(t *testing.T) {
+	var received []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get(CorrelationIDHeader))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	ctx := WithCorrelationID(context.Background(), "3f2b8c1e")
+	assert.Equal(t, "3f2b8c1e", CorrelationID(ctx))
+	assert.Equal(t, "", CorrelationID(context.Background()))
+
+	assert.NoError(t, client.Request(ctx, "team-a", http.MethodPost, "sys/mounts/kv", nil))
+	assert.NoError(t, client.DeleteQuota(ctx, "rate-limit", "team-a"))
+	// The header is not left on the shared client
+	assert.NoError(t, client.Request(context.Background(), "team-a", http.MethodPost, "sys/mounts/kv", nil))
+
+	assert.Equal(t, []string{"3f2b8c1e", "3f2b8c1e", ""}, received)
+}
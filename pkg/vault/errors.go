@@ -0,0 +1,61 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// Failure classes wrapped into errors returned by namespace operations, so
+// callers can choose a retry strategy with errors.Is.
+var (
+	ErrVaultPermissionDenied = errors.New("vault permission denied")
+	ErrVaultInvalidRequest   = errors.New("vault rejected the request")
+	ErrVaultUnavailable      = errors.New("vault unavailable")
+	ErrVaultSealed           = errors.New("vault is sealed")
+	ErrVaultRateLimited      = errors.New("vault rate limit exceeded")
+)
+
+// classifyError returns the failure class of an error from the Vault API, or
+// nil when it has none.
+func classifyError(err error) error {
+	var respErr *api.ResponseError
+	if errors.As(err, &respErr) {
+		switch {
+		case respErr.StatusCode == http.StatusForbidden:
+			return ErrVaultPermissionDenied
+		case respErr.StatusCode == http.StatusBadRequest, respErr.StatusCode == http.StatusNotFound:
+			// An invalid or missing path
+			return ErrVaultInvalidRequest
+		case respErr.StatusCode == http.StatusTooManyRequests:
+			return ErrVaultRateLimited
+		case respErr.StatusCode == http.StatusServiceUnavailable &&
+			strings.Contains(strings.ToLower(strings.Join(respErr.Errors, " ")), "sealed"):
+			return ErrVaultSealed
+		case respErr.StatusCode >= 500:
+			return ErrVaultUnavailable
+		}
+		// Other client errors, such as a conflict or a precondition not yet
+		// met on a performance standby, may succeed when retried
+		return nil
+	}
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	// Anything else failed before Vault answered, such as a refused connection or timeout
+	return ErrVaultUnavailable
+}
+
+// operationError wraps err from a namespace operation with ErrVaultNamespaceOperation
+// and its failure class.
+func operationError(err error, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if class := classifyError(err); class != nil {
+		return fmt.Errorf("%w: %w: %s: %w", ErrVaultNamespaceOperation, class, msg, err)
+	}
+	return fmt.Errorf("%w: %s: %w", ErrVaultNamespaceOperation, msg, err)
+}
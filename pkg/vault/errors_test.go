@@ -0,0 +1,89 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestClassifyError tests mapping Vault API errors to failure classes.
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "permission denied",
+			err:      &api.ResponseError{StatusCode: http.StatusForbidden, Errors: []string{"permission denied"}},
+			expected: ErrVaultPermissionDenied,
+		},
+		{
+			name:     "invalid path",
+			err:      &api.ResponseError{StatusCode: http.StatusBadRequest},
+			expected: ErrVaultInvalidRequest,
+		},
+		{
+			name:     "missing path",
+			err:      &api.ResponseError{StatusCode: http.StatusNotFound},
+			expected: ErrVaultInvalidRequest,
+		},
+		{
+			name:     "precondition failed",
+			err:      &api.ResponseError{StatusCode: http.StatusPreconditionFailed},
+			expected: nil,
+		},
+		{
+			name:     "conflict",
+			err:      &api.ResponseError{StatusCode: http.StatusConflict},
+			expected: nil,
+		},
+		{
+			name:     "rate limited",
+			err:      &api.ResponseError{StatusCode: http.StatusTooManyRequests},
+			expected: ErrVaultRateLimited,
+		},
+		{
+			name:     "sealed",
+			err:      &api.ResponseError{StatusCode: http.StatusServiceUnavailable, Errors: []string{"Vault is sealed"}},
+			expected: ErrVaultSealed,
+		},
+		{
+			name:     "server error",
+			err:      &api.ResponseError{StatusCode: http.StatusInternalServerError},
+			expected: ErrVaultUnavailable,
+		},
+		{
+			name:     "network error",
+			err:      errors.New("dial tcp: connection refused"),
+			expected: ErrVaultUnavailable,
+		},
+		{
+			name:     "cancelled",
+			err:      context.Canceled,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, classifyError(tt.err))
+		})
+	}
+}
+
+// TestVaultClient_CreateNamespaceClassifiesErrors tests that request failures carry their class.
+func TestVaultClient_CreateNamespaceClassifiesErrors(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		writeJSON(w, map[string]interface{}{"errors": []string{"permission denied"}})
+	}))
+
+	err := c.CreateNamespace(context.Background(), "team-a", nil)
+	assert.True(t, errors.Is(err, ErrVaultNamespaceOperation))
+	assert.True(t, errors.Is(err, ErrVaultPermissionDenied))
+}
@@ -0,0 +1,64 @@
+// Package version reports the version the controller binary was built from.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time with -ldflags, e.g.
+// -X github.com/benemon/vault-namespace-controller/pkg/version.version=v1.2.0
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+)
+
+// Info describes the build of the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"goVersion"`
+}
+
+// String returns the build in one line, as printed by --version.
+func (i Info) String() string {
+	return fmt.Sprintf("vault-namespace-controller %s (commit %s, built %s, %s)", i.Version, i.Commit, i.Date, i.GoVersion)
+}
+
+// Get returns the build of the running binary. A commit and date not set at
+// build time are taken from the VCS information Go embeds when building the
+// module, and are "unknown" when that is missing too.
+func Get() Info {
+	info := Info{Version: version, Commit: commit, Date: date, GoVersion: runtime.Version()}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		info = fromBuildInfo(info, build)
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.Date == "" {
+		info.Date = "unknown"
+	}
+	return info
+}
+
+// fromBuildInfo fills the commit and date of info missing from the ldflags
+// with the VCS settings of build.
+func fromBuildInfo(info Info, build *debug.BuildInfo) Info {
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = setting.Value
+			}
+		}
+	}
+	return info
+}
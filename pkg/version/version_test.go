@@ -0,0 +1,32 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGet tests the build set by ldflags is reported.
+func TestGet(t *testing.T) {
+	defer func(v, c, d string) { version, commit, date = v, c, d }(version, commit, date)
+	version, commit, date = "v1.2.0", "3f2b8c1", "2026-10-01T12:00:00Z"
+
+	info := Get()
+	assert.Equal(t, Info{Version: "v1.2.0", Commit: "3f2b8c1", Date: "2026-10-01T12:00:00Z", GoVersion: runtime.Version()}, info)
+	assert.Equal(t, "vault-namespace-controller v1.2.0 (commit 3f2b8c1, built 2026-10-01T12:00:00Z, "+runtime.Version()+")", info.String())
+}
+
+// TestFromBuildInfo tests the VCS settings only fill what ldflags left unset.
+func TestFromBuildInfo(t *testing.T) {
+	build := &debug.BuildInfo{Settings: []debug.BuildSetting{
+		{Key: "vcs.revision", Value: "9aa83ca"},
+		{Key: "vcs.time", Value: "2026-09-30T08:00:00Z"},
+	}}
+
+	assert.Equal(t, Info{Version: "dev", Commit: "9aa83ca", Date: "2026-09-30T08:00:00Z"},
+		fromBuildInfo(Info{Version: "dev"}, build))
+	assert.Equal(t, Info{Version: "v1.2.0", Commit: "3f2b8c1", Date: "2026-09-30T08:00:00Z"},
+		fromBuildInfo(Info{Version: "v1.2.0", Commit: "3f2b8c1"}, build))
+}